@@ -0,0 +1,297 @@
+/*
+Package backtest runs strategies against historical quotes and bars using the simulator as broker.
+
+A strategy only talks to a trading.Broker, so the same strategy can be given a trading.TradingClient
+and be fed live data instead:
+
+	engine := backtest.New(backtest.Config{Simulator: simulator.Config{Cash: 100000000}})
+	strategy := NewMyStrategy(engine.Broker())
+	result, err := engine.Run(strategy, backtest.Bars(client.GetOHLCPerDay(&query)))
+*/
+package backtest
+
+import (
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/quantfamily/lemonmarkets/simulator"
+	"github.com/quantfamily/lemonmarkets/trading"
+)
+
+/*
+Strategy receives market data and fills in time order.
+Orders placed in OnBar or OnQuote are matched from the next event of the same ISIN
+*/
+type Strategy interface {
+	OnBar(bar market_data.OHLC)
+	OnQuote(quote market_data.Quote)
+	OnFill(order trading.Order)
+}
+
+// event is a single bar or quote
+type event struct {
+	time  time.Time
+	bar   *market_data.OHLC
+	quote *market_data.Quote
+}
+
+/*
+Feed is a time ordered stream of bars or quotes, created with Bars, Quotes or their slice variants
+*/
+type Feed interface {
+	next() (event, bool, error)
+}
+
+type barFeed struct {
+	ch <-chan market_data.Item[market_data.OHLC, error]
+}
+
+func (f barFeed) next() (event, bool, error) {
+	item, ok := <-f.ch
+	if !ok {
+		return event{}, false, nil
+	}
+	if item.Error != nil {
+		return event{}, false, item.Error
+	}
+	bar := item.Data
+	return event{time: bar.Time, bar: &bar}, true, nil
+}
+
+type quoteFeed struct {
+	ch <-chan market_data.Item[market_data.Quote, error]
+}
+
+func (f quoteFeed) next() (event, bool, error) {
+	item, ok := <-f.ch
+	if !ok {
+		return event{}, false, nil
+	}
+	if item.Error != nil {
+		return event{}, false, item.Error
+	}
+	quote := item.Data
+	return event{time: quote.Time, quote: &quote}, true, nil
+}
+
+/*
+Bars is a feed of bars such as returned by GetOHLCPerDay, the bars must be sorted oldest first
+*/
+func Bars(ch <-chan market_data.Item[market_data.OHLC, error]) Feed {
+	return barFeed{ch: ch}
+}
+
+/*
+Quotes is a feed of quotes such as returned by GetQuotes, the quotes must be sorted oldest first
+*/
+func Quotes(ch <-chan market_data.Item[market_data.Quote, error]) Feed {
+	return quoteFeed{ch: ch}
+}
+
+// BarSlice is a feed of bars already in memory, sorted oldest first
+func BarSlice(bars []market_data.OHLC) Feed {
+	ch := make(chan market_data.Item[market_data.OHLC, error], len(bars))
+	for _, bar := range bars {
+		ch <- market_data.Item[market_data.OHLC, error]{Data: bar}
+	}
+	close(ch)
+	return Bars(ch)
+}
+
+// QuoteSlice is a feed of quotes already in memory, sorted oldest first
+func QuoteSlice(quotes []market_data.Quote) Feed {
+	ch := make(chan market_data.Item[market_data.Quote, error], len(quotes))
+	for _, quote := range quotes {
+		ch <- market_data.Item[market_data.Quote, error]{Data: quote}
+	}
+	close(ch)
+	return Quotes(ch)
+}
+
+/*
+Config for a backtest. RiskFree is the yearly risk free rate used for Sharpe and Sortino,
+PeriodsPerYear is used to annualize daily returns and defaults to 252
+*/
+type Config struct {
+	Simulator      simulator.Config
+	RiskFree       float64
+	PeriodsPerYear float64
+}
+
+// EquityPoint is the value of cash and positions at a point in time
+type EquityPoint struct {
+	Time   time.Time
+	Equity int
+}
+
+/*
+Trade is a single fill, RealizedPnL is set for sells using FIFO cost basis
+*/
+type Trade struct {
+	Time        time.Time
+	OrderID     string
+	ISIN        string
+	Side        string
+	Quantity    int
+	Price       int
+	Fees        int
+	RealizedPnL int
+}
+
+// Result of a backtest
+type Result struct {
+	Equity  []EquityPoint
+	Trades  []Trade
+	Metrics Metrics
+}
+
+/*
+Engine feeds events in time order to the simulator and the strategy
+*/
+type Engine struct {
+	config    Config
+	simulator *simulator.Simulator
+	ledger    *trading.Ledger
+	executed  map[string]order
+}
+
+// order is what has been seen of an order so that partial fills can be turned into trades
+type order struct {
+	quantity int
+	total    int
+	charge   float64
+}
+
+// New returns an engine with a fresh simulator
+func New(config Config) *Engine {
+	if config.PeriodsPerYear == 0 {
+		config.PeriodsPerYear = 252
+	}
+	return &Engine{
+		config:    config,
+		simulator: simulator.New(config.Simulator),
+		ledger:    trading.NewLedger(trading.FIFO),
+		executed:  make(map[string]order),
+	}
+}
+
+// Broker returns the broker strategies should place orders with
+func (e *Engine) Broker() trading.Broker {
+	return e.simulator
+}
+
+// Simulator returns the underlying simulator
+func (e *Engine) Simulator() *simulator.Simulator {
+	return e.simulator
+}
+
+/*
+Run merges the feeds on time and calls the strategy for every event until all feeds are exhausted.
+Events with the same time are delivered in the order of the feeds
+*/
+func (e *Engine) Run(strategy Strategy, feeds ...Feed) (*Result, error) {
+	result := &Result{}
+	heads := make([]*event, len(feeds))
+	for i, feed := range feeds {
+		head, ok, err := feed.next()
+		if err != nil {
+			return result, err
+		}
+		if ok {
+			heads[i] = &head
+		}
+	}
+	for {
+		index := -1
+		for i, head := range heads {
+			if head != nil && (index == -1 || head.time.Before(heads[index].time)) {
+				index = i
+			}
+		}
+		if index == -1 {
+			break
+		}
+		current := *heads[index]
+		next, ok, err := feeds[index].next()
+		if err != nil {
+			return result, err
+		}
+		heads[index] = nil
+		if ok {
+			heads[index] = &next
+		}
+
+		var changed []trading.Order
+		if current.bar != nil {
+			changed = e.simulator.OnBar(*current.bar)
+		} else {
+			changed = e.simulator.OnQuote(*current.quote)
+		}
+		for _, updated := range changed {
+			if trade, ok := e.trade(updated); ok {
+				result.Trades = append(result.Trades, trade)
+			}
+			strategy.OnFill(updated)
+		}
+		if current.bar != nil {
+			strategy.OnBar(*current.bar)
+		} else {
+			strategy.OnQuote(*current.quote)
+		}
+		e.recordEquity(result, current.time)
+	}
+	result.Metrics = NewMetrics(result.Equity, result.Trades, e.config.RiskFree, e.config.PeriodsPerYear)
+	return result, nil
+}
+
+// trade turns the newly executed part of an order into a trade
+func (e *Engine) trade(updated trading.Order) (Trade, bool) {
+	seen := e.executed[updated.ID]
+	quantity := updated.ExecutedQuantity - seen.quantity
+	if quantity <= 0 {
+		return Trade{}, false
+	}
+	total := updated.ExecutedPriceTotal - seen.total
+	fees := int(updated.Charge - seen.charge)
+	e.executed[updated.ID] = order{quantity: updated.ExecutedQuantity, total: updated.ExecutedPriceTotal, charge: updated.Charge}
+
+	trade := Trade{
+		Time:     updated.ExecutedAt,
+		OrderID:  updated.ID,
+		ISIN:     updated.ISIN,
+		Side:     updated.Side,
+		Quantity: quantity,
+		Price:    total / quantity,
+		Fees:     fees,
+	}
+	eventType := trading.EventBuy
+	if updated.Side == trading.Sell {
+		eventType = trading.EventSell
+	}
+	before := len(e.ledger.Realized())
+	err := e.ledger.Apply(trading.LedgerEvent{
+		ID:       updated.ID,
+		Type:     eventType,
+		Time:     updated.ExecutedAt,
+		ISIN:     updated.ISIN,
+		Quantity: quantity,
+		Amount:   total,
+		Fees:     fees,
+	})
+	if realized := e.ledger.Realized(); err == nil && len(realized) > before {
+		trade.RealizedPnL = realized[len(realized)-1].Gain
+	}
+	return trade, true
+}
+
+func (e *Engine) recordEquity(result *Result, t time.Time) {
+	equity := e.simulator.Cash()
+	for position := range e.simulator.GetPositions() {
+		equity += position.Data.EstimatedPriceTotal
+	}
+	if n := len(result.Equity); n > 0 && result.Equity[n-1].Time.Equal(t) {
+		result.Equity[n-1].Equity = equity
+		return
+	}
+	result.Equity = append(result.Equity, EquityPoint{Time: t, Equity: equity})
+}
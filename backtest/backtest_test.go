@@ -0,0 +1,142 @@
+package backtest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/quantfamily/lemonmarkets/simulator"
+	"github.com/quantfamily/lemonmarkets/trading"
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2022, 3, 1, 17, 30, 0, 0, time.UTC)
+
+func bar(isin string, day int, open, close float64) market_data.OHLC {
+	high, low := open, close
+	if close > open {
+		high, low = close, open
+	}
+	return market_data.OHLC{ISIN: isin, Open: open, High: high, Low: low, Close: close, Volume: 1000, Time: start.AddDate(0, 0, day)}
+}
+
+/*
+roundTrip buys on the first bar of every ISIN and sells on the given day, using only trading.Broker
+so it could be given a TradingClient as well
+*/
+type roundTrip struct {
+	broker  trading.Broker
+	sellDay time.Time
+	bought  map[string]bool
+	sold    map[string]bool
+	bars    []string
+	fills   []trading.Order
+}
+
+func newRoundTrip(broker trading.Broker, sellDay int) *roundTrip {
+	return &roundTrip{broker: broker, sellDay: start.AddDate(0, 0, sellDay), bought: make(map[string]bool), sold: make(map[string]bool)}
+}
+
+func (s *roundTrip) place(isin, side string) {
+	order := s.broker.CreateOrder(&trading.Order{ISIN: isin, Side: side, Quantity: 10})
+	if order.Error == nil {
+		s.broker.ActivateOrder(order.Data.ID)
+	}
+}
+
+func (s *roundTrip) on(isin string, t time.Time) {
+	if !s.bought[isin] {
+		s.bought[isin] = true
+		s.place(isin, trading.Buy)
+		return
+	}
+	if !t.Before(s.sellDay) && !s.sold[isin] {
+		s.sold[isin] = true
+		s.place(isin, trading.Sell)
+	}
+}
+
+func (s *roundTrip) OnBar(bar market_data.OHLC) {
+	s.bars = append(s.bars, bar.ISIN)
+	s.on(bar.ISIN, bar.Time)
+}
+
+func (s *roundTrip) OnQuote(quote market_data.Quote) {
+	s.on(quote.ISIN, quote.Time)
+}
+
+func (s *roundTrip) OnFill(order trading.Order) {
+	s.fills = append(s.fills, order)
+}
+
+func TestRun(t *testing.T) {
+	engine := New(Config{Simulator: simulator.Config{Cash: 100000000, Fees: simulator.Fees{Fixed: 10000}}})
+	strategy := newRoundTrip(engine.Broker(), 2)
+	tesla := []market_data.OHLC{bar("TSLA", 0, 100, 100), bar("TSLA", 1, 100, 110), bar("TSLA", 2, 110, 120), bar("TSLA", 3, 120, 115), bar("TSLA", 4, 115, 115)}
+	apple := []market_data.OHLC{bar("AAPL", 0, 50, 50), bar("AAPL", 1, 50, 45), bar("AAPL", 2, 45, 40), bar("AAPL", 3, 40, 42), bar("AAPL", 4, 42, 42)}
+
+	result, err := engine.Run(strategy, BarSlice(tesla), BarSlice(apple))
+	assert.Nil(t, err)
+
+	t.Run("events are merged on time", func(t *testing.T) {
+		assert.Equal(t, []string{"TSLA", "AAPL", "TSLA", "AAPL"}, strategy.bars[:4])
+		assert.Len(t, strategy.bars, 10)
+	})
+	t.Run("orders fill on the next bar", func(t *testing.T) {
+		assert.Len(t, strategy.fills, 4)
+		assert.Len(t, result.Trades, 4)
+		buy := result.Trades[0]
+		assert.Equal(t, "TSLA", buy.ISIN)
+		assert.Equal(t, trading.Buy, buy.Side)
+		assert.Equal(t, 1000000, buy.Price)
+		assert.Equal(t, 10000, buy.Fees)
+		assert.Equal(t, start.AddDate(0, 0, 1), buy.Time)
+	})
+	t.Run("realized pnl on sells", func(t *testing.T) {
+		sells := map[string]Trade{}
+		for _, trade := range result.Trades {
+			if trade.Side == trading.Sell {
+				sells[trade.ISIN] = trade
+			}
+		}
+		// bought at 100 and sold at the open of day 3 (120), 10 pieces, 1 EUR fee on both sides
+		assert.Equal(t, 10*200000-20000, sells["TSLA"].RealizedPnL)
+		assert.Equal(t, 10*-100000-20000, sells["AAPL"].RealizedPnL)
+		assert.Equal(t, 0.5, result.Metrics.HitRate)
+	})
+	t.Run("equity curve", func(t *testing.T) {
+		assert.Len(t, result.Equity, 5)
+		assert.Equal(t, 100000000, result.Equity[0].Equity)
+		assert.Equal(t, result.Equity[4].Equity, engine.Simulator().Cash())
+		assert.Equal(t, 100000000+10*200000-10*100000-40000, result.Metrics.EndEquity)
+		assert.Equal(t, 4, result.Metrics.Trades)
+		assert.Equal(t, 40000, result.Metrics.Fees)
+		assert.Greater(t, result.Metrics.Turnover, 0.0)
+	})
+}
+
+func TestRunError(t *testing.T) {
+	engine := New(Config{Simulator: simulator.Config{Cash: 100000000}})
+	ch := make(chan market_data.Item[market_data.OHLC, error], 2)
+	ch <- market_data.Item[market_data.OHLC, error]{Data: bar("TSLA", 0, 100, 100)}
+	ch <- market_data.Item[market_data.OHLC, error]{Error: errors.New("not found")}
+	close(ch)
+
+	_, err := engine.Run(newRoundTrip(engine.Broker(), 2), Bars(ch))
+	assert.NotNil(t, err)
+}
+
+func TestRunQuotes(t *testing.T) {
+	engine := New(Config{Simulator: simulator.Config{Cash: 100000000}})
+	strategy := newRoundTrip(engine.Broker(), 1)
+	quotes := []market_data.Quote{
+		{ISIN: "TSLA", Bid: 99, Ask: 101, Time: start},
+		{ISIN: "TSLA", Bid: 104, Ask: 106, Time: start.Add(time.Minute)},
+	}
+	result, err := engine.Run(strategy, QuoteSlice(quotes))
+	assert.Nil(t, err)
+	assert.Len(t, result.Trades, 1)
+	assert.Equal(t, 1060000, result.Trades[0].Price)
+	assert.Equal(t, 100000000-10*1060000+10*1050000, result.Metrics.EndEquity)
+}
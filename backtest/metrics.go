@@ -0,0 +1,166 @@
+package backtest
+
+import (
+	"math"
+	"time"
+)
+
+/*
+Metrics summarise a backtest. Returns are computed from the last equity of every day (UTC),
+rates and ratios are fractions (0.1 = 10%). Turnover is the traded value divided by the average equity,
+HitRate is the fraction of trades with a realized gain among those that realized anything
+*/
+type Metrics struct {
+	StartEquity int
+	EndEquity   int
+	TotalReturn float64
+	CAGR        float64
+	Volatility  float64
+	Sharpe      float64
+	Sortino     float64
+	MaxDrawdown float64
+	Turnover    float64
+	HitRate     float64
+	Trades      int
+	Fees        int
+}
+
+/*
+NewMetrics computes metrics from an equity curve and trade list, riskFree is a yearly rate
+and periodsPerYear the number of daily returns in a year
+*/
+func NewMetrics(equity []EquityPoint, trades []Trade, riskFree, periodsPerYear float64) Metrics {
+	metrics := Metrics{Trades: len(trades)}
+	if len(equity) == 0 {
+		return metrics
+	}
+	first, last := equity[0], equity[len(equity)-1]
+	metrics.StartEquity = first.Equity
+	metrics.EndEquity = last.Equity
+	if first.Equity > 0 {
+		metrics.TotalReturn = float64(last.Equity)/float64(first.Equity) - 1
+		years := last.Time.Sub(first.Time).Hours() / 24 / 365.25
+		if years > 0 && last.Equity > 0 {
+			metrics.CAGR = math.Pow(float64(last.Equity)/float64(first.Equity), 1/years) - 1
+		}
+	}
+	metrics.MaxDrawdown = MaxDrawdown(equity)
+
+	returns := DailyReturns(equity)
+	excess := make([]float64, len(returns))
+	for i, r := range returns {
+		excess[i] = r - riskFree/periodsPerYear
+	}
+	mean, std := meanStd(excess)
+	_, metrics.Volatility = meanStd(returns)
+	metrics.Volatility *= math.Sqrt(periodsPerYear)
+	if std > 0 {
+		metrics.Sharpe = mean / std * math.Sqrt(periodsPerYear)
+	}
+	if downside := downsideDeviation(excess); downside > 0 {
+		metrics.Sortino = mean / downside * math.Sqrt(periodsPerYear)
+	}
+
+	traded, wins, closed := 0, 0, 0
+	for _, trade := range trades {
+		traded += trade.Quantity * trade.Price
+		metrics.Fees += trade.Fees
+		if trade.RealizedPnL != 0 {
+			closed++
+			if trade.RealizedPnL > 0 {
+				wins++
+			}
+		}
+	}
+	if average := averageEquity(equity); average > 0 {
+		metrics.Turnover = float64(traded) / average
+	}
+	if closed > 0 {
+		metrics.HitRate = float64(wins) / float64(closed)
+	}
+	return metrics
+}
+
+// DailyReturns returns the simple returns between the last equity of consecutive days (UTC)
+func DailyReturns(equity []EquityPoint) []float64 {
+	var closes []int
+	var day time.Time
+	for _, point := range equity {
+		current := point.Time.UTC().Truncate(24 * time.Hour)
+		if len(closes) > 0 && current.Equal(day) {
+			closes[len(closes)-1] = point.Equity
+			continue
+		}
+		day = current
+		closes = append(closes, point.Equity)
+	}
+	var returns []float64
+	for i := 1; i < len(closes); i++ {
+		if closes[i-1] == 0 {
+			continue
+		}
+		returns = append(returns, float64(closes[i])/float64(closes[i-1])-1)
+	}
+	return returns
+}
+
+// MaxDrawdown returns the largest fall from a peak as a fraction of the peak
+func MaxDrawdown(equity []EquityPoint) float64 {
+	peak, drawdown := 0, 0.0
+	for _, point := range equity {
+		if point.Equity > peak {
+			peak = point.Equity
+		}
+		if peak > 0 {
+			if current := float64(peak-point.Equity) / float64(peak); current > drawdown {
+				drawdown = current
+			}
+		}
+	}
+	return drawdown
+}
+
+// meanStd returns mean and sample standard deviation
+func meanStd(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	mean := sum / float64(len(values))
+	if len(values) < 2 {
+		return mean, 0
+	}
+	squares := 0.0
+	for _, value := range values {
+		squares += (value - mean) * (value - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)-1))
+}
+
+// downsideDeviation is the root mean square of the negative values
+func downsideDeviation(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	squares := 0.0
+	for _, value := range values {
+		if value < 0 {
+			squares += value * value
+		}
+	}
+	return math.Sqrt(squares / float64(len(values)))
+}
+
+func averageEquity(equity []EquityPoint) float64 {
+	if len(equity) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, point := range equity {
+		sum += float64(point.Equity)
+	}
+	return sum / float64(len(equity))
+}
@@ -0,0 +1,62 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func curve(values ...int) []EquityPoint {
+	var equity []EquityPoint
+	for i, value := range values {
+		equity = append(equity, EquityPoint{Time: start.AddDate(0, 0, i), Equity: value})
+	}
+	return equity
+}
+
+func TestDailyReturns(t *testing.T) {
+	t.Run("last equity of the day is used", func(t *testing.T) {
+		equity := []EquityPoint{
+			{Time: start, Equity: 100},
+			{Time: start.AddDate(0, 0, 1), Equity: 90},
+			{Time: start.AddDate(0, 0, 1).Add(time.Hour), Equity: 110},
+		}
+		assert.InDeltaSlice(t, []float64{0.1}, DailyReturns(equity), 1e-9)
+	})
+	t.Run("empty curve", func(t *testing.T) {
+		assert.Len(t, DailyReturns(nil), 0)
+	})
+}
+
+func TestMaxDrawdown(t *testing.T) {
+	assert.InDelta(t, 0.25, MaxDrawdown(curve(100, 120, 90, 130, 110)), 1e-9)
+	assert.Equal(t, 0.0, MaxDrawdown(curve(100, 110, 120)))
+}
+
+func TestNewMetrics(t *testing.T) {
+	t.Run("Successful test", func(t *testing.T) {
+		equity := []EquityPoint{{Time: start, Equity: 100}, {Time: start.AddDate(2, 0, 0), Equity: 121}}
+		trades := []Trade{
+			{Quantity: 1, Price: 100, Fees: 1},
+			{Quantity: 1, Price: 110, Fees: 1, RealizedPnL: 9},
+			{Quantity: 1, Price: 90, Fees: 1, RealizedPnL: -11},
+		}
+		metrics := NewMetrics(equity, trades, 0, 252)
+		assert.InDelta(t, 0.21, metrics.TotalReturn, 1e-9)
+		assert.InDelta(t, 0.1, metrics.CAGR, 1e-3)
+		assert.InDelta(t, 300/110.5, metrics.Turnover, 1e-9)
+		assert.InDelta(t, 0.5, metrics.HitRate, 1e-9)
+		assert.Equal(t, 3, metrics.Fees)
+	})
+	t.Run("sharpe and sortino", func(t *testing.T) {
+		metrics := NewMetrics(curve(1000, 1100, 990, 1089), nil, 0, 252)
+		// returns are 0.1, -0.1 and 0.1
+		mean, std := 0.1/3, 0.115470054
+		assert.InDelta(t, mean/std*15.874507866, metrics.Sharpe, 1e-6)
+		assert.InDelta(t, mean/0.057735027*15.874507866, metrics.Sortino, 1e-6)
+	})
+	t.Run("empty curve", func(t *testing.T) {
+		assert.Equal(t, Metrics{}, NewMetrics(nil, nil, 0, 252))
+	})
+}
@@ -0,0 +1,277 @@
+/*
+Package cache keeps historical market data on disk so that repeated queries, such as in backtests,
+do not download the same ranges again.
+
+Cache wraps any market_data.MarketDataSource and implements it as well. OHLC and trade queries with
+From and To set are split per ISIN, only the ranges not covered yet are fetched and merged into the store,
+everything else is passed through to the source. Limit and Page select a part of what the source returns,
+queries with either of them are passed through as well:
+
+	cached := cache.New(market_data.NewClient(key), cache.Config{Store: cache.NewFileStore("data")})
+	for bar := range cached.GetOHLCPerMinute(&query) {
+		...
+	}
+*/
+package cache
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+)
+
+/*
+Config for a Cache. Data newer than Open before now may still change, such as today's bars.
+Ranges reaching into that period are only trusted for TTL. Open defaults to 24 hours, TTL to 5 minutes
+and Now to time.Now
+*/
+type Config struct {
+	Store Store
+	Open  time.Duration
+	TTL   time.Duration
+	Now   func() time.Time
+}
+
+// Stats count how queries were served
+type Stats struct {
+	Hits           int
+	Misses         int
+	Bypassed       int
+	Fetches        int
+	RecordsServed  int
+	RecordsFetched int
+}
+
+/*
+Cache is a MarketDataSource serving OHLC and trades from a Store, fetching missing ranges from the source
+*/
+type Cache struct {
+	market_data.MarketDataSource
+	config Config
+	mu     sync.Mutex
+	stats  Stats
+}
+
+var _ market_data.MarketDataSource = (*Cache)(nil)
+
+// New wraps the source, Store defaults to a MemoryStore
+func New(source market_data.MarketDataSource, config Config) *Cache {
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	if config.Open == 0 {
+		config.Open = 24 * time.Hour
+	}
+	if config.TTL == 0 {
+		config.TTL = 5 * time.Minute
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	return &Cache{MarketDataSource: source, config: config}
+}
+
+// Stats returns how queries were served so far
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+func (c *Cache) count(f func(stats *Stats)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f(&c.stats)
+}
+
+// GetOHLCPerMinute serves minute bars from the cache
+func (c *Cache) GetOHLCPerMinute(query *market_data.GetOHLCQuery) <-chan market_data.Item[market_data.OHLC, error] {
+	return c.ohlc(OHLCPerMinute, query, c.MarketDataSource.GetOHLCPerMinute)
+}
+
+// GetOHLCPerHour serves hourly bars from the cache
+func (c *Cache) GetOHLCPerHour(query *market_data.GetOHLCQuery) <-chan market_data.Item[market_data.OHLC, error] {
+	return c.ohlc(OHLCPerHour, query, c.MarketDataSource.GetOHLCPerHour)
+}
+
+// GetOHLCPerDay serves daily bars from the cache
+func (c *Cache) GetOHLCPerDay(query *market_data.GetOHLCQuery) <-chan market_data.Item[market_data.OHLC, error] {
+	return c.ohlc(OHLCPerDay, query, c.MarketDataSource.GetOHLCPerDay)
+}
+
+func (c *Cache) ohlc(kind string, query *market_data.GetOHLCQuery, get func(*market_data.GetOHLCQuery) <-chan market_data.Item[market_data.OHLC, error]) <-chan market_data.Item[market_data.OHLC, error] {
+	if query == nil || query.From.IsZero() || query.To.IsZero() || len(query.ISIN) == 0 || query.Limit > 0 || query.Page > 0 {
+		c.count(func(stats *Stats) { stats.Bypassed++ })
+		return get(query)
+	}
+	request := request{kind: kind, isins: query.ISIN, mic: query.MIC, from: query.From, to: query.To, sorting: query.Sorting}
+	fetch := func(isin string, gap Range) <-chan market_data.Item[market_data.OHLC, error] {
+		return get(&market_data.GetOHLCQuery{ISIN: []string{isin}, MIC: query.MIC, From: gap.From, To: gap.To, Sorting: "oldest_first"})
+	}
+	ch := make(chan market_data.Item[market_data.OHLC, error])
+	go serve(c, request, fetch, func(bar market_data.OHLC) time.Time { return bar.Time }, ch)
+	return ch
+}
+
+// GetTrades serves trades from the cache
+func (c *Cache) GetTrades(query *market_data.GetTradesQuery) <-chan market_data.Item[market_data.Trade, error] {
+	if query == nil || query.From.IsZero() || query.To.IsZero() || len(query.ISIN) == 0 || query.Limit > 0 || query.Page > 0 {
+		c.count(func(stats *Stats) { stats.Bypassed++ })
+		return c.MarketDataSource.GetTrades(query)
+	}
+	request := request{kind: Trades, isins: query.ISIN, mic: query.MIC, from: query.From, to: query.To, sorting: query.Sorting}
+	fetch := func(isin string, gap Range) <-chan market_data.Item[market_data.Trade, error] {
+		return c.MarketDataSource.GetTrades(&market_data.GetTradesQuery{ISIN: []string{isin}, MIC: query.MIC, From: gap.From, To: gap.To, Sorting: "oldest_first"})
+	}
+	ch := make(chan market_data.Item[market_data.Trade, error])
+	go serve(c, request, fetch, func(trade market_data.Trade) time.Time { return trade.Time }, ch)
+	return ch
+}
+
+// request is what a cached query asks for
+type request struct {
+	kind    string
+	isins   []string
+	mic     string
+	from    time.Time
+	to      time.Time
+	sorting string
+}
+
+/*
+serve fills the gaps of every ISIN from the source and then sends the stored records.
+Results are sorted per ISIN in the requested order, oldest first unless sorting is newest_first
+*/
+func serve[T market_data.DataTypes](c *Cache, req request, fetch func(isin string, gap Range) <-chan market_data.Item[T, error], timeOf func(T) time.Time, ch chan<- market_data.Item[T, error]) {
+	defer close(ch)
+	missed := false
+	for _, isin := range req.isins {
+		series := Series{Kind: req.kind, ISIN: isin, MIC: req.mic}
+		fetched, err := fill(c, series, req.from, req.to, fetch, timeOf)
+		if err != nil {
+			ch <- market_data.Item[T, error]{Error: err}
+			return
+		}
+		missed = missed || fetched
+		records, err := c.config.Store.Read(series, req.from, req.to)
+		if err != nil {
+			ch <- market_data.Item[T, error]{Error: err}
+			return
+		}
+		if req.sorting == "newest_first" {
+			for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+				records[i], records[j] = records[j], records[i]
+			}
+		}
+		for _, record := range records {
+			item := market_data.Item[T, error]{}
+			if item.Error = json.Unmarshal(record.Data, &item.Data); item.Error != nil {
+				ch <- item
+				return
+			}
+			c.count(func(stats *Stats) { stats.RecordsServed++ })
+			ch <- item
+		}
+	}
+	c.count(func(stats *Stats) {
+		if missed {
+			stats.Misses++
+		} else {
+			stats.Hits++
+		}
+	})
+}
+
+// fill fetches and stores every range of [from, to) that is not covered, true if anything was fetched
+func fill[T market_data.DataTypes](c *Cache, series Series, from, to time.Time, fetch func(isin string, gap Range) <-chan market_data.Item[T, error], timeOf func(T) time.Time) (bool, error) {
+	now := c.config.Now()
+	coverage, err := c.config.Store.Coverage(series)
+	if err != nil {
+		return false, err
+	}
+	missing := Gaps(coverage, from, to, now)
+	for _, gap := range missing {
+		var records []Record
+		for item := range fetch(series.ISIN, gap) {
+			if item.Error != nil {
+				return true, item.Error
+			}
+			data, err := json.Marshal(item.Data)
+			if err != nil {
+				return true, err
+			}
+			records = append(records, Record{Time: timeOf(item.Data), Data: data})
+		}
+		if gap.To.After(now.Add(-c.config.Open)) {
+			gap.Expires = now.Add(c.config.TTL)
+		}
+		coverage = AddRange(coverage, gap, now)
+		if err := c.config.Store.Write(series, gap, records, coverage); err != nil {
+			return true, err
+		}
+		c.count(func(stats *Stats) {
+			stats.Fetches++
+			stats.RecordsFetched += len(records)
+		})
+	}
+	return len(missing) > 0, nil
+}
+
+/*
+Gaps returns the parts of [from, to) that are not covered by a valid range
+*/
+func Gaps(coverage []Range, from, to, now time.Time) []Range {
+	var valid []Range
+	for _, r := range coverage {
+		if r.valid(now) {
+			valid = append(valid, r)
+		}
+	}
+	sort.Slice(valid, func(i, j int) bool { return valid[i].From.Before(valid[j].From) })
+	var gaps []Range
+	cursor := from
+	for _, r := range valid {
+		if !r.To.After(cursor) {
+			continue
+		}
+		if !r.From.Before(to) {
+			break
+		}
+		if r.From.After(cursor) {
+			gaps = append(gaps, Range{From: cursor, To: r.From})
+		}
+		cursor = r.To
+	}
+	if cursor.Before(to) {
+		gaps = append(gaps, Range{From: cursor, To: to})
+	}
+	return gaps
+}
+
+/*
+AddRange adds a range to the coverage, dropping expired ranges and merging adjacent ranges that do not expire
+*/
+func AddRange(coverage []Range, added Range, now time.Time) []Range {
+	ranges := []Range{added}
+	for _, r := range coverage {
+		if r.valid(now) {
+			ranges = append(ranges, r)
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].From.Before(ranges[j].From) })
+	merged := []Range{ranges[0]}
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if last.Expires.IsZero() && r.Expires.IsZero() && !r.From.After(last.To) {
+			if r.To.After(last.To) {
+				last.To = r.To
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
@@ -0,0 +1,182 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2022, 3, 1, 9, 0, 0, 0, time.UTC)
+
+// fakeSource returns a bar per minute within the queried range and records the queries
+type fakeSource struct {
+	market_data.MarketDataSource
+	queries []market_data.GetOHLCQuery
+	trades  []market_data.GetTradesQuery
+	err     error
+}
+
+func (f *fakeSource) GetOHLCPerMinute(query *market_data.GetOHLCQuery) <-chan market_data.Item[market_data.OHLC, error] {
+	ch := make(chan market_data.Item[market_data.OHLC, error], 1000)
+	defer close(ch)
+	if query != nil {
+		f.queries = append(f.queries, *query)
+	}
+	if f.err != nil {
+		ch <- market_data.Item[market_data.OHLC, error]{Error: f.err}
+		return ch
+	}
+	if query == nil {
+		return ch
+	}
+	for t := query.From; t.Before(query.To); t = t.Add(time.Minute) {
+		bar := market_data.OHLC{ISIN: query.ISIN[0], Mic: query.MIC, Close: float64(t.Minute()), Time: t}
+		ch <- market_data.Item[market_data.OHLC, error]{Data: bar}
+	}
+	return ch
+}
+
+func (f *fakeSource) GetTrades(query *market_data.GetTradesQuery) <-chan market_data.Item[market_data.Trade, error] {
+	ch := make(chan market_data.Item[market_data.Trade, error], 10)
+	defer close(ch)
+	f.trades = append(f.trades, *query)
+	ch <- market_data.Item[market_data.Trade, error]{Data: market_data.Trade{ISIN: query.ISIN[0], Price: 1, Volume: 1, Time: query.From}}
+	ch <- market_data.Item[market_data.Trade, error]{Data: market_data.Trade{ISIN: query.ISIN[0], Price: 2, Volume: 1, Time: query.From}}
+	return ch
+}
+
+func collect[T market_data.DataTypes](t *testing.T, ch <-chan market_data.Item[T, error]) []T {
+	var data []T
+	for item := range ch {
+		assert.Nil(t, item.Error)
+		data = append(data, item.Data)
+	}
+	return data
+}
+
+func query(from, to int) *market_data.GetOHLCQuery {
+	return &market_data.GetOHLCQuery{ISIN: []string{"US88160R1014"}, MIC: "XMUN", From: start.Add(time.Duration(from) * time.Minute), To: start.Add(time.Duration(to) * time.Minute)}
+}
+
+func TestCache(t *testing.T) {
+	now := start.AddDate(0, 1, 0)
+	source := &fakeSource{}
+	cache := New(source, Config{Now: func() time.Time { return now }})
+
+	t.Run("fetch on first query", func(t *testing.T) {
+		bars := collect(t, cache.GetOHLCPerMinute(query(0, 10)))
+		assert.Len(t, bars, 10)
+		assert.Len(t, source.queries, 1)
+		assert.Equal(t, Stats{Misses: 1, Fetches: 1, RecordsServed: 10, RecordsFetched: 10}, cache.Stats())
+	})
+	t.Run("repeated query is served offline", func(t *testing.T) {
+		bars := collect(t, cache.GetOHLCPerMinute(query(2, 8)))
+		assert.Len(t, bars, 6)
+		assert.Equal(t, start.Add(2*time.Minute), bars[0].Time)
+		assert.Len(t, source.queries, 1)
+		assert.Equal(t, 1, cache.Stats().Hits)
+	})
+	t.Run("only gaps are fetched", func(t *testing.T) {
+		collect(t, cache.GetOHLCPerMinute(query(20, 30)))
+		bars := collect(t, cache.GetOHLCPerMinute(query(5, 35)))
+		assert.Len(t, bars, 30)
+		assert.Len(t, source.queries, 4)
+		assert.Equal(t, start.Add(10*time.Minute), source.queries[2].From)
+		assert.Equal(t, start.Add(20*time.Minute), source.queries[2].To)
+		assert.Equal(t, start.Add(30*time.Minute), source.queries[3].From)
+		assert.Equal(t, []Range{{From: start, To: start.Add(35 * time.Minute)}}, mustCoverage(t, cache, "US88160R1014"))
+	})
+	t.Run("newest first", func(t *testing.T) {
+		q := query(0, 3)
+		q.Sorting = "newest_first"
+		bars := collect(t, cache.GetOHLCPerMinute(q))
+		assert.Equal(t, start.Add(2*time.Minute), bars[0].Time)
+	})
+	t.Run("queries without range are passed through", func(t *testing.T) {
+		collect(t, cache.GetOHLCPerMinute(nil))
+		assert.Equal(t, 1, cache.Stats().Bypassed)
+	})
+	t.Run("queries with limit or page are passed through", func(t *testing.T) {
+		limited := query(0, 3)
+		limited.Limit = 1
+		limited.Sorting = "newest_first"
+		collect(t, cache.GetOHLCPerMinute(limited))
+		assert.Equal(t, *limited, source.queries[len(source.queries)-1])
+
+		paged := query(0, 3)
+		paged.Page = 2
+		collect(t, cache.GetOHLCPerMinute(paged))
+		assert.Equal(t, *paged, source.queries[len(source.queries)-1])
+		assert.Equal(t, 3, cache.Stats().Bypassed)
+	})
+}
+
+func mustCoverage(t *testing.T, cache *Cache, isin string) []Range {
+	coverage, err := cache.config.Store.Coverage(Series{Kind: OHLCPerMinute, ISIN: isin, MIC: "XMUN"})
+	assert.Nil(t, err)
+	return coverage
+}
+
+func TestCacheTTL(t *testing.T) {
+	now := start.Add(time.Hour)
+	source := &fakeSource{}
+	cache := New(source, Config{TTL: time.Minute, Now: func() time.Time { return now }})
+
+	collect(t, cache.GetOHLCPerMinute(query(0, 10)))
+	collect(t, cache.GetOHLCPerMinute(query(0, 10)))
+	assert.Len(t, source.queries, 1)
+
+	now = now.Add(2 * time.Minute)
+	collect(t, cache.GetOHLCPerMinute(query(0, 10)))
+	assert.Len(t, source.queries, 2)
+	assert.Equal(t, 2, cache.Stats().Misses)
+}
+
+func TestCacheError(t *testing.T) {
+	source := &fakeSource{err: errors.New("not found")}
+	cache := New(source, Config{})
+	item := <-cache.GetOHLCPerMinute(query(0, 10))
+	assert.NotNil(t, item.Error)
+	coverage, _ := cache.config.Store.Coverage(Series{Kind: OHLCPerMinute, ISIN: "US88160R1014", MIC: "XMUN"})
+	assert.Len(t, coverage, 0)
+}
+
+func TestCacheTrades(t *testing.T) {
+	source := &fakeSource{}
+	cache := New(source, Config{Now: func() time.Time { return start.AddDate(0, 1, 0) }})
+	q := &market_data.GetTradesQuery{ISIN: []string{"A", "B"}, From: start, To: start.Add(time.Hour)}
+	trades := collect(t, cache.GetTrades(q))
+	assert.Len(t, trades, 4)
+	trades = collect(t, cache.GetTrades(q))
+	assert.Len(t, trades, 4)
+	assert.Len(t, source.trades, 2)
+
+	q.Limit = 1
+	collect(t, cache.GetTrades(q))
+	assert.Len(t, source.trades, 3)
+	assert.Equal(t, *q, source.trades[2])
+	assert.Equal(t, 1, cache.Stats().Bypassed)
+}
+
+func TestGaps(t *testing.T) {
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	coverage := []Range{{From: at(10), To: at(20)}, {From: at(30), To: at(40), Expires: at(0)}}
+
+	assert.Equal(t, []Range{{From: at(0), To: at(10)}, {From: at(20), To: at(50)}}, Gaps(coverage, at(0), at(50), at(1)))
+	assert.Len(t, Gaps(coverage, at(12), at(18), at(1)), 0)
+	assert.Equal(t, []Range{{From: at(0), To: at(5)}}, Gaps(nil, at(0), at(5), at(1)))
+}
+
+func TestAddRange(t *testing.T) {
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	coverage := []Range{{From: at(0), To: at(10)}, {From: at(20), To: at(30)}}
+
+	merged := AddRange(coverage, Range{From: at(10), To: at(20)}, at(0))
+	assert.Equal(t, []Range{{From: at(0), To: at(30)}}, merged)
+
+	open := AddRange(coverage, Range{From: at(30), To: at(40), Expires: at(5)}, at(0))
+	assert.Len(t, open, 3)
+}
@@ -0,0 +1,218 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of cached series
+const (
+	OHLCPerMinute = "ohlc_m1"
+	OHLCPerHour   = "ohlc_h1"
+	OHLCPerDay    = "ohlc_d1"
+	Trades        = "trades"
+)
+
+// Series identifies cached data of one instrument at one venue, MIC is empty for the default venue
+type Series struct {
+	Kind string
+	ISIN string
+	MIC  string
+}
+
+/*
+Range is a covered period [From, To). Expires is zero for ranges that will not change anymore,
+otherwise the range is fetched again once Expires has passed
+*/
+type Range struct {
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+func (r Range) valid(now time.Time) bool {
+	return r.Expires.IsZero() || now.Before(r.Expires)
+}
+
+// Record is a single cached bar or trade encoded as JSON
+type Record struct {
+	Time time.Time       `json:"t"`
+	Data json.RawMessage `json:"d"`
+}
+
+/*
+Store persists cached records and the ranges they cover. Write replaces all records of the series
+within the range and stores the new coverage, Read returns records in [from, to) sorted by time.
+Implement it to keep the cache in a database instead of files
+*/
+type Store interface {
+	Coverage(series Series) ([]Range, error)
+	Read(series Series, from, to time.Time) ([]Record, error)
+	Write(series Series, covered Range, records []Record, coverage []Range) error
+}
+
+// seriesData is what is stored for a series
+type seriesData struct {
+	Coverage []Range  `json:"coverage"`
+	Records  []Record `json:"records"`
+}
+
+func (d *seriesData) read(from, to time.Time) []Record {
+	var records []Record
+	for _, record := range d.Records {
+		if !record.Time.Before(from) && record.Time.Before(to) {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+func (d *seriesData) write(covered Range, records []Record, coverage []Range) {
+	kept := make([]Record, 0, len(d.Records)+len(records))
+	for _, record := range d.Records {
+		if record.Time.Before(covered.From) || !record.Time.Before(covered.To) {
+			kept = append(kept, record)
+		}
+	}
+	kept = append(kept, records...)
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].Time.Before(kept[j].Time) })
+	d.Records = kept
+	d.Coverage = coverage
+}
+
+// MemoryStore keeps the cache in memory, mostly useful for tests
+type MemoryStore struct {
+	mu     sync.Mutex
+	series map[Series]*seriesData
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{series: make(map[Series]*seriesData)}
+}
+
+// Coverage returns the ranges covered for the series
+func (s *MemoryStore) Coverage(series Series) ([]Range, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if data, ok := s.series[series]; ok {
+		return append([]Range(nil), data.Coverage...), nil
+	}
+	return nil, nil
+}
+
+// Read returns records in [from, to)
+func (s *MemoryStore) Read(series Series, from, to time.Time) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if data, ok := s.series[series]; ok {
+		return data.read(from, to), nil
+	}
+	return nil, nil
+}
+
+// Write replaces records within the covered range and stores the coverage
+func (s *MemoryStore) Write(series Series, covered Range, records []Record, coverage []Range) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.series[series]
+	if !ok {
+		data = &seriesData{}
+		s.series[series] = data
+	}
+	data.write(covered, records, coverage)
+	return nil
+}
+
+/*
+FileStore keeps one JSON file per series below Dir, Dir/<kind>/<mic>/<isin>.json
+*/
+type FileStore struct {
+	Dir string
+	mu  sync.Mutex
+}
+
+// NewFileStore returns a FileStore, the directory is created on the first write
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{Dir: dir}
+}
+
+func (s *FileStore) path(series Series) string {
+	mic := series.MIC
+	if mic == "" {
+		mic = "default"
+	}
+	return filepath.Join(s.Dir, clean(series.Kind), clean(mic), clean(series.ISIN)+".json")
+}
+
+// clean keeps a name from escaping its directory
+func clean(name string) string {
+	return strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(name)
+}
+
+func (s *FileStore) load(series Series) (*seriesData, error) {
+	data := &seriesData{}
+	raw, err := os.ReadFile(s.path(series))
+	if errors.Is(err, os.ErrNotExist) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, json.Unmarshal(raw, data)
+}
+
+// Coverage returns the ranges covered for the series
+func (s *FileStore) Coverage(series Series) ([]Range, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := s.load(series)
+	if err != nil {
+		return nil, err
+	}
+	return data.Coverage, nil
+}
+
+// Read returns records in [from, to)
+func (s *FileStore) Read(series Series, from, to time.Time) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := s.load(series)
+	if err != nil {
+		return nil, err
+	}
+	return data.read(from, to), nil
+}
+
+/*
+Write replaces records within the covered range and stores the coverage.
+The file is written to a temporary file first so that a failed write does not corrupt the cache
+*/
+func (s *FileStore) Write(series Series, covered Range, records []Record, coverage []Range) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := s.load(series)
+	if err != nil {
+		return err
+	}
+	data.write(covered, records, coverage)
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	path := s.path(series)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
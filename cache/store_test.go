@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func records(minutes ...int) []Record {
+	var result []Record
+	for _, minute := range minutes {
+		data, _ := json.Marshal(minute)
+		result = append(result, Record{Time: start.Add(time.Duration(minute) * time.Minute), Data: data})
+	}
+	return result
+}
+
+func testStore(t *testing.T, store Store) {
+	series := Series{Kind: OHLCPerMinute, ISIN: "US88160R1014", MIC: "XMUN"}
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	t.Run("empty series", func(t *testing.T) {
+		coverage, err := store.Coverage(series)
+		assert.Nil(t, err)
+		assert.Len(t, coverage, 0)
+	})
+	t.Run("write and read", func(t *testing.T) {
+		coverage := []Range{{From: at(0), To: at(5)}}
+		assert.Nil(t, store.Write(series, coverage[0], records(0, 1, 2, 3, 4), coverage))
+		read, err := store.Read(series, at(1), at(3))
+		assert.Nil(t, err)
+		assert.Equal(t, records(1, 2), read)
+		stored, err := store.Coverage(series)
+		assert.Nil(t, err)
+		assert.True(t, stored[0].To.Equal(at(5)))
+	})
+	t.Run("write replaces the range", func(t *testing.T) {
+		covered := Range{From: at(2), To: at(10)}
+		assert.Nil(t, store.Write(series, covered, records(3, 8), []Range{{From: at(0), To: at(10)}}))
+		read, err := store.Read(series, at(0), at(10))
+		assert.Nil(t, err)
+		assert.Len(t, read, 4)
+		assert.True(t, read[2].Time.Equal(at(3)))
+		assert.True(t, read[3].Time.Equal(at(8)))
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	testStore(t, NewFileStore(dir))
+
+	t.Run("one file per series", func(t *testing.T) {
+		_, err := os.Stat(filepath.Join(dir, OHLCPerMinute, "XMUN", "US88160R1014.json"))
+		assert.Nil(t, err)
+	})
+	t.Run("corrupt file", func(t *testing.T) {
+		series := Series{Kind: Trades, ISIN: "broken"}
+		path := filepath.Join(dir, Trades, "default", "broken.json")
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.Nil(t, os.WriteFile(path, []byte("really odd content"), 0o644))
+		_, err := NewFileStore(dir).Coverage(series)
+		assert.NotNil(t, err)
+	})
+}
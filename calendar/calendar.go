@@ -0,0 +1,263 @@
+/*
+Package calendar knows when trading venues are open, without network access.
+
+A Schedule describes the regular session of a venue in its timezone together with its holidays and early closes,
+a Calendar holds the schedules per MIC:
+
+	cal := calendar.Default()
+	open, err := cal.IsOpen("XMUN", time.Now())
+	next, err := cal.NextOpen("XETR", time.Now())
+
+Schedules are static and may fall behind the venues, Calendar.Check compares them with the venues returned
+by market_data.MarketDataClient.GetVenues
+*/
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+)
+
+// ErrUnknownVenue is returned for MICs without a schedule
+var ErrUnknownVenue = errors.New("no schedule for venue")
+
+// maxSearch bounds how far NextOpen, NextClose and AddTradingDays look for trading days
+const maxSearch = 3660
+
+/*
+Schedule of a venue. Open and Close are offsets from local midnight in Location, Weekdays are the days
+the venue trades on and default to monday to friday. Location defaults to Europe/Berlin
+*/
+type Schedule struct {
+	MIC      string
+	Location *time.Location
+	Open     time.Duration
+	Close    time.Duration
+	Weekdays []time.Weekday
+	Holidays []Holiday
+}
+
+func (s Schedule) location() *time.Location {
+	if s.Location != nil {
+		return s.Location
+	}
+	return berlin
+}
+
+var berlin = func() *time.Location {
+	location, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}()
+
+func (s Schedule) tradesOn(weekday time.Weekday) bool {
+	if s.Weekdays == nil {
+		return weekday != time.Saturday && weekday != time.Sunday
+	}
+	for _, w := range s.Weekdays {
+		if w == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// date is local midnight of t
+func (s Schedule) date(t time.Time) time.Time {
+	year, month, day := t.In(s.location()).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, s.location())
+}
+
+/*
+session returns open and close of the trading day starting at the local midnight date, ok is false
+when the venue does not trade that day
+*/
+func (s Schedule) session(date time.Time) (open, close time.Time, ok bool) {
+	if !s.tradesOn(date.Weekday()) {
+		return open, close, false
+	}
+	year, month, day := date.Date()
+	closeOffset := s.Close
+	for _, holiday := range s.Holidays {
+		if !holiday.on(year, month, day) {
+			continue
+		}
+		if holiday.Close == 0 {
+			return open, close, false
+		}
+		if holiday.Close < closeOffset {
+			closeOffset = holiday.Close
+		}
+	}
+	// Offsets are applied to the wall clock, so that sessions keep their local hours on DST changes
+	open = time.Date(year, month, day, 0, 0, 0, int(s.Open), s.location())
+	close = time.Date(year, month, day, 0, 0, 0, int(closeOffset), s.location())
+	return open, close, true
+}
+
+// Session returns the regular trading hours of the venue, such as for market_data.ResampleConfig
+func (s Schedule) Session() market_data.Session {
+	return market_data.Session{Open: s.Open, Close: s.Close}
+}
+
+// IsTradingDay is true when the venue trades on the local date of t
+func (s Schedule) IsTradingDay(t time.Time) bool {
+	_, _, ok := s.session(s.date(t))
+	return ok
+}
+
+// IsOpen is true when t is within a session
+func (s Schedule) IsOpen(t time.Time) bool {
+	open, close, ok := s.session(s.date(t))
+	return ok && !t.Before(open) && t.Before(close)
+}
+
+/*
+NextOpen is the first session open at or after t, zero if the venue does not trade within ten years
+*/
+func (s Schedule) NextOpen(t time.Time) time.Time {
+	date := s.date(t)
+	for i := 0; i < maxSearch; i++ {
+		if open, _, ok := s.session(date); ok && !open.Before(t) {
+			return open
+		}
+		date = date.AddDate(0, 0, 1)
+	}
+	return time.Time{}
+}
+
+/*
+NextClose is the first session close after t, which is the close of the current session while open.
+Zero if the venue does not trade within ten years
+*/
+func (s Schedule) NextClose(t time.Time) time.Time {
+	date := s.date(t)
+	for i := 0; i < maxSearch; i++ {
+		if _, close, ok := s.session(date); ok && close.After(t) {
+			return close
+		}
+		date = date.AddDate(0, 0, 1)
+	}
+	return time.Time{}
+}
+
+/*
+TradingDays returns the local midnight of every trading day from the date of from to the date of to, both included
+*/
+func (s Schedule) TradingDays(from, to time.Time) []time.Time {
+	var days []time.Time
+	last := s.date(to)
+	for date := s.date(from); !date.After(last); date = date.AddDate(0, 0, 1) {
+		if _, _, ok := s.session(date); ok {
+			days = append(days, date)
+		}
+	}
+	return days
+}
+
+/*
+AddTradingDays moves n trading days from the date of t, backwards for negative n, and returns local midnight.
+With n = 0 the date of t is returned even if it is not a trading day. Zero if there are not enough trading days
+within ten years
+*/
+func (s Schedule) AddTradingDays(t time.Time, n int) time.Time {
+	date := s.date(t)
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for i := 0; n > 0; i++ {
+		if i == maxSearch {
+			return time.Time{}
+		}
+		date = date.AddDate(0, 0, step)
+		if _, _, ok := s.session(date); ok {
+			n--
+		}
+	}
+	return date
+}
+
+/*
+TradingDaysBetween counts the trading days after the date of from up to and including the date of to,
+negative when to is before from. For trading days AddTradingDays(from, TradingDaysBetween(from, to)) is to
+*/
+func (s Schedule) TradingDaysBetween(from, to time.Time) int {
+	if s.date(to).Before(s.date(from)) {
+		return -s.TradingDaysBetween(to, from)
+	}
+	return len(s.TradingDays(s.date(from).AddDate(0, 0, 1), to))
+}
+
+/*
+Calendar holds the schedules of venues by MIC
+*/
+type Calendar struct {
+	schedules map[string]Schedule
+}
+
+// New calendar with the given schedules, later schedules replace earlier ones with the same MIC
+func New(schedules ...Schedule) *Calendar {
+	c := &Calendar{schedules: make(map[string]Schedule)}
+	for _, schedule := range schedules {
+		c.Add(schedule)
+	}
+	return c
+}
+
+// Add or replace the schedule of a venue
+func (c *Calendar) Add(schedule Schedule) {
+	c.schedules[schedule.MIC] = schedule
+}
+
+// Schedule of a venue, ErrUnknownVenue if there is none
+func (c *Calendar) Schedule(mic string) (Schedule, error) {
+	schedule, ok := c.schedules[mic]
+	if !ok {
+		return schedule, fmt.Errorf("%w: %s", ErrUnknownVenue, mic)
+	}
+	return schedule, nil
+}
+
+// MICs of all venues with a schedule, sorted
+func (c *Calendar) MICs() []string {
+	mics := make([]string, 0, len(c.schedules))
+	for mic := range c.schedules {
+		mics = append(mics, mic)
+	}
+	sort.Strings(mics)
+	return mics
+}
+
+// IsOpen is true when the venue is in session at t
+func (c *Calendar) IsOpen(mic string, t time.Time) (bool, error) {
+	schedule, err := c.Schedule(mic)
+	if err != nil {
+		return false, err
+	}
+	return schedule.IsOpen(t), nil
+}
+
+// NextOpen is the first session open of the venue at or after t
+func (c *Calendar) NextOpen(mic string, t time.Time) (time.Time, error) {
+	schedule, err := c.Schedule(mic)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.NextOpen(t), nil
+}
+
+// NextClose is the first session close of the venue after t
+func (c *Calendar) NextClose(mic string, t time.Time) (time.Time, error) {
+	schedule, err := c.Schedule(mic)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.NextClose(t), nil
+}
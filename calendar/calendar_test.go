@@ -0,0 +1,115 @@
+package calendar
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/stretchr/testify/assert"
+)
+
+func local(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, berlin)
+}
+
+func TestEasterSunday(t *testing.T) {
+	for year, expected := range map[int][2]int{2000: {4, 23}, 2022: {4, 17}, 2023: {4, 9}, 2024: {3, 31}} {
+		month, day := easterSunday(year)
+		assert.Equal(t, time.Month(expected[0]), month, year)
+		assert.Equal(t, expected[1], day, year)
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	cal := Default()
+	gettex, err := cal.Schedule("XMUN")
+	assert.Nil(t, err)
+	xetra, err := cal.Schedule("XETR")
+	assert.Nil(t, err)
+
+	t.Run("is open", func(t *testing.T) {
+		assert.True(t, gettex.IsOpen(local(2022, 1, 3, 9, 0)))
+		assert.True(t, gettex.IsOpen(local(2022, 1, 3, 8, 0)))
+		assert.False(t, gettex.IsOpen(local(2022, 1, 3, 7, 59)))
+		assert.False(t, gettex.IsOpen(local(2022, 1, 3, 22, 0)))
+		assert.False(t, gettex.IsOpen(local(2022, 1, 8, 12, 0)), "saturday")
+		assert.False(t, gettex.IsOpen(local(2022, 4, 15, 12, 0)), "good friday")
+		assert.False(t, gettex.IsOpen(local(2022, 4, 18, 12, 0)), "easter monday")
+		assert.True(t, gettex.IsOpen(local(2023, 5, 1, 12, 0)), "gettex trades on labour day")
+		assert.False(t, xetra.IsOpen(local(2023, 5, 1, 12, 0)), "xetra does not")
+		assert.False(t, xetra.IsOpen(local(2022, 1, 3, 17, 30)))
+		assert.True(t, xetra.IsOpen(time.Date(2022, 1, 3, 16, 29, 0, 0, time.UTC)))
+	})
+	t.Run("next open and close", func(t *testing.T) {
+		assert.Equal(t, local(2022, 4, 19, 8, 0), gettex.NextOpen(local(2022, 4, 14, 23, 0)))
+		assert.Equal(t, local(2022, 1, 3, 8, 0), gettex.NextOpen(local(2022, 1, 3, 8, 0)))
+		assert.Equal(t, local(2022, 1, 4, 8, 0), gettex.NextOpen(local(2022, 1, 3, 8, 1)))
+		assert.Equal(t, local(2022, 1, 3, 22, 0), gettex.NextClose(local(2022, 1, 3, 12, 0)))
+		assert.Equal(t, local(2022, 1, 10, 22, 0), gettex.NextClose(local(2022, 1, 7, 22, 0)))
+	})
+	t.Run("daylight saving time", func(t *testing.T) {
+		assert.Equal(t, time.Date(2022, 3, 25, 7, 0, 0, 0, time.UTC), gettex.NextOpen(time.Date(2022, 3, 25, 0, 0, 0, 0, time.UTC)).UTC())
+		assert.Equal(t, time.Date(2022, 3, 28, 6, 0, 0, 0, time.UTC), gettex.NextOpen(time.Date(2022, 3, 26, 0, 0, 0, 0, time.UTC)).UTC())
+	})
+	t.Run("early close", func(t *testing.T) {
+		schedule := xetra
+		schedule.Holidays = append(schedule.Holidays, Fixed("Last trading day", time.December, 30).ClosesAt(14*time.Hour))
+		assert.True(t, schedule.IsOpen(local(2022, 12, 30, 13, 59)))
+		assert.False(t, schedule.IsOpen(local(2022, 12, 30, 14, 0)))
+		assert.Equal(t, local(2022, 12, 30, 14, 0), schedule.NextClose(local(2022, 12, 30, 10, 0)))
+		assert.Equal(t, local(2023, 1, 2, 9, 0), schedule.NextOpen(local(2022, 12, 30, 14, 0)))
+	})
+	t.Run("trading days", func(t *testing.T) {
+		days := xetra.TradingDays(local(2022, 12, 20, 12, 0), local(2022, 12, 31, 0, 0))
+		assert.Len(t, days, 8)
+		assert.Equal(t, local(2022, 12, 23, 0, 0), days[3])
+		assert.Equal(t, local(2022, 12, 27, 0, 0), days[4])
+		assert.Equal(t, local(2022, 12, 30, 0, 0), days[7])
+	})
+	t.Run("business day arithmetic", func(t *testing.T) {
+		thursday := local(2022, 4, 14, 15, 0)
+		assert.Equal(t, local(2022, 4, 19, 0, 0), gettex.AddTradingDays(thursday, 1))
+		assert.Equal(t, local(2022, 4, 14, 0, 0), gettex.AddTradingDays(local(2022, 4, 19, 0, 0), -1))
+		assert.Equal(t, local(2022, 4, 14, 0, 0), gettex.AddTradingDays(thursday, 0))
+		assert.Equal(t, local(2022, 4, 25, 0, 0), gettex.AddTradingDays(thursday, 5))
+		assert.Equal(t, 1, gettex.TradingDaysBetween(thursday, local(2022, 4, 19, 9, 0)))
+		assert.Equal(t, -1, gettex.TradingDaysBetween(local(2022, 4, 19, 9, 0), thursday))
+		assert.Equal(t, 0, gettex.TradingDaysBetween(thursday, thursday))
+		assert.True(t, gettex.IsTradingDay(thursday))
+		assert.False(t, gettex.IsTradingDay(local(2022, 4, 15, 0, 0)))
+	})
+	t.Run("never open", func(t *testing.T) {
+		schedule := Schedule{MIC: "NONE", Weekdays: []time.Weekday{}}
+		assert.True(t, schedule.NextOpen(local(2022, 1, 3, 0, 0)).IsZero())
+		assert.True(t, schedule.AddTradingDays(local(2022, 1, 3, 0, 0), 1).IsZero())
+	})
+	t.Run("session", func(t *testing.T) {
+		assert.Equal(t, market_data.Session{Open: 9 * time.Hour, Close: 17*time.Hour + 30*time.Minute}, xetra.Session())
+	})
+}
+
+func TestCalendar(t *testing.T) {
+	cal := Default()
+	assert.Equal(t, []string{"LMBPX", "XETR", "XMUN"}, cal.MICs())
+
+	open, err := cal.IsOpen("LMBPX", local(2022, 1, 3, 12, 0))
+	assert.Nil(t, err)
+	assert.True(t, open)
+	next, err := cal.NextOpen("XETR", local(2022, 1, 3, 18, 0))
+	assert.Nil(t, err)
+	assert.Equal(t, local(2022, 1, 4, 9, 0), next)
+	close, err := cal.NextClose("XETR", local(2022, 1, 3, 18, 0))
+	assert.Nil(t, err)
+	assert.Equal(t, local(2022, 1, 4, 17, 30), close)
+
+	_, err = cal.IsOpen("XNYS", local(2022, 1, 3, 12, 0))
+	assert.True(t, errors.Is(err, ErrUnknownVenue))
+	_, err = cal.NextOpen("XNYS", local(2022, 1, 3, 12, 0))
+	assert.True(t, errors.Is(err, ErrUnknownVenue))
+
+	cal.Add(Schedule{MIC: "XNYS", Location: time.UTC, Open: 14*time.Hour + 30*time.Minute, Close: 21 * time.Hour})
+	open, err = cal.IsOpen("XNYS", time.Date(2022, 1, 3, 15, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	assert.True(t, open)
+}
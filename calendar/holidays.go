@@ -0,0 +1,109 @@
+package calendar
+
+import (
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/trading"
+)
+
+/*
+Holiday is a day on which a venue is closed. With Close set the venue opens as usual but closes early,
+Close is the offset from local midnight
+*/
+type Holiday struct {
+	Name  string
+	Close time.Duration
+	date  func(year int) (time.Month, int, bool)
+}
+
+// Fixed is a holiday on the same date every year, it is not moved when it falls on a weekend
+func Fixed(name string, month time.Month, day int) Holiday {
+	return Holiday{Name: name, date: func(int) (time.Month, int, bool) { return month, day, true }}
+}
+
+// Easter is a holiday days after easter sunday, negative days are before, Good Friday is Easter("Good Friday", -2)
+func Easter(name string, days int) Holiday {
+	return Holiday{Name: name, date: func(year int) (time.Month, int, bool) {
+		month, day := easterSunday(year)
+		date := time.Date(year, month, day+days, 0, 0, 0, 0, time.UTC)
+		return date.Month(), date.Day(), date.Year() == year
+	}}
+}
+
+// Once is a holiday on a single date in trading.DateLayout, such as an unscheduled closure
+func Once(name string, date string) (Holiday, error) {
+	t, err := time.Parse(trading.DateLayout, date)
+	if err != nil {
+		return Holiday{}, err
+	}
+	return Holiday{Name: name, date: func(year int) (time.Month, int, bool) {
+		return t.Month(), t.Day(), year == t.Year()
+	}}, nil
+}
+
+// ClosesAt turns the holiday into an early close at the given offset from local midnight
+func (h Holiday) ClosesAt(close time.Duration) Holiday {
+	h.Close = close
+	return h
+}
+
+// on is true when the holiday falls on the given date
+func (h Holiday) on(year int, month time.Month, day int) bool {
+	if h.date == nil {
+		return false
+	}
+	m, d, ok := h.date(year)
+	return ok && m == month && d == day
+}
+
+/*
+easterSunday of a year in the gregorian calendar, using the anonymous gregorian algorithm
+*/
+func easterSunday(year int) (time.Month, int) {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	n := h + l - 7*m + 114
+	return time.Month(n / 31), n%31 + 1
+}
+
+/*
+GermanExchangeHolidays are the days on which the german exchanges are closed: New Year's Day, Good Friday,
+Easter Monday, Labour Day, Christmas Eve, Christmas Day, Boxing Day and New Year's Eve
+*/
+func GermanExchangeHolidays() []Holiday {
+	return []Holiday{
+		Fixed("New Year's Day", time.January, 1),
+		Easter("Good Friday", -2),
+		Easter("Easter Monday", 1),
+		Fixed("Labour Day", time.May, 1),
+		Fixed("Christmas Eve", time.December, 24),
+		Fixed("Christmas Day", time.December, 25),
+		Fixed("Boxing Day", time.December, 26),
+		Fixed("New Year's Eve", time.December, 31),
+	}
+}
+
+/*
+GettexHolidays are the days on which Gettex is closed. Unlike Xetra, Gettex trades on Labour Day
+*/
+func GettexHolidays() []Holiday {
+	return []Holiday{
+		Fixed("New Year's Day", time.January, 1),
+		Easter("Good Friday", -2),
+		Easter("Easter Monday", 1),
+		Fixed("Christmas Eve", time.December, 24),
+		Fixed("Christmas Day", time.December, 25),
+		Fixed("Boxing Day", time.December, 26),
+		Fixed("New Year's Eve", time.December, 31),
+	}
+}
@@ -0,0 +1,148 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/quantfamily/lemonmarkets/trading"
+)
+
+/*
+Default calendar with the venues available through lemon.markets: Gettex (XMUN), lemon.markets best price (LMBPX),
+which executes on Gettex, and Xetra (XETR)
+*/
+func Default() *Calendar {
+	return New(
+		Schedule{MIC: "XMUN", Location: berlin, Open: 8 * time.Hour, Close: 22 * time.Hour, Holidays: GettexHolidays()},
+		Schedule{MIC: "LMBPX", Location: berlin, Open: 8 * time.Hour, Close: 22 * time.Hour, Holidays: GettexHolidays()},
+		Schedule{MIC: "XETR", Location: berlin, Open: 9 * time.Hour, Close: 17*time.Hour + 30*time.Minute, Holidays: GermanExchangeHolidays()},
+	)
+}
+
+/*
+FromVenue builds a schedule from the opening hours and days reported by the API. Weekdays between the first
+and the last opening day that are not listed become holidays, outside of that range the venue trades on weekdays
+*/
+func FromVenue(venue market_data.Venue) (Schedule, error) {
+	schedule := Schedule{MIC: venue.Mic, Location: berlin}
+	if venue.OpeningHours.Timezone != "" {
+		location, err := time.LoadLocation(venue.OpeningHours.Timezone)
+		if err != nil {
+			return schedule, err
+		}
+		schedule.Location = location
+	}
+	var err error
+	if schedule.Open, err = parseClock(venue.OpeningHours.Start); err != nil {
+		return schedule, err
+	}
+	if schedule.Close, err = parseClock(venue.OpeningHours.End); err != nil {
+		return schedule, err
+	}
+	days, err := openingDays(venue, schedule.Location)
+	if err != nil || len(days) == 0 {
+		return schedule, err
+	}
+	for date := days[0]; date.Before(days[len(days)-1]); date = date.AddDate(0, 0, 1) {
+		if !schedule.tradesOn(date.Weekday()) || contains(days, date) {
+			continue
+		}
+		holiday, _ := Once("closed", date.Format(trading.DateLayout))
+		schedule.Holidays = append(schedule.Holidays, holiday)
+	}
+	return schedule, nil
+}
+
+/*
+Mismatch between a schedule and what the API reports for a venue
+*/
+type Mismatch struct {
+	MIC    string
+	Reason string
+}
+
+func (m Mismatch) String() string {
+	return m.MIC + ": " + m.Reason
+}
+
+/*
+Check compares the calendar with venues from market_data.MarketDataClient.GetVenues. It reports venues without
+a schedule, different opening hours or timezones, opening days on which the schedule is closed and weekdays
+missing from the opening days on which the schedule trades
+*/
+func (c *Calendar) Check(venues []market_data.Venue) []Mismatch {
+	var mismatches []Mismatch
+	report := func(mic, format string, args ...interface{}) {
+		mismatches = append(mismatches, Mismatch{MIC: mic, Reason: fmt.Sprintf(format, args...)})
+	}
+	for _, venue := range venues {
+		schedule, err := c.Schedule(venue.Mic)
+		if err != nil {
+			report(venue.Mic, "no schedule")
+			continue
+		}
+		hours := venue.OpeningHours
+		if hours.Start != "" && hours.Start != formatClock(schedule.Open) {
+			report(venue.Mic, "opens at %s, schedule at %s", hours.Start, formatClock(schedule.Open))
+		}
+		if hours.End != "" && hours.End != formatClock(schedule.Close) {
+			report(venue.Mic, "closes at %s, schedule at %s", hours.End, formatClock(schedule.Close))
+		}
+		if hours.Timezone != "" && hours.Timezone != schedule.location().String() {
+			report(venue.Mic, "timezone %s, schedule %s", hours.Timezone, schedule.location())
+		}
+		days, err := openingDays(venue, schedule.location())
+		if err != nil {
+			report(venue.Mic, "%v", err)
+			continue
+		}
+		if len(days) == 0 {
+			continue
+		}
+		for _, date := range days {
+			if !schedule.IsTradingDay(date) {
+				report(venue.Mic, "opening day %s is not a trading day", date.Format(trading.DateLayout))
+			}
+		}
+		for _, date := range schedule.TradingDays(days[0], days[len(days)-1]) {
+			if !contains(days, date) {
+				report(venue.Mic, "trading day %s is not an opening day", date.Format(trading.DateLayout))
+			}
+		}
+	}
+	return mismatches
+}
+
+// openingDays of the venue as sorted local midnights
+func openingDays(venue market_data.Venue, location *time.Location) ([]time.Time, error) {
+	days := make([]time.Time, 0, len(venue.OpeningDays))
+	for _, day := range venue.OpeningDays {
+		date, err := time.ParseInLocation(trading.DateLayout, day, location)
+		if err != nil {
+			return nil, err
+		}
+		days = append(days, date)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days, nil
+}
+
+func contains(days []time.Time, date time.Time) bool {
+	i := sort.Search(len(days), func(i int) bool { return !days[i].Before(date) })
+	return i < len(days) && days[i].Equal(date)
+}
+
+// parseClock parses a local time such as 08:00 into an offset from midnight
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func formatClock(offset time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(offset/time.Hour), int(offset%time.Hour/time.Minute))
+}
@@ -0,0 +1,63 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/stretchr/testify/assert"
+)
+
+var gettexVenue = market_data.Venue{
+	Name:         "Börse München - Gettex",
+	Mic:          "XMUN",
+	OpeningHours: market_data.OpeningHours{Start: "08:00", End: "22:00", Timezone: "Europe/Berlin"},
+	OpeningDays:  []string{"2022-04-13", "2022-04-14", "2022-04-19", "2022-04-20"},
+}
+
+func TestFromVenue(t *testing.T) {
+	t.Run("Successful test", func(t *testing.T) {
+		schedule, err := FromVenue(gettexVenue)
+		assert.Nil(t, err)
+		assert.Equal(t, "XMUN", schedule.MIC)
+		assert.Equal(t, 8*time.Hour, schedule.Open)
+		assert.Equal(t, 22*time.Hour, schedule.Close)
+		assert.Len(t, schedule.Holidays, 2)
+		assert.False(t, schedule.IsTradingDay(local(2022, 4, 15, 0, 0)))
+		assert.False(t, schedule.IsTradingDay(local(2022, 4, 18, 0, 0)))
+		assert.True(t, schedule.IsTradingDay(local(2022, 4, 21, 0, 0)), "after the last opening day")
+		assert.Equal(t, local(2022, 4, 19, 8, 0), schedule.NextOpen(local(2022, 4, 14, 22, 0)))
+	})
+	t.Run("Fail to decode results", func(t *testing.T) {
+		venue := gettexVenue
+		venue.OpeningHours.Start = "8 o'clock"
+		_, err := FromVenue(venue)
+		assert.NotNil(t, err)
+		venue = gettexVenue
+		venue.OpeningDays = []string{"tomorrow"}
+		_, err = FromVenue(venue)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestCheck(t *testing.T) {
+	cal := Default()
+	t.Run("matching venue", func(t *testing.T) {
+		assert.Empty(t, cal.Check([]market_data.Venue{gettexVenue}))
+	})
+	t.Run("mismatches", func(t *testing.T) {
+		xetra := market_data.Venue{
+			Mic:          "XETR",
+			OpeningHours: market_data.OpeningHours{Start: "09:00", End: "17:35", Timezone: "Europe/Berlin"},
+			OpeningDays:  []string{"2022-04-14", "2022-04-15", "2022-04-20"},
+		}
+		mismatches := cal.Check([]market_data.Venue{xetra, {Mic: "XNYS"}})
+		assert.Equal(t, []Mismatch{
+			{MIC: "XETR", Reason: "closes at 17:35, schedule at 17:30"},
+			{MIC: "XETR", Reason: "opening day 2022-04-15 is not a trading day"},
+			{MIC: "XETR", Reason: "trading day 2022-04-19 is not an opening day"},
+			{MIC: "XNYS", Reason: "no schedule"},
+		}, mismatches)
+		assert.Equal(t, "XNYS: no schedule", mismatches[3].String())
+	})
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -27,6 +28,13 @@ Q as struct holding query- parameters that should be include, eg for filtering
 Data as request body that should be posted
 */
 func (c *Backend) Do(method string, endpoint string, q interface{}, data []byte) (*Response, error) {
+	return c.DoContext(context.Background(), method, endpoint, q, data)
+}
+
+/*
+DoContext is Do with a context, the request is canceled when ctx is done
+*/
+func (c *Backend) DoContext(ctx context.Context, method string, endpoint string, q interface{}, data []byte) (*Response, error) {
 	var url string
 	if strings.Contains(endpoint, "lemon.markets/v1") {
 		url = endpoint
@@ -40,7 +48,7 @@ func (c *Backend) Do(method string, endpoint string, q interface{}, data []byte)
 		}
 		url = fmt.Sprintf("%s?%s", url, queryString.Encode())
 	}
-	request, err := http.NewRequest(method, url, bytes.NewBuffer(data))
+	request, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(data))
 	if err != nil {
 		return nil, err
 	}
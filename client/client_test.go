@@ -1,7 +1,9 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -41,6 +43,22 @@ func TestBackend(t *testing.T) {
 			t.Errorf("Expected error, got nil")
 		}
 	})
+	t.Run("DoContext, canceled request", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer server.Close()
+		defer close(release)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		backend := Backend{BaseURL: server.URL}
+		_, err := backend.DoContext(ctx, "GET", "demo", nil, nil)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got: %v", err)
+		}
+	})
 	t.Run("Do, normal request", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			fmt.Fprint(w, `{"status": "ok"}`)
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+/*
+Config of lemonctl, read from a JSON file and overridden by environment variables.
+TradingURL and MarketDataURL are only needed for proxies or test servers
+*/
+type Config struct {
+	TradingAPIKey    string `json:"trading_api_key"`
+	MarketDataAPIKey string `json:"market_data_api_key"`
+	Live             bool   `json:"live"`
+	TradingURL       string `json:"trading_url,omitempty"`
+	MarketDataURL    string `json:"market_data_url,omitempty"`
+}
+
+// Environment variables read by lemonctl, LEMON_API_KEY is used for both APIs if the specific key is not set
+const (
+	envAPIKey           = "LEMON_API_KEY"
+	envTradingAPIKey    = "LEMON_TRADING_API_KEY"
+	envMarketDataAPIKey = "LEMON_MARKET_DATA_API_KEY"
+	envConfig           = "LEMONCTL_CONFIG"
+)
+
+// defaultConfigPath is $XDG_CONFIG_HOME/lemonctl/config.json or the platform equivalent
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "lemonctl", "config.json")
+}
+
+/*
+loadConfig reads the config file and applies environment variables on top.
+A missing file is only an error when the path was given explicitly
+*/
+func loadConfig(path string, getenv func(string) string) (Config, error) {
+	config := Config{}
+	explicit := path != ""
+	if !explicit {
+		path = getenv(envConfig)
+		explicit = path != ""
+	}
+	if !explicit {
+		path = defaultConfigPath()
+	}
+	if path != "" {
+		raw, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist) && !explicit:
+		case err != nil:
+			return config, err
+		default:
+			if err := json.Unmarshal(raw, &config); err != nil {
+				return config, err
+			}
+		}
+	}
+	if key := getenv(envAPIKey); key != "" {
+		config.TradingAPIKey = key
+		config.MarketDataAPIKey = key
+	}
+	if key := getenv(envTradingAPIKey); key != "" {
+		config.TradingAPIKey = key
+	}
+	if key := getenv(envMarketDataAPIKey); key != "" {
+		config.MarketDataAPIKey = key
+	}
+	return config, nil
+}
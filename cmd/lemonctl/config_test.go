@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	assert.Nil(t, os.WriteFile(path, []byte(`{"trading_api_key": "file-trading", "market_data_api_key": "file-data", "live": true}`), 0600))
+	env := func(values map[string]string) func(string) string {
+		return func(key string) string { return values[key] }
+	}
+
+	t.Run("Successful test", func(t *testing.T) {
+		config, err := loadConfig(path, env(nil))
+		assert.Nil(t, err)
+		assert.Equal(t, Config{TradingAPIKey: "file-trading", MarketDataAPIKey: "file-data", Live: true}, config)
+	})
+	t.Run("environment overrides file", func(t *testing.T) {
+		config, err := loadConfig(path, env(map[string]string{envAPIKey: "shared", envMarketDataAPIKey: "data"}))
+		assert.Nil(t, err)
+		assert.Equal(t, "shared", config.TradingAPIKey)
+		assert.Equal(t, "data", config.MarketDataAPIKey)
+	})
+	t.Run("config path from environment", func(t *testing.T) {
+		config, err := loadConfig("", env(map[string]string{envConfig: path}))
+		assert.Nil(t, err)
+		assert.Equal(t, "file-trading", config.TradingAPIKey)
+	})
+	t.Run("missing explicit file", func(t *testing.T) {
+		_, err := loadConfig(filepath.Join(t.TempDir(), "missing.json"), env(nil))
+		assert.NotNil(t, err)
+	})
+	t.Run("Fail to decode results", func(t *testing.T) {
+		broken := filepath.Join(t.TempDir(), "broken.json")
+		assert.Nil(t, os.WriteFile(broken, []byte(`really odd config`), 0600))
+		_, err := loadConfig(broken, env(nil))
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,188 @@
+/*
+lemonctl is a command-line tool for the lemon.markets trading and market data APIs.
+
+	lemonctl [-live] [-output table|json|csv] [-yes] [-config file] <command> [arguments]
+
+API keys are read from the config file ($XDG_CONFIG_HOME/lemonctl/config.json unless -config or
+LEMONCTL_CONFIG is set) and from LEMON_API_KEY, LEMON_TRADING_API_KEY and LEMON_MARKET_DATA_API_KEY.
+The paper environment is used unless -live is given or the config sets "live": true, commands that
+change orders on the live environment ask for confirmation unless -yes is given.
+*/
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/quantfamily/lemonmarkets/trading"
+)
+
+// app is what every command needs
+type app struct {
+	config  Config
+	format  string
+	yes     bool
+	stdin   *bufio.Reader
+	stdout  io.Writer
+	stderr  io.Writer
+	trading *trading.TradingClient
+	data    *market_data.MarketDataClient
+}
+
+type command struct {
+	usage string
+	run   func(a *app, args []string) error
+}
+
+var commands = map[string]command{
+	"account":     {"show account details", runAccount},
+	"positions":   {"list positions", runPositions},
+	"orders":      {"list, get, create, activate or cancel orders", runOrders},
+	"withdrawals": {"list withdrawals", runWithdrawals},
+	"documents":   {"list documents", runDocuments},
+	"instruments": {"search instruments", runInstruments},
+	"venues":      {"list venues", runVenues},
+	"ohlc":        {"show OHLC bars", runOHLC},
+	"quotes":      {"show quotes", runQuotes},
+	"trades":      {"show trades", runTrades},
+	"stream":      {"poll the latest quotes until stopped", runStream},
+}
+
+// errUsage is returned for wrong arguments, the usage has been printed already
+var errUsage = errors.New("usage")
+
+// errAborted is returned when a live change is not confirmed
+var errAborted = errors.New("aborted")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr, os.Getenv))
+}
+
+// run executes lemonctl and returns the exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer, getenv func(string) string) int {
+	flags := flag.NewFlagSet("lemonctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	live := flags.Bool("live", false, "use the live environment instead of paper trading")
+	format := flags.String("output", formatTable, "output format: table, json or csv")
+	yes := flags.Bool("yes", false, "do not ask for confirmation of live changes")
+	configPath := flags.String("config", "", "config file")
+	flags.Usage = func() { usage(stderr, flags) }
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *format != formatTable && *format != formatJSON && *format != formatCSV {
+		fmt.Fprintf(stderr, "unknown output format %q\n", *format)
+		return 2
+	}
+	if flags.NArg() == 0 {
+		usage(stderr, flags)
+		return 2
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n", flags.Arg(0))
+		usage(stderr, flags)
+		return 2
+	}
+
+	config, err := loadConfig(*configPath, getenv)
+	if err != nil {
+		fmt.Fprintf(stderr, "config: %v\n", err)
+		return 1
+	}
+	config.Live = config.Live || *live
+	a := &app{config: config, format: *format, yes: *yes, stdin: bufio.NewReader(stdin), stdout: stdout, stderr: stderr}
+	a.trading, a.data = clients(config)
+
+	err = cmd.run(a, flags.Args()[1:])
+	switch {
+	case errors.Is(err, errUsage):
+		return 2
+	case err != nil:
+		fmt.Fprintf(stderr, "%s: %v\n", flags.Arg(0), err)
+		return 1
+	}
+	return 0
+}
+
+func clients(config Config) (*trading.TradingClient, *market_data.MarketDataClient) {
+	environment := trading.PAPER
+	if config.Live {
+		environment = trading.LIVE
+	}
+	if config.TradingURL != "" {
+		environment = trading.Environment(config.TradingURL)
+	}
+	data := market_data.NewClient(config.MarketDataAPIKey)
+	if config.MarketDataURL != "" {
+		data = market_data.NewClientWithEnvironment(config.MarketDataAPIKey, market_data.Environment(config.MarketDataURL))
+	}
+	return trading.NewClient(config.TradingAPIKey, environment), data
+}
+
+func usage(w io.Writer, flags *flag.FlagSet) {
+	fmt.Fprintln(w, "usage: lemonctl [flags] <command> [arguments]")
+	fmt.Fprintln(w, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-12s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintln(w, "\nflags:")
+	flags.PrintDefaults()
+}
+
+// confirm asks before changes on the live environment
+func (a *app) confirm(action string) error {
+	if !a.config.Live || a.yes {
+		return nil
+	}
+	fmt.Fprintf(a.stderr, "%s on the LIVE account? [y/N] ", action)
+	answer, _ := a.stdin.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return errAborted
+}
+
+// parse parses command flags, printing usage on errors
+func parse(a *app, flags *flag.FlagSet, args []string) error {
+	flags.SetOutput(a.stderr)
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	return nil
+}
+
+func collectTrading[T trading.DataTypes](ch <-chan trading.Item[T, error]) ([]T, error) {
+	var values []T
+	for item := range ch {
+		if item.Error != nil {
+			return values, item.Error
+		}
+		values = append(values, item.Data)
+	}
+	return values, nil
+}
+
+func collectData[T market_data.DataTypes](ch <-chan market_data.Item[T, error]) ([]T, error) {
+	var values []T
+	for item := range ch {
+		if item.Error != nil {
+			return values, item.Error
+		}
+		values = append(values, item.Data)
+	}
+	return values, nil
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeServer answers the trading and market data endpoints used by lemonctl and records the requests
+type fakeServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []string
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	s := &fakeServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		s.mu.Unlock()
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		switch r.Method + " " + r.URL.Path {
+		case "GET /account":
+			fmt.Fprint(w, `{"results": {"account_id": "acc_1", "mode": "paper", "firstname": "Michael", "lastname": "Burry", "email": "m_burry@tradingapi.com", "balance": 1234500, "cash_to_invest": 1000000, "cash_to_withdraw": 0}}`)
+		case "GET /positions":
+			fmt.Fprint(w, `{"results": [{"isin": "US0378331005", "isin_title": "APPLE INC.", "quantity": 2, "buy_price_avg": 1500000, "estimated_price": 1600000, "estimated_price_total": 3200000}]}`)
+		case "GET /orders":
+			fmt.Fprint(w, `{"results": [
+				{"id": "ord_1", "isin": "US0378331005", "side": "buy", "type": "market", "quantity": 2, "status": "executed"},
+				{"id": "ord_2", "isin": "US88160R1014", "side": "sell", "type": "limit", "quantity": 1, "limit_price": 2000000, "status": "inactive"}
+			]}`)
+		case "GET /orders/ord_2":
+			fmt.Fprint(w, `{"results": {"id": "ord_2", "isin": "US88160R1014", "side": "sell", "type": "limit", "quantity": 1, "limit_price": 2000000, "status": "inactive"}}`)
+		case "POST /orders":
+			fmt.Fprint(w, `{"results": {"id": "ord_3", "isin": "US0378331005", "side": "buy", "type": "limit", "quantity": 1, "limit_price": 1505000, "status": "inactive"}}`)
+		case "POST /orders/ord_2/activate", "POST /orders/ord_3/activate", "DELETE /orders/ord_2":
+			fmt.Fprint(w, `{"status": "ok"}`)
+		case "GET /instruments":
+			assert.Equal(t, "apple", r.URL.Query().Get("search"))
+			fmt.Fprint(w, `{"results": [{"isin": "US0378331005", "wkn": "865985", "symbol": "AAPL", "type": "stock", "name": "APPLE INC.", "venues": [{"mic": "XMUN"}]}]}`)
+		case "GET /venues":
+			fmt.Fprint(w, `{"results": [{"mic": "XMUN", "name": "Börse München", "title": "Munich", "currency": "EUR", "is_open": true, "tradable": true}]}`)
+		case "GET /ohlc/d1":
+			fmt.Fprint(w, `{"results": [{"isin": "US0378331005", "mic": "XMUN", "t": "2022-01-03T00:00:00Z", "o": 150.5, "h": 152, "l": 149.25, "c": 151, "v": 1200}]}`)
+		case "GET /quotes", "GET /quotes/latest":
+			fmt.Fprint(w, `{"results": [{"isin": "US0378331005", "mic": "XMUN", "t": "2022-01-03T09:00:00Z", "b": 150.5, "a": 150.75, "b_v": 100, "a_v": 200}]}`)
+		case "GET /trades":
+			fmt.Fprint(w, `{"results": [{"isin": "US0378331005", "mic": "XMUN", "t": "2022-01-03T09:00:00Z", "p": 150.5, "v": 10}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *fakeServer) received(request string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.requests {
+		if r == request {
+			return true
+		}
+	}
+	return false
+}
+
+// lemonctl runs the tool against the fake server and returns exit code, stdout and stderr
+func lemonctl(t *testing.T, server *fakeServer, stdin string, args ...string) (int, string, string) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	config := fmt.Sprintf(`{"trading_url": %q, "market_data_url": %q}`, server.URL, server.URL)
+	assert.Nil(t, os.WriteFile(path, []byte(config), 0600))
+	getenv := func(key string) string {
+		if key == envAPIKey {
+			return "secret"
+		}
+		return ""
+	}
+	var stdout, stderr bytes.Buffer
+	code := run(append([]string{"-config", path}, args...), strings.NewReader(stdin), &stdout, &stderr, getenv)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestAccountAndPositions(t *testing.T) {
+	server := newFakeServer(t)
+	t.Run("Successful test", func(t *testing.T) {
+		code, stdout, _ := lemonctl(t, server, "", "account")
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stdout, "acc_1")
+		assert.Contains(t, stdout, "123.4500")
+		assert.Contains(t, stdout, "Michael Burry")
+
+		code, stdout, _ = lemonctl(t, server, "", "positions")
+		assert.Equal(t, 0, code)
+		lines := strings.Split(strings.TrimSpace(stdout), "\n")
+		assert.Len(t, lines, 2)
+		assert.True(t, strings.HasPrefix(lines[0], "ISIN"))
+		assert.Contains(t, lines[1], "320.0000")
+	})
+	t.Run("json output", func(t *testing.T) {
+		code, stdout, _ := lemonctl(t, server, "", "-output", "json", "positions")
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stdout, `"isin": "US0378331005"`)
+		assert.True(t, strings.HasPrefix(stdout, "["))
+	})
+	t.Run("csv output", func(t *testing.T) {
+		code, stdout, _ := lemonctl(t, server, "", "-output", "csv", "positions")
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "isin,title,quantity,buy_price_avg,estimated_price,estimated_total\nUS0378331005,APPLE INC.,2,150.0000,160.0000,320.0000\n", stdout)
+	})
+}
+
+func TestOrders(t *testing.T) {
+	server := newFakeServer(t)
+	t.Run("list with filter", func(t *testing.T) {
+		code, stdout, _ := lemonctl(t, server, "", "-output", "csv", "orders", "list", "-status", "inactive")
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stdout, "ord_2")
+		assert.NotContains(t, stdout, "ord_1")
+	})
+	t.Run("get", func(t *testing.T) {
+		code, stdout, _ := lemonctl(t, server, "", "-output", "json", "orders", "get", "ord_2")
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stdout, `"limit_price": 2000000`)
+	})
+	t.Run("create and activate on paper", func(t *testing.T) {
+		code, stdout, _ := lemonctl(t, server, "", "orders", "create", "-isin", "US0378331005", "-side", "buy", "-quantity", "1", "-limit", "150.5", "-activate")
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stdout, "ord_3")
+		assert.Contains(t, stdout, "activated")
+		assert.True(t, server.received("POST /orders/ord_3/activate"))
+	})
+	t.Run("live changes ask for confirmation", func(t *testing.T) {
+		code, _, stderr := lemonctl(t, server, "n\n", "-live", "orders", "cancel", "ord_2")
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr, "Cancel order ord_2 on the LIVE account?")
+		assert.False(t, server.received("DELETE /orders/ord_2"))
+
+		code, stdout, _ := lemonctl(t, server, "yes\n", "-live", "orders", "activate", "ord_2")
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "order ord_2 activated\n", stdout)
+
+		code, stdout, stderr = lemonctl(t, server, "", "-live", "-yes", "orders", "cancel", "ord_2")
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "order ord_2 canceled\n", stdout)
+		assert.NotContains(t, stderr, "LIVE")
+		assert.True(t, server.received("DELETE /orders/ord_2"))
+	})
+	t.Run("usage errors", func(t *testing.T) {
+		code, _, _ := lemonctl(t, server, "", "orders")
+		assert.Equal(t, 2, code)
+		code, _, _ = lemonctl(t, server, "", "orders", "create", "-isin", "US0378331005", "-side", "hold", "-quantity", "1")
+		assert.Equal(t, 2, code)
+		code, _, _ = lemonctl(t, server, "", "orders", "get")
+		assert.Equal(t, 2, code)
+	})
+	t.Run("fail to get response", func(t *testing.T) {
+		code, _, stderr := lemonctl(t, server, "", "orders", "get", "unknown")
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr, "orders:")
+	})
+}
+
+func TestMarketData(t *testing.T) {
+	server := newFakeServer(t)
+	t.Run("instruments and venues", func(t *testing.T) {
+		code, stdout, _ := lemonctl(t, server, "", "-output", "csv", "instruments", "search", "apple")
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "isin,wkn,symbol,type,name,venues\nUS0378331005,865985,AAPL,stock,APPLE INC.,XMUN\n", stdout)
+
+		code, stdout, _ = lemonctl(t, server, "", "venues")
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stdout, "XMUN")
+	})
+	t.Run("series", func(t *testing.T) {
+		code, stdout, _ := lemonctl(t, server, "", "-output", "csv", "ohlc", "-isin", "US0378331005", "-from", "2022-01-01")
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stdout, "US0378331005,XMUN,2022-01-03T00:00:00Z,150.5,152,149.25,151,1200")
+
+		code, stdout, _ = lemonctl(t, server, "", "-output", "csv", "quotes", "-isin", "US0378331005")
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stdout, "150.5,150.75,100,200")
+
+		code, stdout, _ = lemonctl(t, server, "", "-output", "csv", "trades", "-isin", "US0378331005")
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stdout, "2022-01-03T09:00:00Z,150.5,10")
+	})
+	t.Run("usage errors", func(t *testing.T) {
+		code, _, _ := lemonctl(t, server, "", "ohlc")
+		assert.Equal(t, 2, code)
+		code, _, _ = lemonctl(t, server, "", "ohlc", "-isin", "US0378331005", "-interval", "w1")
+		assert.Equal(t, 2, code)
+		code, _, _ = lemonctl(t, server, "", "-output", "xml", "venues")
+		assert.Equal(t, 2, code)
+		code, _, _ = lemonctl(t, server, "", "unknown")
+		assert.Equal(t, 2, code)
+	})
+	t.Run("stream", func(t *testing.T) {
+		code, stdout, _ := lemonctl(t, server, "", "-output", "csv", "stream", "-isin", "US0378331005", "-count", "2", "-interval", "1ms")
+		assert.Equal(t, 0, code)
+		lines := strings.Split(strings.TrimSpace(stdout), "\n")
+		assert.Len(t, lines, 3)
+		assert.Equal(t, "isin,mic,time,bid,ask,bid_volume,ask_volume", lines[0])
+		assert.Equal(t, lines[1], lines[2])
+		assert.True(t, server.received("GET /quotes/latest"))
+	})
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+)
+
+var instrumentView = view[market_data.Instrument]{
+	headers: []string{"isin", "wkn", "symbol", "type", "name", "venues"},
+	row: func(v market_data.Instrument) []string {
+		mics := make([]string, len(v.Venues))
+		for i, venue := range v.Venues {
+			mics[i] = venue.Mic
+		}
+		return []string{v.ISIN, v.WKN, v.Symbol, v.Type, v.Name, strings.Join(mics, " ")}
+	},
+}
+
+var venueView = view[market_data.Venue]{
+	headers: []string{"mic", "name", "title", "currency", "is_open", "tradable"},
+	row: func(v market_data.Venue) []string {
+		return []string{v.Mic, v.Name, v.Title, v.Currency, strconv.FormatBool(v.IsOpen), strconv.FormatBool(v.Tradable)}
+	},
+}
+
+var ohlcView = view[market_data.OHLC]{
+	headers: []string{"isin", "mic", "time", "open", "high", "low", "close", "volume"},
+	row: func(v market_data.OHLC) []string {
+		return []string{v.ISIN, v.Mic, timestamp(v.Time), price(v.Open), price(v.High), price(v.Low), price(v.Close), strconv.Itoa(v.Volume)}
+	},
+}
+
+var quoteView = view[market_data.Quote]{
+	headers: []string{"isin", "mic", "time", "bid", "ask", "bid_volume", "ask_volume"},
+	row: func(v market_data.Quote) []string {
+		return []string{v.ISIN, v.Mic, timestamp(v.Time), price(v.Bid), price(v.Ask), strconv.Itoa(v.BidVolume), strconv.Itoa(v.AskVolume)}
+	},
+}
+
+var tradeView = view[market_data.Trade]{
+	headers: []string{"isin", "mic", "time", "price", "volume"},
+	row: func(v market_data.Trade) []string {
+		return []string{v.ISIN, v.Mic, timestamp(v.Time), strconv.FormatFloat(float64(v.Price), 'f', -1, 32), strconv.Itoa(v.Volume)}
+	},
+}
+
+func runInstruments(a *app, args []string) error {
+	if len(args) == 0 || args[0] != "search" {
+		fmt.Fprintln(a.stderr, "usage: lemonctl instruments search [-type stock] [-limit n] <search term>")
+		return errUsage
+	}
+	flags := flag.NewFlagSet("instruments search", flag.ContinueOnError)
+	instrumentType := flags.String("type", "", "stock, bond, fund, etf or warrant")
+	mic := flags.String("mic", "", "only instruments traded at this venue")
+	limit := flags.Int("limit", 20, "maximum number of instruments, 0 for all")
+	if err := parse(a, flags, args[1:]); err != nil {
+		return err
+	}
+	query := &market_data.GetInstrumentsQuery{Search: strings.Join(flags.Args(), " "), Type: *instrumentType, MIC: *mic}
+	instruments, err := collectLimited(a.data.GetInstruments(query), *limit)
+	if err != nil {
+		return err
+	}
+	return printList(a, instrumentView, instruments)
+}
+
+func runVenues(a *app, args []string) error {
+	if err := parse(a, flag.NewFlagSet("venues", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+	venues, err := collectData(a.data.GetVenues())
+	if err != nil {
+		return err
+	}
+	return printList(a, venueView, venues)
+}
+
+// seriesFlags are the flags shared by ohlc, quotes and trades
+type seriesFlags struct {
+	isins  *string
+	mic    *string
+	from   *string
+	to     *string
+	latest *bool
+	limit  *int
+}
+
+func newSeriesFlags(flags *flag.FlagSet) seriesFlags {
+	return seriesFlags{
+		isins:  flags.String("isin", "", "comma separated ISINs (required)"),
+		mic:    flags.String("mic", "", "MIC of the venue"),
+		from:   flags.String("from", "", "start as date (2006-01-02) or RFC3339 time"),
+		to:     flags.String("to", "", "end as date (2006-01-02) or RFC3339 time"),
+		latest: flags.Bool("latest", false, "newest first"),
+		limit:  flags.Int("limit", 0, "maximum number of rows, 0 for all"),
+	}
+}
+
+func (f seriesFlags) parse(a *app, name string) (isins []string, from, to time.Time, sorting string, err error) {
+	if *f.isins == "" {
+		fmt.Fprintf(a.stderr, "usage: lemonctl %s -isin <isin>[,<isin>] [flags]\n", name)
+		return nil, from, to, "", errUsage
+	}
+	isins = strings.Split(*f.isins, ",")
+	if *f.from != "" {
+		if from, err = parseTime(*f.from); err != nil {
+			return
+		}
+	}
+	if *f.to != "" {
+		if to, err = parseTime(*f.to); err != nil {
+			return
+		}
+	}
+	sorting = "oldest_first"
+	if *f.latest {
+		sorting = "newest_first"
+	}
+	return
+}
+
+func runOHLC(a *app, args []string) error {
+	flags := flag.NewFlagSet("ohlc", flag.ContinueOnError)
+	series := newSeriesFlags(flags)
+	interval := flags.String("interval", "d1", "m1, h1 or d1")
+	if err := parse(a, flags, args); err != nil {
+		return err
+	}
+	isins, from, to, sorting, err := series.parse(a, "ohlc")
+	if err != nil {
+		return err
+	}
+	query := &market_data.GetOHLCQuery{ISIN: isins, MIC: *series.mic, From: from, To: to, Sorting: sorting}
+	var ch <-chan market_data.Item[market_data.OHLC, error]
+	switch *interval {
+	case "m1":
+		ch = a.data.GetOHLCPerMinute(query)
+	case "h1":
+		ch = a.data.GetOHLCPerHour(query)
+	case "d1":
+		ch = a.data.GetOHLCPerDay(query)
+	default:
+		fmt.Fprintf(a.stderr, "unknown interval %q, use m1, h1 or d1\n", *interval)
+		return errUsage
+	}
+	bars, err := collectLimited(ch, *series.limit)
+	if err != nil {
+		return err
+	}
+	return printList(a, ohlcView, bars)
+}
+
+func runQuotes(a *app, args []string) error {
+	flags := flag.NewFlagSet("quotes", flag.ContinueOnError)
+	series := newSeriesFlags(flags)
+	if err := parse(a, flags, args); err != nil {
+		return err
+	}
+	isins, from, to, sorting, err := series.parse(a, "quotes")
+	if err != nil {
+		return err
+	}
+	query := &market_data.GetQuotesQuery{ISIN: isins, MIC: *series.mic, From: from, To: to, Sorting: sorting}
+	quotes, err := collectLimited(a.data.GetQuotes(query), *series.limit)
+	if err != nil {
+		return err
+	}
+	return printList(a, quoteView, quotes)
+}
+
+func runTrades(a *app, args []string) error {
+	flags := flag.NewFlagSet("trades", flag.ContinueOnError)
+	series := newSeriesFlags(flags)
+	if err := parse(a, flags, args); err != nil {
+		return err
+	}
+	isins, from, to, sorting, err := series.parse(a, "trades")
+	if err != nil {
+		return err
+	}
+	query := &market_data.GetTradesQuery{ISIN: isins, MIC: *series.mic, From: from, To: to, Sorting: sorting}
+	trades, err := collectLimited(a.data.GetTrades(query), *series.limit)
+	if err != nil {
+		return err
+	}
+	return printList(a, tradeView, trades)
+}
+
+/*
+runStream polls the latest quote of every ISIN and prints one row per quote, JSON has one object per line.
+Realtime streaming needs an Ably client which this module does not include
+*/
+func runStream(a *app, args []string) error {
+	flags := flag.NewFlagSet("stream", flag.ContinueOnError)
+	isins := flags.String("isin", "", "comma separated ISINs (required)")
+	mic := flags.String("mic", "", "MIC of the venue")
+	interval := flags.Duration("interval", 5*time.Second, "time between polls")
+	count := flags.Int("count", 0, "number of polls, 0 until stopped")
+	if err := parse(a, flags, args); err != nil {
+		return err
+	}
+	if *isins == "" {
+		fmt.Fprintln(a.stderr, "usage: lemonctl stream -isin <isin>[,<isin>] [-interval 5s] [-count n]")
+		return errUsage
+	}
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for poll := 0; *count == 0 || poll < *count; poll++ {
+		if poll > 0 {
+			<-ticker.C
+		}
+		quotes, err := collectData(a.data.GetLatestQuotes(&market_data.GetQuotesQuery{ISIN: strings.Split(*isins, ","), MIC: *mic}))
+		if err != nil {
+			return err
+		}
+		if err := printRows(a, quoteView, quotes, poll == 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectLimited collects at most limit values, 0 for all, what is left on the channel is drained
+func collectLimited[T market_data.DataTypes](ch <-chan market_data.Item[T, error], limit int) ([]T, error) {
+	var values []T
+	for item := range ch {
+		if item.Error != nil {
+			market_data.Drain(ch)
+			return values, item.Error
+		}
+		values = append(values, item.Data)
+		if limit > 0 && len(values) == limit {
+			market_data.Drain(ch)
+			break
+		}
+	}
+	return values, nil
+}
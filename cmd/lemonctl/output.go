@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Output formats
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+// view is how values of T are shown as table or CSV
+type view[T any] struct {
+	headers []string
+	row     func(T) []string
+}
+
+// printList prints all values, JSON is an array
+func printList[T any](a *app, v view[T], values []T) error {
+	if a.format == formatJSON {
+		if values == nil {
+			values = []T{}
+		}
+		return a.printJSON(values)
+	}
+	return printRows(a, v, values, true)
+}
+
+// printRows prints values as table or CSV rows, JSON has one object per line
+func printRows[T any](a *app, v view[T], values []T, header bool) error {
+	switch a.format {
+	case formatJSON:
+		encoder := json.NewEncoder(a.stdout)
+		for _, value := range values {
+			if err := encoder.Encode(value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case formatCSV:
+		writer := csv.NewWriter(a.stdout)
+		if header {
+			writer.Write(v.headers)
+		}
+		for _, value := range values {
+			writer.Write(v.row(value))
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+	writer := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	if header {
+		fmt.Fprintln(writer, strings.ToUpper(strings.Join(v.headers, "\t")))
+	}
+	for _, value := range values {
+		fmt.Fprintln(writer, strings.Join(v.row(value), "\t"))
+	}
+	return writer.Flush()
+}
+
+// printOne prints a single value, as field and value per line for tables and as object for JSON
+func printOne[T any](a *app, v view[T], value T) error {
+	if a.format != formatTable {
+		if a.format == formatJSON {
+			return a.printJSON(value)
+		}
+		return printList(a, v, []T{value})
+	}
+	writer := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	for i, field := range v.row(value) {
+		fmt.Fprintf(writer, "%s\t%s\n", v.headers[i], field)
+	}
+	return writer.Flush()
+}
+
+func (a *app) printJSON(value interface{}) error {
+	encoder := json.NewEncoder(a.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// euro formats an amount in hundredths of a cent (10000 = 1 EUR)
+func euro(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%04d", sign, amount/10000, amount%10000)
+}
+
+func price(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func timestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
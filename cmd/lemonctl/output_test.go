@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatting(t *testing.T) {
+	assert.Equal(t, "1.0000", euro(10000))
+	assert.Equal(t, "0.0005", euro(5))
+	assert.Equal(t, "-12.3400", euro(-123400))
+	assert.Equal(t, "150.25", price(150.25))
+	assert.Equal(t, "", timestamp(time.Time{}))
+
+	date, err := parseTime("2022-01-03")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC), date)
+	_, err = parseTime("yesterday")
+	assert.NotNil(t, err)
+}
+
+func TestCollectLimited(t *testing.T) {
+	produce := func(items ...market_data.Item[market_data.Quote, error]) (<-chan market_data.Item[market_data.Quote, error], <-chan struct{}) {
+		ch := make(chan market_data.Item[market_data.Quote, error])
+		sent := make(chan struct{})
+		go func() {
+			defer close(sent)
+			defer close(ch)
+			for _, item := range items {
+				ch <- item
+			}
+		}()
+		return ch, sent
+	}
+	waitSent := func(t *testing.T, sent <-chan struct{}) {
+		select {
+		case <-sent:
+		case <-time.After(time.Second):
+			t.Fatal("channel is not drained")
+		}
+	}
+	quote := market_data.Item[market_data.Quote, error]{Data: market_data.Quote{ISIN: "US0378331005"}}
+
+	t.Run("limit", func(t *testing.T) {
+		ch, sent := produce(quote, quote, quote)
+		quotes, err := collectLimited(ch, 1)
+		assert.Nil(t, err)
+		assert.Len(t, quotes, 1)
+		waitSent(t, sent)
+	})
+	t.Run("fail to get response", func(t *testing.T) {
+		ch, sent := produce(quote, market_data.Item[market_data.Quote, error]{Error: errors.New("not found")}, quote)
+		quotes, err := collectLimited(ch, 0)
+		assert.EqualError(t, err, "not found")
+		assert.Len(t, quotes, 1)
+		waitSent(t, sent)
+	})
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/trading"
+)
+
+var accountView = view[trading.Account]{
+	headers: []string{"account_id", "mode", "name", "email", "balance", "cash_to_invest", "cash_to_withdraw", "trading_plan", "data_plan"},
+	row: func(v trading.Account) []string {
+		return []string{
+			v.AccountID, v.Mode, v.Firstname + " " + v.Lastname, v.EMail,
+			euro(int(v.Balance)), euro(int(v.CashToInvest)), euro(int(v.CashToWithdraw)), v.TradingPlan, v.DataPlan,
+		}
+	},
+}
+
+var positionView = view[trading.Position]{
+	headers: []string{"isin", "title", "quantity", "buy_price_avg", "estimated_price", "estimated_total"},
+	row: func(v trading.Position) []string {
+		return []string{v.ISIN, v.ISINTitle, strconv.Itoa(v.Quantity), euro(v.BuyPriceAverage), euro(v.EstimatedPrice), euro(v.EstimatedPriceTotal)}
+	},
+}
+
+var orderView = view[trading.Order]{
+	headers: []string{"id", "created_at", "isin", "side", "type", "quantity", "limit_price", "stop_price", "status", "executed_quantity", "executed_price"},
+	row: func(v trading.Order) []string {
+		return []string{
+			v.ID, timestamp(v.CreatedAt), v.ISIN, v.Side, v.Type, strconv.Itoa(v.Quantity), euro(v.LimitPrice), euro(v.StopPrice),
+			v.Status, strconv.Itoa(v.ExecutedQuantity), euro(v.ExecutedPrice),
+		}
+	},
+}
+
+var withdrawalView = view[trading.Withdrawal]{
+	headers: []string{"id", "created_at", "date", "amount"},
+	row: func(v trading.Withdrawal) []string {
+		return []string{v.ID, timestamp(v.CreatedAt), timestamp(v.Date), euro(v.Amount)}
+	},
+}
+
+var documentView = view[trading.Document]{
+	headers: []string{"id", "created_at", "category", "name", "link"},
+	row: func(v trading.Document) []string {
+		return []string{v.ID, timestamp(v.CreatedAt), v.Category, v.Name, v.Link}
+	},
+}
+
+func runAccount(a *app, args []string) error {
+	if err := parse(a, flag.NewFlagSet("account", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+	account := a.trading.GetAccount()
+	if account.Error != nil {
+		return account.Error
+	}
+	return printOne(a, accountView, account.Data)
+}
+
+func runPositions(a *app, args []string) error {
+	if err := parse(a, flag.NewFlagSet("positions", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+	positions, err := collectTrading(a.trading.GetPositions())
+	if err != nil {
+		return err
+	}
+	return printList(a, positionView, positions)
+}
+
+func runWithdrawals(a *app, args []string) error {
+	if err := parse(a, flag.NewFlagSet("withdrawals", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+	withdrawals, err := collectTrading(a.trading.GetWithdrawals())
+	if err != nil {
+		return err
+	}
+	return printList(a, withdrawalView, withdrawals)
+}
+
+func runDocuments(a *app, args []string) error {
+	if err := parse(a, flag.NewFlagSet("documents", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+	documents, err := collectTrading(a.trading.GetDocuments())
+	if err != nil {
+		return err
+	}
+	return printList(a, documentView, documents)
+}
+
+var orderCommands = map[string]func(a *app, args []string) error{
+	"list":     runOrdersList,
+	"get":      runOrdersGet,
+	"create":   runOrdersCreate,
+	"activate": runOrdersActivate,
+	"cancel":   runOrdersCancel,
+}
+
+func runOrders(a *app, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(a.stderr, "usage: lemonctl orders list|get|create|activate|cancel [arguments]")
+		return errUsage
+	}
+	run, ok := orderCommands[args[0]]
+	if !ok {
+		fmt.Fprintf(a.stderr, "unknown orders command %q\n", args[0])
+		return errUsage
+	}
+	return run(a, args[1:])
+}
+
+/*
+runOrdersList filters on the client side, since the query of GetOrders is not sent as url parameters
+*/
+func runOrdersList(a *app, args []string) error {
+	flags := flag.NewFlagSet("orders list", flag.ContinueOnError)
+	isin := flags.String("isin", "", "only orders of this ISIN")
+	side := flags.String("side", "", "only buy or sell orders")
+	status := flags.String("status", "", "only orders with this status")
+	if err := parse(a, flags, args); err != nil {
+		return err
+	}
+	all, err := collectTrading(a.trading.GetOrders(nil))
+	if err != nil {
+		return err
+	}
+	var orders []trading.Order
+	for _, order := range all {
+		if (*isin == "" || order.ISIN == *isin) && (*side == "" || order.Side == *side) && (*status == "" || order.Status == *status) {
+			orders = append(orders, order)
+		}
+	}
+	return printList(a, orderView, orders)
+}
+
+// orderID parses the single order id argument of get, activate and cancel
+func orderID(a *app, name string, args []string) (string, error) {
+	flags := flag.NewFlagSet("orders "+name, flag.ContinueOnError)
+	if err := parse(a, flags, args); err != nil {
+		return "", err
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintf(a.stderr, "usage: lemonctl orders %s <order id>\n", name)
+		return "", errUsage
+	}
+	return flags.Arg(0), nil
+}
+
+func runOrdersGet(a *app, args []string) error {
+	id, err := orderID(a, "get", args)
+	if err != nil {
+		return err
+	}
+	order := a.trading.GetOrder(id)
+	if order.Error != nil {
+		return order.Error
+	}
+	return printOne(a, orderView, order.Data)
+}
+
+func runOrdersCreate(a *app, args []string) error {
+	flags := flag.NewFlagSet("orders create", flag.ContinueOnError)
+	isin := flags.String("isin", "", "ISIN of the instrument (required)")
+	side := flags.String("side", "", "buy or sell (required)")
+	quantity := flags.Int("quantity", 0, "number of shares (required)")
+	limit := flags.Float64("limit", 0, "limit price in EUR")
+	stop := flags.Float64("stop", 0, "stop price in EUR")
+	venue := flags.String("venue", "", "MIC of the venue")
+	expires := flags.String("expires", "", "expiry as date (2006-01-02) or RFC3339 time")
+	idempotency := flags.String("idempotency", "", "idempotency key")
+	activate := flags.Bool("activate", false, "activate the order after creating it")
+	if err := parse(a, flags, args); err != nil {
+		return err
+	}
+	if *isin == "" || *quantity <= 0 || (*side != trading.Buy && *side != trading.Sell) {
+		fmt.Fprintln(a.stderr, "usage: lemonctl orders create -isin <isin> -side buy|sell -quantity <n> [flags]")
+		flags.PrintDefaults()
+		return errUsage
+	}
+	order := &trading.Order{
+		ISIN:        *isin,
+		Side:        *side,
+		Quantity:    *quantity,
+		LimitPrice:  trading.ToAmount(*limit),
+		StopPrice:   trading.ToAmount(*stop),
+		Venue:       *venue,
+		Idempotency: *idempotency,
+	}
+	if *expires != "" {
+		expiresAt, err := parseTime(*expires)
+		if err != nil {
+			return err
+		}
+		order.ExpiresAt = expiresAt
+	}
+	if err := a.confirm(fmt.Sprintf("Create %s order for %d %s", *side, *quantity, *isin)); err != nil {
+		return err
+	}
+	created := a.trading.CreateOrder(order)
+	if created.Error != nil {
+		return created.Error
+	}
+	if *activate {
+		if err := a.trading.ActivateOrder(created.Data.ID); err != nil {
+			return fmt.Errorf("order %s created but not activated: %w", created.Data.ID, err)
+		}
+		created.Data.Status = trading.OrderActivated
+	}
+	return printOne(a, orderView, created.Data)
+}
+
+func runOrdersActivate(a *app, args []string) error {
+	id, err := orderID(a, "activate", args)
+	if err != nil {
+		return err
+	}
+	if err := a.confirm("Activate order " + id); err != nil {
+		return err
+	}
+	if err := a.trading.ActivateOrder(id); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "order %s activated\n", id)
+	return nil
+}
+
+func runOrdersCancel(a *app, args []string) error {
+	id, err := orderID(a, "cancel", args)
+	if err != nil {
+		return err
+	}
+	if err := a.confirm("Cancel order " + id); err != nil {
+		return err
+	}
+	if err := a.trading.DeleteOrder(id); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "order %s canceled\n", id)
+	return nil
+}
+
+// parseTime accepts RFC3339 times and dates in trading.DateLayout
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(trading.DateLayout, value)
+	if err != nil {
+		return time.Time{}, errors.New("expected a date (2006-01-02) or RFC3339 time, got " + strconv.Quote(value))
+	}
+	return t, nil
+}
@@ -0,0 +1,240 @@
+/*
+Package corporate keeps splits and cash dividends and adjusts price series, orders and statements for them,
+so that long series of daily bars can be backtested and historical holdings line up with live positions.
+
+Actions come from a file, from the dividend rows of the bank statements or are added by hand:
+
+	file, _ := os.Open("actions.csv")
+	actions, err := corporate.ReadCSV(file)
+	registry, err := corporate.NewRegistry(actions...)
+	err = registry.AddDividends(bankStatements, orders)
+	bars := registry.Adjust(daily)
+
+	ledger := trading.NewLedger(trading.FIFO)
+	err = ledger.Replay(registry.AdjustOrders(orders), registry.AdjustStatements(statements))
+*/
+package corporate
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/identifiers"
+	"github.com/quantfamily/lemonmarkets/trading"
+)
+
+// Kind of a corporate action
+type Kind string
+
+const (
+	Split    Kind = "split"
+	Dividend Kind = "dividend"
+)
+
+/*
+Action is a split or cash dividend of an ISIN, effective from ExDate on.
+Ratio is the number of new shares per old share of a split, 2 for a 2:1 split and 0.1 for a 1:10 reverse split.
+Amount is the dividend per share in euro, in the prices of market data
+*/
+type Action struct {
+	ISIN   string    `json:"isin"`
+	Kind   Kind      `json:"kind"`
+	ExDate time.Time `json:"ex_date"`
+	Ratio  float64   `json:"ratio,omitempty"`
+	Amount float64   `json:"amount,omitempty"`
+}
+
+// Validate returns an error if the action can not be applied
+func (a Action) Validate() error {
+	if err := identifiers.CheckISINs(a.ISIN); err != nil {
+		return err
+	}
+	if a.ExDate.IsZero() {
+		return fmt.Errorf("%s %s: ex date must be set", a.Kind, a.ISIN)
+	}
+	switch a.Kind {
+	case Split:
+		if a.Ratio <= 0 {
+			return fmt.Errorf("split %s: ratio must be positive, got %v", a.ISIN, a.Ratio)
+		}
+	case Dividend:
+		if a.Amount <= 0 {
+			return fmt.Errorf("dividend %s: amount must be positive, got %v", a.ISIN, a.Amount)
+		}
+	default:
+		return fmt.Errorf("%s: unknown kind %q", a.ISIN, a.Kind)
+	}
+	return nil
+}
+
+/*
+Registry holds the actions per ISIN, oldest first. It is not safe for concurrent use while actions are added
+*/
+type Registry struct {
+	actions map[string][]Action
+}
+
+// NewRegistry returns a registry with the given actions, it fails on the first invalid action
+func NewRegistry(actions ...Action) (*Registry, error) {
+	registry := &Registry{actions: make(map[string][]Action)}
+	return registry, registry.Add(actions...)
+}
+
+// Add actions, nothing is added if one of them is invalid
+func (r *Registry) Add(actions ...Action) error {
+	for _, action := range actions {
+		if err := action.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, action := range actions {
+		list := append(r.actions[action.ISIN], action)
+		sort.SliceStable(list, func(i, j int) bool { return list[i].ExDate.Before(list[j].ExDate) })
+		r.actions[action.ISIN] = list
+	}
+	return nil
+}
+
+// Actions of an ISIN, oldest first
+func (r *Registry) Actions(isin string) []Action {
+	return append([]Action(nil), r.actions[isin]...)
+}
+
+// ISINs with actions, sorted
+func (r *Registry) ISINs() []string {
+	isins := make([]string, 0, len(r.actions))
+	for isin := range r.actions {
+		isins = append(isins, isin)
+	}
+	sort.Strings(isins)
+	return isins
+}
+
+/*
+SplitFactor is the number of shares one share held at from has become at to,
+the product of the ratios of the splits with from before the ex date and the ex date not after to
+*/
+func (r *Registry) SplitFactor(isin string, from, to time.Time) float64 {
+	factor := 1.0
+	for _, action := range r.actions[isin] {
+		if action.Kind == Split && from.Before(action.ExDate) && !action.ExDate.After(to) {
+			factor *= action.Ratio
+		}
+	}
+	return factor
+}
+
+/*
+AddDividends adds a dividend for every "dividend" row of the bank statements. The amount per share is the booked
+amount over the quantity held before the booking, from the executed orders adjusted for known splits, so add splits first.
+The booking date is used as ex date, which is usually a few days late
+*/
+func (r *Registry) AddDividends(statements []trading.BankStatement, orders []trading.Order) error {
+	var dividends []Action
+	for _, statement := range statements {
+		if statement.Type != "dividend" || statement.ISIN == "" {
+			continue
+		}
+		date := statement.Date.Time
+		held := 0.0
+		for _, order := range orders {
+			at := orderTime(order)
+			if order.ISIN != statement.ISIN || order.ExecutedQuantity <= 0 || !at.Before(date) {
+				continue
+			}
+			quantity := float64(order.ExecutedQuantity) * r.SplitFactor(order.ISIN, at, date)
+			if order.Side == trading.Sell {
+				quantity = -quantity
+			}
+			held += quantity
+		}
+		if held <= 0 {
+			return fmt.Errorf("dividend %s on %s: no shares held", statement.ISIN, date.Format(trading.DateLayout))
+		}
+		dividends = append(dividends, Action{
+			ISIN:   statement.ISIN,
+			Kind:   Dividend,
+			ExDate: date,
+			Amount: float64(statement.Amount) / 10000 / held,
+		})
+	}
+	return r.Add(dividends...)
+}
+
+// orderTime is when an order changed holdings, like trading.OrderEvent
+func orderTime(order trading.Order) time.Time {
+	if order.ExecutedAt.IsZero() {
+		return order.CreatedAt
+	}
+	return order.ExecutedAt
+}
+
+// ErrHeader is returned by ReadCSV for files without isin, kind and ex_date columns
+var ErrHeader = errors.New("corporate actions need the columns isin, kind and ex_date")
+
+/*
+ReadCSV reads actions from a file with the header isin,kind,ex_date,ratio,amount in any order.
+Ex dates are written as 2006-01-02, empty ratios and amounts are zero
+*/
+func ReadCSV(r io.Reader) ([]Action, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	for _, name := range []string{"isin", "kind", "ex_date"} {
+		if _, ok := columns[name]; !ok {
+			return nil, ErrHeader
+		}
+	}
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	number := func(row []string, name string) (float64, error) {
+		if value := field(row, name); value != "" {
+			return strconv.ParseFloat(value, 64)
+		}
+		return 0, nil
+	}
+	var actions []Action
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return actions, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		action := Action{ISIN: strings.ToUpper(field(row, "isin")), Kind: Kind(strings.ToLower(field(row, "kind")))}
+		if action.ExDate, err = time.Parse(trading.DateLayout, field(row, "ex_date")); err != nil {
+			return nil, fmt.Errorf("line %d, column ex_date: %w", line, err)
+		}
+		if action.Ratio, err = number(row, "ratio"); err != nil {
+			return nil, fmt.Errorf("line %d, column ratio: %w", line, err)
+		}
+		if action.Amount, err = number(row, "amount"); err != nil {
+			return nil, fmt.Errorf("line %d, column amount: %w", line, err)
+		}
+		if err := action.Validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		actions = append(actions, action)
+	}
+}
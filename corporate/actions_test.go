@@ -0,0 +1,93 @@
+package corporate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/identifiers"
+	"github.com/quantfamily/lemonmarkets/trading"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	apple = "US0378331005"
+	sap   = "DE0007164600"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestRegistry(t *testing.T) {
+	t.Run("invalid actions", func(t *testing.T) {
+		_, err := NewRegistry(Action{ISIN: "US0378331006", Kind: Split, Ratio: 2, ExDate: date(2020, 8, 31)})
+		assert.True(t, errors.Is(err, identifiers.ErrInvalidISIN))
+		registry, _ := NewRegistry()
+		assert.EqualError(t, registry.Add(Action{ISIN: apple, Kind: Split, ExDate: date(2020, 8, 31)}), "split US0378331005: ratio must be positive, got 0")
+		assert.EqualError(t, registry.Add(Action{ISIN: apple, Kind: Dividend, ExDate: date(2020, 8, 31)}), "dividend US0378331005: amount must be positive, got 0")
+		assert.EqualError(t, registry.Add(Action{ISIN: apple, Kind: Split, Ratio: 2}), "split US0378331005: ex date must be set")
+		assert.EqualError(t, registry.Add(Action{ISIN: apple, Kind: "merger", ExDate: date(2020, 8, 31)}), `US0378331005: unknown kind "merger"`)
+		assert.Empty(t, registry.ISINs())
+	})
+	t.Run("split factor", func(t *testing.T) {
+		registry, err := NewRegistry(
+			Action{ISIN: apple, Kind: Split, Ratio: 4, ExDate: date(2020, 8, 31)},
+			Action{ISIN: apple, Kind: Split, Ratio: 7, ExDate: date(2014, 6, 9)},
+			Action{ISIN: apple, Kind: Dividend, Amount: 0.2, ExDate: date(2021, 2, 5)},
+		)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{apple}, registry.ISINs())
+		actions := registry.Actions(apple)
+		assert.Equal(t, date(2014, 6, 9), actions[0].ExDate, "oldest first")
+		assert.Equal(t, 28.0, registry.SplitFactor(apple, date(2014, 1, 1), date(2021, 1, 1)))
+		assert.Equal(t, 4.0, registry.SplitFactor(apple, date(2014, 6, 9), date(2020, 8, 31)), "from the ex date on the split is done")
+		assert.Equal(t, 1.0, registry.SplitFactor(sap, date(2014, 1, 1), date(2021, 1, 1)))
+	})
+}
+
+func TestAddDividends(t *testing.T) {
+	registry, _ := NewRegistry(Action{ISIN: apple, Kind: Split, Ratio: 4, ExDate: date(2020, 8, 31)})
+	orders := []trading.Order{
+		{ISIN: apple, Side: trading.Buy, ExecutedQuantity: 10, ExecutedAt: date(2020, 1, 2)},
+		{ISIN: apple, Side: trading.Sell, ExecutedQuantity: 20, ExecutedAt: date(2020, 10, 1)},
+		{ISIN: apple, Side: trading.Buy, ExecutedQuantity: 100, ExecutedAt: date(2021, 3, 1)},
+		{ISIN: apple, Side: trading.Buy, Quantity: 5, CreatedAt: date(2020, 1, 2)},
+	}
+	statements := []trading.BankStatement{
+		{Type: "dividend", ISIN: apple, Amount: 40000, Date: trading.Date{Time: date(2021, 2, 11)}},
+		{Type: "pay_in", Amount: 1000000, Date: trading.Date{Time: date(2021, 2, 11)}},
+	}
+	assert.Nil(t, registry.AddDividends(statements, orders))
+	actions := registry.Actions(apple)
+	assert.Len(t, actions, 2)
+	assert.Equal(t, Action{ISIN: apple, Kind: Dividend, ExDate: date(2021, 2, 11), Amount: 0.2}, actions[1], "4 EUR on 20 shares")
+
+	statements = []trading.BankStatement{{Type: "dividend", ISIN: sap, Amount: 40000, Date: trading.Date{Time: date(2021, 5, 20)}}}
+	assert.EqualError(t, registry.AddDividends(statements, orders), "dividend DE0007164600 on 2021-05-20: no shares held")
+}
+
+func TestReadCSV(t *testing.T) {
+	t.Run("Successful test", func(t *testing.T) {
+		actions, err := ReadCSV(strings.NewReader("kind,isin,ex_date,ratio,amount\nsplit,us0378331005,2020-08-31,4,\nDividend,DE0007164600,2021-05-20,,1.85\n"))
+		assert.Nil(t, err)
+		assert.Equal(t, []Action{
+			{ISIN: apple, Kind: Split, ExDate: date(2020, 8, 31), Ratio: 4},
+			{ISIN: sap, Kind: Dividend, ExDate: date(2021, 5, 20), Amount: 1.85},
+		}, actions)
+	})
+	t.Run("invalid files", func(t *testing.T) {
+		actions, err := ReadCSV(strings.NewReader(""))
+		assert.Nil(t, err)
+		assert.Empty(t, actions)
+		_, err = ReadCSV(strings.NewReader("isin,ratio\n"))
+		assert.Equal(t, ErrHeader, err)
+		_, err = ReadCSV(strings.NewReader("isin,kind,ex_date\nUS0378331005,split,31.08.2020\n"))
+		assert.EqualError(t, err, `line 2, column ex_date: parsing time "31.08.2020" as "2006-01-02": cannot parse "31.08.2020" as "2006"`)
+		_, err = ReadCSV(strings.NewReader("isin,kind,ex_date,ratio\nUS0378331005,split,2020-08-31,four\n"))
+		assert.EqualError(t, err, `line 2, column ratio: strconv.ParseFloat: parsing "four": invalid syntax`)
+		_, err = ReadCSV(strings.NewReader("isin,kind,ex_date\nUS0378331005,split,2020-08-31\n"))
+		assert.EqualError(t, err, "line 2: split US0378331005: ratio must be positive, got 0")
+	})
+}
@@ -0,0 +1,143 @@
+package corporate
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/quantfamily/lemonmarkets/trading"
+)
+
+/*
+Adjust returns back-adjusted copies of bars, so that the latest bars keep their prices and earlier bars are
+comparable to them. Before a split prices are divided and volumes multiplied by the ratio. Before a dividend
+prices are multiplied by one less the dividend over the last close before the ex date, so returns include the
+dividend, dividends at or above that close are ignored. Bars of every ISIN and venue are adjusted apart
+and keep their order
+*/
+func (r *Registry) Adjust(bars []market_data.OHLC) []market_data.OHLC {
+	return r.adjust(bars, true)
+}
+
+// AdjustSplits is Adjust without dividends, the prices that were actually paid scaled to today's shares
+func (r *Registry) AdjustSplits(bars []market_data.OHLC) []market_data.OHLC {
+	return r.adjust(bars, false)
+}
+
+/*
+AdjustOHLC adjusts bars from the market data client like Adjust. Since later actions change earlier bars,
+all bars are read before the first is passed on, and nothing is passed on after an error
+*/
+func (r *Registry) AdjustOHLC(in <-chan market_data.Item[market_data.OHLC, error]) <-chan market_data.Item[market_data.OHLC, error] {
+	out := make(chan market_data.Item[market_data.OHLC, error])
+	go func() {
+		defer close(out)
+		var bars []market_data.OHLC
+		for item := range in {
+			if item.Error != nil {
+				out <- item
+				return
+			}
+			bars = append(bars, item.Data)
+		}
+		for _, bar := range r.Adjust(bars) {
+			out <- market_data.Item[market_data.OHLC, error]{Data: bar}
+		}
+	}()
+	return out
+}
+
+type seriesKey struct {
+	isin string
+	mic  string
+}
+
+func (r *Registry) adjust(bars []market_data.OHLC, dividends bool) []market_data.OHLC {
+	adjusted := append([]market_data.OHLC(nil), bars...)
+	series := make(map[seriesKey][]int)
+	for i, bar := range adjusted {
+		if len(r.actions[bar.ISIN]) > 0 {
+			k := seriesKey{bar.ISIN, bar.Mic}
+			series[k] = append(series[k], i)
+		}
+	}
+	for k, indices := range series {
+		sort.SliceStable(indices, func(i, j int) bool { return adjusted[indices[i]].Time.Before(adjusted[indices[j]].Time) })
+		actions := r.actions[k.isin]
+		next := len(actions) - 1
+		price, volume := 1.0, 1.0
+		for i := len(indices) - 1; i >= 0; i-- {
+			bar := &adjusted[indices[i]]
+			// actions from this bar on apply to it and every earlier bar
+			for ; next >= 0 && bar.Time.Before(actions[next].ExDate); next-- {
+				action := actions[next]
+				switch {
+				case action.Kind == Split:
+					price /= action.Ratio
+					volume *= action.Ratio
+				case dividends:
+					// the close in shares of the ex date, splits in between are not applied to it yet
+					close := bar.Close / r.SplitFactor(k.isin, bar.Time, action.ExDate)
+					if action.Amount < close {
+						price *= 1 - action.Amount/close
+					}
+				}
+			}
+			bar.Open *= price
+			bar.High *= price
+			bar.Low *= price
+			bar.Close *= price
+			bar.Volume = int(math.Round(float64(bar.Volume) * volume))
+		}
+	}
+	return adjusted
+}
+
+// latest is after every ex date, so SplitFactor up to it covers all splits
+var latest = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+/*
+AdjustOrders returns copies of orders with quantities in today's shares and prices per today's share.
+Totals and charges stay as they were, so replaying the orders into a trading.Ledger gives the same cost
+basis with quantities that match the live positions. Quantities are rounded to whole shares
+*/
+func (r *Registry) AdjustOrders(orders []trading.Order) []trading.Order {
+	adjusted := append([]trading.Order(nil), orders...)
+	for i := range adjusted {
+		order := &adjusted[i]
+		factor := r.SplitFactor(order.ISIN, orderTime(*order), latest)
+		if factor == 1 {
+			continue
+		}
+		order.Quantity = shares(order.Quantity, factor)
+		order.ExecutedQuantity = shares(order.ExecutedQuantity, factor)
+		order.StopPrice = price(order.StopPrice, factor)
+		order.LimitPrice = price(order.LimitPrice, factor)
+		order.EstimatedPrice = price(order.EstimatedPrice, factor)
+		order.ExecutedPrice = price(order.ExecutedPrice, factor)
+	}
+	return adjusted
+}
+
+// AdjustStatements returns copies of position statements with quantities in today's shares, see AdjustOrders
+func (r *Registry) AdjustStatements(statements []trading.Statement) []trading.Statement {
+	adjusted := append([]trading.Statement(nil), statements...)
+	for i := range adjusted {
+		statement := &adjusted[i]
+		at := statement.CreatedAt
+		if at.IsZero() {
+			at = statement.Date.Time
+		}
+		statement.Quantity = shares(statement.Quantity, r.SplitFactor(statement.ISIN, at, latest))
+	}
+	return adjusted
+}
+
+func shares(quantity int, factor float64) int {
+	return int(math.Round(float64(quantity) * factor))
+}
+
+func price(amount int, factor float64) int {
+	return int(math.Round(float64(amount) / factor))
+}
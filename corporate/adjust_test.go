@@ -0,0 +1,111 @@
+package corporate
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/quantfamily/lemonmarkets/trading"
+	"github.com/stretchr/testify/assert"
+)
+
+func bar(t time.Time, close float64, volume int) market_data.OHLC {
+	return market_data.OHLC{ISIN: apple, Mic: "XMUN", Open: close, High: close, Low: close, Close: close, Volume: volume, Time: t}
+}
+
+func closes(bars []market_data.OHLC) []float64 {
+	result := make([]float64, len(bars))
+	for i, bar := range bars {
+		result[i] = bar.Close
+	}
+	return result
+}
+
+func testRegistry(t *testing.T) *Registry {
+	registry, err := NewRegistry(
+		Action{ISIN: apple, Kind: Split, Ratio: 4, ExDate: date(2020, 8, 31)},
+		Action{ISIN: apple, Kind: Dividend, Amount: 5, ExDate: date(2020, 9, 2)},
+	)
+	assert.Nil(t, err)
+	return registry
+}
+
+func TestAdjust(t *testing.T) {
+	bars := []market_data.OHLC{
+		bar(date(2020, 9, 2), 95, 40),
+		bar(date(2020, 8, 28), 400, 10),
+		bar(date(2020, 8, 31), 100, 40),
+		bar(date(2020, 9, 1), 100, 40),
+	}
+	other := bar(date(2020, 8, 28), 400, 10)
+	other.ISIN = sap
+
+	t.Run("splits and dividends", func(t *testing.T) {
+		registry := testRegistry(t)
+		adjusted := registry.Adjust(append(bars, other))
+		assert.InDeltaSlice(t, []float64{95, 95, 95, 95, 400}, closes(adjusted), 1e-9)
+		assert.Equal(t, 40, adjusted[1].Volume)
+		assert.Equal(t, 400.0, bars[1].Close, "bars are copied")
+	})
+	t.Run("splits only", func(t *testing.T) {
+		adjusted := testRegistry(t).AdjustSplits(bars)
+		assert.InDeltaSlice(t, []float64{95, 100, 100, 100}, closes(adjusted), 1e-9)
+	})
+	t.Run("series per venue", func(t *testing.T) {
+		lemon := bar(date(2020, 8, 28), 404, 1)
+		lemon.Mic = "LMBPX"
+		adjusted := testRegistry(t).Adjust([]market_data.OHLC{lemon, bars[0]})
+		assert.InDelta(t, 96, adjusted[0].Close, 1e-9, "the dividend uses the split adjusted close of the same venue")
+	})
+	t.Run("stream", func(t *testing.T) {
+		in := make(chan market_data.Item[market_data.OHLC, error], len(bars))
+		for _, b := range bars {
+			in <- market_data.Item[market_data.OHLC, error]{Data: b}
+		}
+		close(in)
+		var adjusted []market_data.OHLC
+		for item := range testRegistry(t).AdjustOHLC(in) {
+			assert.Nil(t, item.Error)
+			adjusted = append(adjusted, item.Data)
+		}
+		assert.InDeltaSlice(t, []float64{95, 95, 95, 95}, closes(adjusted), 1e-9)
+	})
+	t.Run("fail to get response", func(t *testing.T) {
+		in := make(chan market_data.Item[market_data.OHLC, error], 2)
+		in <- market_data.Item[market_data.OHLC, error]{Data: bars[0]}
+		in <- market_data.Item[market_data.OHLC, error]{Error: errors.New("unknown http error from backend: 500")}
+		close(in)
+		var items []market_data.Item[market_data.OHLC, error]
+		for item := range testRegistry(t).AdjustOHLC(in) {
+			items = append(items, item)
+		}
+		assert.Len(t, items, 1)
+		assert.EqualError(t, items[0].Error, "unknown http error from backend: 500")
+	})
+}
+
+func TestAdjustOrders(t *testing.T) {
+	registry := testRegistry(t)
+	orders := []trading.Order{
+		{ID: "ord_1", ISIN: apple, Side: trading.Buy, Quantity: 10, ExecutedQuantity: 10, ExecutedPrice: 4000000, ExecutedPriceTotal: 40000000, LimitPrice: 4100000, ExecutedAt: date(2020, 8, 3)},
+		{ID: "ord_2", ISIN: apple, Side: trading.Sell, Quantity: 20, ExecutedQuantity: 20, ExecutedPrice: 1100000, ExecutedPriceTotal: 22000000, ExecutedAt: date(2020, 10, 1)},
+	}
+	statements := []trading.Statement{{ID: "sta_1", ISIN: apple, Type: "import", Quantity: 5, Date: trading.Date{Time: date(2020, 1, 2)}}}
+
+	adjusted := registry.AdjustOrders(orders)
+	assert.Equal(t, 40, adjusted[0].ExecutedQuantity)
+	assert.Equal(t, 40, adjusted[0].Quantity)
+	assert.Equal(t, 1000000, adjusted[0].ExecutedPrice)
+	assert.Equal(t, 1025000, adjusted[0].LimitPrice)
+	assert.Equal(t, 40000000, adjusted[0].ExecutedPriceTotal, "totals stay")
+	assert.Equal(t, orders[1], adjusted[1], "after the split")
+	assert.Equal(t, 10, orders[0].ExecutedQuantity, "orders are copied")
+	adjustedStatements := registry.AdjustStatements(statements)
+	assert.Equal(t, 20, adjustedStatements[0].Quantity)
+
+	ledger := trading.NewLedger(trading.FIFO)
+	assert.Nil(t, ledger.Replay(adjusted, adjustedStatements))
+	assert.Equal(t, 40, ledger.Quantity(apple), "matches the live position")
+	assert.Equal(t, []trading.Lot{{ISIN: apple, Time: date(2020, 8, 3), Quantity: 40, Cost: 40000000}}, ledger.Lots(apple), "the imported shares were sold first")
+}
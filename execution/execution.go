@@ -0,0 +1,418 @@
+/*
+Package execution works a large parent order as a series of smaller child limit orders, so that a single
+order does not move a thin market.
+
+TWAP releases the quantity in equal slices between Start and End, VWAP in proportion to the intraday volume
+profile of historical trades and Iceberg shows at most Clip at a time. Children are limit orders at the
+current bid or ask, capped by the parent's limit price. A child that is no longer at the quote is canceled
+with DeleteOrder and replaced with CreateOrder. The executor is driven by quotes and works with a
+trading.TradingClient as well as with the simulator:
+
+	profile, err := execution.VolumeProfile(client.GetTrades(&history), isin, "XMUN", liquidity.Config{})
+	executor, err := execution.New(broker, trading.Order{ISIN: isin, Side: trading.Buy, Quantity: 5000, Venue: "XMUN"},
+		execution.Config{Algorithm: execution.VWAP, Start: start, End: start.Add(4 * time.Hour), Profile: profile})
+	for progress := range executor.Run(poller.Quotes()) {
+		fmt.Println(progress.Event, progress.Filled, "of", progress.Filled+progress.Remaining)
+	}
+*/
+package execution
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/liquidity"
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/quantfamily/lemonmarkets/trading"
+)
+
+// Broker is what an Executor needs to place and follow child orders
+type Broker interface {
+	trading.OrderEntry
+	trading.OrderQuery
+}
+
+// Algorithm that decides when quantity becomes due
+type Algorithm string
+
+const (
+	// TWAP releases the quantity in equal slices
+	TWAP Algorithm = "twap"
+	// VWAP releases the quantity in proportion to the volume profile
+	VWAP Algorithm = "vwap"
+	// Iceberg releases the whole quantity at Start and shows at most Clip at a time
+	Iceberg Algorithm = "iceberg"
+)
+
+/*
+Config of an Executor. TWAP and VWAP split the time from Start to End into Slices, 10 by default.
+VWAP weighs the slices by Profile, such as from VolumeProfile, whose buckets are Bucket wide and start
+at midnight in Location, 30 minutes and Europe/Berlin by default as in liquidity.Config.
+Clip is the largest child order and required for Iceberg.
+A working child whose limit price is no longer at the quote is replaced once it is older than Refresh,
+0 replaces it on every quote that moves
+*/
+type Config struct {
+	Algorithm Algorithm
+	Start     time.Time
+	End       time.Time
+	Slices    int
+	Profile   []liquidity.Bucket
+	Bucket    time.Duration
+	Location  *time.Location
+	Clip      int
+	Refresh   time.Duration
+}
+
+// Event that a Progress reports
+type Event string
+
+const (
+	// Placed is a new child order
+	Placed Event = "placed"
+	// Replaced is a child order that was deleted and created again at a new price or quantity
+	Replaced Event = "replaced"
+	// Filled is a child order that was executed in full or in part since the last quote
+	Filled Event = "filled"
+	// Completed is the parent order executed in full
+	Completed Event = "completed"
+	// Canceled is an execution that was stopped by Cancel
+	Canceled Event = "canceled"
+	// Failed is a call to the broker or a quote that failed, Error tells why
+	Failed Event = "failed"
+)
+
+/*
+Progress of an execution after an event. Child is the child order of the event, Scheduled is the quantity due
+so far, Filled the executed quantity of all children and AveragePrice their average execution price
+*/
+type Progress struct {
+	Time         time.Time
+	Event        Event
+	Child        trading.Order
+	Scheduled    int
+	Filled       int
+	Remaining    int
+	AveragePrice int
+	Error        error
+}
+
+/*
+Executor works a parent order as child orders. It is driven by OnQuote or Run with quotes of the parent's ISIN,
+quotes of other instruments and venues are ignored. It is safe for concurrent use
+*/
+type Executor struct {
+	mu       sync.Mutex
+	broker   Broker
+	parent   trading.Order
+	config   Config
+	schedule []Slice
+	child    *trading.Order
+	placedAt time.Time
+	filled   int
+	total    int
+	done     bool
+}
+
+/*
+New validates the parent order and the config and computes the schedule. The parent's ISIN, Side, Quantity,
+Venue and ExpiresAt are used for the children, its LimitPrice is the worst price any child may have
+*/
+func New(broker Broker, parent trading.Order, config Config) (*Executor, error) {
+	if parent.ISIN == "" {
+		return nil, errors.New("parent order has no ISIN")
+	}
+	if parent.Side != trading.Buy && parent.Side != trading.Sell {
+		return nil, fmt.Errorf("unknown side %q", parent.Side)
+	}
+	if parent.Quantity <= 0 {
+		return nil, fmt.Errorf("quantity must be positive, got %d", parent.Quantity)
+	}
+	if config.Location == nil {
+		location, err := time.LoadLocation("Europe/Berlin")
+		if err != nil {
+			return nil, err
+		}
+		config.Location = location
+	}
+	if config.Bucket <= 0 {
+		config.Bucket = 30 * time.Minute
+	}
+	if config.Slices <= 0 {
+		config.Slices = 10
+	}
+	if config.Clip < 0 {
+		return nil, fmt.Errorf("clip must not be negative, got %d", config.Clip)
+	}
+
+	executor := &Executor{broker: broker, parent: parent, config: config}
+	switch config.Algorithm {
+	case TWAP, VWAP:
+		if !config.End.After(config.Start) {
+			return nil, fmt.Errorf("%s needs an end after the start", config.Algorithm)
+		}
+		if config.Algorithm == TWAP {
+			executor.schedule = twap(parent.Quantity, config.Start, config.End, config.Slices)
+			break
+		}
+		schedule, err := vwap(parent.Quantity, config.Start, config.End, config.Slices, config)
+		if err != nil {
+			return nil, err
+		}
+		executor.schedule = schedule
+	case Iceberg:
+		if config.Clip == 0 {
+			return nil, errors.New("iceberg needs a clip size")
+		}
+		executor.schedule = []Slice{{Time: config.Start, Quantity: parent.Quantity}}
+	default:
+		return nil, fmt.Errorf("unknown algorithm %q", config.Algorithm)
+	}
+	return executor, nil
+}
+
+// Schedule returns the slices of the parent order, oldest first
+func (e *Executor) Schedule() []Slice {
+	return append([]Slice(nil), e.schedule...)
+}
+
+// Done is true once the parent order is executed in full or the execution was canceled
+func (e *Executor) Done() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.done
+}
+
+/*
+OnQuote follows the working child order and places, replaces or leaves it according to the schedule and the quote.
+It returns what happened, nothing when the quote does not change anything. Failed calls to the broker are
+reported as Failed and tried again on the next quote
+*/
+func (e *Executor) OnQuote(quote market_data.Quote) []Progress {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.done || quote.ISIN != e.parent.ISIN || (e.parent.Venue != "" && quote.Mic != "" && quote.Mic != e.parent.Venue) {
+		return nil
+	}
+	if quote.Bid <= 0 || quote.Ask <= 0 || quote.Bid > quote.Ask {
+		return nil
+	}
+	var events []Progress
+	if e.child != nil {
+		if event, ok := e.refresh(quote.Time); ok {
+			events = append(events, event)
+		}
+	}
+	if e.executed() >= e.parent.Quantity {
+		e.done = true
+		return append(events, e.progress(quote.Time, Completed, trading.Order{}, nil))
+	}
+
+	price := e.price(quote)
+	want := e.want(quote.Time)
+	if e.child == nil {
+		if want > 0 {
+			events = append(events, e.place(quote.Time, Placed, want, price))
+		}
+		return events
+	}
+	// a child is topped up when a new slice became due, not after every partial execution
+	working := e.child.Quantity - e.child.ExecutedQuantity
+	stale := e.child.LimitPrice != price && quote.Time.Sub(e.placedAt) >= e.config.Refresh
+	grown := want > working && due(e.schedule, quote.Time) > due(e.schedule, e.placedAt)
+	if !stale && !grown {
+		return events
+	}
+	canceled, err := e.cancel(quote.Time)
+	events = append(events, canceled...)
+	if err != nil {
+		return events
+	}
+	if e.executed() >= e.parent.Quantity {
+		e.done = true
+		return append(events, e.progress(quote.Time, Completed, trading.Order{}, nil))
+	}
+	if want = e.want(quote.Time); want > 0 {
+		events = append(events, e.place(quote.Time, Replaced, want, price))
+	}
+	return events
+}
+
+/*
+Cancel stops the execution and deletes the working child order, its executed part is kept in Filled.
+When the child can not be deleted the execution goes on and the error is returned
+*/
+func (e *Executor) Cancel(t time.Time) (Progress, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.done {
+		return e.progress(t, Canceled, trading.Order{}, nil), nil
+	}
+	if e.child != nil {
+		if events, err := e.cancel(t); err != nil {
+			return events[len(events)-1], err
+		}
+	}
+	e.done = true
+	return e.progress(t, Canceled, trading.Order{}, nil), nil
+}
+
+/*
+Run calls OnQuote for every quote on the channel and reports progress on the returned channel,
+which is closed when the parent order is executed, the execution canceled, the quotes end or a quote fails.
+A working child order is left as it is when the quotes end, use Cancel to delete it
+*/
+func (e *Executor) Run(quotes <-chan market_data.Item[market_data.Quote, error]) <-chan Progress {
+	out := make(chan Progress)
+	go func() {
+		defer close(out)
+		for item := range quotes {
+			if item.Error != nil {
+				// sent without the lock, so that Cancel is not blocked while nobody reads
+				e.mu.Lock()
+				failed := e.progress(item.Data.Time, Failed, trading.Order{}, item.Error)
+				e.mu.Unlock()
+				out <- failed
+				market_data.Drain(quotes)
+				return
+			}
+			for _, progress := range e.OnQuote(item.Data) {
+				out <- progress
+			}
+			if e.Done() {
+				market_data.Drain(quotes)
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// executed is the quantity executed by all children, including the working one
+func (e *Executor) executed() int {
+	if e.child == nil {
+		return e.filled
+	}
+	return e.filled + e.child.ExecutedQuantity
+}
+
+// want is the size of the next child, the quantity due and not executed yet, at most Clip
+func (e *Executor) want(t time.Time) int {
+	want := due(e.schedule, t) - e.executed()
+	if e.config.Clip > 0 && want > e.config.Clip {
+		want = e.config.Clip
+	}
+	return want
+}
+
+// price is the bid for sells and the ask for buys, but not worse than the parent's limit
+func (e *Executor) price(quote market_data.Quote) int {
+	if e.parent.Side == trading.Buy {
+		price := trading.ToAmount(quote.Ask)
+		if e.parent.LimitPrice > 0 && price > e.parent.LimitPrice {
+			price = e.parent.LimitPrice
+		}
+		return price
+	}
+	price := trading.ToAmount(quote.Bid)
+	if e.parent.LimitPrice > 0 && price < e.parent.LimitPrice {
+		price = e.parent.LimitPrice
+	}
+	return price
+}
+
+/*
+refresh gets the working child, reports new executions and forgets the child once it is done.
+The second return value is false when there is nothing to report
+*/
+func (e *Executor) refresh(t time.Time) (Progress, bool) {
+	item := e.broker.GetOrder(e.child.ID)
+	if item.Error != nil {
+		return e.progress(t, Failed, *e.child, item.Error), true
+	}
+	order := item.Data
+	executed := order.ExecutedQuantity > e.child.ExecutedQuantity
+	e.child = &order
+	child := order
+	if order.Done() {
+		e.filled += order.ExecutedQuantity
+		e.total += order.ExecutedPriceTotal
+		e.child = nil
+	}
+	if !executed {
+		return Progress{}, false
+	}
+	return e.progress(t, Filled, child, nil), true
+}
+
+/*
+cancel deletes the working child and gets it again, as it may have been executed in the meantime.
+The child is only forgotten when it is done, otherwise the error is returned with a Failed progress
+*/
+func (e *Executor) cancel(t time.Time) ([]Progress, error) {
+	deleteErr := e.broker.DeleteOrder(e.child.ID)
+	event, ok := e.refresh(t)
+	if ok && event.Error != nil {
+		return []Progress{event}, event.Error
+	}
+	var events []Progress
+	if ok {
+		events = append(events, event)
+	}
+	if e.child != nil {
+		err := deleteErr
+		if err == nil {
+			err = fmt.Errorf("order %s is still %s after deleting it", e.child.ID, e.child.Status)
+		}
+		return append(events, e.progress(t, Failed, *e.child, err)), err
+	}
+	return events, nil
+}
+
+// place creates and activates a child, an inactive child that can not be activated is deleted again
+func (e *Executor) place(t time.Time, event Event, quantity, price int) Progress {
+	child := &trading.Order{
+		ISIN:       e.parent.ISIN,
+		Side:       e.parent.Side,
+		Quantity:   quantity,
+		LimitPrice: price,
+		Venue:      e.parent.Venue,
+		ExpiresAt:  e.parent.ExpiresAt,
+	}
+	item := e.broker.CreateOrder(child)
+	if item.Error != nil {
+		return e.progress(t, Failed, *child, item.Error)
+	}
+	created := item.Data
+	if err := e.broker.ActivateOrder(created.ID); err != nil {
+		e.broker.DeleteOrder(created.ID)
+		return e.progress(t, Failed, created, err)
+	}
+	created.Status = trading.OrderActivated
+	e.child = &created
+	e.placedAt = t
+	return e.progress(t, event, created, nil)
+}
+
+func (e *Executor) progress(t time.Time, event Event, child trading.Order, err error) Progress {
+	filled, total := e.filled, e.total
+	if e.child != nil {
+		filled += e.child.ExecutedQuantity
+		total += e.child.ExecutedPriceTotal
+	}
+	progress := Progress{
+		Time:      t,
+		Event:     event,
+		Child:     child,
+		Scheduled: due(e.schedule, t),
+		Filled:    filled,
+		Remaining: e.parent.Quantity - filled,
+		Error:     err,
+	}
+	if filled > 0 {
+		progress.AveragePrice = total / filled
+	}
+	return progress
+}
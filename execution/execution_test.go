@@ -0,0 +1,291 @@
+package execution
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/quantfamily/lemonmarkets/simulator"
+	"github.com/quantfamily/lemonmarkets/trading"
+	"github.com/stretchr/testify/assert"
+)
+
+const isin = "US88160R1014"
+
+func quote(bid, ask float64, minutes int) market_data.Quote {
+	return market_data.Quote{ISIN: isin, Bid: bid, Ask: ask, BidVolume: 100, AskVolume: 100, Time: start.Add(time.Duration(minutes) * time.Minute)}
+}
+
+// run feeds every quote to the simulator before the executor, as the exchange matches before quotes are published
+func run(sim *simulator.Simulator, executor *Executor, quotes ...market_data.Quote) []Progress {
+	var progress []Progress
+	for _, q := range quotes {
+		sim.OnQuote(q)
+		progress = append(progress, executor.OnQuote(q)...)
+	}
+	return progress
+}
+
+func events(progress []Progress) []Event {
+	values := make([]Event, len(progress))
+	for i, p := range progress {
+		values[i] = p.Event
+	}
+	return values
+}
+
+func orders(sim *simulator.Simulator) []trading.Order {
+	var data []trading.Order
+	for item := range sim.GetOrders(nil) {
+		data = append(data, item.Data)
+	}
+	return data
+}
+
+func TestNew(t *testing.T) {
+	sim := simulator.New(simulator.Config{})
+	parent := trading.Order{ISIN: isin, Side: trading.Buy, Quantity: 100}
+	twapConfig := Config{Algorithm: TWAP, Start: start, End: start.Add(time.Hour), Location: time.UTC}
+
+	executor, err := New(sim, parent, twapConfig)
+	assert.Nil(t, err)
+	assert.Len(t, executor.Schedule(), 10, "10 slices by default")
+
+	_, err = New(sim, trading.Order{Side: trading.Buy, Quantity: 100}, twapConfig)
+	assert.EqualError(t, err, "parent order has no ISIN")
+	_, err = New(sim, trading.Order{ISIN: isin, Side: "hold", Quantity: 100}, twapConfig)
+	assert.EqualError(t, err, `unknown side "hold"`)
+	_, err = New(sim, trading.Order{ISIN: isin, Side: trading.Buy}, twapConfig)
+	assert.EqualError(t, err, "quantity must be positive, got 0")
+	_, err = New(sim, parent, Config{Algorithm: TWAP, Start: start, End: start, Location: time.UTC})
+	assert.EqualError(t, err, "twap needs an end after the start")
+	_, err = New(sim, parent, Config{Algorithm: VWAP, Start: start, End: start.Add(time.Hour), Location: time.UTC})
+	assert.EqualError(t, err, "profile has no volume between 2022-03-01T09:00:00Z and 2022-03-01T10:00:00Z")
+	_, err = New(sim, parent, Config{Algorithm: Iceberg, Start: start, Location: time.UTC})
+	assert.EqualError(t, err, "iceberg needs a clip size")
+	_, err = New(sim, parent, Config{Algorithm: Iceberg, Clip: -1, Location: time.UTC})
+	assert.EqualError(t, err, "clip must not be negative, got -1")
+	_, err = New(sim, parent, Config{Algorithm: "pov", Location: time.UTC})
+	assert.EqualError(t, err, `unknown algorithm "pov"`)
+}
+
+func TestTWAPExecution(t *testing.T) {
+	sim := simulator.New(simulator.Config{Cash: 100000000})
+	executor, err := New(sim, trading.Order{ISIN: isin, Side: trading.Buy, Quantity: 100},
+		Config{Algorithm: TWAP, Start: start, End: start.Add(40 * time.Minute), Slices: 4, Location: time.UTC})
+	assert.Nil(t, err)
+
+	var quotes []market_data.Quote
+	for minutes := 0; minutes <= 50; minutes += 5 {
+		quotes = append(quotes, quote(99.9, 100, minutes))
+	}
+	progress := run(sim, executor, quotes...)
+	assert.Equal(t, []Event{Placed, Filled, Placed, Filled, Placed, Filled, Placed, Filled, Completed}, events(progress))
+	assert.Equal(t, 25, progress[0].Child.Quantity)
+	assert.Equal(t, 1000000, progress[0].Child.LimitPrice, "buys at the ask")
+	assert.Equal(t, 50, progress[3].Filled)
+	assert.Equal(t, 50, progress[3].Scheduled)
+
+	last := progress[len(progress)-1]
+	assert.Equal(t, start.Add(35*time.Minute), last.Time)
+	assert.Equal(t, 100, last.Filled)
+	assert.Equal(t, 0, last.Remaining)
+	assert.Equal(t, 1000000, last.AveragePrice)
+	assert.True(t, executor.Done())
+	assert.Len(t, orders(sim), 4)
+	position := <-sim.GetPositions()
+	assert.Equal(t, 100, position.Data.Quantity)
+}
+
+func TestReplace(t *testing.T) {
+	parent := trading.Order{ISIN: isin, Side: trading.Buy, Quantity: 30}
+	t.Run("Successful test", func(t *testing.T) {
+		sim := simulator.New(simulator.Config{Cash: 100000000, Participation: 0.1})
+		executor, _ := New(sim, parent, Config{Algorithm: TWAP, Start: start, End: start.Add(time.Minute), Slices: 1, Location: time.UTC})
+		progress := run(sim, executor, quote(99.9, 100, 0), quote(100.4, 100.5, 5), quote(100.4, 100.5, 10), quote(100.4, 100.5, 15), quote(100.4, 100.5, 20))
+		assert.Equal(t, []Event{Placed, Replaced, Filled, Filled, Filled, Completed}, events(progress))
+		assert.Equal(t, 1005000, progress[1].Child.LimitPrice)
+
+		placed := orders(sim)
+		assert.Equal(t, trading.OrderCanceled, placed[0].Status, "deleted before it is created again")
+		assert.Equal(t, 0, placed[0].ExecutedQuantity)
+		assert.Equal(t, trading.OrderExecuted, placed[1].Status)
+	})
+	t.Run("keep partial execution", func(t *testing.T) {
+		sim := simulator.New(simulator.Config{Cash: 100000000, Participation: 0.1})
+		executor, _ := New(sim, parent, Config{Algorithm: TWAP, Start: start, End: start.Add(time.Minute), Slices: 1, Location: time.UTC})
+		progress := run(sim, executor, quote(99.9, 100, 0), quote(99.9, 100, 5), quote(100.4, 100.5, 10))
+		assert.Equal(t, []Event{Placed, Filled, Replaced}, events(progress))
+		assert.Equal(t, 20, progress[2].Child.Quantity, "only the remaining quantity")
+		assert.Equal(t, 10, progress[2].Filled)
+	})
+	t.Run("refresh", func(t *testing.T) {
+		sim := simulator.New(simulator.Config{Cash: 100000000})
+		executor, _ := New(sim, parent, Config{Algorithm: TWAP, Start: start, End: start.Add(time.Minute), Slices: 1, Location: time.UTC, Refresh: 10 * time.Minute})
+		progress := run(sim, executor, quote(99.9, 100, 0), quote(100.4, 100.5, 5))
+		assert.Equal(t, []Event{Placed}, events(progress), "too young to be replaced")
+		progress = run(sim, executor, quote(100.4, 100.5, 10))
+		assert.Equal(t, []Event{Replaced}, events(progress))
+	})
+	t.Run("parent limit", func(t *testing.T) {
+		sim := simulator.New(simulator.Config{Cash: 100000000})
+		limited := parent
+		limited.LimitPrice = 1003000
+		executor, _ := New(sim, limited, Config{Algorithm: TWAP, Start: start, End: start.Add(time.Minute), Slices: 1, Location: time.UTC})
+		progress := run(sim, executor, quote(100.4, 100.5, 0), quote(100.5, 100.6, 5))
+		assert.Equal(t, []Event{Placed}, events(progress), "the limit does not move with the quote")
+		assert.Equal(t, 1003000, progress[0].Child.LimitPrice)
+		progress = run(sim, executor, quote(100.1, 100.2, 10))
+		assert.Equal(t, []Event{Filled, Completed}, events(progress), "executed before it is replaced")
+		assert.Equal(t, 1002000, progress[1].AveragePrice)
+	})
+	t.Run("new slice", func(t *testing.T) {
+		sim := simulator.New(simulator.Config{Cash: 100000000})
+		executor, _ := New(sim, parent, Config{Algorithm: TWAP, Start: start, End: start.Add(10 * time.Minute), Slices: 2, Location: time.UTC})
+		progress := run(sim, executor, quote(100.4, 100.5, 0))
+		progress = append(progress, executor.OnQuote(quote(100.4, 100.5, 5))...)
+		assert.Equal(t, []Event{Placed, Replaced}, events(progress))
+		assert.Equal(t, 30, progress[1].Child.Quantity, "topped up with the next slice")
+	})
+}
+
+// racingBroker executes orders at the quote right before deleting them
+type racingBroker struct {
+	*simulator.Simulator
+	quote market_data.Quote
+}
+
+func (b *racingBroker) DeleteOrder(orderID string) error {
+	b.OnQuote(b.quote)
+	return b.Simulator.DeleteOrder(orderID)
+}
+
+func TestRace(t *testing.T) {
+	sim := simulator.New(simulator.Config{Cash: 100000000})
+	broker := &racingBroker{Simulator: sim, quote: quote(99.4, 99.5, 5)}
+	executor, _ := New(broker, trading.Order{ISIN: isin, Side: trading.Buy, Quantity: 30}, Config{Algorithm: TWAP, Start: start, End: start.Add(time.Minute), Slices: 1, Location: time.UTC})
+	progress := executor.OnQuote(quote(99.9, 100, 0))
+	progress = append(progress, executor.OnQuote(quote(100.4, 100.5, 5))...)
+	assert.Equal(t, []Event{Placed, Filled, Completed}, events(progress), "not replaced once executed")
+	assert.Len(t, orders(sim), 1)
+	assert.Equal(t, trading.OrderExecuted, orders(sim)[0].Status)
+}
+
+func TestIceberg(t *testing.T) {
+	sim := simulator.New(simulator.Config{Cash: 100000000})
+	seed := sim.CreateOrder(&trading.Order{ISIN: isin, Side: trading.Buy, Quantity: 100})
+	assert.Nil(t, sim.ActivateOrder(seed.Data.ID))
+	sim.OnQuote(quote(99.9, 100, -5))
+
+	executor, err := New(sim, trading.Order{ISIN: isin, Side: trading.Sell, Quantity: 100}, Config{Algorithm: Iceberg, Start: start, Clip: 40, Location: time.UTC})
+	assert.Nil(t, err)
+	progress := run(sim, executor, quote(99.9, 100, 0), quote(99.9, 100, 1), quote(99.9, 100, 2), quote(99.9, 100, 3))
+	assert.Equal(t, []Event{Placed, Filled, Placed, Filled, Placed, Filled, Completed}, events(progress))
+	assert.Equal(t, []int{40, 40, 20}, []int{progress[0].Child.Quantity, progress[2].Child.Quantity, progress[4].Child.Quantity})
+	assert.Equal(t, 999000, progress[0].Child.LimitPrice, "sells at the bid")
+	assert.Equal(t, 100, progress[0].Scheduled, "everything is due at once")
+	assert.Equal(t, 0, len(collectPositions(sim)))
+}
+
+func collectPositions(sim *simulator.Simulator) []trading.Position {
+	var positions []trading.Position
+	for item := range sim.GetPositions() {
+		if item.Data.Quantity > 0 {
+			positions = append(positions, item.Data)
+		}
+	}
+	return positions
+}
+
+func TestFailed(t *testing.T) {
+	sim := simulator.New(simulator.Config{Cash: 1000000})
+	executor, _ := New(sim, trading.Order{ISIN: isin, Side: trading.Buy, Quantity: 30}, Config{Algorithm: Iceberg, Start: start, Clip: 20, Location: time.UTC})
+	progress := run(sim, executor, quote(99.9, 100, 0))
+	assert.Equal(t, []Event{Failed}, events(progress))
+	assert.EqualError(t, progress[0].Error, "estimated total price is greater than cash to invest")
+	assert.Equal(t, trading.OrderCanceled, orders(sim)[0].Status, "the inactive order is deleted")
+	assert.False(t, executor.Done())
+}
+
+// fillingBroker executes every order at the market quote as soon as it is activated
+type fillingBroker struct {
+	*simulator.Simulator
+	market market_data.Quote
+}
+
+func (b *fillingBroker) ActivateOrder(orderID string) error {
+	if err := b.Simulator.ActivateOrder(orderID); err != nil {
+		return err
+	}
+	b.OnQuote(b.market)
+	return nil
+}
+
+func quotes(items ...market_data.Item[market_data.Quote, error]) <-chan market_data.Item[market_data.Quote, error] {
+	ch := make(chan market_data.Item[market_data.Quote, error], len(items))
+	for _, item := range items {
+		ch <- item
+	}
+	close(ch)
+	return ch
+}
+
+func item(q market_data.Quote) market_data.Item[market_data.Quote, error] {
+	return market_data.Item[market_data.Quote, error]{Data: q}
+}
+
+func TestRun(t *testing.T) {
+	parent := trading.Order{ISIN: isin, Side: trading.Buy, Quantity: 50}
+	config := Config{Algorithm: Iceberg, Start: start, Clip: 20, Location: time.UTC}
+	t.Run("Successful test", func(t *testing.T) {
+		broker := &fillingBroker{Simulator: simulator.New(simulator.Config{Cash: 100000000}), market: quote(99.9, 100, 0)}
+		executor, _ := New(broker, parent, config)
+		other := quote(10, 11, 1)
+		other.ISIN = "DE0008232125"
+		var progress []Progress
+		for p := range executor.Run(quotes(item(quote(99.9, 100, 0)), item(other), item(quote(99.9, 100, 1)), item(quote(99.9, 100, 2)), item(quote(99.9, 100, 3)), item(quote(99.9, 100, 4)))) {
+			progress = append(progress, p)
+		}
+		assert.Equal(t, []Event{Placed, Filled, Placed, Filled, Placed, Filled, Completed}, events(progress))
+		assert.Equal(t, 10, progress[4].Child.Quantity)
+	})
+	t.Run("fail to get quotes", func(t *testing.T) {
+		sim := simulator.New(simulator.Config{Cash: 100000000})
+		executor, _ := New(sim, parent, config)
+		var progress []Progress
+		for p := range executor.Run(quotes(item(quote(100.4, 100.5, 0)), market_data.Item[market_data.Quote, error]{Error: errors.New("timeout")})) {
+			progress = append(progress, p)
+		}
+		assert.Equal(t, []Event{Placed, Failed}, events(progress))
+		assert.EqualError(t, progress[1].Error, "timeout")
+		assert.False(t, executor.Done(), "the child is still working")
+
+		canceled, err := executor.Cancel(start.Add(time.Minute))
+		assert.Nil(t, err)
+		assert.Equal(t, Canceled, canceled.Event)
+		assert.Equal(t, 50, canceled.Remaining)
+		assert.Equal(t, trading.OrderCanceled, orders(sim)[0].Status)
+		assert.True(t, executor.Done())
+		assert.Nil(t, executor.OnQuote(quote(100.4, 100.5, 2)))
+	})
+	t.Run("cancel before the failure is read", func(t *testing.T) {
+		sim := simulator.New(simulator.Config{Cash: 100000000})
+		executor, _ := New(sim, parent, config)
+		progress := executor.Run(quotes(market_data.Item[market_data.Quote, error]{Error: errors.New("timeout")}))
+		// Run is waiting to send the failure by now
+		time.Sleep(20 * time.Millisecond)
+		canceled := make(chan Progress)
+		go func() {
+			p, _ := executor.Cancel(start)
+			canceled <- p
+		}()
+		select {
+		case p := <-canceled:
+			assert.Equal(t, Canceled, p.Event)
+		case <-time.After(time.Second):
+			t.Fatal("cancel is blocked by the unread failure")
+		}
+		assert.Equal(t, Failed, (<-progress).Event)
+	})
+}
@@ -0,0 +1,148 @@
+package execution
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/liquidity"
+	"github.com/quantfamily/lemonmarkets/market_data"
+)
+
+// Slice of the parent order that becomes due at Time
+type Slice struct {
+	Time     time.Time
+	Quantity int
+}
+
+// due is the quantity of the slices due at t
+func due(schedule []Slice, t time.Time) int {
+	quantity := 0
+	for _, slice := range schedule {
+		if !slice.Time.After(t) {
+			quantity += slice.Quantity
+		}
+	}
+	return quantity
+}
+
+// slices returns the start of n equally long slices between start and end
+func slices(start, end time.Time, n int) []time.Time {
+	times := make([]time.Time, n+1)
+	step := end.Sub(start) / time.Duration(n)
+	for i := range times {
+		times[i] = start.Add(time.Duration(i) * step)
+	}
+	times[n] = end
+	return times
+}
+
+/*
+allocate splits quantity by weight, rounding the running total so that the slices add up to quantity.
+Slices without quantity are left out
+*/
+func allocate(quantity int, times []time.Time, weights []float64) []Slice {
+	total := 0.0
+	for _, weight := range weights {
+		total += weight
+	}
+	var schedule []Slice
+	running, allocated := 0.0, 0
+	for i, weight := range weights {
+		running += weight
+		cumulative := int(math.Round(float64(quantity) * running / total))
+		if cumulative > allocated {
+			schedule = append(schedule, Slice{Time: times[i], Quantity: cumulative - allocated})
+			allocated = cumulative
+		}
+	}
+	return schedule
+}
+
+// twap releases the quantity in n equal slices from start to end
+func twap(quantity int, start, end time.Time, n int) []Slice {
+	weights := make([]float64, n)
+	for i := range weights {
+		weights[i] = 1
+	}
+	return allocate(quantity, slices(start, end, n), weights)
+}
+
+// vwap releases the quantity in n slices from start to end, each in proportion to the profile's volume during it
+func vwap(quantity int, start, end time.Time, n int, config Config) ([]Slice, error) {
+	times := slices(start, end, n)
+	weights := make([]float64, n)
+	total := 0.0
+	for i := range weights {
+		weights[i] = volumeShare(config.Profile, config.Bucket, config.Location, times[i], times[i+1])
+		total += weights[i]
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("profile has no volume between %s and %s", start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+	return allocate(quantity, times, weights), nil
+}
+
+/*
+volumeShare is the share of the profile's volume between from and to on any day,
+buckets count in proportion to their overlap with the time
+*/
+func volumeShare(profile []liquidity.Bucket, bucket time.Duration, location *time.Location, from, to time.Time) float64 {
+	share := 0.0
+	for from.Before(to) {
+		local := from.In(location)
+		midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+		end := midnight.AddDate(0, 0, 1)
+		if to.Before(end) {
+			end = to
+		}
+		low, high := from.Sub(midnight), end.Sub(midnight)
+		for _, b := range profile {
+			overlap := shorter(high, b.Start+bucket) - longer(low, b.Start)
+			if overlap > 0 {
+				share += b.Share * float64(overlap) / float64(bucket)
+			}
+		}
+		from = end
+	}
+	return share
+}
+
+func shorter(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func longer(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+/*
+VolumeProfile reads historical trades, such as from GetTrades over the last weeks, and returns the intraday
+volume profile of the ISIN on the venue for Config.Profile. The config is the one of the liquidity package,
+its Bucket and Location have to match the execution Config. It stops at the first error
+*/
+func VolumeProfile(trades <-chan market_data.Item[market_data.Trade, error], isin, mic string, config liquidity.Config) ([]liquidity.Bucket, error) {
+	analyzer, err := liquidity.New(config)
+	if err != nil {
+		market_data.Drain(trades)
+		return nil, err
+	}
+	for item := range trades {
+		if item.Error != nil {
+			market_data.Drain(trades)
+			return nil, item.Error
+		}
+		analyzer.AddTrade(item.Data)
+	}
+	report, ok := analyzer.Report(isin, mic)
+	if !ok || report.Volume == 0 {
+		return nil, fmt.Errorf("no trades of %s on %s", isin, mic)
+	}
+	return report.Profile, nil
+}
@@ -0,0 +1,95 @@
+package execution
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/liquidity"
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2022, 3, 1, 9, 0, 0, 0, time.UTC)
+
+func quantities(schedule []Slice) []int {
+	values := make([]int, len(schedule))
+	for i, slice := range schedule {
+		values[i] = slice.Quantity
+	}
+	return values
+}
+
+func TestTWAP(t *testing.T) {
+	schedule := twap(100, start, start.Add(time.Hour), 4)
+	assert.Equal(t, []int{25, 25, 25, 25}, quantities(schedule))
+	assert.Equal(t, start.Add(45*time.Minute), schedule[3].Time)
+
+	schedule = twap(10, start, start.Add(time.Hour), 3)
+	assert.Equal(t, []int{3, 4, 3}, quantities(schedule), "rounded so that the slices add up")
+	schedule = twap(2, start, start.Add(time.Hour), 4)
+	assert.Equal(t, []int{1, 1}, quantities(schedule), "empty slices are left out")
+	assert.Equal(t, start.Add(30*time.Minute), schedule[1].Time)
+
+	assert.Equal(t, 0, due(schedule, start.Add(-time.Second)))
+	assert.Equal(t, 1, due(schedule, start))
+	assert.Equal(t, 2, due(schedule, start.Add(2*time.Hour)))
+}
+
+func TestVWAP(t *testing.T) {
+	profile := []liquidity.Bucket{{Start: 9 * time.Hour, Share: 0.6}, {Start: 9*time.Hour + 30*time.Minute, Share: 0.2}, {Start: 15 * time.Hour, Share: 0.2}}
+	config := Config{Profile: profile, Bucket: 30 * time.Minute, Location: time.UTC}
+	t.Run("Successful test", func(t *testing.T) {
+		schedule, err := vwap(100, start, start.Add(time.Hour), 2, config)
+		assert.Nil(t, err)
+		assert.Equal(t, []int{75, 25}, quantities(schedule))
+
+		schedule, err = vwap(100, start, start.Add(time.Hour), 4, config)
+		assert.Nil(t, err)
+		assert.Equal(t, []int{38, 37, 13, 12}, quantities(schedule), "buckets count in proportion to the overlap")
+	})
+	t.Run("over midnight", func(t *testing.T) {
+		schedule, err := vwap(100, start.Add(6*time.Hour), start.Add(24*time.Hour+30*time.Minute), 2, config)
+		assert.Nil(t, err)
+		assert.Equal(t, []int{25, 75}, quantities(schedule))
+	})
+	t.Run("no volume", func(t *testing.T) {
+		_, err := vwap(100, start.Add(2*time.Hour), start.Add(3*time.Hour), 2, config)
+		assert.EqualError(t, err, "profile has no volume between 2022-03-01T11:00:00Z and 2022-03-01T12:00:00Z")
+	})
+}
+
+func TestVolumeProfile(t *testing.T) {
+	trades := func(items ...market_data.Item[market_data.Trade, error]) <-chan market_data.Item[market_data.Trade, error] {
+		ch := make(chan market_data.Item[market_data.Trade, error], len(items))
+		for _, item := range items {
+			ch <- item
+		}
+		close(ch)
+		return ch
+	}
+	trade := func(t time.Time, volume int) market_data.Item[market_data.Trade, error] {
+		return market_data.Item[market_data.Trade, error]{Data: market_data.Trade{ISIN: "DE0008232125", Mic: "XMUN", Price: 10, Volume: volume, Time: t}}
+	}
+	config := liquidity.Config{Location: time.UTC}
+	t.Run("Successful test", func(t *testing.T) {
+		profile, err := VolumeProfile(trades(
+			trade(start, 30),
+			trade(start.Add(40*time.Minute), 10),
+			trade(start.Add(24*time.Hour+5*time.Minute), 60),
+		), "DE0008232125", "XMUN", config)
+		assert.Nil(t, err)
+		assert.Equal(t, []liquidity.Bucket{
+			{Start: 9 * time.Hour, Trades: 2, Volume: 90, Share: 0.9},
+			{Start: 9*time.Hour + 30*time.Minute, Trades: 1, Volume: 10, Share: 0.1},
+		}, profile, "days are added up")
+	})
+	t.Run("no trades", func(t *testing.T) {
+		_, err := VolumeProfile(trades(trade(start, 30)), "DE0005140008", "XMUN", config)
+		assert.EqualError(t, err, "no trades of DE0005140008 on XMUN")
+	})
+	t.Run("fail to get trades", func(t *testing.T) {
+		_, err := VolumeProfile(trades(trade(start, 30), market_data.Item[market_data.Trade, error]{Error: errors.New("timeout")}), "DE0008232125", "XMUN", config)
+		assert.EqualError(t, err, "timeout")
+	})
+}
@@ -0,0 +1,94 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/quantfamily/lemonmarkets/trading"
+)
+
+/*
+WriteCSV writes a header and one row per value and returns the number of rows written.
+It stops at the first error, including errors returned by next, rows written before are flushed
+*/
+func WriteCSV[T any](w io.Writer, schema Schema[T], options Options, next Next[T]) (int, error) {
+	writer := csv.NewWriter(w)
+	writer.Comma = options.comma()
+	defer writer.Flush()
+	if err := writer.Write(schema.Names()); err != nil {
+		return 0, err
+	}
+	count := 0
+	row := make([]string, len(schema.Columns))
+	for {
+		value, ok, err := next()
+		if err != nil {
+			return count, err
+		}
+		if !ok {
+			break
+		}
+		for i, column := range schema.Columns {
+			row[i] = column.format(value, options)
+		}
+		if err := writer.Write(row); err != nil {
+			return count, err
+		}
+		count++
+	}
+	writer.Flush()
+	return count, writer.Error()
+}
+
+/*
+ReadCSV calls emit for every row. Columns are matched on the header, columns not in the schema are ignored
+and columns of the schema that are not in the file are left empty
+*/
+func ReadCSV[T any](r io.Reader, schema Schema[T], options Options, emit func(T)) error {
+	reader := csv.NewReader(r)
+	reader.Comma = options.comma()
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	columns := make([]*Column[T], len(header))
+	for i, name := range header {
+		if column, ok := schema.column(name); ok {
+			columns[i] = &column
+		}
+	}
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var value T
+		for i, text := range row {
+			if columns[i] == nil {
+				continue
+			}
+			if err := columns[i].parse(&value, text, options); err != nil {
+				return fmt.Errorf("line %d, column %s: %w", line, columns[i].Name, err)
+			}
+		}
+		emit(value)
+	}
+}
+
+// MarketDataCSV reads a CSV file as a channel like the ones returned by the market data client
+func MarketDataCSV[T market_data.DataTypes](r io.Reader, schema Schema[T], options Options) <-chan market_data.Item[T, error] {
+	return marketData(func(emit func(T)) error { return ReadCSV(r, schema, options, emit) })
+}
+
+// TradingCSV reads a CSV file as a channel like the ones returned by the trading client
+func TradingCSV[T trading.DataTypes](r io.Reader, schema Schema[T], options Options) <-chan trading.Item[T, error] {
+	return tradingData(func(emit func(T)) error { return ReadCSV(r, schema, options, emit) })
+}
@@ -0,0 +1,116 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/quantfamily/lemonmarkets/trading"
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2022, 3, 1, 9, 0, 0, 123456789, time.UTC)
+
+var bars = []market_data.OHLC{
+	{ISIN: "US88160R1014", Mic: "XMUN", Time: start, Open: 0.1, High: 1.0 / 3, Low: 0.05, Close: 0.2, Volume: 10},
+	{ISIN: "US88160R1014", Mic: "XMUN", Time: start.Add(time.Minute), Open: 609.5, High: 612.25, Low: 609, Close: 611, Volume: 7},
+}
+
+func TestWriteCSV(t *testing.T) {
+	t.Run("Successful test", func(t *testing.T) {
+		var buffer bytes.Buffer
+		n, err := WriteCSV(&buffer, OHLCSchema, Options{}, FromSlice(bars))
+		assert.Nil(t, err)
+		assert.Equal(t, 2, n)
+		lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+		assert.Equal(t, "isin,mic,time,open,high,low,close,volume", lines[0])
+		assert.Equal(t, "US88160R1014,XMUN,2022-03-01T09:00:00.123456789Z,0.1,0.3333333333333333,0.05,0.2,10", lines[1])
+	})
+	t.Run("selected columns and separator", func(t *testing.T) {
+		schema, _ := OHLCSchema.Select("time", "close")
+		var buffer bytes.Buffer
+		_, err := WriteCSV(&buffer, schema, Options{TimeFormat: "2006-01-02", Comma: ';'}, FromSlice(bars[:1]))
+		assert.Nil(t, err)
+		assert.Equal(t, "time;close\n2022-03-01;0.2\n", buffer.String())
+	})
+	t.Run("fail to get response", func(t *testing.T) {
+		ch := make(chan market_data.Item[market_data.OHLC, error], 2)
+		ch <- market_data.Item[market_data.OHLC, error]{Data: bars[0]}
+		ch <- market_data.Item[market_data.OHLC, error]{Error: errors.New("not found")}
+		close(ch)
+		var buffer bytes.Buffer
+		n, err := WriteCSV(&buffer, OHLCSchema, Options{}, FromMarketData(ch))
+		assert.NotNil(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, 2, strings.Count(buffer.String(), "\n"))
+	})
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	t.Run("ohlc", func(t *testing.T) {
+		var buffer bytes.Buffer
+		_, err := WriteCSV(&buffer, OHLCSchema, Options{}, FromSlice(bars))
+		assert.Nil(t, err)
+		var read []market_data.OHLC
+		for item := range MarketDataCSV(&buffer, OHLCSchema, Options{}) {
+			assert.Nil(t, item.Error)
+			read = append(read, item.Data)
+		}
+		assert.Equal(t, bars, read)
+	})
+	t.Run("trades", func(t *testing.T) {
+		trades := []market_data.Trade{{ISIN: "US88160R1014", Mic: "XMUN", Time: start, Price: 611.3, Volume: 3}}
+		var buffer bytes.Buffer
+		_, err := WriteCSV(&buffer, TradeSchema, Options{}, FromSlice(trades))
+		assert.Nil(t, err)
+		assert.Contains(t, buffer.String(), ",611.3,")
+		item := <-MarketDataCSV(&buffer, TradeSchema, Options{})
+		assert.Equal(t, trades[0], item.Data)
+	})
+	t.Run("orders", func(t *testing.T) {
+		orders := []trading.Order{{
+			ID: "ord_1", ISIN: "US88160R1014", ISINTitle: "TESLA, INC.", CreatedAt: start, Side: trading.Buy, Quantity: 2,
+			LimitPrice: 6000000, Status: trading.OrderExecuted, ExecutedAt: start.Add(time.Second), Charge: 10000, Notes: "said \"hi\", twice",
+			RegulatoryInformation: &trading.RegulatoryInformation{CostsEntry: 1.5, CostsEntryPct: "0.1%"},
+		}}
+		var buffer bytes.Buffer
+		_, err := WriteCSV(&buffer, OrderSchema, Options{}, FromSlice(orders))
+		assert.Nil(t, err)
+		item := <-TradingCSV(&buffer, OrderSchema, Options{})
+		assert.Nil(t, item.Error)
+		assert.Equal(t, orders[0], item.Data)
+	})
+	t.Run("bank statements", func(t *testing.T) {
+		date, _ := time.Parse(trading.DateLayout, "2022-03-01")
+		statements := []trading.BankStatement{{ID: "bst_1", Type: "pay_in", Date: trading.Date{Time: date}, Amount: 1000000, CreatedAt: start}}
+		var buffer bytes.Buffer
+		_, err := WriteCSV(&buffer, BankStatementSchema, Options{}, FromSlice(statements))
+		assert.Nil(t, err)
+		item := <-TradingCSV(&buffer, BankStatementSchema, Options{})
+		assert.Equal(t, statements[0], item.Data)
+	})
+}
+
+func TestReadCSV(t *testing.T) {
+	t.Run("columns are matched on the header", func(t *testing.T) {
+		input := "close,extra,isin\n1.5,x,A\n"
+		var read []market_data.OHLC
+		assert.Nil(t, ReadCSV(strings.NewReader(input), OHLCSchema, Options{}, func(bar market_data.OHLC) { read = append(read, bar) }))
+		assert.Equal(t, []market_data.OHLC{{ISIN: "A", Close: 1.5}}, read)
+	})
+	t.Run("Fail to decode results", func(t *testing.T) {
+		input := "isin,close\nA,cheap\n"
+		var last market_data.Item[market_data.OHLC, error]
+		for item := range MarketDataCSV(strings.NewReader(input), OHLCSchema, Options{}) {
+			last = item
+		}
+		assert.NotNil(t, last.Error)
+		assert.Contains(t, last.Error.Error(), "line 2, column close")
+	})
+	t.Run("empty file", func(t *testing.T) {
+		assert.Nil(t, ReadCSV(strings.NewReader(""), OHLCSchema, Options{}, func(market_data.OHLC) {}))
+	})
+}
@@ -0,0 +1,274 @@
+/*
+Package export writes market data and trading records to CSV, JSON Lines and Parquet and reads them back.
+
+Writers consume the channels returned by the clients directly and readers return the same kind of channels,
+so an exported file can be fed to anything that expects a client, such as the backtester:
+
+	file, _ := os.Create("ohlc.csv")
+	n, err := export.WriteCSV(file, export.OHLCSchema, export.Options{}, export.FromMarketData(client.GetOHLCPerDay(&query)))
+
+	bars := export.MarketDataCSV(file, export.OHLCSchema, export.Options{})
+
+Parquet files are read from an io.ReaderAt, such as an *os.File, as the metadata is at the end of the file:
+
+	n, err := export.WriteParquet(file, export.OHLCSchema, export.FromMarketData(client.GetOHLCPerDay(&query)))
+
+	info, _ := file.Stat()
+	bars := export.MarketDataParquet(file, info.Size(), export.OHLCSchema)
+
+With the default options every value is written without loss, so reading a file returns what was written.
+*/
+package export
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/quantfamily/lemonmarkets/trading"
+)
+
+// Special time formats, any other value is used as layout for time.Format
+const (
+	UnixSeconds = "unix"
+	UnixMillis  = "unix_ms"
+)
+
+/*
+Options for writing and reading. TimeFormat defaults to time.RFC3339Nano, Location converts times
+before they are written and is not used when reading. Comma is the CSV separator and defaults to ','
+*/
+type Options struct {
+	TimeFormat string
+	Location   *time.Location
+	Comma      rune
+}
+
+func (o Options) timeFormat() string {
+	if o.TimeFormat == "" {
+		return time.RFC3339Nano
+	}
+	return o.TimeFormat
+}
+
+func (o Options) comma() rune {
+	if o.Comma == 0 {
+		return ','
+	}
+	return o.Comma
+}
+
+func (o Options) formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	if o.Location != nil {
+		t = t.In(o.Location)
+	}
+	switch o.timeFormat() {
+	case UnixSeconds:
+		return strconv.FormatInt(t.Unix(), 10)
+	case UnixMillis:
+		return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+	}
+	return t.Format(o.timeFormat())
+}
+
+func (o Options) parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	switch o.timeFormat() {
+	case UnixSeconds:
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		return time.Unix(seconds, 0).UTC(), err
+	case UnixMillis:
+		millis, err := strconv.ParseInt(value, 10, 64)
+		return time.Unix(0, millis*int64(time.Millisecond)).UTC(), err
+	}
+	return time.Parse(o.timeFormat(), value)
+}
+
+// numericTime is true when times are written as numbers
+func (o Options) numericTime() bool {
+	return o.timeFormat() == UnixSeconds || o.timeFormat() == UnixMillis
+}
+
+/*
+Column is a single field of T, written as text in CSV, as number or string in JSON Lines
+and as typed column in Parquet
+*/
+type Column[T any] struct {
+	Name   string
+	format func(value T, options Options) string
+	parse  func(value *T, text string, options Options) error
+	kind   kind
+	// bits of float columns, 32 or 64
+	bits int
+}
+
+type kind int
+
+const (
+	textKind kind = iota
+	intKind
+	floatKind
+	timeKind
+)
+
+func (c Column[T]) number(options Options) bool {
+	return c.kind == intKind || c.kind == floatKind || c.kind == timeKind && options.numericTime()
+}
+
+/*
+Schema is the ordered set of columns written for T, use Select to write only some of them
+*/
+type Schema[T any] struct {
+	Columns []Column[T]
+}
+
+// Select returns a schema with the named columns in the given order
+func (s Schema[T]) Select(names ...string) (Schema[T], error) {
+	selected := Schema[T]{}
+	for _, name := range names {
+		column, ok := s.column(name)
+		if !ok {
+			return selected, fmt.Errorf("unknown column %q", name)
+		}
+		selected.Columns = append(selected.Columns, column)
+	}
+	return selected, nil
+}
+
+// Names returns the column names
+func (s Schema[T]) Names() []string {
+	names := make([]string, len(s.Columns))
+	for i, column := range s.Columns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+func (s Schema[T]) column(name string) (Column[T], bool) {
+	for _, column := range s.Columns {
+		if column.Name == name {
+			return column, true
+		}
+	}
+	return Column[T]{}, false
+}
+
+func stringColumn[T any](name string, get func(T) string, set func(*T, string)) Column[T] {
+	return Column[T]{
+		Name:   name,
+		format: func(value T, _ Options) string { return get(value) },
+		parse: func(value *T, text string, _ Options) error {
+			set(value, text)
+			return nil
+		},
+	}
+}
+
+func intColumn[T any](name string, get func(T) int, set func(*T, int)) Column[T] {
+	return Column[T]{
+		Name:   name,
+		kind:   intKind,
+		format: func(value T, _ Options) string { return strconv.Itoa(get(value)) },
+		parse: func(value *T, text string, _ Options) error {
+			if text == "" {
+				return nil
+			}
+			parsed, err := strconv.Atoi(text)
+			set(value, parsed)
+			return err
+		},
+	}
+}
+
+func floatColumn[T any](name string, bits int, get func(T) float64, set func(*T, float64)) Column[T] {
+	return Column[T]{
+		Name:   name,
+		kind:   floatKind,
+		bits:   bits,
+		format: func(value T, _ Options) string { return strconv.FormatFloat(get(value), 'g', -1, bits) },
+		parse: func(value *T, text string, _ Options) error {
+			if text == "" {
+				return nil
+			}
+			parsed, err := strconv.ParseFloat(text, bits)
+			set(value, parsed)
+			return err
+		},
+	}
+}
+
+func timeColumn[T any](name string, get func(T) time.Time, set func(*T, time.Time)) Column[T] {
+	return Column[T]{
+		Name:   name,
+		kind:   timeKind,
+		format: func(value T, options Options) string { return options.formatTime(get(value)) },
+		parse: func(value *T, text string, options Options) error {
+			parsed, err := options.parseTime(text)
+			set(value, parsed)
+			return err
+		},
+	}
+}
+
+// Next returns the next value to write, false when there are no more values
+type Next[T any] func() (T, bool, error)
+
+// FromMarketData returns the values of a channel from the market data client
+func FromMarketData[T market_data.DataTypes](ch <-chan market_data.Item[T, error]) Next[T] {
+	return func() (T, bool, error) {
+		item, ok := <-ch
+		return item.Data, ok, item.Error
+	}
+}
+
+// FromTrading returns the values of a channel from the trading client
+func FromTrading[T trading.DataTypes](ch <-chan trading.Item[T, error]) Next[T] {
+	return func() (T, bool, error) {
+		item, ok := <-ch
+		return item.Data, ok, item.Error
+	}
+}
+
+// FromSlice returns the values of a slice
+func FromSlice[T any](values []T) Next[T] {
+	i := 0
+	return func() (T, bool, error) {
+		var value T
+		if i >= len(values) {
+			return value, false, nil
+		}
+		value = values[i]
+		i++
+		return value, true, nil
+	}
+}
+
+// marketData runs read in a goroutine and sends what it emits as market data items
+func marketData[T market_data.DataTypes](read func(emit func(T)) error) <-chan market_data.Item[T, error] {
+	ch := make(chan market_data.Item[T, error])
+	go func() {
+		defer close(ch)
+		if err := read(func(value T) { ch <- market_data.Item[T, error]{Data: value} }); err != nil {
+			ch <- market_data.Item[T, error]{Error: err}
+		}
+	}()
+	return ch
+}
+
+// tradingData runs read in a goroutine and sends what it emits as trading items
+func tradingData[T trading.DataTypes](read func(emit func(T)) error) <-chan trading.Item[T, error] {
+	ch := make(chan trading.Item[T, error])
+	go func() {
+		defer close(ch)
+		if err := read(func(value T) { ch <- trading.Item[T, error]{Data: value} }); err != nil {
+			ch <- trading.Item[T, error]{Error: err}
+		}
+	}()
+	return ch
+}
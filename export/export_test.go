@@ -0,0 +1,45 @@
+package export
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelect(t *testing.T) {
+	t.Run("Successful test", func(t *testing.T) {
+		schema, err := OHLCSchema.Select("time", "close")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"time", "close"}, schema.Names())
+	})
+	t.Run("unknown column", func(t *testing.T) {
+		_, err := OHLCSchema.Select("time", "adjusted_close")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestTimeFormats(t *testing.T) {
+	moment := time.Date(2022, 3, 1, 9, 30, 15, 500000000, time.UTC)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.Nil(t, err)
+
+	cases := []struct {
+		options  Options
+		text     string
+		expected time.Time
+	}{
+		{Options{}, "2022-03-01T09:30:15.5Z", moment},
+		{Options{Location: berlin}, "2022-03-01T10:30:15.5+01:00", moment},
+		{Options{TimeFormat: UnixSeconds}, "1646127015", moment.Truncate(time.Second)},
+		{Options{TimeFormat: UnixMillis}, "1646127015500", moment},
+		{Options{TimeFormat: "2006-01-02"}, "2022-03-01", time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.text, c.options.formatTime(moment))
+		parsed, err := c.options.parseTime(c.text)
+		assert.Nil(t, err)
+		assert.True(t, c.expected.Equal(parsed), c.text)
+	}
+	assert.Equal(t, "", Options{}.formatTime(time.Time{}))
+}
@@ -0,0 +1,102 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/quantfamily/lemonmarkets/trading"
+)
+
+/*
+WriteJSONLines writes one JSON object per value and line, keyed by column name, and returns the number
+of lines written. Numbers are written as JSON numbers, everything else as strings
+*/
+func WriteJSONLines[T any](w io.Writer, schema Schema[T], options Options, next Next[T]) (int, error) {
+	writer := bufio.NewWriter(w)
+	defer writer.Flush()
+	count := 0
+	var line bytes.Buffer
+	for {
+		value, ok, err := next()
+		if err != nil {
+			return count, err
+		}
+		if !ok {
+			break
+		}
+		line.Reset()
+		line.WriteByte('{')
+		for i, column := range schema.Columns {
+			if i > 0 {
+				line.WriteByte(',')
+			}
+			name, _ := json.Marshal(column.Name)
+			line.Write(name)
+			line.WriteByte(':')
+			text := column.format(value, options)
+			if column.number(options) && text != "" {
+				line.WriteString(text)
+				continue
+			}
+			quoted, _ := json.Marshal(text)
+			line.Write(quoted)
+		}
+		line.WriteString("}\n")
+		if _, err := writer.Write(line.Bytes()); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, writer.Flush()
+}
+
+/*
+ReadJSONLines calls emit for every line, empty lines are skipped. Keys that are not in the schema are ignored
+*/
+func ReadJSONLines[T any](r io.Reader, schema Schema[T], options Options, emit func(T)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(scanner.Bytes(), &fields); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		var value T
+		for _, column := range schema.Columns {
+			raw, ok := fields[column.Name]
+			if !ok {
+				continue
+			}
+			text := string(raw)
+			if len(raw) > 0 && raw[0] == '"' {
+				if err := json.Unmarshal(raw, &text); err != nil {
+					return fmt.Errorf("line %d, column %s: %w", line, column.Name, err)
+				}
+			} else if text == "null" {
+				text = ""
+			}
+			if err := column.parse(&value, text, options); err != nil {
+				return fmt.Errorf("line %d, column %s: %w", line, column.Name, err)
+			}
+		}
+		emit(value)
+	}
+	return scanner.Err()
+}
+
+// MarketDataJSONLines reads a JSON Lines file as a channel like the ones returned by the market data client
+func MarketDataJSONLines[T market_data.DataTypes](r io.Reader, schema Schema[T], options Options) <-chan market_data.Item[T, error] {
+	return marketData(func(emit func(T)) error { return ReadJSONLines(r, schema, options, emit) })
+}
+
+// TradingJSONLines reads a JSON Lines file as a channel like the ones returned by the trading client
+func TradingJSONLines[T trading.DataTypes](r io.Reader, schema Schema[T], options Options) <-chan trading.Item[T, error] {
+	return tradingData(func(emit func(T)) error { return ReadJSONLines(r, schema, options, emit) })
+}
@@ -0,0 +1,75 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/quantfamily/lemonmarkets/trading"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteJSONLines(t *testing.T) {
+	t.Run("Successful test", func(t *testing.T) {
+		var buffer bytes.Buffer
+		n, err := WriteJSONLines(&buffer, OHLCSchema, Options{}, FromSlice(bars[:1]))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, `{"isin":"US88160R1014","mic":"XMUN","time":"2022-03-01T09:00:00.123456789Z","open":0.1,"high":0.3333333333333333,"low":0.05,"close":0.2,"volume":10}`+"\n", buffer.String())
+	})
+	t.Run("unix times are numbers", func(t *testing.T) {
+		schema, _ := OHLCSchema.Select("time")
+		var buffer bytes.Buffer
+		_, err := WriteJSONLines(&buffer, schema, Options{TimeFormat: UnixSeconds}, FromSlice(bars[:1]))
+		assert.Nil(t, err)
+		assert.Equal(t, `{"time":1646125200}`+"\n", buffer.String())
+	})
+}
+
+func TestJSONLinesRoundTrip(t *testing.T) {
+	t.Run("ohlc", func(t *testing.T) {
+		var buffer bytes.Buffer
+		_, err := WriteJSONLines(&buffer, OHLCSchema, Options{}, FromSlice(bars))
+		assert.Nil(t, err)
+		var read []market_data.OHLC
+		for item := range MarketDataJSONLines(&buffer, OHLCSchema, Options{}) {
+			assert.Nil(t, item.Error)
+			read = append(read, item.Data)
+		}
+		assert.Equal(t, bars, read)
+	})
+	t.Run("quotes", func(t *testing.T) {
+		quotes := []market_data.Quote{{ISIN: "A", Mic: "XMUN", Time: start, Bid: 1.1, Ask: 1.2, BidVolume: 3, AskVolume: 4}}
+		var buffer bytes.Buffer
+		_, err := WriteJSONLines(&buffer, QuoteSchema, Options{}, FromSlice(quotes))
+		assert.Nil(t, err)
+		item := <-MarketDataJSONLines(&buffer, QuoteSchema, Options{})
+		assert.Equal(t, quotes[0], item.Data)
+	})
+	t.Run("orders", func(t *testing.T) {
+		orders := []trading.Order{{ID: "ord_1", Notes: "line\nbreak", Quantity: 1, CreatedAt: start}}
+		var buffer bytes.Buffer
+		_, err := WriteJSONLines(&buffer, OrderSchema, Options{}, FromSlice(orders))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, strings.Count(buffer.String(), "\n"))
+		item := <-TradingJSONLines(&buffer, OrderSchema, Options{})
+		assert.Equal(t, orders[0], item.Data)
+	})
+}
+
+func TestReadJSONLines(t *testing.T) {
+	t.Run("Fail to decode results", func(t *testing.T) {
+		var last market_data.Item[market_data.OHLC, error]
+		for item := range MarketDataJSONLines(strings.NewReader("{\"close\":1}\nreally odd response\n"), OHLCSchema, Options{}) {
+			last = item
+		}
+		assert.NotNil(t, last.Error)
+	})
+	t.Run("null and empty lines", func(t *testing.T) {
+		var read []market_data.OHLC
+		err := ReadJSONLines(strings.NewReader("{\"close\":null,\"isin\":\"A\"}\n\n"), OHLCSchema, Options{}, func(bar market_data.OHLC) { read = append(read, bar) })
+		assert.Nil(t, err)
+		assert.Equal(t, []market_data.OHLC{{ISIN: "A"}}, read)
+	})
+}
@@ -0,0 +1,611 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/quantfamily/lemonmarkets/trading"
+)
+
+// parquetMagic starts and ends every Parquet file
+const parquetMagic = "PAR1"
+
+// parquetRowGroup is the number of rows buffered and written as one row group
+const parquetRowGroup = 64 * 1024
+
+// physical types, repetitions, encodings and page types of the Parquet format
+const (
+	parquetInt32     = 1
+	parquetInt64     = 2
+	parquetFloat     = 4
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetRequired = 0
+	parquetOptional = 1
+
+	parquetPlain = 0
+	parquetRLE   = 3
+
+	parquetDataPage = 0
+
+	parquetUTF8            = 0
+	parquetTimestampMillis = 9
+	parquetTimestampMicros = 10
+	parquetSignedInt64     = 18
+)
+
+/*
+WriteParquet writes all values as a single Parquet file and returns the number of rows written.
+Text columns are written as strings, numbers as signed INT64, FLOAT or DOUBLE and times as nanosecond timestamps in UTC,
+zero times are written as null. Pages are written plain and uncompressed, in row groups of 65536 rows.
+The footer is only written when next is done, so the file is not valid when an error is returned
+*/
+func WriteParquet[T any](w io.Writer, schema Schema[T], next Next[T]) (int, error) {
+	if len(schema.Columns) == 0 {
+		return 0, errors.New("schema has no columns")
+	}
+	writer := &parquetWriter{w: bufio.NewWriter(w)}
+	defer writer.w.Flush()
+	writer.write([]byte(parquetMagic))
+	columns := make([]parquetColumn, len(schema.Columns))
+	for i, column := range schema.Columns {
+		columns[i] = newParquetColumn(column.Name, column.kind, column.bits)
+	}
+	var groups []parquetGroup
+	count, rows := 0, 0
+	for {
+		value, ok, err := next()
+		if err != nil {
+			return count, err
+		}
+		if !ok {
+			break
+		}
+		for i, column := range schema.Columns {
+			if err := columns[i].add(column.format(value, Options{})); err != nil {
+				return count, fmt.Errorf("row %d, column %s: %w", count+1, column.Name, err)
+			}
+		}
+		count++
+		if rows++; rows == parquetRowGroup {
+			groups = append(groups, writer.group(columns, rows))
+			rows = 0
+		}
+		if writer.err != nil {
+			return count, writer.err
+		}
+	}
+	if rows > 0 {
+		groups = append(groups, writer.group(columns, rows))
+	}
+	writer.footer(columns, groups, count)
+	if writer.err != nil {
+		return count, writer.err
+	}
+	return count, writer.w.Flush()
+}
+
+/*
+ReadParquet calls emit for every row of a Parquet file of the given size. Columns are matched on their name,
+columns not in the schema are ignored and columns of the schema that are not in the file are left empty.
+Flat files with plain encoded, uncompressed version 1 data pages can be read, which includes every file written
+by WriteParquet
+*/
+func ReadParquet[T any](r io.ReaderAt, size int64, schema Schema[T], emit func(T)) error {
+	metadata, err := readParquetFooter(r, size)
+	if err != nil {
+		return err
+	}
+	elements := metadata.list(2)
+	if len(elements) == 0 {
+		return errors.New("parquet schema is missing")
+	}
+	columns := make([]*Column[T], len(elements)-1)
+	fields := make([]parquetField, len(elements)-1)
+	for i, element := range elements[1:] {
+		element, _ := element.(thriftFields)
+		if children, _ := element.int(5); children > 0 {
+			return fmt.Errorf("nested column %s is not supported", element.string(4))
+		}
+		fields[i] = newParquetField(element)
+		if column, ok := schema.column(fields[i].name); ok {
+			columns[i] = &column
+		}
+	}
+
+	row := 1
+	for _, group := range metadata.list(4) {
+		group, _ := group.(thriftFields)
+		rows, _ := group.int(3)
+		chunks := group.list(1)
+		if len(chunks) != len(fields) {
+			return errors.New("row group does not match the parquet schema")
+		}
+		texts := make([][]string, len(fields))
+		for i, chunk := range chunks {
+			if columns[i] == nil {
+				continue
+			}
+			chunk, _ := chunk.(thriftFields)
+			if texts[i], err = fields[i].read(r, size, chunk.child(3), int(rows)); err != nil {
+				return fmt.Errorf("column %s: %w", fields[i].name, err)
+			}
+		}
+		for j := 0; j < int(rows); j++ {
+			var value T
+			for i, column := range columns {
+				if column == nil {
+					continue
+				}
+				if err := column.parse(&value, texts[i][j], Options{}); err != nil {
+					return fmt.Errorf("row %d, column %s: %w", row, column.Name, err)
+				}
+			}
+			emit(value)
+			row++
+		}
+	}
+	return nil
+}
+
+// MarketDataParquet reads a Parquet file as a channel like the ones returned by the market data client
+func MarketDataParquet[T market_data.DataTypes](r io.ReaderAt, size int64, schema Schema[T]) <-chan market_data.Item[T, error] {
+	return marketData(func(emit func(T)) error { return ReadParquet(r, size, schema, emit) })
+}
+
+// TradingParquet reads a Parquet file as a channel like the ones returned by the trading client
+func TradingParquet[T trading.DataTypes](r io.ReaderAt, size int64, schema Schema[T]) <-chan trading.Item[T, error] {
+	return tradingData(func(emit func(T)) error { return ReadParquet(r, size, schema, emit) })
+}
+
+// parquetColumn buffers the values of a column for the current row group
+type parquetColumn struct {
+	name     string
+	kind     kind
+	typ      int32
+	optional bool
+	levels   []byte
+	values   bytes.Buffer
+}
+
+func newParquetColumn(name string, kind kind, bits int) parquetColumn {
+	column := parquetColumn{name: name, kind: kind, typ: parquetByteArray}
+	switch kind {
+	case intKind:
+		column.typ = parquetInt64
+	case floatKind:
+		column.typ = parquetDouble
+		if bits == 32 {
+			column.typ = parquetFloat
+		}
+	case timeKind:
+		column.typ = parquetInt64
+		column.optional = true
+	}
+	return column
+}
+
+// add appends a value as formatted by the column
+func (c *parquetColumn) add(text string) error {
+	var b [8]byte
+	switch c.kind {
+	case intKind:
+		v, err := strconv.ParseInt(text, 10, 64)
+		binary.LittleEndian.PutUint64(b[:], uint64(v))
+		c.values.Write(b[:])
+		return err
+	case floatKind:
+		v, err := strconv.ParseFloat(text, 64)
+		if c.typ == parquetFloat {
+			binary.LittleEndian.PutUint32(b[:], math.Float32bits(float32(v)))
+			c.values.Write(b[:4])
+		} else {
+			binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+			c.values.Write(b[:])
+		}
+		return err
+	case timeKind:
+		if text == "" {
+			c.levels = append(c.levels, 0)
+			return nil
+		}
+		c.levels = append(c.levels, 1)
+		t, err := time.Parse(time.RFC3339Nano, text)
+		binary.LittleEndian.PutUint64(b[:], uint64(t.UnixNano()))
+		c.values.Write(b[:])
+		return err
+	}
+	binary.LittleEndian.PutUint32(b[:], uint32(len(text)))
+	c.values.Write(b[:4])
+	c.values.WriteString(text)
+	return nil
+}
+
+// page returns the data page of the buffered values and resets the column
+func (c *parquetColumn) page() []byte {
+	var page bytes.Buffer
+	if c.optional {
+		levels := encodeLevels(c.levels)
+		var length [4]byte
+		binary.LittleEndian.PutUint32(length[:], uint32(len(levels)))
+		page.Write(length[:])
+		page.Write(levels)
+	}
+	page.Write(c.values.Bytes())
+	c.levels = c.levels[:0]
+	c.values.Reset()
+	return page.Bytes()
+}
+
+// encodeLevels encodes definition levels of 0 and 1 as RLE runs
+func encodeLevels(levels []byte) []byte {
+	var encoded []byte
+	var b [binary.MaxVarintLen64]byte
+	for i := 0; i < len(levels); {
+		run := 1
+		for i+run < len(levels) && levels[i+run] == levels[i] {
+			run++
+		}
+		encoded = append(encoded, b[:binary.PutUvarint(b[:], uint64(run)<<1)]...)
+		encoded = append(encoded, levels[i])
+		i += run
+	}
+	return encoded
+}
+
+// parquetChunk is where a column chunk was written
+type parquetChunk struct {
+	offset int64
+	size   int64
+	values int
+}
+
+type parquetGroup struct {
+	rows   int
+	chunks []parquetChunk
+}
+
+// parquetWriter keeps the first error and the offset, so that chunks can be written without checking every write
+type parquetWriter struct {
+	w      *bufio.Writer
+	offset int64
+	err    error
+}
+
+func (pw *parquetWriter) write(data []byte) {
+	if pw.err != nil {
+		return
+	}
+	var n int
+	n, pw.err = pw.w.Write(data)
+	pw.offset += int64(n)
+}
+
+// group writes the buffered values of all columns as a row group
+func (pw *parquetWriter) group(columns []parquetColumn, rows int) parquetGroup {
+	group := parquetGroup{rows: rows}
+	for i := range columns {
+		data := columns[i].page()
+		header := compactWriter{}
+		header.i32(1, parquetDataPage)
+		header.i32(2, int32(len(data)))
+		header.i32(3, int32(len(data)))
+		header.structField(5)
+		header.i32(1, int32(group.rows))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetRLE)
+		header.i32(4, parquetRLE)
+		header.endStruct()
+		header.buf.WriteByte(0)
+
+		chunk := parquetChunk{offset: pw.offset, size: int64(header.buf.Len() + len(data)), values: group.rows}
+		pw.write(header.buf.Bytes())
+		pw.write(data)
+		group.chunks = append(group.chunks, chunk)
+	}
+	return group
+}
+
+// footer writes the file metadata
+func (pw *parquetWriter) footer(columns []parquetColumn, groups []parquetGroup, rows int) {
+	metadata := compactWriter{}
+	metadata.i32(1, 1)
+	metadata.list(2, thriftStruct, len(columns)+1)
+	metadata.beginStruct()
+	metadata.string(4, "schema")
+	metadata.i32(5, int32(len(columns)))
+	metadata.endStruct()
+	for _, column := range columns {
+		metadata.beginStruct()
+		metadata.i32(1, column.typ)
+		if column.optional {
+			metadata.i32(3, parquetOptional)
+		} else {
+			metadata.i32(3, parquetRequired)
+		}
+		metadata.string(4, column.name)
+		switch {
+		case column.typ == parquetByteArray:
+			metadata.i32(6, parquetUTF8)
+			metadata.structField(10)
+			metadata.emptyStruct(1)
+			metadata.endStruct()
+		case column.kind == intKind:
+			metadata.i32(6, parquetSignedInt64)
+			metadata.structField(10)
+			metadata.structField(10)
+			metadata.byte(1, 64)
+			metadata.bool(2, true)
+			metadata.endStruct()
+			metadata.endStruct()
+		case column.kind == timeKind:
+			metadata.structField(10)
+			metadata.structField(8)
+			metadata.bool(1, true)
+			metadata.structField(2)
+			metadata.emptyStruct(3)
+			metadata.endStruct()
+			metadata.endStruct()
+			metadata.endStruct()
+		}
+		metadata.endStruct()
+	}
+	metadata.i64(3, int64(rows))
+	metadata.list(4, thriftStruct, len(groups))
+	for _, group := range groups {
+		metadata.beginStruct()
+		metadata.list(1, thriftStruct, len(group.chunks))
+		total := int64(0)
+		for i, chunk := range group.chunks {
+			metadata.beginStruct()
+			metadata.i64(2, chunk.offset)
+			metadata.structField(3)
+			metadata.i32(1, columns[i].typ)
+			metadata.list(2, thriftI32, 2)
+			metadata.varint(parquetPlain)
+			metadata.varint(parquetRLE)
+			metadata.list(3, thriftBinary, 1)
+			metadata.uvarint(uint64(len(columns[i].name)))
+			metadata.buf.WriteString(columns[i].name)
+			metadata.i32(4, 0)
+			metadata.i64(5, int64(chunk.values))
+			metadata.i64(6, chunk.size)
+			metadata.i64(7, chunk.size)
+			metadata.i64(9, chunk.offset)
+			metadata.endStruct()
+			metadata.endStruct()
+			total += chunk.size
+		}
+		metadata.i64(2, total)
+		metadata.i64(3, int64(group.rows))
+		metadata.endStruct()
+	}
+	metadata.string(6, "github.com/quantfamily/lemonmarkets/export")
+	metadata.buf.WriteByte(0)
+
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(metadata.buf.Len()))
+	pw.write(metadata.buf.Bytes())
+	pw.write(length[:])
+	pw.write([]byte(parquetMagic))
+}
+
+func readParquetFooter(r io.ReaderAt, size int64) (thriftFields, error) {
+	if size < 12 {
+		return nil, errors.New("not a parquet file")
+	}
+	var head [4]byte
+	var tail [8]byte
+	if _, err := r.ReadAt(head[:], 0); err != nil {
+		return nil, err
+	}
+	if _, err := r.ReadAt(tail[:], size-8); err != nil {
+		return nil, err
+	}
+	if string(head[:]) != parquetMagic || string(tail[4:]) != parquetMagic {
+		return nil, errors.New("not a parquet file")
+	}
+	length := int64(binary.LittleEndian.Uint32(tail[:4]))
+	if length > size-12 {
+		return nil, errors.New("parquet footer is too long")
+	}
+	footer := make([]byte, length)
+	if _, err := r.ReadAt(footer, size-8-length); err != nil {
+		return nil, err
+	}
+	reader := compactReader{data: footer}
+	metadata, err := reader.readStruct()
+	if err != nil {
+		return nil, fmt.Errorf("parquet footer: %w", err)
+	}
+	return metadata, nil
+}
+
+// parquetField is a column as described by the schema of a file
+type parquetField struct {
+	name     string
+	typ      int64
+	optional bool
+	// unit of timestamps, zero for other columns
+	unit time.Duration
+}
+
+func newParquetField(element thriftFields) parquetField {
+	field := parquetField{name: element.string(4)}
+	field.typ, _ = element.int(1)
+	repetition, _ := element.int(3)
+	field.optional = repetition == parquetOptional
+	switch converted, _ := element.int(6); converted {
+	case parquetTimestampMillis:
+		field.unit = time.Millisecond
+	case parquetTimestampMicros:
+		field.unit = time.Microsecond
+	}
+	if timestamp := element.child(10).child(8); timestamp != nil {
+		unit := timestamp.child(2)
+		switch {
+		case unit.child(1) != nil:
+			field.unit = time.Millisecond
+		case unit.child(2) != nil:
+			field.unit = time.Microsecond
+		case unit.child(3) != nil:
+			field.unit = time.Nanosecond
+		}
+	}
+	return field
+}
+
+// read returns the values of a column chunk as text that can be parsed by the columns of a schema
+func (f parquetField) read(r io.ReaderAt, size int64, metadata thriftFields, rows int) ([]string, error) {
+	if codec, _ := metadata.int(4); codec != 0 {
+		return nil, fmt.Errorf("compression codec %d is not supported", codec)
+	}
+	offset, _ := metadata.int(9)
+	if dictionary, ok := metadata.int(11); ok && dictionary > 0 && dictionary < offset {
+		offset = dictionary
+	}
+	length, _ := metadata.int(7)
+	if offset < 0 || length < 0 || offset+length > size {
+		return nil, errors.New("column chunk is outside of the file")
+	}
+	data := make([]byte, length)
+	if _, err := r.ReadAt(data, offset); err != nil {
+		return nil, err
+	}
+
+	texts := make([]string, 0, rows)
+	reader := compactReader{data: data}
+	for len(texts) < rows {
+		header, err := reader.readStruct()
+		if err != nil {
+			return nil, fmt.Errorf("page header: %w", err)
+		}
+		pageSize, _ := header.int(3)
+		if pageSize < 0 || pageSize > int64(len(data)-reader.pos) {
+			return nil, errors.New("page is outside of the column chunk")
+		}
+		page := data[reader.pos : reader.pos+int(pageSize)]
+		reader.pos += int(pageSize)
+		if typ, _ := header.int(1); typ != parquetDataPage {
+			return nil, fmt.Errorf("page type %d is not supported", typ)
+		}
+		dataPage := header.child(5)
+		values, _ := dataPage.int(1)
+		if encoding, _ := dataPage.int(2); encoding != parquetPlain {
+			return nil, fmt.Errorf("encoding %d is not supported", encoding)
+		}
+		if values <= 0 || values > int64(rows-len(texts)) {
+			return nil, errors.New("page has more values than the row group")
+		}
+		if texts, err = f.page(texts, page, int(values)); err != nil {
+			return nil, err
+		}
+	}
+	return texts, nil
+}
+
+// page appends the values of a data page to texts, null values as empty text
+func (f parquetField) page(texts []string, page []byte, values int) ([]string, error) {
+	levels := make([]byte, values)
+	for i := range levels {
+		levels[i] = 1
+	}
+	if f.optional {
+		if len(page) < 4 {
+			return nil, errors.New("page is too short")
+		}
+		length := int(binary.LittleEndian.Uint32(page))
+		if length > len(page)-4 {
+			return nil, errors.New("page is too short")
+		}
+		if err := decodeLevels(levels, page[4:4+length]); err != nil {
+			return nil, err
+		}
+		page = page[4+length:]
+	}
+	width := map[int64]int{parquetInt32: 4, parquetInt64: 8, parquetFloat: 4, parquetDouble: 8}[f.typ]
+	if width == 0 && f.typ != parquetByteArray {
+		return nil, fmt.Errorf("type %d is not supported", f.typ)
+	}
+	for _, level := range levels {
+		if level == 0 {
+			texts = append(texts, "")
+			continue
+		}
+		if f.typ == parquetByteArray {
+			if len(page) < 4 || int(binary.LittleEndian.Uint32(page)) > len(page)-4 {
+				return nil, errors.New("page is too short")
+			}
+			length := int(binary.LittleEndian.Uint32(page))
+			texts = append(texts, string(page[4:4+length]))
+			page = page[4+length:]
+			continue
+		}
+		if len(page) < width {
+			return nil, errors.New("page is too short")
+		}
+		texts = append(texts, f.text(page[:width]))
+		page = page[width:]
+	}
+	return texts, nil
+}
+
+// text formats a fixed width value
+func (f parquetField) text(value []byte) string {
+	switch f.typ {
+	case parquetInt32:
+		return strconv.FormatInt(int64(int32(binary.LittleEndian.Uint32(value))), 10)
+	case parquetFloat:
+		return strconv.FormatFloat(float64(math.Float32frombits(binary.LittleEndian.Uint32(value))), 'g', -1, 32)
+	case parquetDouble:
+		return strconv.FormatFloat(math.Float64frombits(binary.LittleEndian.Uint64(value)), 'g', -1, 64)
+	}
+	v := int64(binary.LittleEndian.Uint64(value))
+	if f.unit != 0 {
+		return time.Unix(0, v*int64(f.unit)).UTC().Format(time.RFC3339Nano)
+	}
+	return strconv.FormatInt(v, 10)
+}
+
+// decodeLevels decodes definition levels of bit width 1, encoded as runs of RLE and bit packed values
+func decodeLevels(levels []byte, data []byte) error {
+	for i := 0; i < len(levels); {
+		header, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errors.New("invalid definition levels")
+		}
+		data = data[n:]
+		if header&1 == 0 {
+			if len(data) < 1 {
+				return errors.New("invalid definition levels")
+			}
+			for run := header >> 1; run > 0 && i < len(levels); run-- {
+				levels[i] = data[0]
+				i++
+			}
+			data = data[1:]
+			continue
+		}
+		groups := int(header >> 1)
+		if groups > len(data) {
+			return errors.New("invalid definition levels")
+		}
+		for _, packed := range data[:groups] {
+			for bit := 0; bit < 8 && i < len(levels); bit++ {
+				levels[i] = packed >> bit & 1
+				i++
+			}
+		}
+		data = data[groups:]
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/client/helpers"
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/quantfamily/lemonmarkets/trading"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteParquet(t *testing.T) {
+	t.Run("Successful test", func(t *testing.T) {
+		var buffer bytes.Buffer
+		n, err := WriteParquet(&buffer, OHLCSchema, FromSlice(bars))
+		assert.Nil(t, err)
+		assert.Equal(t, 2, n)
+		data := buffer.Bytes()
+		assert.Equal(t, "PAR1", string(data[:4]))
+		assert.Equal(t, "PAR1", string(data[len(data)-4:]))
+	})
+	t.Run("fail to get response", func(t *testing.T) {
+		ch := make(chan market_data.Item[market_data.OHLC, error], 2)
+		ch <- market_data.Item[market_data.OHLC, error]{Data: bars[0]}
+		ch <- market_data.Item[market_data.OHLC, error]{Error: errors.New("not found")}
+		close(ch)
+		var buffer bytes.Buffer
+		n, err := WriteParquet(&buffer, OHLCSchema, FromMarketData(ch))
+		assert.EqualError(t, err, "not found")
+		assert.Equal(t, 1, n)
+		assert.NotNil(t, ReadParquet(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()), OHLCSchema, func(market_data.OHLC) {}))
+	})
+	t.Run("no columns", func(t *testing.T) {
+		_, err := WriteParquet(&bytes.Buffer{}, Schema[market_data.OHLC]{}, FromSlice(bars))
+		assert.EqualError(t, err, "schema has no columns")
+	})
+}
+
+func TestParquetRoundTrip(t *testing.T) {
+	roundTrip := func(t *testing.T, write func(*bytes.Buffer) error) *bytes.Reader {
+		var buffer bytes.Buffer
+		assert.Nil(t, write(&buffer))
+		return bytes.NewReader(buffer.Bytes())
+	}
+	t.Run("ohlc", func(t *testing.T) {
+		file := roundTrip(t, func(buffer *bytes.Buffer) error {
+			_, err := WriteParquet(buffer, OHLCSchema, FromSlice(bars))
+			return err
+		})
+		var read []market_data.OHLC
+		for item := range MarketDataParquet(file, file.Size(), OHLCSchema) {
+			assert.Nil(t, item.Error)
+			read = append(read, item.Data)
+		}
+		assert.Equal(t, bars, read)
+	})
+	t.Run("trades", func(t *testing.T) {
+		trades := []market_data.Trade{{ISIN: "US88160R1014", Mic: "XMUN", Time: start, Price: 611.3, Volume: 3}}
+		file := roundTrip(t, func(buffer *bytes.Buffer) error {
+			_, err := WriteParquet(buffer, TradeSchema, FromSlice(trades))
+			return err
+		})
+		item := <-MarketDataParquet(file, file.Size(), TradeSchema)
+		assert.Equal(t, trades[0], item.Data)
+	})
+	t.Run("orders", func(t *testing.T) {
+		orders := []trading.Order{{
+			ID: "ord_1", ISIN: "US88160R1014", ISINTitle: "TESLA, INC.", CreatedAt: start, Side: trading.Buy, Quantity: 2,
+			LimitPrice: 6000000, Status: trading.OrderExecuted, ExecutedAt: start.Add(time.Second), Charge: 10000, Notes: "line\nbreak",
+			RegulatoryInformation: &trading.RegulatoryInformation{CostsEntry: 1.5, CostsEntryPct: "0.1%"},
+		}, {ID: "ord_2", Quantity: 1}}
+		file := roundTrip(t, func(buffer *bytes.Buffer) error {
+			_, err := WriteParquet(buffer, OrderSchema, FromSlice(orders))
+			return err
+		})
+		var read []trading.Order
+		for item := range TradingParquet(file, file.Size(), OrderSchema) {
+			assert.Nil(t, item.Error)
+			read = append(read, item.Data)
+		}
+		assert.Equal(t, orders, read, "zero times are null")
+	})
+	t.Run("bank statements", func(t *testing.T) {
+		statements := []trading.BankStatement{{ID: "bst_1", Type: "pay_in", Date: trading.Date{Time: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)}, Amount: -100, CreatedAt: start}}
+		file := roundTrip(t, func(buffer *bytes.Buffer) error {
+			_, err := WriteParquet(buffer, BankStatementSchema, FromSlice(statements))
+			return err
+		})
+		item := <-TradingParquet(file, file.Size(), BankStatementSchema)
+		assert.Equal(t, statements[0], item.Data)
+	})
+	t.Run("row groups", func(t *testing.T) {
+		quotes := make([]market_data.Quote, parquetRowGroup+10)
+		for i := range quotes {
+			quotes[i] = market_data.Quote{ISIN: "A", Time: start.Add(time.Duration(i) * time.Second), Bid: float64(i), BidVolume: i}
+		}
+		file := roundTrip(t, func(buffer *bytes.Buffer) error {
+			_, err := WriteParquet(buffer, QuoteSchema, FromSlice(quotes))
+			return err
+		})
+		var read []market_data.Quote
+		assert.Nil(t, ReadParquet(file, file.Size(), QuoteSchema, func(quote market_data.Quote) { read = append(read, quote) }))
+		assert.Equal(t, quotes, read)
+	})
+	t.Run("selected columns", func(t *testing.T) {
+		schema, _ := OHLCSchema.Select("close", "isin")
+		file := roundTrip(t, func(buffer *bytes.Buffer) error {
+			_, err := WriteParquet(buffer, schema, FromSlice(bars))
+			return err
+		})
+		var read []market_data.OHLC
+		assert.Nil(t, ReadParquet(file, file.Size(), OHLCSchema, func(bar market_data.OHLC) { read = append(read, bar) }))
+		assert.Equal(t, []market_data.OHLC{{ISIN: "US88160R1014", Close: 0.2}, {ISIN: "US88160R1014", Close: 611}}, read)
+	})
+	t.Run("empty", func(t *testing.T) {
+		file := roundTrip(t, func(buffer *bytes.Buffer) error {
+			_, err := WriteParquet(buffer, OHLCSchema, FromSlice([]market_data.OHLC{}))
+			return err
+		})
+		item, ok := <-MarketDataParquet(file, file.Size(), OHLCSchema)
+		assert.False(t, ok, item.Error)
+	})
+}
+
+func TestReadParquet(t *testing.T) {
+	t.Run("not a parquet file", func(t *testing.T) {
+		file := bytes.NewReader([]byte("isin,mic,time\nUS88160R1014,XMUN,\n"))
+		err := ReadParquet(file, file.Size(), OHLCSchema, func(market_data.OHLC) {})
+		assert.EqualError(t, err, "not a parquet file")
+	})
+	t.Run("bit packed definition levels", func(t *testing.T) {
+		levels := make([]byte, 10)
+		assert.Nil(t, decodeLevels(levels, []byte{0x05, 0b10110101, 0b00000010}))
+		assert.Equal(t, []byte{1, 0, 1, 0, 1, 1, 0, 1, 0, 1}, levels)
+	})
+	t.Run("wrong column type", func(t *testing.T) {
+		schema, _ := OHLCSchema.Select("isin")
+		schema.Columns[0].Name = "volume"
+		var buffer bytes.Buffer
+		_, err := WriteParquet(&buffer, schema, FromSlice(bars))
+		assert.Nil(t, err)
+		file := bytes.NewReader(buffer.Bytes())
+		err = ReadParquet(file, file.Size(), OHLCSchema, func(market_data.OHLC) {})
+		assert.EqualError(t, err, `row 1, column volume: strconv.Atoi: parsing "US88160R1014": invalid syntax`)
+	})
+}
+
+/*
+ohlc_reference.parquet is written by github.com/parquet-go/parquet-go v0.32.0 with the columns of OHLCSchema,
+plain encoding, no compression and data page v1. The timestamp of the last row is null
+*/
+func TestParquetReference(t *testing.T) {
+	reference := helpers.ParseFile(t, "ohlc_reference.parquet")
+
+	t.Run("read file of reference writer", func(t *testing.T) {
+		file := bytes.NewReader(reference)
+		var read []market_data.OHLC
+		for item := range MarketDataParquet(file, file.Size(), OHLCSchema) {
+			assert.Nil(t, item.Error)
+			read = append(read, item.Data)
+		}
+		assert.Equal(t, []market_data.OHLC{
+			{ISIN: "US88160R1014", Mic: "XMUN", Time: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC), Open: 870.5, High: 880.25, Low: 860, Close: 875.75, Volume: 1200},
+			{ISIN: "US88160R1014", Mic: "XMUN", Time: time.Date(2022, 3, 2, 0, 0, 0, 0, time.UTC), Open: 875.75, High: 900, Low: 870.1, Close: 899.99, Volume: 3400},
+			{ISIN: "DE0008232125", Mic: "XMUN", Open: 12.5, High: 12.75, Low: 12.25, Close: 12.5},
+		}, read)
+	})
+	t.Run("schema matches reference writer", func(t *testing.T) {
+		var buffer bytes.Buffer
+		_, err := WriteParquet(&buffer, OHLCSchema, FromSlice(bars))
+		assert.Nil(t, err)
+		assert.Equal(t, parquetSchema(t, reference), parquetSchema(t, buffer.Bytes()))
+	})
+}
+
+// parquetSchema returns type, repetition, converted and logical type of every column by name
+func parquetSchema(t *testing.T, data []byte) map[string]thriftFields {
+	t.Helper()
+	metadata, err := readParquetFooter(bytes.NewReader(data), int64(len(data)))
+	assert.Nil(t, err)
+	columns := make(map[string]thriftFields)
+	for _, element := range metadata.list(2)[1:] {
+		element := element.(thriftFields)
+		column := thriftFields{}
+		for _, id := range []int16{1, 3, 6, 10} {
+			if value, ok := element[id]; ok {
+				column[id] = value
+			}
+		}
+		columns[element.string(4)] = column
+	}
+	return columns
+}
@@ -0,0 +1,115 @@
+package export
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/quantfamily/lemonmarkets/trading"
+)
+
+// OHLCSchema has all columns of market_data.OHLC
+var OHLCSchema = Schema[market_data.OHLC]{Columns: []Column[market_data.OHLC]{
+	stringColumn("isin", func(v market_data.OHLC) string { return v.ISIN }, func(v *market_data.OHLC, s string) { v.ISIN = s }),
+	stringColumn("mic", func(v market_data.OHLC) string { return v.Mic }, func(v *market_data.OHLC, s string) { v.Mic = s }),
+	timeColumn("time", func(v market_data.OHLC) time.Time { return v.Time }, func(v *market_data.OHLC, t time.Time) { v.Time = t }),
+	floatColumn("open", 64, func(v market_data.OHLC) float64 { return v.Open }, func(v *market_data.OHLC, f float64) { v.Open = f }),
+	floatColumn("high", 64, func(v market_data.OHLC) float64 { return v.High }, func(v *market_data.OHLC, f float64) { v.High = f }),
+	floatColumn("low", 64, func(v market_data.OHLC) float64 { return v.Low }, func(v *market_data.OHLC, f float64) { v.Low = f }),
+	floatColumn("close", 64, func(v market_data.OHLC) float64 { return v.Close }, func(v *market_data.OHLC, f float64) { v.Close = f }),
+	intColumn("volume", func(v market_data.OHLC) int { return v.Volume }, func(v *market_data.OHLC, i int) { v.Volume = i }),
+}}
+
+// QuoteSchema has all columns of market_data.Quote
+var QuoteSchema = Schema[market_data.Quote]{Columns: []Column[market_data.Quote]{
+	stringColumn("isin", func(v market_data.Quote) string { return v.ISIN }, func(v *market_data.Quote, s string) { v.ISIN = s }),
+	stringColumn("mic", func(v market_data.Quote) string { return v.Mic }, func(v *market_data.Quote, s string) { v.Mic = s }),
+	timeColumn("time", func(v market_data.Quote) time.Time { return v.Time }, func(v *market_data.Quote, t time.Time) { v.Time = t }),
+	floatColumn("bid", 64, func(v market_data.Quote) float64 { return v.Bid }, func(v *market_data.Quote, f float64) { v.Bid = f }),
+	floatColumn("ask", 64, func(v market_data.Quote) float64 { return v.Ask }, func(v *market_data.Quote, f float64) { v.Ask = f }),
+	intColumn("bid_volume", func(v market_data.Quote) int { return v.BidVolume }, func(v *market_data.Quote, i int) { v.BidVolume = i }),
+	intColumn("ask_volume", func(v market_data.Quote) int { return v.AskVolume }, func(v *market_data.Quote, i int) { v.AskVolume = i }),
+}}
+
+// TradeSchema has all columns of market_data.Trade
+var TradeSchema = Schema[market_data.Trade]{Columns: []Column[market_data.Trade]{
+	stringColumn("isin", func(v market_data.Trade) string { return v.ISIN }, func(v *market_data.Trade, s string) { v.ISIN = s }),
+	stringColumn("mic", func(v market_data.Trade) string { return v.Mic }, func(v *market_data.Trade, s string) { v.Mic = s }),
+	timeColumn("time", func(v market_data.Trade) time.Time { return v.Time }, func(v *market_data.Trade, t time.Time) { v.Time = t }),
+	floatColumn("price", 32, func(v market_data.Trade) float64 { return float64(v.Price) }, func(v *market_data.Trade, f float64) { v.Price = float32(f) }),
+	intColumn("volume", func(v market_data.Trade) int { return v.Volume }, func(v *market_data.Trade, i int) { v.Volume = i }),
+}}
+
+// OrderSchema has all columns of trading.Order, regulatory information is written as JSON
+var OrderSchema = Schema[trading.Order]{Columns: []Column[trading.Order]{
+	stringColumn("id", func(v trading.Order) string { return v.ID }, func(v *trading.Order, s string) { v.ID = s }),
+	stringColumn("isin", func(v trading.Order) string { return v.ISIN }, func(v *trading.Order, s string) { v.ISIN = s }),
+	stringColumn("isin_title", func(v trading.Order) string { return v.ISINTitle }, func(v *trading.Order, s string) { v.ISINTitle = s }),
+	timeColumn("created_at", func(v trading.Order) time.Time { return v.CreatedAt }, func(v *trading.Order, t time.Time) { v.CreatedAt = t }),
+	timeColumn("expires_at", func(v trading.Order) time.Time { return v.ExpiresAt }, func(v *trading.Order, t time.Time) { v.ExpiresAt = t }),
+	stringColumn("side", func(v trading.Order) string { return v.Side }, func(v *trading.Order, s string) { v.Side = s }),
+	stringColumn("type", func(v trading.Order) string { return v.Type }, func(v *trading.Order, s string) { v.Type = s }),
+	stringColumn("status", func(v trading.Order) string { return v.Status }, func(v *trading.Order, s string) { v.Status = s }),
+	stringColumn("venue", func(v trading.Order) string { return v.Venue }, func(v *trading.Order, s string) { v.Venue = s }),
+	intColumn("quantity", func(v trading.Order) int { return v.Quantity }, func(v *trading.Order, i int) { v.Quantity = i }),
+	intColumn("stop_price", func(v trading.Order) int { return v.StopPrice }, func(v *trading.Order, i int) { v.StopPrice = i }),
+	intColumn("limit_price", func(v trading.Order) int { return v.LimitPrice }, func(v *trading.Order, i int) { v.LimitPrice = i }),
+	intColumn("estimated_price", func(v trading.Order) int { return v.EstimatedPrice }, func(v *trading.Order, i int) { v.EstimatedPrice = i }),
+	intColumn("estimated_price_total", func(v trading.Order) int { return v.EstimatedPriceTotal }, func(v *trading.Order, i int) { v.EstimatedPriceTotal = i }),
+	intColumn("executed_quantity", func(v trading.Order) int { return v.ExecutedQuantity }, func(v *trading.Order, i int) { v.ExecutedQuantity = i }),
+	intColumn("executed_price", func(v trading.Order) int { return v.ExecutedPrice }, func(v *trading.Order, i int) { v.ExecutedPrice = i }),
+	intColumn("executed_price_total", func(v trading.Order) int { return v.ExecutedPriceTotal }, func(v *trading.Order, i int) { v.ExecutedPriceTotal = i }),
+	timeColumn("executed_at", func(v trading.Order) time.Time { return v.ExecutedAt }, func(v *trading.Order, t time.Time) { v.ExecutedAt = t }),
+	timeColumn("rejected_at", func(v trading.Order) time.Time { return v.RejectedAt }, func(v *trading.Order, t time.Time) { v.RejectedAt = t }),
+	floatColumn("charge", 64, func(v trading.Order) float64 { return v.Charge }, func(v *trading.Order, f float64) { v.Charge = f }),
+	timeColumn("chargeable_at", func(v trading.Order) time.Time { return v.ChargeableAt }, func(v *trading.Order, t time.Time) { v.ChargeableAt = t }),
+	stringColumn("notes", func(v trading.Order) string { return v.Notes }, func(v *trading.Order, s string) { v.Notes = s }),
+	stringColumn("idempotency", func(v trading.Order) string { return v.Idempotency }, func(v *trading.Order, s string) { v.Idempotency = s }),
+	stringColumn("key_creation_id", func(v trading.Order) string { return v.KeyCreationID }, func(v *trading.Order, s string) { v.KeyCreationID = s }),
+	stringColumn("key_activation_id", func(v trading.Order) string { return v.KeyActivationID }, func(v *trading.Order, s string) { v.KeyActivationID = s }),
+	{
+		Name: "regulatory_information",
+		format: func(v trading.Order, _ Options) string {
+			if v.RegulatoryInformation == nil {
+				return ""
+			}
+			raw, _ := json.Marshal(v.RegulatoryInformation)
+			return string(raw)
+		},
+		parse: func(v *trading.Order, text string, _ Options) error {
+			if text == "" {
+				return nil
+			}
+			v.RegulatoryInformation = &trading.RegulatoryInformation{}
+			return json.Unmarshal([]byte(text), v.RegulatoryInformation)
+		},
+	},
+}}
+
+// BankStatementSchema has all columns of trading.BankStatement, the date is always written as 2006-01-02
+var BankStatementSchema = Schema[trading.BankStatement]{Columns: []Column[trading.BankStatement]{
+	stringColumn("id", func(v trading.BankStatement) string { return v.ID }, func(v *trading.BankStatement, s string) { v.ID = s }),
+	stringColumn("account_id", func(v trading.BankStatement) string { return v.AcountID }, func(v *trading.BankStatement, s string) { v.AcountID = s }),
+	stringColumn("type", func(v trading.BankStatement) string { return v.Type }, func(v *trading.BankStatement, s string) { v.Type = s }),
+	{
+		Name: "date",
+		format: func(v trading.BankStatement, _ Options) string {
+			if v.Date.IsZero() {
+				return ""
+			}
+			return v.Date.Format(trading.DateLayout)
+		},
+		parse: func(v *trading.BankStatement, text string, _ Options) error {
+			if text == "" {
+				return nil
+			}
+			date, err := time.Parse(trading.DateLayout, text)
+			v.Date = trading.Date{Time: date}
+			return err
+		},
+	},
+	intColumn("amount", func(v trading.BankStatement) int { return v.Amount }, func(v *trading.BankStatement, i int) { v.Amount = i }),
+	stringColumn("isin", func(v trading.BankStatement) string { return v.ISIN }, func(v *trading.BankStatement, s string) { v.ISIN = s }),
+	stringColumn("isin_title", func(v trading.BankStatement) string { return v.ISINTitle }, func(v *trading.BankStatement, s string) { v.ISINTitle = s }),
+	timeColumn("created_at", func(v trading.BankStatement) time.Time { return v.CreatedAt }, func(v *trading.BankStatement, t time.Time) { v.CreatedAt = t }),
+}}
@@ -0,0 +1,291 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Thrift compact protocol, the encoding of Parquet metadata and page headers
+
+// compact types of fields and list elements
+const (
+	thriftTrue   byte = 1
+	thriftFalse  byte = 2
+	thriftByte   byte = 3
+	thriftI16    byte = 4
+	thriftI32    byte = 5
+	thriftI64    byte = 6
+	thriftDouble byte = 7
+	thriftBinary byte = 8
+	thriftList   byte = 9
+	thriftSet    byte = 10
+	thriftMap    byte = 11
+	thriftStruct byte = 12
+)
+
+// thriftDepth limits nesting, so that a broken file can not exhaust the stack
+const thriftDepth = 32
+
+var errThrift = errors.New("invalid thrift data")
+
+/*
+compactWriter writes structs field by field, beginStruct and endStruct have to be called around nested structs
+and the elements of struct lists
+*/
+type compactWriter struct {
+	buf    bytes.Buffer
+	last   int16
+	parent []int16
+}
+
+func (w *compactWriter) field(id int16, typ byte) {
+	if delta := id - w.last; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		w.buf.WriteByte(typ)
+		w.varint(int64(id))
+	}
+	w.last = id
+}
+
+func (w *compactWriter) varint(v int64) {
+	w.uvarint(uint64(v<<1) ^ uint64(v>>63))
+}
+
+func (w *compactWriter) uvarint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	w.buf.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+func (w *compactWriter) byte(id int16, v int8) {
+	w.field(id, thriftByte)
+	w.buf.WriteByte(byte(v))
+}
+
+func (w *compactWriter) i32(id int16, v int32) {
+	w.field(id, thriftI32)
+	w.varint(int64(v))
+}
+
+func (w *compactWriter) i64(id int16, v int64) {
+	w.field(id, thriftI64)
+	w.varint(v)
+}
+
+func (w *compactWriter) bool(id int16, v bool) {
+	if v {
+		w.field(id, thriftTrue)
+	} else {
+		w.field(id, thriftFalse)
+	}
+}
+
+func (w *compactWriter) string(id int16, v string) {
+	w.field(id, thriftBinary)
+	w.uvarint(uint64(len(v)))
+	w.buf.WriteString(v)
+}
+
+func (w *compactWriter) list(id int16, typ byte, size int) {
+	w.field(id, thriftList)
+	if size < 15 {
+		w.buf.WriteByte(byte(size)<<4 | typ)
+		return
+	}
+	w.buf.WriteByte(0xf0 | typ)
+	w.uvarint(uint64(size))
+}
+
+// structField starts a struct field, it is ended by endStruct
+func (w *compactWriter) structField(id int16) {
+	w.field(id, thriftStruct)
+	w.beginStruct()
+}
+
+func (w *compactWriter) beginStruct() {
+	w.parent = append(w.parent, w.last)
+	w.last = 0
+}
+
+func (w *compactWriter) endStruct() {
+	w.buf.WriteByte(0)
+	w.last = w.parent[len(w.parent)-1]
+	w.parent = w.parent[:len(w.parent)-1]
+}
+
+// emptyStruct writes a struct field without fields, as used for unions such as the unit of a timestamp
+func (w *compactWriter) emptyStruct(id int16) {
+	w.structField(id)
+	w.endStruct()
+}
+
+/*
+thriftFields is a decoded struct by field id. Values are int64 for integers, bool, float64, []byte for binaries,
+[]interface{} for lists and sets and thriftFields for structs, maps are skipped
+*/
+type thriftFields map[int16]interface{}
+
+func (f thriftFields) int(id int16) (int64, bool) {
+	v, ok := f[id].(int64)
+	return v, ok
+}
+
+func (f thriftFields) string(id int16) string {
+	v, _ := f[id].([]byte)
+	return string(v)
+}
+
+func (f thriftFields) child(id int16) thriftFields {
+	v, _ := f[id].(thriftFields)
+	return v
+}
+
+func (f thriftFields) list(id int16) []interface{} {
+	v, _ := f[id].([]interface{})
+	return v
+}
+
+// compactReader decodes structs from data, pos is where the next value starts
+type compactReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *compactReader) byte() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, errThrift
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *compactReader) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		return 0, errThrift
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *compactReader) varint() (int64, error) {
+	v, err := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1), err
+}
+
+func (r *compactReader) readStruct() (thriftFields, error) {
+	return r.structAt(0)
+}
+
+func (r *compactReader) structAt(depth int) (thriftFields, error) {
+	if depth > thriftDepth {
+		return nil, errThrift
+	}
+	fields := thriftFields{}
+	var id int16
+	for {
+		header, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if header == 0 {
+			return fields, nil
+		}
+		typ := header & 0x0f
+		if delta := int16(header >> 4); delta != 0 {
+			id += delta
+		} else {
+			v, err := r.varint()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+		switch typ {
+		case thriftTrue:
+			fields[id] = true
+		case thriftFalse:
+			fields[id] = false
+		default:
+			if fields[id], err = r.value(typ, depth); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (r *compactReader) value(typ byte, depth int) (interface{}, error) {
+	switch typ {
+	case thriftTrue, thriftFalse:
+		// booleans in lists take a byte each
+		b, err := r.byte()
+		return b == thriftTrue, err
+	case thriftByte:
+		b, err := r.byte()
+		return int64(int8(b)), err
+	case thriftI16, thriftI32, thriftI64:
+		return r.varint()
+	case thriftDouble:
+		if len(r.data)-r.pos < 8 {
+			return nil, errThrift
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(r.data[r.pos:]))
+		r.pos += 8
+		return v, nil
+	case thriftBinary:
+		size, err := r.uvarint()
+		if err != nil || size > uint64(len(r.data)-r.pos) {
+			return nil, errThrift
+		}
+		v := r.data[r.pos : r.pos+int(size)]
+		r.pos += int(size)
+		return v, nil
+	case thriftList, thriftSet:
+		header, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size := uint64(header >> 4)
+		if size == 15 {
+			if size, err = r.uvarint(); err != nil {
+				return nil, err
+			}
+		}
+		// every element takes at least a byte
+		if size > uint64(len(r.data)-r.pos) {
+			return nil, errThrift
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			if list[i], err = r.value(header&0x0f, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case thriftMap:
+		size, err := r.uvarint()
+		if err != nil || size == 0 {
+			return nil, err
+		}
+		types, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		for i := uint64(0); i < size; i++ {
+			if _, err := r.value(types>>4, depth+1); err != nil {
+				return nil, err
+			}
+			if _, err := r.value(types&0x0f, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case thriftStruct:
+		return r.structAt(depth + 1)
+	}
+	return nil, fmt.Errorf("%w: unknown type %d", errThrift, typ)
+}
@@ -0,0 +1,47 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompactProtocol(t *testing.T) {
+	t.Run("Successful test", func(t *testing.T) {
+		w := compactWriter{}
+		w.i32(1, -3)
+		w.string(2, "name")
+		w.structField(3)
+		w.bool(1, true)
+		w.i64(20, 1<<40)
+		w.byte(21, -8)
+		w.endStruct()
+		w.list(4, thriftI32, 16)
+		for i := 0; i < 16; i++ {
+			w.varint(int64(i))
+		}
+		w.buf.WriteByte(0)
+
+		r := compactReader{data: w.buf.Bytes()}
+		fields, err := r.readStruct()
+		assert.Nil(t, err)
+		assert.Equal(t, r.pos, w.buf.Len())
+		value, _ := fields.int(1)
+		assert.Equal(t, int64(-3), value)
+		assert.Equal(t, "name", fields.string(2))
+		assert.Equal(t, true, fields.child(3)[1])
+		value, _ = fields.child(3).int(20)
+		assert.Equal(t, int64(1<<40), value)
+		value, _ = fields.child(3).int(21)
+		assert.Equal(t, int64(-8), value)
+		assert.Len(t, fields.list(4), 16)
+		assert.Equal(t, int64(15), fields.list(4)[15])
+	})
+	t.Run("truncated", func(t *testing.T) {
+		w := compactWriter{}
+		w.string(1, "name")
+		r := compactReader{data: w.buf.Bytes()[:3]}
+		_, err := r.readStruct()
+		assert.Equal(t, errThrift, err)
+	})
+}
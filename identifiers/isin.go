@@ -0,0 +1,200 @@
+/*
+Package identifiers validates and normalizes the identifiers used by lemon.markets: ISINs, WKNs and MICs.
+
+The types are strings underneath, so they can be converted to and from the plain strings of the query and order
+structs, are encoded as plain strings by encoding/json and go-querystring and validate themselves when decoded:
+
+	isin, err := identifiers.ParseISIN(" us0378331005 ")
+	query := market_data.GetOHLCQuery{ISIN: []string{isin.String()}}
+*/
+package identifiers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidISIN is wrapped by all ISIN validation errors
+var ErrInvalidISIN = errors.New("invalid ISIN")
+
+/*
+ISIN is an International Securities Identification Number: a two letter country prefix, a nine character
+national number and a check digit
+*/
+type ISIN string
+
+// normalize removes spaces and dashes and converts to upper case
+func normalize(value string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "", "\t", "").Replace(value))
+}
+
+// ParseISIN normalizes value and validates format and check digit
+func ParseISIN(value string) (ISIN, error) {
+	isin := ISIN(normalize(value))
+	return isin, isin.Validate()
+}
+
+// MustParseISIN is ParseISIN for constants, it panics on invalid ISINs
+func MustParseISIN(value string) ISIN {
+	isin, err := ParseISIN(value)
+	if err != nil {
+		panic(err)
+	}
+	return isin
+}
+
+/*
+NewISIN builds an ISIN from a country prefix and a national number, which is padded with leading zeros
+to nine characters, and appends the check digit
+*/
+func NewISIN(country, nsin string) (ISIN, error) {
+	country, nsin = normalize(country), normalize(nsin)
+	if len(nsin) < 9 {
+		nsin = strings.Repeat("0", 9-len(nsin)) + nsin
+	}
+	body := country + nsin
+	if err := validateBody(body); err != nil {
+		return "", fmt.Errorf("%w %q: %v", ErrInvalidISIN, body, err)
+	}
+	digit := checkDigit(body)
+	return ISIN(body + string(rune('0'+digit))), nil
+}
+
+// Validate checks format and check digit of an already normalized ISIN
+func (i ISIN) Validate() error {
+	value := string(i)
+	if len(value) != 12 {
+		return fmt.Errorf("%w %q: must have 12 characters", ErrInvalidISIN, value)
+	}
+	if err := validateBody(value[:11]); err != nil {
+		return fmt.Errorf("%w %q: %v", ErrInvalidISIN, value, err)
+	}
+	last := value[11]
+	if last < '0' || last > '9' {
+		return fmt.Errorf("%w %q: check digit must be a digit", ErrInvalidISIN, value)
+	}
+	digit := checkDigit(value[:11])
+	if int(last-'0') != digit {
+		return fmt.Errorf("%w %q: check digit should be %d", ErrInvalidISIN, value, digit)
+	}
+	return nil
+}
+
+// Valid is true when Validate finds no error
+func (i ISIN) Valid() bool {
+	return i.Validate() == nil
+}
+
+// Country is the two letter prefix, the ISO 3166 country of the issuer or XS and EU for international securities
+func (i ISIN) Country() string {
+	if len(i) < 2 {
+		return ""
+	}
+	return string(i[:2])
+}
+
+// NSIN is the national securities identifying number between prefix and check digit
+func (i ISIN) NSIN() string {
+	if len(i) != 12 {
+		return ""
+	}
+	return string(i[2:11])
+}
+
+/*
+WKN of german ISINs following the DE000 + WKN scheme, ok is false for all other ISINs
+*/
+func (i ISIN) WKN() (wkn WKN, ok bool) {
+	if len(i) != 12 || !strings.HasPrefix(string(i), "DE000") {
+		return "", false
+	}
+	wkn = WKN(i[5:11])
+	return wkn, wkn.Valid()
+}
+
+func (i ISIN) String() string {
+	return string(i)
+}
+
+// MarshalText encodes the ISIN as is
+func (i ISIN) MarshalText() ([]byte, error) {
+	return []byte(i), nil
+}
+
+// UnmarshalText normalizes and validates, empty values are accepted
+func (i *ISIN) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*i = ""
+		return nil
+	}
+	isin, err := ParseISIN(string(text))
+	if err != nil {
+		return err
+	}
+	*i = isin
+	return nil
+}
+
+// validateBody checks the eleven characters before the check digit
+func validateBody(body string) error {
+	if len(body) != 11 {
+		return errors.New("must have a two letter prefix and nine characters")
+	}
+	for k := 0; k < 2; k++ {
+		if body[k] < 'A' || body[k] > 'Z' {
+			return errors.New("must start with a two letter country prefix")
+		}
+	}
+	for k := 2; k < 11; k++ {
+		if !isAlphanumeric(body[k]) {
+			return errors.New("national number must be letters and digits")
+		}
+	}
+	return nil
+}
+
+func isAlphanumeric(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z')
+}
+
+/*
+checkDigit computes the ISIN check digit of a validated body: letters are replaced by two digits (A = 10 to Z = 35)
+and the Luhn algorithm is applied, doubling every other digit starting from the right
+*/
+func checkDigit(body string) int {
+	digits := make([]int, 0, 2*len(body))
+	for k := 0; k < len(body); k++ {
+		c := body[k]
+		if c >= '0' && c <= '9' {
+			digits = append(digits, int(c-'0'))
+			continue
+		}
+		n := int(c-'A') + 10
+		digits = append(digits, n/10, n%10)
+	}
+	sum := 0
+	for k := range digits {
+		d := digits[len(digits)-1-k]
+		if k%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return (10 - sum%10) % 10
+}
+
+/*
+CheckISINs validates plain string ISINs such as those of the query structs, returning the first error
+*/
+func CheckISINs(values ...string) error {
+	for _, value := range values {
+		if err := ISIN(normalize(value)).Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
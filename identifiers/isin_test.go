@@ -0,0 +1,100 @@
+package identifiers
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-querystring/query"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseISIN(t *testing.T) {
+	t.Run("valid ISINs", func(t *testing.T) {
+		for _, value := range []string{"US0378331005", "DE0007164600", "DE000BASF111", "US88160R1014", "IE00B4L5Y983", "AU0000XVGZA3", "GB0002634946", "NL0000235190", "SE0000115446"} {
+			isin, err := ParseISIN(value)
+			assert.Nil(t, err, value)
+			assert.Equal(t, ISIN(value), isin)
+			assert.True(t, isin.Valid())
+		}
+	})
+	t.Run("normalization", func(t *testing.T) {
+		isin, err := ParseISIN(" us-0378331005 ")
+		assert.Nil(t, err)
+		assert.Equal(t, ISIN("US0378331005"), isin)
+	})
+	t.Run("invalid ISINs", func(t *testing.T) {
+		for value, reason := range map[string]string{
+			"US0378331006":  `invalid ISIN "US0378331006": check digit should be 5`,
+			"US037833100":   `invalid ISIN "US037833100": must have 12 characters`,
+			"1S0378331005":  `invalid ISIN "1S0378331005": must start with a two letter country prefix`,
+			"US03783310.5":  `invalid ISIN "US03783310.5": national number must be letters and digits`,
+			"US037833100X":  `invalid ISIN "US037833100X": check digit must be a digit`,
+			"":              `invalid ISIN "": must have 12 characters`,
+			"US0378331005X": `invalid ISIN "US0378331005X": must have 12 characters`,
+		} {
+			_, err := ParseISIN(value)
+			assert.True(t, errors.Is(err, ErrInvalidISIN), value)
+			assert.EqualError(t, err, reason)
+		}
+		assert.Panics(t, func() { MustParseISIN("US0378331006") })
+	})
+}
+
+func TestNewISIN(t *testing.T) {
+	isin, err := NewISIN("us", "037833100")
+	assert.Nil(t, err)
+	assert.Equal(t, ISIN("US0378331005"), isin)
+	isin, err = NewISIN("DE", "716460")
+	assert.Nil(t, err)
+	assert.Equal(t, ISIN("DE0007164600"), isin)
+	_, err = NewISIN("D", "037833100")
+	assert.True(t, errors.Is(err, ErrInvalidISIN))
+}
+
+func TestISINParts(t *testing.T) {
+	isin := MustParseISIN("DE000BASF111")
+	assert.Equal(t, "DE", isin.Country())
+	assert.Equal(t, "000BASF11", isin.NSIN())
+	wkn, ok := isin.WKN()
+	assert.True(t, ok)
+	assert.Equal(t, WKN("BASF11"), wkn)
+	_, ok = MustParseISIN("US0378331005").WKN()
+	assert.False(t, ok)
+	assert.Equal(t, "", ISIN("").Country())
+	assert.Equal(t, "", ISIN("US").NSIN())
+}
+
+func TestISINMarshalling(t *testing.T) {
+	type order struct {
+		ISIN ISIN `json:"isin,omitempty"`
+	}
+	t.Run("json", func(t *testing.T) {
+		raw, err := json.Marshal(order{ISIN: "US0378331005"})
+		assert.Nil(t, err)
+		assert.Equal(t, `{"isin":"US0378331005"}`, string(raw))
+
+		decoded := order{}
+		assert.Nil(t, json.Unmarshal([]byte(`{"isin": "us0378331005"}`), &decoded))
+		assert.Equal(t, ISIN("US0378331005"), decoded.ISIN)
+		assert.Nil(t, json.Unmarshal([]byte(`{"isin": ""}`), &decoded))
+		assert.Equal(t, ISIN(""), decoded.ISIN)
+		err = json.Unmarshal([]byte(`{"isin": "US0378331006"}`), &decoded)
+		assert.True(t, errors.Is(err, ErrInvalidISIN))
+	})
+	t.Run("url", func(t *testing.T) {
+		q := struct {
+			ISIN []ISIN `url:"isin,omitempty"`
+			MIC  MIC    `url:"mic,omitempty"`
+		}{ISIN: []ISIN{"US0378331005", "DE0007164600"}, MIC: "XMUN"}
+		values, err := query.Values(q)
+		assert.Nil(t, err)
+		assert.Equal(t, "isin=US0378331005&isin=DE0007164600&mic=XMUN", values.Encode())
+	})
+}
+
+func TestCheckISINs(t *testing.T) {
+	assert.Nil(t, CheckISINs())
+	assert.Nil(t, CheckISINs("US0378331005", "de0007164600"))
+	assert.True(t, errors.Is(CheckISINs("US0378331005", "US0378331006"), ErrInvalidISIN))
+}
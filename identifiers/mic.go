@@ -0,0 +1,128 @@
+package identifiers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidMIC is wrapped by all MIC validation errors
+var ErrInvalidMIC = errors.New("invalid MIC")
+
+/*
+MIC is an ISO 10383 Market Identifier Code of four letters and digits. Venues of lemon.markets that are not
+ISO venues, such as LMBPX, are accepted when they are in the registry
+*/
+type MIC string
+
+// registry of the venues supported by lemon.markets
+var registry = struct {
+	sync.RWMutex
+	names map[MIC]string
+}{names: map[MIC]string{
+	"XMUN":   "Börse München - Gettex",
+	"LMBPX":  "lemon.markets Best Price",
+	"ALLDAY": "lemon.markets all day",
+}}
+
+/*
+RegisterMIC adds a venue to the registry of supported venues, such as when lemon.markets adds a venue
+*/
+func RegisterMIC(mic MIC, name string) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.names[MIC(normalize(string(mic)))] = name
+}
+
+// SupportedMICs are the registered venues, sorted
+func SupportedMICs() []MIC {
+	registry.RLock()
+	defer registry.RUnlock()
+	mics := make([]MIC, 0, len(registry.names))
+	for mic := range registry.names {
+		mics = append(mics, mic)
+	}
+	sort.Slice(mics, func(i, j int) bool { return mics[i] < mics[j] })
+	return mics
+}
+
+/*
+ParseMIC normalizes value to upper case and validates it
+*/
+func ParseMIC(value string) (MIC, error) {
+	mic := MIC(normalize(value))
+	return mic, mic.Validate()
+}
+
+/*
+Validate accepts registered venues and four letters and digits. The comparison ignores case,
+since lemon.markets accepts venues in lower case as well
+*/
+func (m MIC) Validate() error {
+	value := strings.ToUpper(string(m))
+	if MIC(value).Supported() {
+		return nil
+	}
+	if len(value) != 4 {
+		return fmt.Errorf("%w %q: must have 4 characters", ErrInvalidMIC, string(m))
+	}
+	for k := 0; k < len(value); k++ {
+		if !isAlphanumeric(value[k]) {
+			return fmt.Errorf("%w %q: must be letters and digits", ErrInvalidMIC, string(m))
+		}
+	}
+	return nil
+}
+
+// Valid is true when Validate finds no error
+func (m MIC) Valid() bool {
+	return m.Validate() == nil
+}
+
+// Supported is true for venues in the registry
+func (m MIC) Supported() bool {
+	registry.RLock()
+	defer registry.RUnlock()
+	_, ok := registry.names[MIC(strings.ToUpper(string(m)))]
+	return ok
+}
+
+// Name of a registered venue, empty for all others
+func (m MIC) Name() string {
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.names[MIC(strings.ToUpper(string(m)))]
+}
+
+func (m MIC) String() string {
+	return string(m)
+}
+
+// MarshalText encodes the MIC as is
+func (m MIC) MarshalText() ([]byte, error) {
+	return []byte(m), nil
+}
+
+// UnmarshalText normalizes and validates, empty values are accepted
+func (m *MIC) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*m = ""
+		return nil
+	}
+	mic, err := ParseMIC(string(text))
+	if err != nil {
+		return err
+	}
+	*m = mic
+	return nil
+}
+
+// CheckMIC validates a plain string MIC such as those of the query structs, empty values are accepted
+func CheckMIC(value string) error {
+	if value == "" {
+		return nil
+	}
+	return MIC(value).Validate()
+}
@@ -0,0 +1,54 @@
+package identifiers
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMIC(t *testing.T) {
+	mic, err := ParseMIC("xmun")
+	assert.Nil(t, err)
+	assert.Equal(t, MIC("XMUN"), mic)
+	assert.True(t, mic.Supported())
+	assert.Equal(t, "Börse München - Gettex", mic.Name())
+
+	mic, err = ParseMIC("XETR")
+	assert.Nil(t, err)
+	assert.False(t, mic.Supported(), "valid but not a lemon.markets venue")
+	assert.Equal(t, "", mic.Name())
+
+	assert.True(t, MIC("LMBPX").Valid())
+	assert.True(t, MIC("allday").Valid())
+	for _, value := range []string{"XMU", "XMUNN", "XM.N", ""} {
+		_, err := ParseMIC(value)
+		assert.True(t, errors.Is(err, ErrInvalidMIC), value)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	assert.Equal(t, []MIC{"ALLDAY", "LMBPX", "XMUN"}, SupportedMICs())
+	assert.False(t, MIC("LMBPY").Valid())
+	RegisterMIC("lmbpy", "lemon.markets test venue")
+	defer func() {
+		registry.Lock()
+		delete(registry.names, "LMBPY")
+		registry.Unlock()
+	}()
+	assert.True(t, MIC("LMBPY").Valid())
+	assert.Equal(t, "lemon.markets test venue", MIC("lmbpy").Name())
+}
+
+func TestMICMarshalling(t *testing.T) {
+	order := struct {
+		Venue MIC `json:"venue,omitempty"`
+	}{}
+	assert.Nil(t, json.Unmarshal([]byte(`{"venue": "xmun"}`), &order))
+	assert.Equal(t, MIC("XMUN"), order.Venue)
+	assert.True(t, errors.Is(json.Unmarshal([]byte(`{"venue": "X.MUN"}`), &order), ErrInvalidMIC))
+	assert.Nil(t, CheckMIC(""))
+	assert.Nil(t, CheckMIC("xmun"))
+	assert.True(t, errors.Is(CheckMIC("MUNICH"), ErrInvalidMIC))
+}
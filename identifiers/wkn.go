@@ -0,0 +1,74 @@
+package identifiers
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidWKN is wrapped by all WKN validation errors
+var ErrInvalidWKN = errors.New("invalid WKN")
+
+/*
+WKN is a german Wertpapierkennnummer: six letters and digits without I and O. WKNs have no check digit
+*/
+type WKN string
+
+// ParseWKN normalizes value and validates the format
+func ParseWKN(value string) (WKN, error) {
+	wkn := WKN(normalize(value))
+	return wkn, wkn.Validate()
+}
+
+// Validate checks the format of an already normalized WKN
+func (w WKN) Validate() error {
+	value := string(w)
+	if len(value) != 6 {
+		return fmt.Errorf("%w %q: must have 6 characters", ErrInvalidWKN, value)
+	}
+	for k := 0; k < len(value); k++ {
+		c := value[k]
+		if !isAlphanumeric(c) || c == 'I' || c == 'O' {
+			return fmt.Errorf("%w %q: must be digits and letters other than I and O", ErrInvalidWKN, value)
+		}
+	}
+	return nil
+}
+
+// Valid is true when Validate finds no error
+func (w WKN) Valid() bool {
+	return w.Validate() == nil
+}
+
+/*
+ISIN following the DE000 + WKN scheme of newer german securities. Older securities have other ISINs,
+look them up in an instrument master instead
+*/
+func (w WKN) ISIN() (ISIN, error) {
+	if err := w.Validate(); err != nil {
+		return "", err
+	}
+	return NewISIN("DE", "000"+string(w))
+}
+
+func (w WKN) String() string {
+	return string(w)
+}
+
+// MarshalText encodes the WKN as is
+func (w WKN) MarshalText() ([]byte, error) {
+	return []byte(w), nil
+}
+
+// UnmarshalText normalizes and validates, empty values are accepted
+func (w *WKN) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*w = ""
+		return nil
+	}
+	wkn, err := ParseWKN(string(text))
+	if err != nil {
+		return err
+	}
+	*w = wkn
+	return nil
+}
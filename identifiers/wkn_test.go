@@ -0,0 +1,44 @@
+package identifiers
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWKN(t *testing.T) {
+	wkn, err := ParseWKN(" a1cx3t")
+	assert.Nil(t, err)
+	assert.Equal(t, WKN("A1CX3T"), wkn)
+	assert.True(t, WKN("865985").Valid())
+
+	for _, value := range []string{"86598", "8659855", "A1CO3T", "A1CI3T", "A1C.3T"} {
+		_, err := ParseWKN(value)
+		assert.True(t, errors.Is(err, ErrInvalidWKN), value)
+	}
+}
+
+func TestWKNToISIN(t *testing.T) {
+	isin, err := WKN("716460").ISIN()
+	assert.Nil(t, err)
+	assert.Equal(t, ISIN("DE0007164600"), isin)
+	isin, err = WKN("BASF11").ISIN()
+	assert.Nil(t, err)
+	assert.Equal(t, ISIN("DE000BASF111"), isin)
+	_, err = WKN("BASF1").ISIN()
+	assert.True(t, errors.Is(err, ErrInvalidWKN))
+}
+
+func TestWKNMarshalling(t *testing.T) {
+	instrument := struct {
+		WKN WKN `json:"wkn"`
+	}{}
+	assert.Nil(t, json.Unmarshal([]byte(`{"wkn": "a1cx3t"}`), &instrument))
+	assert.Equal(t, WKN("A1CX3T"), instrument.WKN)
+	assert.True(t, errors.Is(json.Unmarshal([]byte(`{"wkn": "A1CO3T"}`), &instrument), ErrInvalidWKN))
+	raw, err := json.Marshal(instrument)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"wkn":"A1CX3T"}`, string(raw))
+}
@@ -0,0 +1,190 @@
+/*
+Package indicators computes technical indicators from market_data.OHLC and market_data.Trade values.
+
+Every indicator is a small state machine with an Update method that takes the next value and returns the
+indicator value and whether enough values have been seen. The same indicators can be used on slices with Batch
+or as channel stages with Stage:
+
+	closes := indicators.Map(indicators.StreamOHLC(client.GetOHLCPerDay(&query)), indicators.Close)
+	for rsi := range indicators.Stage(closes, indicators.NewRSI(14)) {
+		fmt.Println(rsi.Time, rsi.Value, rsi.Error)
+	}
+
+A stream is expected to hold a single instrument sorted oldest first.
+*/
+package indicators
+
+import (
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+)
+
+/*
+Indicator is updated with one value at a time, ready is false until enough values have been seen
+*/
+type Indicator[In, Out any] interface {
+	Update(value In) (Out, bool)
+}
+
+/*
+Result is a value in a stream of indicator values, Error is set on the last result if the source failed
+*/
+type Result[T any] struct {
+	Time  time.Time
+	Value T
+	Error error
+}
+
+// Close returns the close price of a bar
+func Close(bar market_data.OHLC) float64 {
+	return bar.Close
+}
+
+// Typical returns the typical price of a bar, (high + low + close) / 3
+func Typical(bar market_data.OHLC) float64 {
+	return (bar.High + bar.Low + bar.Close) / 3
+}
+
+// TradePrice returns the price of a trade
+func TradePrice(trade market_data.Trade) float64 {
+	return float64(trade.Price)
+}
+
+// Prices returns price(bar) for every bar
+func Prices(bars []market_data.OHLC, price func(market_data.OHLC) float64) []float64 {
+	prices := make([]float64, len(bars))
+	for i, bar := range bars {
+		prices[i] = price(bar)
+	}
+	return prices
+}
+
+/*
+Batch updates the indicator with every value and returns the values once the indicator is ready.
+The first result belongs to values[len(values)-len(result)]
+*/
+func Batch[In, Out any](indicator Indicator[In, Out], values []In) []Out {
+	var result []Out
+	for _, value := range values {
+		if out, ready := indicator.Update(value); ready {
+			result = append(result, out)
+		}
+	}
+	return result
+}
+
+/*
+StreamOHLC turns bars from the market data client into a stream of results
+*/
+func StreamOHLC(in <-chan market_data.Item[market_data.OHLC, error]) <-chan Result[market_data.OHLC] {
+	out := make(chan Result[market_data.OHLC])
+	go func() {
+		defer close(out)
+		for item := range in {
+			if item.Error != nil {
+				out <- Result[market_data.OHLC]{Error: item.Error}
+				return
+			}
+			out <- Result[market_data.OHLC]{Time: item.Data.Time, Value: item.Data}
+		}
+	}()
+	return out
+}
+
+/*
+StreamTrades turns trades from the market data client into a stream of results
+*/
+func StreamTrades(in <-chan market_data.Item[market_data.Trade, error]) <-chan Result[market_data.Trade] {
+	out := make(chan Result[market_data.Trade])
+	go func() {
+		defer close(out)
+		for item := range in {
+			if item.Error != nil {
+				out <- Result[market_data.Trade]{Error: item.Error}
+				return
+			}
+			out <- Result[market_data.Trade]{Time: item.Data.Time, Value: item.Data}
+		}
+	}()
+	return out
+}
+
+/*
+Map applies f to every value in the stream, such as Close to get close prices from bars
+*/
+func Map[In, Out any](in <-chan Result[In], f func(In) Out) <-chan Result[Out] {
+	out := make(chan Result[Out])
+	go func() {
+		defer close(out)
+		for result := range in {
+			if result.Error != nil {
+				out <- Result[Out]{Time: result.Time, Error: result.Error}
+				return
+			}
+			out <- Result[Out]{Time: result.Time, Value: f(result.Value)}
+		}
+	}()
+	return out
+}
+
+/*
+Stage updates the indicator with every value in the stream and passes on values once the indicator is ready.
+Stages can be chained, such as an RSI of an EMA
+*/
+func Stage[In, Out any](in <-chan Result[In], indicator Indicator[In, Out]) <-chan Result[Out] {
+	out := make(chan Result[Out])
+	go func() {
+		defer close(out)
+		for result := range in {
+			if result.Error != nil {
+				out <- Result[Out]{Time: result.Time, Error: result.Error}
+				return
+			}
+			if value, ready := indicator.Update(result.Value); ready {
+				out <- Result[Out]{Time: result.Time, Value: value}
+			}
+		}
+	}()
+	return out
+}
+
+// window keeps the last size values and their sum
+type window struct {
+	values []float64
+	next   int
+	full   bool
+	sum    float64
+}
+
+func newWindow(size int) *window {
+	if size < 1 {
+		size = 1
+	}
+	return &window{values: make([]float64, size)}
+}
+
+// push adds a value, removing the oldest one when the window is full
+func (w *window) push(value float64) {
+	if w.full {
+		w.sum -= w.values[w.next]
+	}
+	w.values[w.next] = value
+	w.sum += value
+	w.next++
+	if w.next == len(w.values) {
+		w.next = 0
+		w.full = true
+	}
+}
+
+func (w *window) mean() float64 {
+	return w.sum / float64(len(w.values))
+}
+
+// each calls f for the values oldest first
+func (w *window) each(f func(i int, value float64)) {
+	for i := 0; i < len(w.values); i++ {
+		f(i, w.values[(w.next+i)%len(w.values)])
+	}
+}
@@ -0,0 +1,77 @@
+package indicators
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/stretchr/testify/assert"
+)
+
+func ohlcChannel(bars []market_data.OHLC, err error) <-chan market_data.Item[market_data.OHLC, error] {
+	ch := make(chan market_data.Item[market_data.OHLC, error], len(bars)+1)
+	for _, bar := range bars {
+		ch <- market_data.Item[market_data.OHLC, error]{Data: bar}
+	}
+	if err != nil {
+		ch <- market_data.Item[market_data.OHLC, error]{Error: err}
+	}
+	close(ch)
+	return ch
+}
+
+func TestStage(t *testing.T) {
+	start := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	var bars []market_data.OHLC
+	for i, price := range prices[:10] {
+		bars = append(bars, market_data.OHLC{Close: price, Time: start.AddDate(0, 0, i)})
+	}
+
+	t.Run("Successful test", func(t *testing.T) {
+		closes := Map(StreamOHLC(ohlcChannel(bars, nil)), Close)
+		var results []Result[float64]
+		for result := range Stage[float64, float64](closes, NewSMA(5)) {
+			results = append(results, result)
+		}
+		assert.Len(t, results, 6)
+		assert.InDelta(t, 44.104, results[0].Value, 1e-6)
+		assert.Equal(t, start.AddDate(0, 0, 4), results[0].Time)
+	})
+	t.Run("chained stages", func(t *testing.T) {
+		closes := Map(StreamOHLC(ohlcChannel(bars, nil)), Close)
+		smoothed := Stage[float64, float64](Stage[float64, float64](closes, NewSMA(2)), NewSMA(2))
+		var values []float64
+		for result := range smoothed {
+			values = append(values, result.Value)
+		}
+		assert.Equal(t, Batch[float64, float64](NewSMA(2), Batch[float64, float64](NewSMA(2), prices[:10])), values)
+	})
+	t.Run("fail to get response", func(t *testing.T) {
+		closes := Map(StreamOHLC(ohlcChannel(bars[:2], errors.New("not found"))), Close)
+		var results []Result[float64]
+		for result := range Stage[float64, float64](closes, NewSMA(5)) {
+			results = append(results, result)
+		}
+		assert.Len(t, results, 1)
+		assert.NotNil(t, results[0].Error)
+	})
+}
+
+func TestStreamTrades(t *testing.T) {
+	ch := make(chan market_data.Item[market_data.Trade, error], 2)
+	ch <- market_data.Item[market_data.Trade, error]{Data: market_data.Trade{Price: 10, Volume: 1}}
+	ch <- market_data.Item[market_data.Trade, error]{Data: market_data.Trade{Price: 20, Volume: 3}}
+	close(ch)
+	var values []float64
+	for result := range Stage(StreamTrades(ch), NewVWAP(nil).Trades()) {
+		values = append(values, result.Value)
+	}
+	assert.Equal(t, []float64{10, 17.5}, values)
+}
+
+func TestPrices(t *testing.T) {
+	bars := []market_data.OHLC{{High: 3, Low: 1, Close: 2}}
+	assert.Equal(t, []float64{2}, Prices(bars, Close))
+	assert.Equal(t, []float64{2}, Prices(bars, Typical))
+}
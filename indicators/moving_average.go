@@ -0,0 +1,68 @@
+package indicators
+
+// SMA is the simple moving average over a number of periods
+type SMA struct {
+	window *window
+}
+
+// NewSMA returns a simple moving average, ready after period values
+func NewSMA(period int) *SMA {
+	return &SMA{window: newWindow(period)}
+}
+
+// Update adds a value and returns the average of the last period values
+func (s *SMA) Update(value float64) (float64, bool) {
+	s.window.push(value)
+	return s.window.mean(), s.window.full
+}
+
+/*
+EMA is the exponential moving average with smoothing 2 / (period + 1), seeded with the simple average
+of the first period values
+*/
+type EMA struct {
+	period int
+	alpha  float64
+	count  int
+	value  float64
+}
+
+// NewEMA returns an exponential moving average, ready after period values
+func NewEMA(period int) *EMA {
+	if period < 1 {
+		period = 1
+	}
+	return &EMA{period: period, alpha: 2 / float64(period+1)}
+}
+
+// Update adds a value and returns the current average
+func (e *EMA) Update(value float64) (float64, bool) {
+	e.count++
+	if e.count <= e.period {
+		e.value += (value - e.value) / float64(e.count)
+		return e.value, e.count == e.period
+	}
+	e.value += e.alpha * (value - e.value)
+	return e.value, true
+}
+
+// WMA is the linearly weighted moving average, the latest value has weight period and the oldest weight 1
+type WMA struct {
+	window *window
+}
+
+// NewWMA returns a weighted moving average, ready after period values
+func NewWMA(period int) *WMA {
+	return &WMA{window: newWindow(period)}
+}
+
+// Update adds a value and returns the weighted average of the last period values
+func (w *WMA) Update(value float64) (float64, bool) {
+	w.window.push(value)
+	total, weights := 0.0, 0.0
+	w.window.each(func(i int, value float64) {
+		total += float64(i+1) * value
+		weights += float64(i + 1)
+	})
+	return total / weights, w.window.full
+}
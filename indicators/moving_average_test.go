@@ -0,0 +1,34 @@
+package indicators
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// prices is the RSI example used by StockCharts, also used as reference for the other indicators
+var prices = []float64{
+	44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08, 45.89, 46.03, 45.61, 46.28, 46.28, 46.00, 46.03,
+	46.41, 46.22, 45.64, 46.21, 46.25, 45.71, 46.45, 45.78, 45.35, 44.03, 44.18, 44.22, 44.57, 43.42, 42.66, 43.13,
+}
+
+func TestSMA(t *testing.T) {
+	t.Run("not ready before period", func(t *testing.T) {
+		_, ready := NewSMA(3).Update(1)
+		assert.False(t, ready)
+	})
+	t.Run("Successful test", func(t *testing.T) {
+		result := Batch[float64, float64](NewSMA(5), prices[:10])
+		assert.InDeltaSlice(t, []float64{44.104, 44.202, 44.404, 44.658, 45.104, 45.454}, result, 1e-6)
+	})
+}
+
+func TestEMA(t *testing.T) {
+	result := Batch[float64, float64](NewEMA(5), prices[:10])
+	assert.InDeltaSlice(t, []float64{44.104, 44.346, 44.597333, 44.871556, 45.19437, 45.48958}, result, 1e-6)
+}
+
+func TestWMA(t *testing.T) {
+	result := Batch[float64, float64](NewWMA(5), prices[:10])
+	assert.InDeltaSlice(t, []float64{44.070667, 44.312667, 44.612, 44.950667, 45.344667, 45.67}, result, 1e-6)
+}
@@ -0,0 +1,124 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+)
+
+/*
+RSI is the relative strength index using Wilder's smoothing, between 0 and 100
+*/
+type RSI struct {
+	period   int
+	count    int
+	previous float64
+	gain     float64
+	loss     float64
+}
+
+// NewRSI returns a relative strength index, ready after period + 1 values
+func NewRSI(period int) *RSI {
+	if period < 1 {
+		period = 1
+	}
+	return &RSI{period: period}
+}
+
+// Update adds a price and returns the current RSI
+func (r *RSI) Update(value float64) (float64, bool) {
+	r.count++
+	if r.count == 1 {
+		r.previous = value
+		return 0, false
+	}
+	change := value - r.previous
+	r.previous = value
+	gain, loss := math.Max(change, 0), math.Max(-change, 0)
+	if r.count <= r.period+1 {
+		r.gain += gain / float64(r.period)
+		r.loss += loss / float64(r.period)
+		if r.count <= r.period {
+			return 0, false
+		}
+	} else {
+		r.gain = (r.gain*float64(r.period-1) + gain) / float64(r.period)
+		r.loss = (r.loss*float64(r.period-1) + loss) / float64(r.period)
+	}
+	if r.loss == 0 {
+		if r.gain == 0 {
+			return 50, true
+		}
+		return 100, true
+	}
+	return 100 - 100/(1+r.gain/r.loss), true
+}
+
+// MACDValue is the MACD line, its signal line and the difference between them
+type MACDValue struct {
+	MACD      float64
+	Signal    float64
+	Histogram float64
+}
+
+/*
+MACD is the difference between a fast and a slow EMA together with an EMA of that difference as signal
+*/
+type MACD struct {
+	fast, slow, signal *EMA
+}
+
+// NewMACD returns a MACD, commonly used with 12, 26 and 9. It is ready after slow + signal - 1 values
+func NewMACD(fast, slow, signal int) *MACD {
+	return &MACD{fast: NewEMA(fast), slow: NewEMA(slow), signal: NewEMA(signal)}
+}
+
+// Update adds a price and returns the current MACD
+func (m *MACD) Update(value float64) (MACDValue, bool) {
+	fast, fastReady := m.fast.Update(value)
+	slow, slowReady := m.slow.Update(value)
+	if !fastReady || !slowReady {
+		return MACDValue{}, false
+	}
+	macd := fast - slow
+	signal, ready := m.signal.Update(macd)
+	return MACDValue{MACD: macd, Signal: signal, Histogram: macd - signal}, ready
+}
+
+// StochasticValue is %K and its moving average %D, both between 0 and 100
+type StochasticValue struct {
+	K float64
+	D float64
+}
+
+/*
+Stochastic is the close relative to the high and low of the last period bars.
+%K is 50 when high and low of the period are equal
+*/
+type Stochastic struct {
+	highs, lows *window
+	d           *SMA
+}
+
+// NewStochastic returns a stochastic oscillator, commonly used with 14 and 3. It is ready after period + smoothing - 1 bars
+func NewStochastic(period, smoothing int) *Stochastic {
+	return &Stochastic{highs: newWindow(period), lows: newWindow(period), d: NewSMA(smoothing)}
+}
+
+// Update adds a bar and returns the current %K and %D
+func (s *Stochastic) Update(bar market_data.OHLC) (StochasticValue, bool) {
+	s.highs.push(bar.High)
+	s.lows.push(bar.Low)
+	if !s.highs.full {
+		return StochasticValue{}, false
+	}
+	high, low := math.Inf(-1), math.Inf(1)
+	s.highs.each(func(_ int, value float64) { high = math.Max(high, value) })
+	s.lows.each(func(_ int, value float64) { low = math.Min(low, value) })
+	k := 50.0
+	if high > low {
+		k = 100 * (bar.Close - low) / (high - low)
+	}
+	d, ready := s.d.Update(k)
+	return StochasticValue{K: k, D: d}, ready
+}
@@ -0,0 +1,52 @@
+package indicators
+
+import (
+	"testing"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRSI(t *testing.T) {
+	t.Run("Successful test", func(t *testing.T) {
+		result := Batch[float64, float64](NewRSI(14), prices)
+		expected := []float64{70.46, 66.25, 66.48, 69.35, 66.29, 57.92, 62.88, 63.21, 56.01, 62.34, 54.67, 50.39, 40.02, 41.49, 41.9, 45.5, 37.32, 33.09, 37.79}
+		assert.InDeltaSlice(t, expected, result, 0.01)
+	})
+	t.Run("only gains", func(t *testing.T) {
+		result := Batch[float64, float64](NewRSI(2), []float64{1, 2, 3, 4})
+		assert.Equal(t, []float64{100, 100}, result)
+	})
+}
+
+func TestMACD(t *testing.T) {
+	result := Batch[float64, MACDValue](NewMACD(3, 6, 2), prices)
+	assert.Len(t, result, len(prices)-6)
+	expected := [][2]float64{{0.311458, 0.279688}, {0.358229, 0.332049}, {0.413757, 0.386521}}
+	for i, value := range expected {
+		assert.InDelta(t, value[0], result[i].MACD, 1e-6)
+		assert.InDelta(t, value[1], result[i].Signal, 1e-6)
+		assert.InDelta(t, value[0]-value[1], result[i].Histogram, 1e-6)
+	}
+}
+
+func TestStochastic(t *testing.T) {
+	bars := []market_data.OHLC{
+		{High: 10, Low: 8, Close: 9},
+		{High: 11, Low: 9, Close: 10},
+		{High: 12, Low: 10, Close: 12},
+		{High: 12, Low: 10, Close: 10},
+	}
+	t.Run("Successful test", func(t *testing.T) {
+		result := Batch[market_data.OHLC, StochasticValue](NewStochastic(3, 2), bars)
+		// %K is 100 * (12 - 8) / (12 - 8) and then 100 * (10 - 9) / (12 - 9)
+		assert.Len(t, result, 1)
+		assert.InDelta(t, 100.0/3, result[0].K, 1e-9)
+		assert.InDelta(t, (100+100.0/3)/2, result[0].D, 1e-9)
+	})
+	t.Run("flat range", func(t *testing.T) {
+		value, ready := NewStochastic(1, 1).Update(market_data.OHLC{High: 10, Low: 10, Close: 10})
+		assert.True(t, ready)
+		assert.Equal(t, 50.0, value.K)
+	})
+}
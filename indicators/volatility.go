@@ -0,0 +1,109 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+)
+
+// BollingerValue is the middle band and the bands above and below it
+type BollingerValue struct {
+	Middle float64
+	Upper  float64
+	Lower  float64
+}
+
+/*
+Bollinger bands are a simple moving average plus and minus a multiple of the population standard deviation
+*/
+type Bollinger struct {
+	window *window
+	width  float64
+}
+
+// NewBollinger returns Bollinger bands, commonly used with 20 and 2. They are ready after period values
+func NewBollinger(period int, width float64) *Bollinger {
+	return &Bollinger{window: newWindow(period), width: width}
+}
+
+// Update adds a price and returns the current bands
+func (b *Bollinger) Update(value float64) (BollingerValue, bool) {
+	b.window.push(value)
+	mean := b.window.mean()
+	squares := 0.0
+	b.window.each(func(_ int, value float64) { squares += (value - mean) * (value - mean) })
+	deviation := math.Sqrt(squares / float64(len(b.window.values)))
+	return BollingerValue{Middle: mean, Upper: mean + b.width*deviation, Lower: mean - b.width*deviation}, b.window.full
+}
+
+/*
+ATR is the average true range using Wilder's smoothing, the first bar uses high - low as true range
+*/
+type ATR struct {
+	period   int
+	count    int
+	previous float64
+	value    float64
+}
+
+// NewATR returns an average true range, ready after period bars
+func NewATR(period int) *ATR {
+	if period < 1 {
+		period = 1
+	}
+	return &ATR{period: period}
+}
+
+// Update adds a bar and returns the current ATR
+func (a *ATR) Update(bar market_data.OHLC) (float64, bool) {
+	trueRange := bar.High - bar.Low
+	if a.count > 0 {
+		trueRange = math.Max(trueRange, math.Max(math.Abs(bar.High-a.previous), math.Abs(bar.Low-a.previous)))
+	}
+	a.previous = bar.Close
+	a.count++
+	if a.count <= a.period {
+		a.value += trueRange / float64(a.period)
+		return a.value, a.count == a.period
+	}
+	a.value = (a.value*float64(a.period-1) + trueRange) / float64(a.period)
+	return a.value, true
+}
+
+/*
+Volatility is the sample standard deviation of log returns over a number of periods,
+annualized with the square root of periodsPerYear unless that is 0
+*/
+type Volatility struct {
+	returns  *window
+	scale    float64
+	previous float64
+	count    int
+}
+
+// NewVolatility returns rolling volatility, such as NewVolatility(20, 252) for daily closes. It is ready after period + 1 prices
+func NewVolatility(period int, periodsPerYear float64) *Volatility {
+	scale := 1.0
+	if periodsPerYear > 0 {
+		scale = math.Sqrt(periodsPerYear)
+	}
+	return &Volatility{returns: newWindow(period), scale: scale}
+}
+
+// Update adds a price and returns the current volatility
+func (v *Volatility) Update(value float64) (float64, bool) {
+	v.count++
+	previous := v.previous
+	v.previous = value
+	if v.count == 1 || previous <= 0 || value <= 0 {
+		return 0, false
+	}
+	v.returns.push(math.Log(value / previous))
+	if !v.returns.full || len(v.returns.values) < 2 {
+		return 0, false
+	}
+	mean := v.returns.mean()
+	squares := 0.0
+	v.returns.each(func(_ int, value float64) { squares += (value - mean) * (value - mean) })
+	return math.Sqrt(squares/float64(len(v.returns.values)-1)) * v.scale, true
+}
@@ -0,0 +1,41 @@
+package indicators
+
+import (
+	"testing"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBollinger(t *testing.T) {
+	result := Batch[float64, BollingerValue](NewBollinger(5, 2), prices[:6])
+	assert.Len(t, result, 2)
+	assert.InDelta(t, 44.104, result[0].Middle, 1e-6)
+	assert.InDelta(t, 44.635504, result[0].Upper, 1e-6)
+	assert.InDelta(t, 43.572496, result[0].Lower, 1e-6)
+	assert.InDelta(t, 44.990152, result[1].Upper, 1e-6)
+}
+
+func TestATR(t *testing.T) {
+	bars := []market_data.OHLC{
+		{High: 10, Low: 8, Close: 9},
+		{High: 12, Low: 10, Close: 11},
+		{High: 11, Low: 10, Close: 10.5},
+		{High: 10, Low: 7, Close: 8},
+	}
+	// true ranges are 2, 3 (12 - 9), 1 and 3.5 (10.5 - 7)
+	result := Batch[market_data.OHLC, float64](NewATR(3), bars)
+	assert.InDeltaSlice(t, []float64{2, (2*2 + 3.5) / 3}, result, 1e-9)
+}
+
+func TestVolatility(t *testing.T) {
+	t.Run("Successful test", func(t *testing.T) {
+		result := Batch[float64, float64](NewVolatility(5, 252), prices[:10])
+		assert.InDeltaSlice(t, []float64{0.186998, 0.174023, 0.172182, 0.065113, 0.038906}, result, 1e-6)
+	})
+	t.Run("not annualized", func(t *testing.T) {
+		result := Batch[float64, float64](NewVolatility(2, 0), []float64{100, 110, 99})
+		assert.Len(t, result, 1)
+		assert.Greater(t, result[0], 0.0)
+	})
+}
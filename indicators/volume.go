@@ -0,0 +1,93 @@
+package indicators
+
+import (
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+)
+
+/*
+OBV is on-balance volume, the running total of volume added on up closes and subtracted on down closes
+*/
+type OBV struct {
+	count    int
+	previous float64
+	value    float64
+}
+
+// NewOBV returns on-balance volume starting at 0, it is ready from the first bar
+func NewOBV() *OBV {
+	return &OBV{}
+}
+
+// Update adds a bar and returns the current OBV
+func (o *OBV) Update(bar market_data.OHLC) (float64, bool) {
+	if o.count > 0 {
+		switch {
+		case bar.Close > o.previous:
+			o.value += float64(bar.Volume)
+		case bar.Close < o.previous:
+			o.value -= float64(bar.Volume)
+		}
+	}
+	o.count++
+	o.previous = bar.Close
+	return o.value, true
+}
+
+/*
+VWAP is the volume weighted average price. Bars are weighted at their typical price.
+With a Location set the average restarts on every new day in that location, such as Europe/Berlin
+for a session VWAP, otherwise it runs over everything it is given
+*/
+type VWAP struct {
+	Location *time.Location
+	day      time.Time
+	value    float64
+	volume   float64
+}
+
+// NewVWAP returns a VWAP that restarts every day in location, nil never restarts
+func NewVWAP(location *time.Location) *VWAP {
+	return &VWAP{Location: location}
+}
+
+// Update adds a bar and returns the current VWAP, it is not ready until some volume has been seen
+func (v *VWAP) Update(bar market_data.OHLC) (float64, bool) {
+	return v.add(bar.Time, Typical(bar), float64(bar.Volume))
+}
+
+// UpdateTrade adds a trade and returns the current VWAP
+func (v *VWAP) UpdateTrade(trade market_data.Trade) (float64, bool) {
+	return v.add(trade.Time, TradePrice(trade), float64(trade.Volume))
+}
+
+// Trades returns the VWAP as an indicator of trades, for use with Stage and Batch
+func (v *VWAP) Trades() Indicator[market_data.Trade, float64] {
+	return tradeVWAP{v}
+}
+
+type tradeVWAP struct {
+	*VWAP
+}
+
+func (t tradeVWAP) Update(trade market_data.Trade) (float64, bool) {
+	return t.UpdateTrade(trade)
+}
+
+func (v *VWAP) add(t time.Time, price, volume float64) (float64, bool) {
+	if v.Location != nil {
+		local := t.In(v.Location)
+		day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, v.Location)
+		if !day.Equal(v.day) {
+			v.day = day
+			v.value, v.volume = 0, 0
+		}
+	}
+	v.value += price * volume
+	v.volume += volume
+	if v.volume == 0 {
+		return 0, false
+	}
+	return v.value / v.volume, true
+}
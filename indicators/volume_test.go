@@ -0,0 +1,43 @@
+package indicators
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOBV(t *testing.T) {
+	bars := []market_data.OHLC{{Close: 10, Volume: 100}, {Close: 11, Volume: 50}, {Close: 11, Volume: 70}, {Close: 9, Volume: 20}}
+	result := Batch[market_data.OHLC, float64](NewOBV(), bars)
+	assert.Equal(t, []float64{0, 50, 50, 30}, result)
+}
+
+func TestVWAP(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.Nil(t, err)
+	day := time.Date(2022, 3, 1, 9, 0, 0, 0, berlin)
+
+	t.Run("bars use typical price", func(t *testing.T) {
+		bars := []market_data.OHLC{
+			{High: 12, Low: 9, Close: 9, Volume: 100, Time: day},
+			{High: 13, Low: 11, Close: 12, Volume: 300, Time: day.Add(time.Hour)},
+		}
+		result := Batch[market_data.OHLC, float64](NewVWAP(nil), bars)
+		assert.InDeltaSlice(t, []float64{10, (10*100 + 12*300) / 400.0}, result, 1e-9)
+	})
+	t.Run("restarts on a new day in the location", func(t *testing.T) {
+		trades := []market_data.Trade{
+			{Price: 10, Volume: 100, Time: day},
+			{Price: 20, Volume: 100, Time: day.Add(14 * time.Hour)},
+			{Price: 30, Volume: 100, Time: day.Add(15 * time.Hour)},
+		}
+		result := Batch(NewVWAP(berlin).Trades(), trades)
+		assert.Equal(t, []float64{10, 15, 30}, result)
+	})
+	t.Run("no volume", func(t *testing.T) {
+		_, ready := NewVWAP(nil).Update(market_data.OHLC{Close: 10})
+		assert.False(t, ready)
+	})
+}
@@ -0,0 +1,379 @@
+/*
+Package instruments keeps a local instrument master, so that instruments can be searched and WKNs or ticker symbols
+resolved to ISINs without a network round trip.
+
+Master syncs all instruments and venues from a market_data.MarketDataSource into a Store and answers
+GetInstruments and GetVenues from it, every other method is passed through to the source:
+
+	master, err := instruments.New(market_data.NewClient(key), instruments.NewFileStore("instruments.json"))
+	if master.Synced().IsZero() {
+		err = master.Sync()
+	}
+	apple, err := master.Resolve("865985")
+*/
+package instruments
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+)
+
+var (
+	// ErrNotFound is returned when no instrument matches an identifier
+	ErrNotFound = errors.New("instrument not found")
+	// ErrAmbiguous is returned when a symbol belongs to several instruments
+	ErrAmbiguous = errors.New("ambiguous instrument")
+)
+
+// entry is an instrument together with its normalized search words
+type entry struct {
+	instrument market_data.Instrument
+	words      []string
+}
+
+/*
+Master is the local instrument master. It is safe for concurrent use, lookups are served from memory
+*/
+type Master struct {
+	market_data.MarketDataSource
+	store Store
+
+	mu       sync.RWMutex
+	synced   time.Time
+	entries  []*entry
+	byISIN   map[string]*entry
+	byWKN    map[string]*entry
+	bySymbol map[string][]*entry
+	venues   map[string]market_data.Venue
+}
+
+var _ market_data.MarketDataSource = (*Master)(nil)
+
+/*
+New master on top of source, loading what the store has saved. Source may be nil for a master that is
+only used offline, then Sync and Refresh fail and only GetInstruments and GetVenues can be called
+*/
+func New(source market_data.MarketDataSource, store Store) (*Master, error) {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	m := &Master{MarketDataSource: source, store: store}
+	snapshot, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if snapshot == nil {
+		snapshot = &Snapshot{}
+	}
+	m.index(snapshot)
+	return m, nil
+}
+
+// index replaces everything in memory with the snapshot, the caller holds the lock
+func (m *Master) index(snapshot *Snapshot) {
+	m.synced = snapshot.Synced
+	m.entries = make([]*entry, 0, len(snapshot.Instruments))
+	m.byISIN = make(map[string]*entry, len(snapshot.Instruments))
+	m.byWKN = make(map[string]*entry, len(snapshot.Instruments))
+	m.bySymbol = make(map[string][]*entry)
+	m.venues = make(map[string]market_data.Venue, len(snapshot.Venues))
+	for _, instrument := range snapshot.Instruments {
+		e := &entry{instrument: instrument, words: words(instrument.Name + " " + instrument.Title)}
+		isin := strings.ToUpper(instrument.ISIN)
+		if previous, ok := m.byISIN[isin]; ok {
+			*previous = *e
+			continue
+		}
+		m.entries = append(m.entries, e)
+		m.byISIN[isin] = e
+	}
+	sort.Slice(m.entries, func(i, j int) bool { return m.entries[i].instrument.ISIN < m.entries[j].instrument.ISIN })
+	for _, e := range m.entries {
+		if e.instrument.WKN != "" {
+			m.byWKN[strings.ToUpper(e.instrument.WKN)] = e
+		}
+		if e.instrument.Symbol != "" {
+			symbol := strings.ToUpper(e.instrument.Symbol)
+			m.bySymbol[symbol] = append(m.bySymbol[symbol], e)
+		}
+	}
+	for _, venue := range snapshot.Venues {
+		m.venues[strings.ToUpper(venue.Mic)] = venue
+	}
+}
+
+// snapshot of what is in memory, the caller holds the lock
+func (m *Master) snapshot() *Snapshot {
+	snapshot := &Snapshot{Synced: m.synced, Instruments: make([]market_data.Instrument, len(m.entries))}
+	for i, e := range m.entries {
+		snapshot.Instruments[i] = e.instrument
+	}
+	snapshot.Venues = m.sortedVenues()
+	return snapshot
+}
+
+// sortedVenues sorted by MIC, the caller holds the lock
+func (m *Master) sortedVenues() []market_data.Venue {
+	venues := make([]market_data.Venue, 0, len(m.venues))
+	for _, venue := range m.venues {
+		venues = append(venues, venue)
+	}
+	sort.Slice(venues, func(i, j int) bool { return venues[i].Mic < venues[j].Mic })
+	return venues
+}
+
+/*
+Sync downloads all instruments and venues, page by page, and replaces the master with them.
+Nothing is changed when the download fails
+*/
+func (m *Master) Sync() error {
+	if m.MarketDataSource == nil {
+		return errors.New("instrument master has no source")
+	}
+	instruments, err := collect(m.MarketDataSource.GetInstruments(nil))
+	if err != nil {
+		return err
+	}
+	venues, err := collect(m.MarketDataSource.GetVenues())
+	if err != nil {
+		return err
+	}
+	snapshot := &Snapshot{Synced: time.Now(), Instruments: instruments, Venues: venues}
+	if err := m.store.Save(snapshot); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.index(snapshot)
+	return nil
+}
+
+/*
+Refresh downloads the instruments matching query, such as a list of ISINs or one type, and merges them into
+the master without removing any other instrument. It returns the number of instruments downloaded
+*/
+func (m *Master) Refresh(query *market_data.GetInstrumentsQuery) (int, error) {
+	if m.MarketDataSource == nil {
+		return 0, errors.New("instrument master has no source")
+	}
+	instruments, err := collect(m.MarketDataSource.GetInstruments(query))
+	if err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := m.snapshot()
+	positions := make(map[string]int, len(snapshot.Instruments))
+	for i, instrument := range snapshot.Instruments {
+		positions[strings.ToUpper(instrument.ISIN)] = i
+	}
+	for _, instrument := range instruments {
+		if i, ok := positions[strings.ToUpper(instrument.ISIN)]; ok {
+			snapshot.Instruments[i] = instrument
+			continue
+		}
+		positions[strings.ToUpper(instrument.ISIN)] = len(snapshot.Instruments)
+		snapshot.Instruments = append(snapshot.Instruments, instrument)
+	}
+	if err := m.store.Save(snapshot); err != nil {
+		return 0, err
+	}
+	m.index(snapshot)
+	return len(instruments), nil
+}
+
+// Synced is when the last full Sync happened, zero if never
+func (m *Master) Synced() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.synced
+}
+
+// Len is the number of instruments in the master
+func (m *Master) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.entries)
+}
+
+// ByISIN looks up an instrument by ISIN
+func (m *Master) ByISIN(isin string) (market_data.Instrument, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.byISIN[strings.ToUpper(isin)]
+	if !ok {
+		return market_data.Instrument{}, false
+	}
+	return e.instrument, true
+}
+
+// ByWKN looks up an instrument by WKN
+func (m *Master) ByWKN(wkn string) (market_data.Instrument, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.byWKN[strings.ToUpper(wkn)]
+	if !ok {
+		return market_data.Instrument{}, false
+	}
+	return e.instrument, true
+}
+
+// BySymbol returns all instruments with the ticker symbol, sorted by ISIN
+func (m *Master) BySymbol(symbol string) []market_data.Instrument {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	matches := m.bySymbol[strings.ToUpper(symbol)]
+	instruments := make([]market_data.Instrument, len(matches))
+	for i, e := range matches {
+		instruments[i] = e.instrument
+	}
+	return instruments
+}
+
+/*
+Resolve finds the instrument for an ISIN, WKN or ticker symbol, in that order.
+ErrAmbiguous is returned when a symbol belongs to several instruments and ErrNotFound when nothing matches
+*/
+func (m *Master) Resolve(id string) (market_data.Instrument, error) {
+	if instrument, ok := m.ByISIN(id); ok {
+		return instrument, nil
+	}
+	if instrument, ok := m.ByWKN(id); ok {
+		return instrument, nil
+	}
+	switch matches := m.BySymbol(id); len(matches) {
+	case 0:
+		return market_data.Instrument{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	case 1:
+		return matches[0], nil
+	default:
+		return market_data.Instrument{}, fmt.Errorf("%w: %s is the symbol of %d instruments", ErrAmbiguous, id, len(matches))
+	}
+}
+
+// Venue looks up a venue by MIC
+func (m *Master) Venue(mic string) (market_data.Venue, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	venue, ok := m.venues[strings.ToUpper(mic)]
+	return venue, ok
+}
+
+/*
+Find returns the instruments matching query, with the same filters as the API: ISIN, Type, MIC, Currency and
+Search, paged by Limit and Page. Search results are ranked, see Search, everything else is sorted by ISIN
+*/
+func (m *Master) Find(query *market_data.GetInstrumentsQuery) []market_data.Instrument {
+	if query == nil {
+		query = &market_data.GetInstrumentsQuery{}
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var candidates []*entry
+	if len(query.ISIN) > 0 {
+		for _, isin := range query.ISIN {
+			if e, ok := m.byISIN[strings.ToUpper(isin)]; ok {
+				candidates = append(candidates, e)
+			}
+		}
+	} else {
+		candidates = m.entries
+	}
+	var filtered []*entry
+	for _, e := range candidates {
+		if matches(e.instrument, query) {
+			filtered = append(filtered, e)
+		}
+	}
+	if query.Search != "" {
+		filtered = rank(filtered, query.Search)
+	}
+	instruments := make([]market_data.Instrument, 0, len(filtered))
+	for _, e := range page(filtered, query.Limit, query.Page) {
+		instruments = append(instruments, e.instrument)
+	}
+	return instruments
+}
+
+// matches is true when the instrument passes the Type, MIC and Currency filters of the query
+func matches(instrument market_data.Instrument, query *market_data.GetInstrumentsQuery) bool {
+	if query.Type != "" && !strings.EqualFold(instrument.Type, query.Type) {
+		return false
+	}
+	if query.MIC == "" && query.Currency == "" {
+		return true
+	}
+	for _, venue := range instrument.Venues {
+		if (query.MIC == "" || strings.EqualFold(venue.Mic, query.MIC)) &&
+			(query.Currency == "" || strings.EqualFold(venue.Currency, query.Currency)) {
+			return true
+		}
+	}
+	return false
+}
+
+// page returns the entries of a 1-based page, all entries without a limit
+func page(entries []*entry, limit, number int) []*entry {
+	if limit <= 0 {
+		return entries
+	}
+	if number < 1 {
+		number = 1
+	}
+	start := (number - 1) * limit
+	if start >= len(entries) {
+		return nil
+	}
+	end := start + limit
+	if end > len(entries) {
+		end = len(entries)
+	}
+	return entries[start:end]
+}
+
+// GetInstruments answers the query from the master, without network access
+func (m *Master) GetInstruments(query *market_data.GetInstrumentsQuery) <-chan market_data.Item[market_data.Instrument, error] {
+	instruments := m.Find(query)
+	ch := make(chan market_data.Item[market_data.Instrument, error])
+	go func() {
+		defer close(ch)
+		for _, instrument := range instruments {
+			ch <- market_data.Item[market_data.Instrument, error]{Data: instrument}
+		}
+	}()
+	return ch
+}
+
+// GetVenues returns the venues of the master sorted by MIC, without network access
+func (m *Master) GetVenues() <-chan market_data.Item[market_data.Venue, error] {
+	m.mu.RLock()
+	venues := m.sortedVenues()
+	m.mu.RUnlock()
+	ch := make(chan market_data.Item[market_data.Venue, error])
+	go func() {
+		defer close(ch)
+		for _, venue := range venues {
+			ch <- market_data.Item[market_data.Venue, error]{Data: venue}
+		}
+	}()
+	return ch
+}
+
+func collect[T market_data.DataTypes](ch <-chan market_data.Item[T, error]) ([]T, error) {
+	var values []T
+	for item := range ch {
+		if item.Error != nil {
+			for range ch {
+			}
+			return nil, item.Error
+		}
+		values = append(values, item.Data)
+	}
+	return values, nil
+}
@@ -0,0 +1,186 @@
+package instruments
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	gettex = market_data.Venue{Name: "Börse München - Gettex", Title: "Gettex", Mic: "XMUN", Tradable: true, Currency: "EUR"}
+	xetra  = market_data.Venue{Name: "Xetra", Title: "Xetra", Mic: "XETR", Tradable: true, Currency: "EUR"}
+	nasdaq = market_data.Venue{Name: "Nasdaq", Title: "Nasdaq", Mic: "XNAS", Tradable: false, Currency: "USD"}
+
+	apple     = market_data.Instrument{ISIN: "US0378331005", WKN: "865985", Symbol: "APC", Type: "stock", Name: "APPLE INC.", Title: "APPLE INC", Venues: []market_data.Venue{gettex, nasdaq}}
+	tesla     = market_data.Instrument{ISIN: "US88160R1014", WKN: "A1CX3T", Symbol: "TL0", Type: "stock", Name: "TESLA INC. DL -,001", Title: "TESLA INC", Venues: []market_data.Venue{gettex}}
+	microsoft = market_data.Instrument{ISIN: "US5949181045", WKN: "870747", Symbol: "MSF", Type: "stock", Name: "MICROSOFT DL-,00000625", Title: "MICROSOFT CORP", Venues: []market_data.Venue{gettex, xetra}}
+	world     = market_data.Instrument{ISIN: "IE00B4L5Y983", WKN: "A0RPWH", Symbol: "EUNL", Type: "etf", Name: "ISHSIII-CORE MSCI WORLD U.ETF", Title: "ISHARES CORE MSCI WORLD UCITS ETF", Venues: []market_data.Venue{gettex, xetra}}
+	appleBond = market_data.Instrument{ISIN: "US037833DX52", WKN: "A28RKT", Symbol: "APC", Type: "bond", Name: "APPLE 20/30", Title: "APPLE INC. 1.25% 20/30", Venues: []market_data.Venue{gettex}}
+)
+
+// fakeSource serves instruments and venues and records the queries
+type fakeSource struct {
+	market_data.MarketDataSource
+	instruments []market_data.Instrument
+	venues      []market_data.Venue
+	err         error
+	queries     []*market_data.GetInstrumentsQuery
+}
+
+func (s *fakeSource) GetInstruments(query *market_data.GetInstrumentsQuery) <-chan market_data.Item[market_data.Instrument, error] {
+	s.queries = append(s.queries, query)
+	ch := make(chan market_data.Item[market_data.Instrument, error], len(s.instruments)+1)
+	for _, instrument := range s.instruments {
+		if query == nil || len(query.ISIN) == 0 || query.ISIN[0] == instrument.ISIN {
+			ch <- market_data.Item[market_data.Instrument, error]{Data: instrument}
+		}
+	}
+	if s.err != nil {
+		ch <- market_data.Item[market_data.Instrument, error]{Error: s.err}
+	}
+	close(ch)
+	return ch
+}
+
+func (s *fakeSource) GetVenues() <-chan market_data.Item[market_data.Venue, error] {
+	ch := make(chan market_data.Item[market_data.Venue, error], len(s.venues))
+	for _, venue := range s.venues {
+		ch <- market_data.Item[market_data.Venue, error]{Data: venue}
+	}
+	close(ch)
+	return ch
+}
+
+func synced(t *testing.T) *Master {
+	source := &fakeSource{instruments: []market_data.Instrument{apple, tesla, microsoft, world, appleBond}, venues: []market_data.Venue{xetra, gettex, nasdaq}}
+	master, err := New(source, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, master.Sync())
+	return master
+}
+
+func isins(instruments []market_data.Instrument) []string {
+	result := make([]string, len(instruments))
+	for i, instrument := range instruments {
+		result[i] = instrument.ISIN
+	}
+	return result
+}
+
+func TestSync(t *testing.T) {
+	t.Run("Successful test", func(t *testing.T) {
+		store := NewMemoryStore()
+		source := &fakeSource{instruments: []market_data.Instrument{apple, tesla}, venues: []market_data.Venue{gettex}}
+		master, err := New(source, store)
+		assert.Nil(t, err)
+		assert.True(t, master.Synced().IsZero())
+		assert.Nil(t, master.Sync())
+		assert.False(t, master.Synced().IsZero())
+		assert.Equal(t, 2, master.Len())
+
+		offline, err := New(nil, store)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, offline.Len())
+		instrument, ok := offline.ByWKN("a1cx3t")
+		assert.True(t, ok)
+		assert.Equal(t, tesla, instrument)
+		venue, ok := offline.Venue("xmun")
+		assert.True(t, ok)
+		assert.Equal(t, gettex, venue)
+		assert.NotNil(t, offline.Sync())
+	})
+	t.Run("fail to get response", func(t *testing.T) {
+		source := &fakeSource{instruments: []market_data.Instrument{apple}}
+		master, err := New(source, nil)
+		assert.Nil(t, err)
+		assert.Nil(t, master.Sync())
+		source.instruments = []market_data.Instrument{tesla}
+		source.err = errors.New("connection reset")
+		assert.Equal(t, source.err, master.Sync())
+		_, ok := master.ByISIN(apple.ISIN)
+		assert.True(t, ok, "a failed sync keeps the master")
+	})
+	t.Run("incremental refresh", func(t *testing.T) {
+		store := NewMemoryStore()
+		source := &fakeSource{instruments: []market_data.Instrument{apple, tesla}}
+		master, err := New(source, store)
+		assert.Nil(t, err)
+		assert.Nil(t, master.Sync())
+
+		renamed := tesla
+		renamed.Name = "TESLA INC."
+		source.instruments = []market_data.Instrument{renamed, world}
+		n, err := master.Refresh(&market_data.GetInstrumentsQuery{ISIN: []string{tesla.ISIN}})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, 2, master.Len())
+		instrument, _ := master.ByISIN(tesla.ISIN)
+		assert.Equal(t, "TESLA INC.", instrument.Name)
+
+		n, err = master.Refresh(&market_data.GetInstrumentsQuery{Type: "etf"})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, n)
+		assert.Equal(t, 3, master.Len())
+		saved, _ := store.Load()
+		assert.Len(t, saved.Instruments, 3)
+		assert.Equal(t, "etf", source.queries[2].Type)
+	})
+}
+
+func TestResolve(t *testing.T) {
+	master := synced(t)
+	for _, id := range []string{"US0378331005", "us0378331005", "865985"} {
+		instrument, err := master.Resolve(id)
+		assert.Nil(t, err, id)
+		assert.Equal(t, apple, instrument, id)
+	}
+	instrument, err := master.Resolve("tl0")
+	assert.Nil(t, err)
+	assert.Equal(t, tesla, instrument)
+	_, err = master.Resolve("APC")
+	assert.True(t, errors.Is(err, ErrAmbiguous))
+	assert.Len(t, master.BySymbol("APC"), 2)
+	_, err = master.Resolve("NOPE")
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestFind(t *testing.T) {
+	master := synced(t)
+	t.Run("filters", func(t *testing.T) {
+		assert.Equal(t, []string{"IE00B4L5Y983", "US0378331005", "US037833DX52", "US5949181045", "US88160R1014"}, isins(master.Find(nil)))
+		assert.Equal(t, []string{"IE00B4L5Y983"}, isins(master.Find(&market_data.GetInstrumentsQuery{Type: "ETF"})))
+		assert.Equal(t, []string{"IE00B4L5Y983", "US5949181045"}, isins(master.Find(&market_data.GetInstrumentsQuery{MIC: "XETR"})))
+		assert.Equal(t, []string{"US0378331005"}, isins(master.Find(&market_data.GetInstrumentsQuery{Currency: "USD"})))
+		assert.Empty(t, master.Find(&market_data.GetInstrumentsQuery{MIC: "XETR", Currency: "USD"}))
+		assert.Equal(t, []string{"US88160R1014", "US0378331005"}, isins(master.Find(&market_data.GetInstrumentsQuery{ISIN: []string{tesla.ISIN, apple.ISIN, "XX0000000000"}})))
+	})
+	t.Run("paging", func(t *testing.T) {
+		assert.Equal(t, []string{"IE00B4L5Y983", "US0378331005"}, isins(master.Find(&market_data.GetInstrumentsQuery{Limit: 2})))
+		assert.Equal(t, []string{"US88160R1014"}, isins(master.Find(&market_data.GetInstrumentsQuery{Limit: 2, Page: 3})))
+		assert.Empty(t, master.Find(&market_data.GetInstrumentsQuery{Limit: 2, Page: 4}))
+	})
+	t.Run("search", func(t *testing.T) {
+		assert.Equal(t, []string{"US037833DX52", "US0378331005"}, isins(master.Find(&market_data.GetInstrumentsQuery{Search: "apple"})))
+		assert.Equal(t, []string{"US037833DX52"}, isins(master.Find(&market_data.GetInstrumentsQuery{Search: "apple", Type: "bond"})))
+		assert.Equal(t, []string{"IE00B4L5Y983"}, isins(master.Find(&market_data.GetInstrumentsQuery{Search: "msci world"})))
+		assert.Equal(t, []string{"US5949181045"}, isins(master.Find(&market_data.GetInstrumentsQuery{Search: "870747"})))
+	})
+}
+
+func TestOffline(t *testing.T) {
+	master := synced(t)
+	var instruments []market_data.Instrument
+	for item := range master.GetInstruments(&market_data.GetInstrumentsQuery{Search: "tesla"}) {
+		assert.Nil(t, item.Error)
+		instruments = append(instruments, item.Data)
+	}
+	assert.Equal(t, []market_data.Instrument{tesla}, instruments)
+
+	var mics []string
+	for item := range master.GetVenues() {
+		mics = append(mics, item.Data.Mic)
+	}
+	assert.Equal(t, []string{"XETR", "XMUN", "XNAS"}, mics)
+}
@@ -0,0 +1,134 @@
+package instruments
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Search ranks, lower is better
+const (
+	exactMatch = iota
+	prefixMatch
+	wordPrefixMatch
+	substringMatch
+	fuzzyMatch
+	noMatch
+)
+
+// words splits text into lower case words of letters and digits
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+/*
+score ranks how well an instrument matches a search:
+an exact ISIN, WKN or symbol is best, followed by symbols or names starting with the search,
+names with words starting with every search term, names containing every term and finally names
+with a word within a small edit distance of every term, which catches typos
+*/
+func score(e *entry, search string, terms []string) int {
+	instrument := e.instrument
+	if strings.EqualFold(instrument.ISIN, search) || strings.EqualFold(instrument.WKN, search) || strings.EqualFold(instrument.Symbol, search) {
+		return exactMatch
+	}
+	lower := strings.ToLower(search)
+	if len(terms) == 0 {
+		return noMatch
+	}
+	name := strings.Join(e.words, " ")
+	if strings.HasPrefix(strings.ToLower(instrument.Symbol), lower) || strings.HasPrefix(name, strings.Join(terms, " ")) {
+		return prefixMatch
+	}
+	best := wordPrefixMatch
+	for _, term := range terms {
+		rank := noMatch
+		for _, word := range e.words {
+			switch {
+			case strings.HasPrefix(word, term):
+				rank = wordPrefixMatch
+			case strings.Contains(word, term) && rank > substringMatch:
+				rank = substringMatch
+			case rank > fuzzyMatch && len(term) >= 4 && distance(word, term) <= maxDistance(term):
+				rank = fuzzyMatch
+			}
+			if rank == wordPrefixMatch {
+				break
+			}
+		}
+		if rank > best {
+			best = rank
+		}
+		if best == noMatch {
+			return noMatch
+		}
+	}
+	return best
+}
+
+// maxDistance allows one typo in short terms and two in longer ones
+func maxDistance(term string) int {
+	if len([]rune(term)) < 8 {
+		return 1
+	}
+	return 2
+}
+
+// distance is the Levenshtein distance between a and b
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+/*
+rank keeps the entries matching the search, best matches first and ties sorted by name
+*/
+func rank(entries []*entry, search string) []*entry {
+	search = strings.TrimSpace(search)
+	terms := words(search)
+	type scored struct {
+		entry *entry
+		score int
+	}
+	var matched []scored
+	for _, e := range entries {
+		if s := score(e, search, terms); s != noMatch {
+			matched = append(matched, scored{e, s})
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		if matched[i].score != matched[j].score {
+			return matched[i].score < matched[j].score
+		}
+		return matched[i].entry.instrument.Name < matched[j].entry.instrument.Name
+	})
+	ranked := make([]*entry, len(matched))
+	for i, m := range matched {
+		ranked[i] = m.entry
+	}
+	return ranked
+}
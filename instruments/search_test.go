@@ -0,0 +1,42 @@
+package instruments
+
+import (
+	"testing"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 0, distance("tesla", "tesla"))
+	assert.Equal(t, 2, distance("tesla", "telsa"))
+	assert.Equal(t, 1, distance("microsoft", "microsft"))
+	assert.Equal(t, 3, distance("kitten", "sitting"))
+	assert.Equal(t, 4, distance("", "münc"))
+}
+
+func TestScore(t *testing.T) {
+	score := func(instrument market_data.Instrument, search string) int {
+		e := &entry{instrument: instrument, words: words(instrument.Name + " " + instrument.Title)}
+		return score(e, search, words(search))
+	}
+	assert.Equal(t, exactMatch, score(apple, "US0378331005"))
+	assert.Equal(t, exactMatch, score(apple, "apc"))
+	assert.Equal(t, prefixMatch, score(apple, "appl"))
+	assert.Equal(t, prefixMatch, score(world, "ishsiii core"))
+	assert.Equal(t, wordPrefixMatch, score(world, "world msci"))
+	assert.Equal(t, substringMatch, score(world, "shares"))
+	assert.Equal(t, fuzzyMatch, score(microsoft, "microsft"))
+	assert.Equal(t, fuzzyMatch, score(tesla, "tesle inc"))
+	assert.Equal(t, noMatch, score(tesla, "tsl"))
+	assert.Equal(t, noMatch, score(tesla, "tesla apple"))
+	assert.Equal(t, noMatch, score(tesla, "--"))
+}
+
+func TestRank(t *testing.T) {
+	master := synced(t)
+	ranked := master.Find(&market_data.GetInstrumentsQuery{Search: "micrsoft"})
+	assert.Equal(t, []string{microsoft.ISIN}, isins(ranked))
+	ranked = master.Find(&market_data.GetInstrumentsQuery{Search: "inc"})
+	assert.Equal(t, []string{appleBond.ISIN, apple.ISIN, tesla.ISIN}, isins(ranked))
+}
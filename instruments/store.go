@@ -0,0 +1,102 @@
+package instruments
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+)
+
+// Snapshot is everything the master knows, as it is persisted
+type Snapshot struct {
+	Synced      time.Time                `json:"synced"`
+	Instruments []market_data.Instrument `json:"instruments"`
+	Venues      []market_data.Venue      `json:"venues"`
+}
+
+/*
+Store persists the snapshot of a Master. Load returns nil without an error when nothing has been saved yet.
+Implement it to keep the instruments in a database instead of a file
+*/
+type Store interface {
+	Load() (*Snapshot, error)
+	Save(snapshot *Snapshot) error
+}
+
+// MemoryStore keeps the snapshot in memory, mostly useful for tests
+type MemoryStore struct {
+	mu       sync.Mutex
+	snapshot *Snapshot
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Load returns the saved snapshot
+func (s *MemoryStore) Load() (*Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshot, nil
+}
+
+// Save replaces the snapshot
+func (s *MemoryStore) Save(snapshot *Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshot = snapshot
+	return nil
+}
+
+/*
+FileStore keeps the snapshot as a single JSON file at Path
+*/
+type FileStore struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// NewFileStore returns a FileStore, the directory is created on the first save
+func NewFileStore(path string) *FileStore {
+	return &FileStore{Path: path}
+}
+
+// Load reads the snapshot, nil if the file does not exist
+func (s *FileStore) Load() (*Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	raw, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{}
+	return snapshot, json.Unmarshal(raw, snapshot)
+}
+
+/*
+Save writes the snapshot to a temporary file first so that a failed write does not corrupt the store
+*/
+func (s *FileStore) Save(snapshot *Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	raw, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.Path)
+}
@@ -0,0 +1,45 @@
+package instruments
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "master", "instruments.json")
+	store := NewFileStore(path)
+
+	t.Run("empty store", func(t *testing.T) {
+		snapshot, err := store.Load()
+		assert.Nil(t, err)
+		assert.Nil(t, snapshot)
+	})
+	t.Run("Successful test", func(t *testing.T) {
+		snapshot := &Snapshot{
+			Synced:      time.Date(2022, 2, 14, 20, 44, 3, 0, time.UTC),
+			Instruments: []market_data.Instrument{apple, world},
+			Venues:      []market_data.Venue{gettex},
+		}
+		assert.Nil(t, store.Save(snapshot))
+		loaded, err := NewFileStore(path).Load()
+		assert.Nil(t, err)
+		assert.Equal(t, snapshot, loaded)
+
+		master, err := New(nil, store)
+		assert.Nil(t, err)
+		assert.Equal(t, snapshot.Synced, master.Synced())
+		assert.Equal(t, 2, master.Len())
+	})
+	t.Run("Fail to decode results", func(t *testing.T) {
+		assert.Nil(t, os.WriteFile(path, []byte("really odd content"), 0o644))
+		_, err := store.Load()
+		assert.NotNil(t, err)
+		_, err = New(nil, store)
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,113 @@
+package liquidity
+
+import (
+	"sort"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+)
+
+/*
+Analyze reads quotes and trades from the market data client and returns the reports sorted by ISIN and MIC.
+Both are read to the end and merged by time, quotes before trades at the same time, so they can be
+requested in any order. It stops at the first error
+*/
+func Analyze(quotes <-chan market_data.Item[market_data.Quote, error], trades <-chan market_data.Item[market_data.Trade, error], config Config) ([]Report, error) {
+	analyzer, err := New(config)
+	if err != nil {
+		return nil, err
+	}
+	type row struct {
+		time  time.Time
+		quote *market_data.Quote
+		trade *market_data.Trade
+	}
+	var rows []row
+	for item := range quotes {
+		if item.Error != nil {
+			market_data.Drain(trades)
+			return nil, item.Error
+		}
+		quote := item.Data
+		rows = append(rows, row{time: quote.Time, quote: &quote})
+	}
+	for item := range trades {
+		if item.Error != nil {
+			market_data.Drain(trades)
+			return nil, item.Error
+		}
+		trade := item.Data
+		rows = append(rows, row{time: trade.Time, trade: &trade})
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if !rows[i].time.Equal(rows[j].time) {
+			return rows[i].time.Before(rows[j].time)
+		}
+		return rows[i].quote != nil && rows[j].quote == nil
+	})
+	for _, row := range rows {
+		if row.quote != nil {
+			analyzer.AddQuote(*row.quote)
+		} else {
+			analyzer.AddTrade(*row.trade)
+		}
+	}
+	return analyzer.Reports(), nil
+}
+
+/*
+Comparison of the venues of an instrument for an execution planner. Venues are sorted tightest spread first,
+venues without quotes last. Tightest, Deepest and MostTraded name the MIC with the lowest SpreadBps,
+the highest Depth and the highest Turnover, empty when no venue has any
+*/
+type Comparison struct {
+	ISIN       string
+	Venues     []Report
+	Tightest   string
+	Deepest    string
+	MostTraded string
+}
+
+// Compare groups reports by ISIN, sorted by ISIN
+func Compare(reports []Report) []Comparison {
+	byISIN := make(map[string][]Report)
+	var isins []string
+	for _, report := range reports {
+		if _, ok := byISIN[report.ISIN]; !ok {
+			isins = append(isins, report.ISIN)
+		}
+		byISIN[report.ISIN] = append(byISIN[report.ISIN], report)
+	}
+	sort.Strings(isins)
+	comparisons := make([]Comparison, len(isins))
+	for i, isin := range isins {
+		venues := byISIN[isin]
+		sort.SliceStable(venues, func(i, j int) bool {
+			a, b := venues[i], venues[j]
+			if (a.Quotes > 0) != (b.Quotes > 0) {
+				return a.Quotes > 0
+			}
+			if a.SpreadBps != b.SpreadBps {
+				return a.SpreadBps < b.SpreadBps
+			}
+			return a.Turnover > b.Turnover
+		})
+		comparison := Comparison{ISIN: isin, Venues: venues}
+		if venues[0].Quotes > 0 {
+			comparison.Tightest = venues[0].MIC
+		}
+		depth, turnover := 0.0, 0.0
+		for _, venue := range venues {
+			if venue.Depth() > depth {
+				depth = venue.Depth()
+				comparison.Deepest = venue.MIC
+			}
+			if venue.Turnover > turnover {
+				turnover = venue.Turnover
+				comparison.MostTraded = venue.MIC
+			}
+		}
+		comparisons[i] = comparison
+	}
+	return comparisons
+}
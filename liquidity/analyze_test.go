@@ -0,0 +1,81 @@
+package liquidity
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/stretchr/testify/assert"
+)
+
+func channel[T market_data.DataTypes](rows []T, err error) <-chan market_data.Item[T, error] {
+	ch := make(chan market_data.Item[T, error], len(rows)+1)
+	for _, row := range rows {
+		ch <- market_data.Item[T, error]{Data: row}
+	}
+	if err != nil {
+		ch <- market_data.Item[T, error]{Error: err}
+	}
+	close(ch)
+	return ch
+}
+
+func TestAnalyze(t *testing.T) {
+	t.Run("Successful test", func(t *testing.T) {
+		quotes := []market_data.Quote{quote(at(9, 0, 0), 99, 101, 100), quote(at(9, 1, 0), 100, 102, 100)}
+		trades := []market_data.Trade{trade(at(9, 1, 0), 101.5, 10), trade(at(9, 0, 30), 100.5, 10)}
+		reports, err := Analyze(channel(quotes, nil), channel(trades, nil), Config{})
+		assert.Nil(t, err)
+		assert.Len(t, reports, 1)
+		assert.Equal(t, 20, reports[0].BuyVolume, "each trade against the quote before it")
+		assert.Equal(t, 2, reports[0].Quotes)
+	})
+	t.Run("fail to get response", func(t *testing.T) {
+		_, err := Analyze(channel([]market_data.Quote{}, errors.New("unknown http error from backend: 500")), channel([]market_data.Trade{}, nil), Config{})
+		assert.EqualError(t, err, "unknown http error from backend: 500")
+		_, err = Analyze(channel([]market_data.Quote{}, nil), channel([]market_data.Trade{}, errors.New("trades failed")), Config{})
+		assert.EqualError(t, err, "trades failed")
+	})
+	t.Run("trades are drained after an error", func(t *testing.T) {
+		trades := make(chan market_data.Item[market_data.Trade, error])
+		sent := make(chan struct{})
+		go func() {
+			defer close(sent)
+			trades <- market_data.Item[market_data.Trade, error]{Error: errors.New("trades failed")}
+			trades <- market_data.Item[market_data.Trade, error]{Data: trade(at(9, 0, 0), 100, 1)}
+			close(trades)
+		}()
+		_, err := Analyze(channel([]market_data.Quote{}, nil), trades, Config{})
+		assert.EqualError(t, err, "trades failed")
+		select {
+		case <-sent:
+		case <-time.After(time.Second):
+			t.Fatal("trades are not drained")
+		}
+	})
+}
+
+func TestCompare(t *testing.T) {
+	reports := []Report{
+		{ISIN: apple, MIC: "XMUN", Quotes: 10, SpreadBps: 20, BidDepth: 100, AskDepth: 100, Turnover: 5000},
+		{ISIN: apple, MIC: "LMBPX", Quotes: 10, SpreadBps: 10, BidDepth: 50, AskDepth: 50, Turnover: 1000},
+		{ISIN: apple, MIC: "XETR", Turnover: 9000},
+		{ISIN: "DE0007164600", MIC: "XMUN", Quotes: 1, SpreadBps: 15},
+	}
+	comparisons := Compare(reports)
+	assert.Len(t, comparisons, 2)
+	assert.Equal(t, "DE0007164600", comparisons[0].ISIN)
+	assert.Equal(t, "XMUN", comparisons[0].Tightest)
+	assert.Equal(t, "", comparisons[0].MostTraded)
+
+	comparison := comparisons[1]
+	var mics []string
+	for _, venue := range comparison.Venues {
+		mics = append(mics, venue.MIC)
+	}
+	assert.Equal(t, []string{"LMBPX", "XMUN", "XETR"}, mics)
+	assert.Equal(t, "LMBPX", comparison.Tightest)
+	assert.Equal(t, "XMUN", comparison.Deepest)
+	assert.Equal(t, "XETR", comparison.MostTraded)
+}
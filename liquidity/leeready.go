@@ -0,0 +1,40 @@
+package liquidity
+
+import "github.com/quantfamily/lemonmarkets/market_data"
+
+// Side of a trade, the side that initiated it
+type Side int
+
+const (
+	Unknown Side = iota
+	Buy
+	Sell
+)
+
+func (s Side) String() string {
+	switch s {
+	case Buy:
+		return "buy"
+	case Sell:
+		return "sell"
+	}
+	return "unknown"
+}
+
+/*
+LeeReady classifies a trade by the Lee-Ready algorithm: above the mid of the prevailing quote it was bought,
+below it sold. At the mid, or without a quote, the tick test decides and tick is the direction of the last
+price change before the trade
+*/
+func LeeReady(price float64, quote *market_data.Quote, tick Side) Side {
+	if quote != nil {
+		mid := (quote.Bid + quote.Ask) / 2
+		switch {
+		case price > mid:
+			return Buy
+		case price < mid:
+			return Sell
+		}
+	}
+	return tick
+}
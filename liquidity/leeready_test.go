@@ -0,0 +1,19 @@
+package liquidity
+
+import (
+	"testing"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeeReady(t *testing.T) {
+	q := &market_data.Quote{Bid: 99, Ask: 101}
+	assert.Equal(t, Buy, LeeReady(100.5, q, Sell))
+	assert.Equal(t, Sell, LeeReady(99.5, q, Buy))
+	assert.Equal(t, Buy, LeeReady(100, q, Buy), "tick test at the mid")
+	assert.Equal(t, Sell, LeeReady(105, nil, Sell), "tick test without quote")
+	assert.Equal(t, Unknown, LeeReady(100, nil, Unknown))
+	assert.Equal(t, "buy", Buy.String())
+	assert.Equal(t, "unknown", Unknown.String())
+}
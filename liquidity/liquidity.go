@@ -0,0 +1,270 @@
+/*
+Package liquidity measures spreads, quoted depth, traded volume and the side of trades per instrument and venue,
+to choose where and when to execute.
+
+Quotes and trades are added oldest first, or analyzed together from the market data client:
+
+	reports, err := liquidity.Analyze(client.GetQuotes(&quotes), client.GetTrades(&trades), liquidity.Config{})
+	for _, comparison := range liquidity.Compare(reports) {
+		fmt.Println(comparison.ISIN, "tightest on", comparison.Tightest)
+	}
+*/
+package liquidity
+
+import (
+	"sort"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+)
+
+/*
+Config of an Analyzer. Bucket is the width of the intraday volume profile and defaults to 30 minutes,
+buckets start at midnight in Location which defaults to Europe/Berlin. A quote counts for the spread and
+depth until the next quote, for at most MaxQuoteAge which defaults to 5 minutes, so that the time between
+sessions does not count. Trades are only classified against quotes younger than MaxQuoteAge
+*/
+type Config struct {
+	Bucket      time.Duration
+	Location    *time.Location
+	MaxQuoteAge time.Duration
+}
+
+// Bucket of the intraday volume profile, Start is the time since midnight
+type Bucket struct {
+	Start  time.Duration
+	Trades int
+	Volume int
+	Share  float64
+}
+
+/*
+Report of an instrument on a venue. Spread, SpreadBps, BidDepth and AskDepth are time weighted averages
+over the valid quotes, crossed and one-sided quotes are counted in SkippedQuotes.
+Turnover is the sum of price times volume of the trades and VWAP their volume weighted average price
+*/
+type Report struct {
+	ISIN          string
+	MIC           string
+	From          time.Time
+	To            time.Time
+	Quotes        int
+	SkippedQuotes int
+	Spread        float64
+	SpreadBps     float64
+	BidDepth      float64
+	AskDepth      float64
+	Trades        int
+	Volume        int
+	Turnover      float64
+	VWAP          float64
+	BuyVolume     int
+	SellVolume    int
+	Unclassified  int
+	Profile       []Bucket
+}
+
+// Imbalance is the buy volume less the sell volume over both, between -1 and 1
+func (r Report) Imbalance() float64 {
+	total := r.BuyVolume + r.SellVolume
+	if total == 0 {
+		return 0
+	}
+	return float64(r.BuyVolume-r.SellVolume) / float64(total)
+}
+
+// Depth is the average quoted volume on both sides
+func (r Report) Depth() float64 {
+	return r.BidDepth + r.AskDepth
+}
+
+type key struct {
+	isin string
+	mic  string
+}
+
+// series accumulates a report, the sums are weighted by seconds
+type series struct {
+	report    Report
+	quote     market_data.Quote
+	hasQuote  bool
+	seconds   float64
+	spread    float64
+	spreadBps float64
+	bidDepth  float64
+	askDepth  float64
+	price     float64
+	tick      Side
+	buckets   map[time.Duration]*Bucket
+}
+
+/*
+Analyzer accumulates reports of any number of instruments and venues.
+Quotes and trades are expected oldest first, it is not safe for concurrent use
+*/
+type Analyzer struct {
+	config Config
+	series map[key]*series
+}
+
+// New returns an Analyzer, it fails if Location is not set and Europe/Berlin can not be loaded
+func New(config Config) (*Analyzer, error) {
+	if config.Location == nil {
+		location, err := time.LoadLocation("Europe/Berlin")
+		if err != nil {
+			return nil, err
+		}
+		config.Location = location
+	}
+	if config.Bucket <= 0 {
+		config.Bucket = 30 * time.Minute
+	}
+	if config.MaxQuoteAge <= 0 {
+		config.MaxQuoteAge = 5 * time.Minute
+	}
+	return &Analyzer{config: config, series: make(map[key]*series)}, nil
+}
+
+func (a *Analyzer) get(isin, mic string, t time.Time) *series {
+	k := key{isin, mic}
+	s, ok := a.series[k]
+	if !ok {
+		s = &series{report: Report{ISIN: isin, MIC: mic, From: t}, buckets: make(map[time.Duration]*Bucket)}
+		a.series[k] = s
+	}
+	if t.Before(s.report.From) {
+		s.report.From = t
+	}
+	if t.After(s.report.To) {
+		s.report.To = t
+	}
+	return s
+}
+
+// AddQuote weighs the previous quote of the instrument and venue by the time until this one
+func (a *Analyzer) AddQuote(quote market_data.Quote) {
+	s := a.get(quote.ISIN, quote.Mic, quote.Time)
+	if quote.Bid <= 0 || quote.Ask <= 0 || quote.Bid > quote.Ask || (s.hasQuote && quote.Time.Before(s.quote.Time)) {
+		s.report.SkippedQuotes++
+		return
+	}
+	s.report.Quotes++
+	if s.hasQuote {
+		age := quote.Time.Sub(s.quote.Time)
+		if age > a.config.MaxQuoteAge {
+			age = a.config.MaxQuoteAge
+		}
+		seconds := age.Seconds()
+		spread, bps := spreads(s.quote)
+		s.seconds += seconds
+		s.spread += spread * seconds
+		s.spreadBps += bps * seconds
+		s.bidDepth += float64(s.quote.BidVolume) * seconds
+		s.askDepth += float64(s.quote.AskVolume) * seconds
+	}
+	s.quote = quote
+	s.hasQuote = true
+}
+
+// spreads of a quote, absolute and in basis points of the mid price
+func spreads(quote market_data.Quote) (float64, float64) {
+	spread := quote.Ask - quote.Bid
+	return spread, spread / ((quote.Ask + quote.Bid) / 2) * 10000
+}
+
+/*
+AddTrade adds a trade to the volume, VWAP and profile and classifies it with LeeReady
+against the last quote of the instrument and venue
+*/
+func (a *Analyzer) AddTrade(trade market_data.Trade) {
+	s := a.get(trade.ISIN, trade.Mic, trade.Time)
+	price := float64(trade.Price)
+	s.report.Trades++
+	s.report.Volume += trade.Volume
+	s.report.Turnover += price * float64(trade.Volume)
+
+	if s.price != 0 && price != s.price {
+		s.tick = Buy
+		if price < s.price {
+			s.tick = Sell
+		}
+	}
+	s.price = price
+	var quote *market_data.Quote
+	if s.hasQuote && trade.Time.Sub(s.quote.Time) <= a.config.MaxQuoteAge {
+		quote = &s.quote
+	}
+	switch LeeReady(price, quote, s.tick) {
+	case Buy:
+		s.report.BuyVolume += trade.Volume
+	case Sell:
+		s.report.SellVolume += trade.Volume
+	default:
+		s.report.Unclassified++
+	}
+
+	local := trade.Time.In(a.config.Location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, a.config.Location)
+	offset := local.Sub(midnight)
+	start := offset - offset%a.config.Bucket
+	bucket, ok := s.buckets[start]
+	if !ok {
+		bucket = &Bucket{Start: start}
+		s.buckets[start] = bucket
+	}
+	bucket.Trades++
+	bucket.Volume += trade.Volume
+}
+
+// Report of an instrument on a venue, false if nothing was added for it
+func (a *Analyzer) Report(isin, mic string) (Report, bool) {
+	s, ok := a.series[key{isin, mic}]
+	if !ok {
+		return Report{}, false
+	}
+	return s.build(), true
+}
+
+// Reports of every instrument and venue, sorted by ISIN and MIC
+func (a *Analyzer) Reports() []Report {
+	reports := make([]Report, 0, len(a.series))
+	for _, s := range a.series {
+		reports = append(reports, s.build())
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].ISIN != reports[j].ISIN {
+			return reports[i].ISIN < reports[j].ISIN
+		}
+		return reports[i].MIC < reports[j].MIC
+	})
+	return reports
+}
+
+// build finishes the report, with a single quote its spread and depth are the averages
+func (s *series) build() Report {
+	report := s.report
+	switch {
+	case s.seconds > 0:
+		report.Spread = s.spread / s.seconds
+		report.SpreadBps = s.spreadBps / s.seconds
+		report.BidDepth = s.bidDepth / s.seconds
+		report.AskDepth = s.askDepth / s.seconds
+	case s.hasQuote:
+		report.Spread, report.SpreadBps = spreads(s.quote)
+		report.BidDepth = float64(s.quote.BidVolume)
+		report.AskDepth = float64(s.quote.AskVolume)
+	}
+	if report.Volume > 0 {
+		report.VWAP = report.Turnover / float64(report.Volume)
+	}
+	report.Profile = make([]Bucket, 0, len(s.buckets))
+	for _, bucket := range s.buckets {
+		b := *bucket
+		if report.Volume > 0 {
+			b.Share = float64(b.Volume) / float64(report.Volume)
+		}
+		report.Profile = append(report.Profile, b)
+	}
+	sort.Slice(report.Profile, func(i, j int) bool { return report.Profile[i].Start < report.Profile[j].Start })
+	return report
+}
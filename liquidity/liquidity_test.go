@@ -0,0 +1,94 @@
+package liquidity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/stretchr/testify/assert"
+)
+
+const apple = "US0378331005"
+
+func at(hour, minute, second int) time.Time {
+	return time.Date(2022, 1, 3, hour, minute, second, 0, time.UTC)
+}
+
+func quote(t time.Time, bid, ask float64, volume int) market_data.Quote {
+	return market_data.Quote{ISIN: apple, Mic: "XMUN", Bid: bid, Ask: ask, BidVolume: volume, AskVolume: volume, Time: t}
+}
+
+func trade(t time.Time, price float32, volume int) market_data.Trade {
+	return market_data.Trade{ISIN: apple, Mic: "XMUN", Price: price, Volume: volume, Time: t}
+}
+
+func TestAnalyzer(t *testing.T) {
+	t.Run("time weighted spread and depth", func(t *testing.T) {
+		analyzer, err := New(Config{Location: time.UTC})
+		assert.Nil(t, err)
+		analyzer.AddQuote(quote(at(9, 0, 0), 99, 101, 100))
+		analyzer.AddQuote(quote(at(9, 0, 30), 99.5, 100.5, 200))
+		analyzer.AddQuote(quote(at(9, 1, 0), 102, 101, 10))
+		analyzer.AddQuote(quote(at(9, 2, 0), 99, 101, 100))
+		report, ok := analyzer.Report(apple, "XMUN")
+		assert.True(t, ok)
+		assert.Equal(t, 3, report.Quotes)
+		assert.Equal(t, 1, report.SkippedQuotes, "crossed quote")
+		assert.InDelta(t, (2*30+1*90)/120.0, report.Spread, 1e-9)
+		assert.InDelta(t, (200*30+100*90)/120.0, report.SpreadBps, 1e-9)
+		assert.InDelta(t, (100*30+200*90)/120.0, report.BidDepth, 1e-9)
+		assert.Equal(t, report.BidDepth*2, report.Depth())
+		assert.Equal(t, at(9, 0, 0), report.From)
+		assert.Equal(t, at(9, 2, 0), report.To)
+	})
+	t.Run("quotes are stale after MaxQuoteAge", func(t *testing.T) {
+		analyzer, _ := New(Config{Location: time.UTC, MaxQuoteAge: time.Minute})
+		analyzer.AddQuote(quote(at(9, 0, 0), 99, 101, 100))
+		analyzer.AddQuote(quote(at(9, 1, 0), 99.5, 100.5, 100))
+		analyzer.AddQuote(quote(at(21, 0, 0), 99, 101, 100))
+		report, _ := analyzer.Report(apple, "XMUN")
+		assert.InDelta(t, 1.5, report.Spread, 1e-9, "the night counts one minute")
+	})
+	t.Run("single quote", func(t *testing.T) {
+		analyzer, _ := New(Config{})
+		analyzer.AddQuote(quote(at(9, 0, 0), 99, 101, 100))
+		report, _ := analyzer.Report(apple, "XMUN")
+		assert.Equal(t, 2.0, report.Spread)
+		assert.Equal(t, 100.0, report.AskDepth)
+		_, ok := analyzer.Report(apple, "LMBPX")
+		assert.False(t, ok)
+	})
+	t.Run("trades, VWAP and profile", func(t *testing.T) {
+		analyzer, _ := New(Config{})
+		analyzer.AddQuote(quote(at(8, 0, 0), 99, 101, 100))
+		analyzer.AddTrade(trade(at(8, 10, 0), 101, 10))
+		analyzer.AddQuote(quote(at(8, 15, 0), 99, 101, 100))
+		analyzer.AddTrade(trade(at(8, 20, 0), 99, 30))
+		analyzer.AddTrade(trade(at(8, 40, 0), 100, 20))
+		analyzer.AddTrade(trade(at(9, 0, 0), 100, 40))
+		report, _ := analyzer.Report(apple, "XMUN")
+		assert.Equal(t, 4, report.Trades)
+		assert.Equal(t, 100, report.Volume)
+		assert.Equal(t, 9980.0, report.Turnover)
+		assert.InDelta(t, 99.8, report.VWAP, 1e-9)
+		assert.Equal(t, 1, report.Unclassified, "stale quote and no earlier price")
+		assert.Equal(t, 30, report.SellVolume)
+		assert.Equal(t, 60, report.BuyVolume, "stale quote, classified by the up tick")
+		assert.InDelta(t, 1/3.0, report.Imbalance(), 1e-9)
+		assert.Equal(t, []Bucket{
+			{Start: 9 * time.Hour, Trades: 2, Volume: 40, Share: 0.4},
+			{Start: 9*time.Hour + 30*time.Minute, Trades: 1, Volume: 20, Share: 0.2},
+			{Start: 10 * time.Hour, Trades: 1, Volume: 40, Share: 0.4},
+		}, report.Profile, "buckets in Berlin time")
+	})
+	t.Run("reports sorted", func(t *testing.T) {
+		analyzer, _ := New(Config{})
+		other := trade(at(9, 0, 0), 100, 1)
+		other.Mic = "LMBPX"
+		analyzer.AddTrade(trade(at(9, 0, 0), 100, 1))
+		analyzer.AddTrade(other)
+		reports := analyzer.Reports()
+		assert.Equal(t, "LMBPX", reports[0].MIC)
+		assert.Equal(t, "XMUN", reports[1].MIC)
+	})
+}
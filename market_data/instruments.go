@@ -1,7 +1,7 @@
 package market_data
 
 import (
-	"encoding/json"
+	"github.com/quantfamily/lemonmarkets/identifiers"
 )
 
 /*
@@ -18,6 +18,19 @@ type GetInstrumentsQuery struct {
 	Page     int      `url:"page,omitempty"`
 }
 
+/*
+Validate checks ISINs and MIC locally, so that typos fail without a request
+*/
+func (q *GetInstrumentsQuery) Validate() error {
+	if q == nil {
+		return nil
+	}
+	if err := identifiers.CheckISINs(q.ISIN...); err != nil {
+		return err
+	}
+	return identifiers.CheckMIC(q.MIC)
+}
+
 /*
 Instrument that can be tradeable
 */
@@ -43,45 +56,34 @@ func (cl *MarketDataClient) GetInstruments(query *GetInstrumentsQuery) <-chan It
 
 func (cl *MarketDataClient) returnInstruments(query *GetInstrumentsQuery, ch chan<- Item[Instrument, error]) {
 	defer close(ch)
-	response, err := cl.backend.Do("GET", "instruments", query, nil)
-	if err != nil {
-		instrument := Item[Instrument, error]{}
-		instrument.Error = err
-		ch <- instrument
+	if err := query.Validate(); err != nil {
+		ch <- Item[Instrument, error]{Error: err}
 		return
 	}
-	for {
-		var instruments []Instrument
-		instrument := Item[Instrument, error]{}
-		instrument.Error = json.Unmarshal(response.Results, &instruments)
-		if instrument.Error != nil {
-			ch <- instrument
-			return
-		}
-		for _, instrument := range instruments {
-			ch <- Item[Instrument, error]{instrument, nil}
-		}
-		if response.Next == "" {
-			return
-		}
-		response, instrument.Error = cl.backend.Do("GET", response.Next, nil, nil)
-		if instrument.Error != nil {
-			ch <- instrument
-			return
-		}
-	}
+	returnData(cl.backend, "instruments", query, ch)
 }
 
 /*
 Venue of where the tradeable instrument is located
 */
 type Venue struct {
-	Name     string `json:"name"`
-	Title    string `json:"title"`
-	Mic      string `json:"mic"`
-	IsOpen   bool   `json:"is_open"`
-	Tradable bool   `json:"tradable"`
-	Currency string `json:"currency"`
+	Name         string       `json:"name"`
+	Title        string       `json:"title"`
+	Mic          string       `json:"mic"`
+	IsOpen       bool         `json:"is_open"`
+	Tradable     bool         `json:"tradable"`
+	Currency     string       `json:"currency"`
+	OpeningHours OpeningHours `json:"opening_hours"`
+	OpeningDays  []string     `json:"opening_days"`
+}
+
+/*
+OpeningHours of a venue as local times (15:04) in Timezone
+*/
+type OpeningHours struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone"`
 }
 
 /*
@@ -95,31 +97,5 @@ func (cl *MarketDataClient) GetVenues() <-chan Item[Venue, error] {
 
 func (cl *MarketDataClient) returnVenues(ch chan<- Item[Venue, error]) {
 	defer close(ch)
-	response, err := cl.backend.Do("GET", "venues", nil, nil)
-	if err != nil {
-		venue := Item[Venue, error]{}
-		venue.Error = err
-		ch <- venue
-		return
-	}
-	for {
-		var venues []Venue
-		venue := Item[Venue, error]{}
-		venue.Error = json.Unmarshal(response.Results, &venues)
-		if venue.Error != nil {
-			ch <- venue
-			return
-		}
-		for _, venue := range venues {
-			ch <- Item[Venue, error]{venue, nil}
-		}
-		if response.Next == "" {
-			return
-		}
-		response, venue.Error = cl.backend.Do("GET", response.Next, nil, nil)
-		if venue.Error != nil {
-			ch <- venue
-			return
-		}
-	}
+	returnData[Venue](cl.backend, "venues", nil, ch)
 }
@@ -108,6 +108,8 @@ func TestGetVenues(t *testing.T) {
 		venue := <-venueCh
 		assert.Nil(t, venue.Error)
 		assert.Equal(t, true, venue.Data.IsOpen)
+		assert.Equal(t, OpeningHours{Start: "08:00", End: "22:00", Timezone: "Europe/Berlin"}, venue.Data.OpeningHours)
+		assert.Equal(t, []string{"2021-12-06", "2021-12-07", "2021-12-08"}, venue.Data.OpeningDays)
 	})
 }
 
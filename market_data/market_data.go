@@ -1,6 +1,12 @@
 package market_data
 
-import "github.com/quantfamily/lemonmarkets/client"
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/client"
+)
 
 // DataTypes that we use in this package
 type DataTypes interface {
@@ -13,6 +19,48 @@ type Item[data DataTypes, err error] struct {
 	Error err
 }
 
+// Drain consumes what is left on a channel in the background, so that the goroutine sending on it can exit
+func Drain[T any](ch <-chan T) {
+	go func() {
+		for range ch {
+		}
+	}()
+}
+
+/*
+fetch requests endpoint and passes every value to emit, following the pages until the last one.
+Requests are made with ctx, so that a request that hangs is canceled when ctx is done
+*/
+func fetch[T DataTypes](ctx context.Context, backend *client.Backend, endpoint string, query interface{}, emit func(T)) error {
+	response, err := backend.DoContext(ctx, "GET", endpoint, query, nil)
+	for {
+		if err != nil {
+			return err
+		}
+		var values []T
+		if err := json.Unmarshal(response.Results, &values); err != nil {
+			return err
+		}
+		for _, value := range values {
+			emit(value)
+		}
+		if response.Next == "" {
+			return nil
+		}
+		response, err = backend.DoContext(ctx, "GET", response.Next, nil, nil)
+	}
+}
+
+// returnData sends every value of endpoint to ch, followed by the error if a request fails
+func returnData[T DataTypes](backend *client.Backend, endpoint string, query interface{}, ch chan<- Item[T, error]) {
+	err := fetch(context.Background(), backend, endpoint, query, func(value T) {
+		ch <- Item[T, error]{value, nil}
+	})
+	if err != nil {
+		ch <- Item[T, error]{Error: err}
+	}
+}
+
 // Environment, pointing to a url that we use as backend base- url
 type Environment string
 
@@ -22,10 +70,16 @@ const BASE_URL string = "https://data.lemon.markets/v1"
 // MarketDataClient with methods that we use to fetch data
 type MarketDataClient struct {
 	backend *client.Backend
+	now     func() time.Time // replaced in tests, time.Now if nil
 }
 
 // NewClient takes APIKey and returns a MarketDataClient
 func NewClient(APIKey string) *MarketDataClient {
-	backend := client.Backend{APIKey: APIKey, BaseURL: BASE_URL}
+	return NewClientWithEnvironment(APIKey, Environment(BASE_URL))
+}
+
+// NewClientWithEnvironment takes APIKey and a base- url other than BASE_URL, such as a proxy or test server
+func NewClientWithEnvironment(APIKey string, environment Environment) *MarketDataClient {
+	backend := client.Backend{APIKey: APIKey, BaseURL: string(environment)}
 	return &MarketDataClient{backend: &backend}
 }
@@ -3,6 +3,7 @@ package market_data
 import (
 	"os"
 	"testing"
+	"time"
 )
 
 func IntegrationClient(t *testing.T) *MarketDataClient {
@@ -13,3 +14,21 @@ func IntegrationClient(t *testing.T) *MarketDataClient {
 	}
 	return NewClient(apiKey)
 }
+
+func TestDrain(t *testing.T) {
+	ch := make(chan Item[Quote, error])
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		for i := 0; i < 3; i++ {
+			ch <- Item[Quote, error]{}
+		}
+		close(ch)
+	}()
+	Drain(ch)
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("channel is not drained")
+	}
+}
@@ -1,9 +1,10 @@
 package market_data
 
 import (
-	"encoding/json"
 	"fmt"
 	"time"
+
+	"github.com/quantfamily/lemonmarkets/identifiers"
 )
 
 /*
@@ -20,6 +21,19 @@ type GetOHLCQuery struct {
 	Page    int       `url:"page,omitempty"`
 }
 
+/*
+Validate checks ISINs and MIC locally, so that typos fail without a request
+*/
+func (q *GetOHLCQuery) Validate() error {
+	if q == nil {
+		return nil
+	}
+	if err := identifiers.CheckISINs(q.ISIN...); err != nil {
+		return err
+	}
+	return identifiers.CheckMIC(q.MIC)
+}
+
 /*
 OHLC (Open, High, Low, Closed) containing information regarding how a instrument preformed during a period of time
 */
@@ -63,31 +77,9 @@ func (cl *MarketDataClient) GetOHLCPerDay(query *GetOHLCQuery) <-chan Item[OHLC,
 
 func (cl *MarketDataClient) returnOHLC(interval string, query *GetOHLCQuery, ch chan<- Item[OHLC, error]) {
 	defer close(ch)
-	response, err := cl.backend.Do("GET", fmt.Sprintf("ohlc/%s", interval), query, nil)
-	if err != nil {
-		ohlc := Item[OHLC, error]{}
-		ohlc.Error = err
-		ch <- ohlc
+	if err := query.Validate(); err != nil {
+		ch <- Item[OHLC, error]{Error: err}
 		return
 	}
-	for {
-		var ohlcs []OHLC
-		ohlc := Item[OHLC, error]{}
-		ohlc.Error = json.Unmarshal(response.Results, &ohlcs)
-		if ohlc.Error != nil {
-			ch <- ohlc
-			return
-		}
-		for _, ohlc := range ohlcs {
-			ch <- Item[OHLC, error]{ohlc, nil}
-		}
-		if response.Next == "" {
-			return
-		}
-		response, ohlc.Error = cl.backend.Do("GET", response.Next, nil, nil)
-		if ohlc.Error != nil {
-			ch <- ohlc
-			return
-		}
-	}
+	returnData(cl.backend, fmt.Sprintf("ohlc/%s", interval), query, ch)
 }
@@ -2,6 +2,7 @@ package market_data
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/quantfamily/lemonmarkets/client"
 	"github.com/quantfamily/lemonmarkets/client/helpers"
+	"github.com/quantfamily/lemonmarkets/identifiers"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -48,6 +50,18 @@ func TestGetOHLCs(t *testing.T) {
 		assert.NotNil(t, ohlc.Error)
 		assert.ObjectsAreEqual(&json.SyntaxError{}, ohlc.Error)
 	})
+	t.Run("invalid identifiers", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("invalid query was sent to the backend")
+		}))
+		defer server.Close()
+		backend := client.Backend{BaseURL: server.URL}
+		client := MarketDataClient{backend: &backend}
+		ohlc := <-client.GetOHLCPerDay(&GetOHLCQuery{ISIN: []string{"US0378331005", "US0378331006"}})
+		assert.True(t, errors.Is(ohlc.Error, identifiers.ErrInvalidISIN))
+		ohlc = <-client.GetOHLCPerDay(&GetOHLCQuery{ISIN: []string{"US0378331005"}, MIC: "MUNICH"})
+		assert.True(t, errors.Is(ohlc.Error, identifiers.ErrInvalidMIC))
+	})
 	t.Run("Successful test, m1", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			fmt.Fprint(w, string(rawFileBytes))
@@ -0,0 +1,319 @@
+package market_data
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/identifiers"
+)
+
+// ErrClosed is returned when subscribing to a closed stream
+var ErrClosed = errors.New("quote stream closed")
+
+/*
+QuoteStream delivers quotes of the subscribed ISINs as they change. The Poller implements it on top of GetQuotes,
+a realtime client implements it on top of the streaming endpoint, so that consumers can swap them.
+The Quotes channel is closed by Close
+*/
+type QuoteStream interface {
+	Subscribe(isins ...string) error
+	Unsubscribe(isins ...string) error
+	Quotes() <-chan Item[Quote, error]
+	Close() error
+}
+
+// QuoteSource is what the Poller needs, MarketDataClient or anything wrapping it such as a cache
+type QuoteSource interface {
+	GetQuotes(query *GetQuotesQuery) <-chan Item[Quote, error]
+}
+
+// LatestQuoteSource is a QuoteSource that can also return the latest quote per ISIN, such as MarketDataClient
+type LatestQuoteSource interface {
+	QuoteSource
+	GetLatestQuotes(query *GetQuotesQuery) <-chan Item[Quote, error]
+}
+
+var _ LatestQuoteSource = (*MarketDataClient)(nil)
+
+/*
+PollerConfig for a Poller. Interval is the time between polls and defaults to 5 seconds.
+RequestsPerMinute limits the requests to the rate limit of the data plan, polls take longer when
+there are more ISINs than fit in the limit, 0 means no limit. After failed requests the poller waits
+twice as long as before, up to MaxBackoff which defaults to one minute
+*/
+type PollerConfig struct {
+	MIC               string
+	Interval          time.Duration
+	RequestsPerMinute int
+	MaxBackoff        time.Duration
+}
+
+/*
+Poller is a QuoteStream for accounts without a realtime data plan (see Account.DataPlan).
+It polls the latest quotes of the subscribed ISINs in batches and only emits quotes newer than
+the last one emitted for the same ISIN. Errors are emitted as items without stopping the poller
+*/
+type Poller struct {
+	source QuoteSource
+	config PollerConfig
+
+	mu     sync.Mutex
+	latest map[string]time.Time
+	wake   chan struct{}
+	quotes chan Item[Quote, error]
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+var _ QuoteStream = (*Poller)(nil)
+
+// NewPoller starts polling, nothing is requested until the first Subscribe
+func NewPoller(source QuoteSource, config PollerConfig) *Poller {
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Second
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = time.Minute
+	}
+	p := &Poller{
+		source: source,
+		config: config,
+		latest: make(map[string]time.Time),
+		wake:   make(chan struct{}, 1),
+		quotes: make(chan Item[Quote, error]),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Subscribe adds ISINs to the poll, invalid ISINs are rejected before anything is added
+func (p *Poller) Subscribe(isins ...string) error {
+	if err := identifiers.CheckISINs(isins...); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	select {
+	case <-p.stop:
+		return ErrClosed
+	default:
+	}
+	added := false
+	for _, isin := range isins {
+		if _, ok := p.latest[isin]; !ok {
+			p.latest[isin] = time.Time{}
+			added = true
+		}
+	}
+	if added {
+		select {
+		case p.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Unsubscribe removes ISINs from the poll, quotes already requested may still be emitted
+func (p *Poller) Unsubscribe(isins ...string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, isin := range isins {
+		delete(p.latest, isin)
+	}
+	return nil
+}
+
+// Subscriptions are the subscribed ISINs, sorted
+func (p *Poller) Subscriptions() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	isins := make([]string, 0, len(p.latest))
+	for isin := range p.latest {
+		isins = append(isins, isin)
+	}
+	sort.Strings(isins)
+	return isins
+}
+
+// Quotes emits changed quotes and errors until Close
+func (p *Poller) Quotes() <-chan Item[Quote, error] {
+	return p.quotes
+}
+
+// Close stops polling and closes the Quotes channel
+func (p *Poller) Close() error {
+	p.once.Do(func() {
+		p.mu.Lock()
+		close(p.stop)
+		p.mu.Unlock()
+	})
+	<-p.done
+	return nil
+}
+
+func (p *Poller) run() {
+	defer close(p.done)
+	defer close(p.quotes)
+	var lastRequest time.Time
+	backoff := time.Duration(0)
+	for {
+		subscriptions := p.Subscriptions()
+		failed := false
+		batch := p.batch()
+		for start := 0; start < len(subscriptions); start += batch {
+			end := start + batch
+			if end > len(subscriptions) {
+				end = len(subscriptions)
+			}
+			if !p.wait(p.gap(lastRequest)) {
+				return
+			}
+			lastRequest = time.Now()
+			ok, stopped := p.poll(subscriptions[start:end])
+			if stopped {
+				return
+			}
+			failed = failed || !ok
+		}
+		wait := p.config.Interval
+		if failed {
+			backoff = 2 * backoff
+			if backoff == 0 {
+				backoff = p.config.Interval
+			}
+			if backoff > p.config.MaxBackoff {
+				backoff = p.config.MaxBackoff
+			}
+			wait = backoff
+		} else {
+			backoff = 0
+		}
+		if len(subscriptions) == 0 {
+			wait = -1
+		}
+		if !p.sleep(wait) {
+			return
+		}
+	}
+}
+
+/*
+batch is the number of ISINs per request. The newest quotes of several ISINs can all belong to the busiest
+of them, so without a LatestQuoteSource every ISIN is requested on its own
+*/
+func (p *Poller) batch() int {
+	if _, ok := p.source.(LatestQuoteSource); ok {
+		return snapshotBatch
+	}
+	return 1
+}
+
+// gap is how long to wait before the next request to stay within the rate limit
+func (p *Poller) gap(lastRequest time.Time) time.Duration {
+	if p.config.RequestsPerMinute <= 0 || lastRequest.IsZero() {
+		return 0
+	}
+	return time.Until(lastRequest.Add(time.Minute / time.Duration(p.config.RequestsPerMinute)))
+}
+
+// wait sleeps for d, false when the poller was closed
+func (p *Poller) wait(d time.Duration) bool {
+	if d <= 0 {
+		select {
+		case <-p.stop:
+			return false
+		default:
+			return true
+		}
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-p.stop:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// sleep waits for d or until new ISINs are subscribed, forever for negative d. False when the poller was closed
+func (p *Poller) sleep(d time.Duration) bool {
+	var timeout <-chan time.Time
+	if d >= 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-p.stop:
+		return false
+	case <-p.wake:
+		return true
+	case <-timeout:
+		return true
+	}
+}
+
+/*
+poll requests the newest quotes of a batch and emits those newer than the last emitted ones.
+A LatestQuoteSource is asked for the latest quotes, any other source for the newest quote of a single ISIN,
+so that ISINs without quotes do not page through the history and every poll is a single request.
+ok is false when the request failed, stopped is true when the poller was closed while emitting
+*/
+func (p *Poller) poll(isins []string) (ok bool, stopped bool) {
+	query := &GetQuotesQuery{ISIN: isins, MIC: p.config.MIC, Sorting: "newest_first", Limit: len(isins)}
+	var ch <-chan Item[Quote, error]
+	if latest, ok := p.source.(LatestQuoteSource); ok {
+		ch = latest.GetLatestQuotes(query)
+	} else {
+		ch = p.source.GetQuotes(query)
+	}
+	newest := make(map[string]Quote, len(isins))
+	for received := 0; received < query.Limit; received++ {
+		item, open := <-ch
+		if !open {
+			break
+		}
+		if item.Error != nil {
+			Drain(ch)
+			return false, !p.emit(Item[Quote, error]{Error: item.Error})
+		}
+		if current, seen := newest[item.Data.ISIN]; !seen || item.Data.Time.After(current.Time) {
+			newest[item.Data.ISIN] = item.Data
+		}
+	}
+	Drain(ch)
+	for _, isin := range isins {
+		quote, found := newest[isin]
+		if !found {
+			continue
+		}
+		p.mu.Lock()
+		last, subscribed := p.latest[isin]
+		changed := subscribed && quote.Time.After(last)
+		if changed {
+			p.latest[isin] = quote.Time
+		}
+		p.mu.Unlock()
+		if changed && !p.emit(Item[Quote, error]{Data: quote}) {
+			return true, true
+		}
+	}
+	return true, false
+}
+
+// emit sends an item, false when the poller was closed before it was received
+func (p *Poller) emit(item Item[Quote, error]) bool {
+	select {
+	case p.quotes <- item:
+		return true
+	case <-p.stop:
+		return false
+	}
+}
@@ -0,0 +1,204 @@
+package market_data
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/identifiers"
+	"github.com/stretchr/testify/assert"
+)
+
+// pollSource returns a quote per ISIN that changes on every second call and records the queries
+type pollSource struct {
+	mu      sync.Mutex
+	calls   int
+	queries []GetQuotesQuery
+	times   []time.Time
+	fail    map[int]error
+	missing string
+	busy    string
+	history int
+}
+
+func (s *pollSource) GetQuotes(query *GetQuotesQuery) <-chan Item[Quote, error] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	s.queries = append(s.queries, *query)
+	s.times = append(s.times, time.Now())
+	ch := make(chan Item[Quote, error], len(query.ISIN)*(s.history+1)+1)
+	defer close(ch)
+	if err, ok := s.fail[s.calls]; ok {
+		ch <- Item[Quote, error]{Error: err}
+		return ch
+	}
+	// every ISIN but the missing one has a quote and older ones behind it, newest first
+	t := time.Date(2022, 1, 3, 9, 0, s.calls/2, 0, time.UTC)
+	if s.busy != "" && contains(query.ISIN, s.busy) {
+		// the busy ISIN is quoted every millisecond and comes before all others
+		for i := 0; i <= s.history; i++ {
+			ch <- Item[Quote, error]{Data: Quote{ISIN: s.busy, Mic: query.MIC, Time: t.Add(-time.Duration(i) * time.Millisecond)}}
+		}
+		t = t.Add(-time.Second)
+	}
+	for i := 0; i <= s.history; i++ {
+		for _, isin := range query.ISIN {
+			if isin != s.missing && isin != s.busy {
+				ch <- Item[Quote, error]{Data: Quote{ISIN: isin, Mic: query.MIC, Bid: 100, Ask: 101, Time: t.Add(-time.Duration(i) * time.Hour)}}
+			}
+		}
+	}
+	return ch
+}
+
+// latestPollSource is a pollSource with a latest endpoint, only the latest quotes are requested from it
+type latestPollSource struct {
+	pollSource
+	latest int
+}
+
+func (s *latestPollSource) GetLatestQuotes(query *GetQuotesQuery) <-chan Item[Quote, error] {
+	s.mu.Lock()
+	s.latest++
+	s.mu.Unlock()
+	return s.pollSource.GetQuotes(query)
+}
+
+func (s *latestPollSource) GetQuotes(query *GetQuotesQuery) <-chan Item[Quote, error] {
+	panic("the history is not requested when there is a latest endpoint")
+}
+
+func contains(isins []string, isin string) bool {
+	for _, i := range isins {
+		if i == isin {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *pollSource) snapshot() ([]GetQuotesQuery, []time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]GetQuotesQuery(nil), s.queries...), append([]time.Time(nil), s.times...)
+}
+
+func TestPoller(t *testing.T) {
+	apple := "US0378331005"
+	tesla := "US88160R1014"
+
+	t.Run("emits only changed quotes", func(t *testing.T) {
+		source := &pollSource{}
+		poller := NewPoller(source, PollerConfig{MIC: "XMUN", Interval: time.Millisecond})
+		assert.Nil(t, poller.Subscribe(apple, tesla))
+		last := map[string]time.Time{}
+		for i := 0; i < 6; i++ {
+			item := <-poller.Quotes()
+			assert.Nil(t, item.Error)
+			assert.Equal(t, "XMUN", item.Data.Mic)
+			assert.True(t, item.Data.Time.After(last[item.Data.ISIN]), "no unchanged quotes")
+			last[item.Data.ISIN] = item.Data.Time
+		}
+		assert.Nil(t, poller.Close())
+		queries, _ := source.snapshot()
+		assert.Greater(t, len(queries), 3, "unchanged polls are not emitted")
+		assert.Equal(t, []string{apple}, queries[0].ISIN)
+		assert.Equal(t, []string{tesla}, queries[1].ISIN)
+		assert.Equal(t, "newest_first", queries[0].Sorting)
+		_, open := <-poller.Quotes()
+		assert.False(t, open)
+	})
+	t.Run("unsubscribe", func(t *testing.T) {
+		source := &pollSource{}
+		poller := NewPoller(source, PollerConfig{Interval: time.Millisecond})
+		defer poller.Close()
+		assert.Nil(t, poller.Subscribe(apple, tesla))
+		<-poller.Quotes()
+		assert.Nil(t, poller.Unsubscribe(tesla))
+		assert.Equal(t, []string{apple}, poller.Subscriptions())
+		for i := 0; i < 4; i++ {
+			<-poller.Quotes()
+		}
+		queries, _ := source.snapshot()
+		assert.Equal(t, []string{apple}, queries[len(queries)-1].ISIN)
+	})
+	t.Run("batches within the rate limit", func(t *testing.T) {
+		source := &latestPollSource{}
+		poller := NewPoller(source, PollerConfig{Interval: time.Hour, RequestsPerMinute: 3000})
+		isins := snapshotISINs(t, 12)
+		assert.Nil(t, poller.Subscribe(isins...))
+		for range isins {
+			<-poller.Quotes()
+		}
+		assert.Nil(t, poller.Close())
+		queries, times := source.snapshot()
+		assert.Len(t, queries, 2)
+		assert.Len(t, queries[0].ISIN, 10)
+		assert.Len(t, queries[1].ISIN, 2)
+		assert.GreaterOrEqual(t, times[1].Sub(times[0]), 19*time.Millisecond)
+	})
+	t.Run("ISIN without quotes", func(t *testing.T) {
+		source := &pollSource{missing: tesla, history: 5}
+		poller := NewPoller(source, PollerConfig{Interval: time.Millisecond})
+		assert.Nil(t, poller.Subscribe(apple, tesla))
+		for i := 0; i < 3; i++ {
+			item := <-poller.Quotes()
+			assert.Nil(t, item.Error)
+			assert.Equal(t, apple, item.Data.ISIN)
+		}
+		assert.Nil(t, poller.Close())
+		queries, _ := source.snapshot()
+		for _, query := range queries {
+			assert.Equal(t, 1, query.Limit, "at most one quote per ISIN")
+		}
+	})
+	t.Run("busy ISIN does not hide the others", func(t *testing.T) {
+		source := &pollSource{busy: apple, history: 5}
+		poller := NewPoller(source, PollerConfig{Interval: time.Hour})
+		assert.Nil(t, poller.Subscribe(apple, tesla))
+		received := map[string]bool{}
+		for i := 0; i < 2; i++ {
+			item := <-poller.Quotes()
+			assert.Nil(t, item.Error)
+			received[item.Data.ISIN] = true
+		}
+		assert.Nil(t, poller.Close())
+		assert.Equal(t, map[string]bool{apple: true, tesla: true}, received)
+		queries, _ := source.snapshot()
+		for _, query := range queries {
+			assert.Len(t, query.ISIN, 1, "one ISIN per request without a latest endpoint")
+		}
+	})
+	t.Run("latest quotes", func(t *testing.T) {
+		source := &latestPollSource{pollSource: pollSource{missing: tesla}}
+		poller := NewPoller(source, PollerConfig{Interval: time.Millisecond})
+		assert.Nil(t, poller.Subscribe(apple, tesla))
+		item := <-poller.Quotes()
+		assert.Equal(t, apple, item.Data.ISIN)
+		assert.Nil(t, poller.Close())
+		queries, _ := source.snapshot()
+		assert.Equal(t, len(queries), source.latest)
+	})
+	t.Run("fail to get response", func(t *testing.T) {
+		source := &pollSource{fail: map[int]error{1: errors.New("unknown http error from backend: 429")}}
+		poller := NewPoller(source, PollerConfig{Interval: time.Millisecond})
+		defer poller.Close()
+		assert.Nil(t, poller.Subscribe(apple))
+		item := <-poller.Quotes()
+		assert.EqualError(t, item.Error, "unknown http error from backend: 429")
+		item = <-poller.Quotes()
+		assert.Nil(t, item.Error)
+		assert.Equal(t, apple, item.Data.ISIN)
+	})
+	t.Run("invalid ISINs and closed poller", func(t *testing.T) {
+		poller := NewPoller(&pollSource{}, PollerConfig{})
+		err := poller.Subscribe(apple, "US0378331006")
+		assert.True(t, errors.Is(err, identifiers.ErrInvalidISIN))
+		assert.Empty(t, poller.Subscriptions())
+		assert.Nil(t, poller.Close())
+		assert.Nil(t, poller.Close())
+		assert.Equal(t, ErrClosed, poller.Subscribe(apple))
+	})
+}
@@ -1,8 +1,9 @@
 package market_data
 
 import (
-	"encoding/json"
 	"time"
+
+	"github.com/quantfamily/lemonmarkets/identifiers"
 )
 
 /*
@@ -19,6 +20,19 @@ type GetQuotesQuery struct {
 	Page    int       `url:"page,omitempty"`
 }
 
+/*
+Validate checks ISINs and MIC locally, so that typos fail without a request
+*/
+func (q *GetQuotesQuery) Validate() error {
+	if q == nil {
+		return nil
+	}
+	if err := identifiers.CheckISINs(q.ISIN...); err != nil {
+		return err
+	}
+	return identifiers.CheckMIC(q.MIC)
+}
+
 /*
 Quote contains quote data for a specific asset known by its ISIN
 */
@@ -37,37 +51,24 @@ GetQuotes takes a possible query parameter and returns Response containing one o
 */
 func (cl *MarketDataClient) GetQuotes(query *GetQuotesQuery) <-chan Item[Quote, error] {
 	ch := make(chan Item[Quote, error])
-	go cl.returnQuotes(query, ch)
+	go cl.returnQuotes("quotes", query, ch)
 	return ch
 }
 
-func (cl *MarketDataClient) returnQuotes(query *GetQuotesQuery, ch chan<- Item[Quote, error]) {
+/*
+GetLatestQuotes returns the latest quote of every ISIN in the query, From, To and Sorting are ignored
+*/
+func (cl *MarketDataClient) GetLatestQuotes(query *GetQuotesQuery) <-chan Item[Quote, error] {
+	ch := make(chan Item[Quote, error])
+	go cl.returnQuotes("quotes/latest", query, ch)
+	return ch
+}
+
+func (cl *MarketDataClient) returnQuotes(endpoint string, query *GetQuotesQuery, ch chan<- Item[Quote, error]) {
 	defer close(ch)
-	response, err := cl.backend.Do("GET", "quotes", query, nil)
-	if err != nil {
-		quote := Item[Quote, error]{}
-		quote.Error = err
-		ch <- quote
+	if err := query.Validate(); err != nil {
+		ch <- Item[Quote, error]{Error: err}
 		return
 	}
-	for {
-		var quotes []Quote
-		quote := Item[Quote, error]{}
-		quote.Error = json.Unmarshal(response.Results, &quotes)
-		if quote.Error != nil {
-			ch <- quote
-			return
-		}
-		for _, quote := range quotes {
-			ch <- Item[Quote, error]{quote, nil}
-		}
-		if response.Next == "" {
-			return
-		}
-		response, quote.Error = cl.backend.Do("GET", response.Next, nil, nil)
-		if quote.Error != nil {
-			ch <- quote
-			return
-		}
-	}
+	returnData(cl.backend, endpoint, query, ch)
 }
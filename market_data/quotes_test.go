@@ -62,6 +62,20 @@ func TestGetQuotes(t *testing.T) {
 	})
 }
 
+func TestGetLatestQuotes(t *testing.T) {
+	rawFileBytes := helpers.ParseFile(t, "get_quotes.json")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/quotes/latest", r.URL.Path)
+		fmt.Fprint(w, string(rawFileBytes))
+	}))
+	defer server.Close()
+	backend := client.Backend{BaseURL: server.URL}
+	client := MarketDataClient{backend: &backend}
+	quote := <-client.GetLatestQuotes(&GetQuotesQuery{ISIN: []string{"US88160R1014"}})
+	assert.Nil(t, quote.Error)
+	assert.NotEmpty(t, quote.Data.ISIN)
+}
+
 func TestGetQuotesIntegration(t *testing.T) {
 	client := IntegrationClient(t)
 	quotesq := GetQuotesQuery{ISIN: []string{"SE0000115446"}}
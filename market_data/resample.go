@@ -0,0 +1,274 @@
+package market_data
+
+import (
+	"sort"
+	"time"
+)
+
+/*
+Interval of resampled bars, exactly one field should be set.
+Duration is for intraday bars such as 5 minutes or 4 hours, Days, Weeks and Months for longer bars
+*/
+type Interval struct {
+	Duration time.Duration
+	Days     int
+	Weeks    int
+	Months   int
+}
+
+// Commonly used intervals
+var (
+	FiveMinutes    = Interval{Duration: 5 * time.Minute}
+	FifteenMinutes = Interval{Duration: 15 * time.Minute}
+	ThirtyMinutes  = Interval{Duration: 30 * time.Minute}
+	FourHours      = Interval{Duration: 4 * time.Hour}
+	Daily          = Interval{Days: 1}
+	Weekly         = Interval{Weeks: 1}
+	Monthly        = Interval{Months: 1}
+)
+
+/*
+Session is the trading hours of a venue, Open and Close are offsets from local midnight
+*/
+type Session struct {
+	Open  time.Duration
+	Close time.Duration
+}
+
+// contains is true when the offset from local midnight is within the session
+func (s Session) contains(offset time.Duration) bool {
+	return offset >= s.Open && offset < s.Close
+}
+
+/*
+ResampleConfig for a Resampler. Location is used to align bars and defaults to Europe/Berlin.
+With a Session, intraday bars are aligned to the session open, never span two sessions and
+input outside the session is dropped. Longer bars are aligned to local midnight, weeks start on monday
+and months on the first
+*/
+type ResampleConfig struct {
+	Interval Interval
+	Location *time.Location
+	Session  *Session
+}
+
+// resampleKey groups bars per instrument and venue
+type resampleKey struct {
+	isin string
+	mic  string
+}
+
+/*
+Resampler aggregates bars or trades of any number of instruments and venues into bars of a longer interval.
+Input has to be sorted oldest first per instrument and venue
+*/
+type Resampler struct {
+	config ResampleConfig
+	bars   map[resampleKey]*OHLC
+}
+
+// NewResampler returns a resampler, it fails if Location is not set and Europe/Berlin can not be loaded
+func NewResampler(config ResampleConfig) (*Resampler, error) {
+	if config.Location == nil {
+		location, err := time.LoadLocation("Europe/Berlin")
+		if err != nil {
+			return nil, err
+		}
+		config.Location = location
+	}
+	return &Resampler{config: config, bars: make(map[resampleKey]*OHLC)}, nil
+}
+
+/*
+Add aggregates a bar and returns the bar of the same instrument and venue it completed, if any
+*/
+func (r *Resampler) Add(bar OHLC) []OHLC {
+	start, ok := r.start(bar.Time)
+	if !ok {
+		return nil
+	}
+	key := resampleKey{isin: bar.ISIN, mic: bar.Mic}
+	current, exists := r.bars[key]
+	var completed []OHLC
+	if exists && !current.Time.Equal(start) {
+		completed = append(completed, *current)
+		exists = false
+	}
+	if !exists {
+		r.bars[key] = &OHLC{ISIN: bar.ISIN, Mic: bar.Mic, Time: start, Open: bar.Open, High: bar.High, Low: bar.Low, Close: bar.Close, Volume: bar.Volume}
+		return completed
+	}
+	if bar.High > current.High {
+		current.High = bar.High
+	}
+	if bar.Low < current.Low {
+		current.Low = bar.Low
+	}
+	current.Close = bar.Close
+	current.Volume += bar.Volume
+	return completed
+}
+
+// AddTrade aggregates a trade like a bar where open, high, low and close are the trade price
+func (r *Resampler) AddTrade(trade Trade) []OHLC {
+	price := float64(trade.Price)
+	return r.Add(OHLC{ISIN: trade.ISIN, Mic: trade.Mic, Time: trade.Time, Open: price, High: price, Low: price, Close: price, Volume: trade.Volume})
+}
+
+// Flush returns all bars that are not completed yet sorted by time, ISIN and MIC, and resets the resampler
+func (r *Resampler) Flush() []OHLC {
+	bars := make([]OHLC, 0, len(r.bars))
+	for _, bar := range r.bars {
+		bars = append(bars, *bar)
+	}
+	sortOHLC(bars)
+	r.bars = make(map[resampleKey]*OHLC)
+	return bars
+}
+
+// start returns the start of the bar a time belongs to, false if it is outside the session
+func (r *Resampler) start(t time.Time) (time.Time, bool) {
+	location := r.config.Location
+	local := t.In(location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	interval := r.config.Interval
+	switch {
+	case interval.Duration > 0:
+		// Offsets are wall clock time so that sessions keep their hours on daylight saving days
+		offset := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute +
+			time.Duration(local.Second())*time.Second + time.Duration(local.Nanosecond())
+		origin := time.Duration(0)
+		if session := r.config.Session; session != nil {
+			if !session.contains(offset) {
+				return time.Time{}, false
+			}
+			origin = session.Open
+		}
+		offset = origin + (offset-origin)/interval.Duration*interval.Duration
+		return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, int(offset), location), true
+	case interval.Weeks > 0:
+		monday := midnight.AddDate(0, 0, -((int(midnight.Weekday()) + 6) % 7))
+		weeks := daysSinceEpoch(monday) / 7
+		return monday.AddDate(0, 0, -7*(floorMod(weeks, interval.Weeks))), true
+	case interval.Months > 0:
+		months := local.Year()*12 + int(local.Month()) - 1
+		months -= floorMod(months, interval.Months)
+		return time.Date(months/12, time.Month(months%12+1), 1, 0, 0, 0, 0, location), true
+	case interval.Days > 1:
+		return midnight.AddDate(0, 0, -floorMod(daysSinceEpoch(midnight), interval.Days)), true
+	}
+	return midnight, true
+}
+
+// daysSinceEpoch counts calendar days, independent of daylight saving time
+func daysSinceEpoch(day time.Time) int {
+	utc := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	return int(utc.Unix() / 86400)
+}
+
+func floorMod(a, b int) int {
+	return ((a % b) + b) % b
+}
+
+func sortOHLC(bars []OHLC) {
+	sort.SliceStable(bars, func(i, j int) bool {
+		if !bars[i].Time.Equal(bars[j].Time) {
+			return bars[i].Time.Before(bars[j].Time)
+		}
+		if bars[i].ISIN != bars[j].ISIN {
+			return bars[i].ISIN < bars[j].ISIN
+		}
+		return bars[i].Mic < bars[j].Mic
+	})
+}
+
+/*
+ResampleOHLC aggregates bars into the configured interval, the result is sorted by time, ISIN and MIC
+*/
+func ResampleOHLC(bars []OHLC, config ResampleConfig) ([]OHLC, error) {
+	resampler, err := NewResampler(config)
+	if err != nil {
+		return nil, err
+	}
+	var result []OHLC
+	for _, bar := range bars {
+		result = append(result, resampler.Add(bar)...)
+	}
+	result = append(result, resampler.Flush()...)
+	sortOHLC(result)
+	return result, nil
+}
+
+/*
+TradesToOHLC builds bars of the configured interval from trades, the result is sorted by time, ISIN and MIC
+*/
+func TradesToOHLC(trades []Trade, config ResampleConfig) ([]OHLC, error) {
+	resampler, err := NewResampler(config)
+	if err != nil {
+		return nil, err
+	}
+	var result []OHLC
+	for _, trade := range trades {
+		result = append(result, resampler.AddTrade(trade)...)
+	}
+	result = append(result, resampler.Flush()...)
+	sortOHLC(result)
+	return result, nil
+}
+
+/*
+Resample aggregates bars from a channel, such as GetOHLCPerMinute sorted oldest first.
+Bars are sent as soon as they are completed, the remaining bars when the input is closed
+*/
+func Resample(in <-chan Item[OHLC, error], config ResampleConfig) <-chan Item[OHLC, error] {
+	ch := make(chan Item[OHLC, error])
+	go func() {
+		defer close(ch)
+		resampler, err := NewResampler(config)
+		if err != nil {
+			ch <- Item[OHLC, error]{Error: err}
+			return
+		}
+		for bar := range in {
+			if bar.Error != nil {
+				ch <- Item[OHLC, error]{Error: bar.Error}
+				return
+			}
+			for _, completed := range resampler.Add(bar.Data) {
+				ch <- Item[OHLC, error]{completed, nil}
+			}
+		}
+		for _, remaining := range resampler.Flush() {
+			ch <- Item[OHLC, error]{remaining, nil}
+		}
+	}()
+	return ch
+}
+
+/*
+ResampleTrades builds bars from a channel of trades, such as GetTrades sorted oldest first
+*/
+func ResampleTrades(in <-chan Item[Trade, error], config ResampleConfig) <-chan Item[OHLC, error] {
+	ch := make(chan Item[OHLC, error])
+	go func() {
+		defer close(ch)
+		resampler, err := NewResampler(config)
+		if err != nil {
+			ch <- Item[OHLC, error]{Error: err}
+			return
+		}
+		for trade := range in {
+			if trade.Error != nil {
+				ch <- Item[OHLC, error]{Error: trade.Error}
+				return
+			}
+			for _, completed := range resampler.AddTrade(trade.Data) {
+				ch <- Item[OHLC, error]{completed, nil}
+			}
+		}
+		for _, remaining := range resampler.Flush() {
+			ch <- Item[OHLC, error]{remaining, nil}
+		}
+	}()
+	return ch
+}
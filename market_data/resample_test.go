@@ -0,0 +1,164 @@
+package market_data
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func berlin(t *testing.T) *time.Location {
+	location, err := time.LoadLocation("Europe/Berlin")
+	assert.Nil(t, err)
+	return location
+}
+
+func minuteBar(isin, mic string, t time.Time, open, high, low, close float64, volume int) OHLC {
+	return OHLC{ISIN: isin, Mic: mic, Time: t, Open: open, High: high, Low: low, Close: close, Volume: volume}
+}
+
+func TestResampleOHLC(t *testing.T) {
+	location := berlin(t)
+	nine := time.Date(2022, 3, 1, 9, 0, 0, 0, location)
+
+	t.Run("Successful test, 5 minutes", func(t *testing.T) {
+		bars := []OHLC{
+			minuteBar("A", "XMUN", nine, 10, 11, 9, 10.5, 1),
+			minuteBar("A", "XMUN", nine.Add(time.Minute), 10.5, 12, 10, 11, 2),
+			minuteBar("A", "XMUN", nine.Add(4*time.Minute), 11, 11, 8, 9, 3),
+			minuteBar("A", "XMUN", nine.Add(5*time.Minute), 9, 9.5, 9, 9.5, 4),
+		}
+		result, err := ResampleOHLC(bars, ResampleConfig{Interval: FiveMinutes, Location: location})
+		assert.Nil(t, err)
+		assert.Equal(t, []OHLC{
+			minuteBar("A", "XMUN", nine, 10, 12, 8, 9, 6),
+			minuteBar("A", "XMUN", nine.Add(5*time.Minute), 9, 9.5, 9, 9.5, 4),
+		}, result)
+	})
+	t.Run("grouped per ISIN and MIC", func(t *testing.T) {
+		bars := []OHLC{
+			minuteBar("A", "XMUN", nine, 10, 10, 10, 10, 1),
+			minuteBar("A", "LMBPX", nine, 20, 20, 20, 20, 1),
+			minuteBar("B", "XMUN", nine, 30, 30, 30, 30, 1),
+			minuteBar("A", "XMUN", nine.Add(time.Minute), 11, 11, 11, 11, 1),
+		}
+		result, err := ResampleOHLC(bars, ResampleConfig{Interval: FifteenMinutes, Location: location})
+		assert.Nil(t, err)
+		assert.Len(t, result, 3)
+		assert.Equal(t, minuteBar("A", "LMBPX", nine, 20, 20, 20, 20, 1), result[0])
+		assert.Equal(t, minuteBar("A", "XMUN", nine, 10, 11, 10, 11, 2), result[1])
+		assert.Equal(t, "B", result[2].ISIN)
+	})
+	t.Run("session boundaries", func(t *testing.T) {
+		session := &Session{Open: 8 * time.Hour, Close: 22 * time.Hour}
+		day := time.Date(2022, 3, 1, 0, 0, 0, 0, location)
+		bars := []OHLC{
+			minuteBar("A", "XMUN", day.Add(7*time.Hour+59*time.Minute), 1, 1, 1, 1, 1),
+			minuteBar("A", "XMUN", day.Add(8*time.Hour), 2, 2, 2, 2, 1),
+			minuteBar("A", "XMUN", day.Add(11*time.Hour+59*time.Minute), 3, 3, 3, 3, 1),
+			minuteBar("A", "XMUN", day.Add(21*time.Hour+59*time.Minute), 4, 4, 4, 4, 1),
+			minuteBar("A", "XMUN", day.Add(22*time.Hour), 5, 5, 5, 5, 1),
+		}
+		result, err := ResampleOHLC(bars, ResampleConfig{Interval: FourHours, Location: location, Session: session})
+		assert.Nil(t, err)
+		assert.Equal(t, []OHLC{
+			minuteBar("A", "XMUN", day.Add(8*time.Hour), 2, 3, 2, 3, 2),
+			minuteBar("A", "XMUN", day.Add(20*time.Hour), 4, 4, 4, 4, 1),
+		}, result)
+	})
+	t.Run("session on daylight saving day", func(t *testing.T) {
+		session := &Session{Open: 8 * time.Hour, Close: 22 * time.Hour}
+		open := time.Date(2022, 3, 27, 8, 0, 0, 0, location)
+		result, err := ResampleOHLC([]OHLC{minuteBar("A", "XMUN", open.Add(time.Minute), 1, 1, 1, 1, 1)}, ResampleConfig{Interval: Interval{Duration: time.Hour}, Location: location, Session: session})
+		assert.Nil(t, err)
+		assert.True(t, open.Equal(result[0].Time))
+	})
+	t.Run("daily bars in Europe/Berlin", func(t *testing.T) {
+		// 23:30 UTC is already the next day in Berlin
+		late := time.Date(2022, 3, 1, 23, 30, 0, 0, time.UTC)
+		bars := []OHLC{
+			minuteBar("A", "XMUN", late.Add(-time.Hour), 1, 1, 1, 1, 1),
+			minuteBar("A", "XMUN", late, 2, 2, 2, 2, 1),
+		}
+		result, err := ResampleOHLC(bars, ResampleConfig{Interval: Daily})
+		assert.Nil(t, err)
+		assert.Len(t, result, 2)
+		assert.True(t, time.Date(2022, 3, 2, 0, 0, 0, 0, location).Equal(result[1].Time))
+	})
+	t.Run("weekly and monthly", func(t *testing.T) {
+		bars := []OHLC{
+			minuteBar("A", "XMUN", time.Date(2022, 2, 27, 12, 0, 0, 0, location), 1, 1, 1, 1, 1),
+			minuteBar("A", "XMUN", time.Date(2022, 2, 28, 12, 0, 0, 0, location), 2, 2, 2, 2, 1),
+			minuteBar("A", "XMUN", time.Date(2022, 3, 4, 12, 0, 0, 0, location), 3, 3, 3, 3, 1),
+		}
+		weekly, err := ResampleOHLC(bars, ResampleConfig{Interval: Weekly, Location: location})
+		assert.Nil(t, err)
+		assert.Len(t, weekly, 2)
+		assert.True(t, time.Date(2022, 2, 21, 0, 0, 0, 0, location).Equal(weekly[0].Time))
+		assert.True(t, time.Date(2022, 2, 28, 0, 0, 0, 0, location).Equal(weekly[1].Time))
+		assert.Equal(t, 2, weekly[1].Volume)
+
+		monthly, err := ResampleOHLC(bars, ResampleConfig{Interval: Monthly, Location: location})
+		assert.Nil(t, err)
+		assert.Len(t, monthly, 2)
+		assert.Equal(t, 2.0, monthly[0].Close)
+
+		quarterly, err := ResampleOHLC(bars, ResampleConfig{Interval: Interval{Months: 3}, Location: location})
+		assert.Nil(t, err)
+		assert.Len(t, quarterly, 1)
+		assert.True(t, time.Date(2022, 1, 1, 0, 0, 0, 0, location).Equal(quarterly[0].Time))
+	})
+}
+
+func TestTradesToOHLC(t *testing.T) {
+	location := berlin(t)
+	nine := time.Date(2022, 3, 1, 9, 0, 0, 0, location)
+	trades := []Trade{
+		{ISIN: "A", Mic: "XMUN", Price: 10, Volume: 5, Time: nine.Add(10 * time.Second)},
+		{ISIN: "A", Mic: "XMUN", Price: 12, Volume: 1, Time: nine.Add(20 * time.Second)},
+		{ISIN: "A", Mic: "XMUN", Price: 9, Volume: 2, Time: nine.Add(30 * time.Second)},
+		{ISIN: "A", Mic: "XMUN", Price: 11, Volume: 1, Time: nine.Add(70 * time.Second)},
+	}
+	result, err := TradesToOHLC(trades, ResampleConfig{Interval: Interval{Duration: time.Minute}, Location: location})
+	assert.Nil(t, err)
+	assert.Equal(t, []OHLC{
+		minuteBar("A", "XMUN", nine, 10, 12, 9, 9, 8),
+		minuteBar("A", "XMUN", nine.Add(time.Minute), 11, 11, 11, 11, 1),
+	}, result)
+}
+
+func TestResample(t *testing.T) {
+	location := berlin(t)
+	nine := time.Date(2022, 3, 1, 9, 0, 0, 0, location)
+
+	t.Run("Successful test", func(t *testing.T) {
+		in := make(chan Item[OHLC, error], 3)
+		in <- Item[OHLC, error]{Data: minuteBar("A", "XMUN", nine, 1, 1, 1, 1, 1)}
+		in <- Item[OHLC, error]{Data: minuteBar("A", "XMUN", nine.Add(5*time.Minute), 2, 2, 2, 2, 1)}
+		in <- Item[OHLC, error]{Data: minuteBar("A", "XMUN", nine.Add(6*time.Minute), 3, 3, 3, 3, 1)}
+		close(in)
+		var bars []OHLC
+		for bar := range Resample(in, ResampleConfig{Interval: FiveMinutes, Location: location}) {
+			assert.Nil(t, bar.Error)
+			bars = append(bars, bar.Data)
+		}
+		assert.Len(t, bars, 2)
+		assert.Equal(t, 2, bars[1].Volume)
+	})
+	t.Run("fail to get response", func(t *testing.T) {
+		in := make(chan Item[OHLC, error], 1)
+		in <- Item[OHLC, error]{Error: errors.New("not found")}
+		close(in)
+		bar := <-Resample(in, ResampleConfig{Interval: FiveMinutes, Location: location})
+		assert.NotNil(t, bar.Error)
+	})
+	t.Run("trades", func(t *testing.T) {
+		in := make(chan Item[Trade, error], 1)
+		in <- Item[Trade, error]{Data: Trade{ISIN: "A", Price: 1, Volume: 1, Time: nine}}
+		close(in)
+		bar := <-ResampleTrades(in, ResampleConfig{Interval: FiveMinutes, Location: location})
+		assert.Nil(t, bar.Error)
+		assert.Equal(t, 1.0, bar.Data.Close)
+	})
+}
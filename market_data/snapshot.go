@@ -0,0 +1,209 @@
+package market_data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/client"
+	"github.com/quantfamily/lemonmarkets/identifiers"
+)
+
+// snapshotBatch is the number of ISINs the API accepts per request
+const snapshotBatch = 10
+
+/*
+Snapshot of an instrument: latest quote, last trade, today's daily bar and the bar of the previous trading day.
+Fields the venue has no data for are nil, Error is the first error of the requests for this ISIN
+*/
+type Snapshot struct {
+	ISIN     string
+	Quote    *Quote
+	Trade    *Trade
+	Today    *OHLC
+	Previous *OHLC
+	Error    error
+}
+
+// PreviousClose is the close of the previous trading day, 0 if unknown
+func (s Snapshot) PreviousClose() float64 {
+	if s.Previous == nil {
+		return 0
+	}
+	return s.Previous.Close
+}
+
+/*
+Snapshot fetches latest quote, last trade and recent daily bars of many ISINs at once. ISINs are batched
+into as few requests as the API allows and the requests run concurrently. Errors are reported per ISIN,
+an invalid ISIN or a failed batch does not fail the other ISINs. When ctx is done before all requests
+returned, ISINs still waiting get ctx.Err()
+*/
+func (cl *MarketDataClient) Snapshot(ctx context.Context, isins []string, mic string) map[string]Snapshot {
+	snapshots := make(map[string]Snapshot, len(isins))
+	micErr := identifiers.CheckMIC(mic)
+	var valid []string
+	for _, isin := range isins {
+		if _, ok := snapshots[isin]; ok {
+			continue
+		}
+		snapshot := Snapshot{ISIN: isin, Error: micErr}
+		if snapshot.Error == nil {
+			snapshot.Error = identifiers.CheckISINs(isin)
+		}
+		if snapshot.Error == nil {
+			valid = append(valid, isin)
+		}
+		snapshots[isin] = snapshot
+	}
+
+	now := time.Now()
+	if cl.now != nil {
+		now = cl.now()
+	}
+	var batches [][]string
+	for start := 0; start < len(valid); start += snapshotBatch {
+		end := start + snapshotBatch
+		if end > len(valid) {
+			end = len(valid)
+		}
+		batches = append(batches, valid[start:end])
+	}
+	parts := make(chan snapshotPart, 3*len(batches))
+	for _, batch := range batches {
+		go func(batch []string) {
+			parts <- cl.snapshotQuotes(ctx, batch, mic)
+		}(batch)
+		go func(batch []string) {
+			parts <- cl.snapshotTrades(ctx, batch, mic)
+		}(batch)
+		go func(batch []string) {
+			parts <- cl.snapshotBars(ctx, batch, mic, now)
+		}(batch)
+	}
+
+	pending := make(map[string]int, len(valid))
+	for _, isin := range valid {
+		pending[isin] = 3
+	}
+	for received := 0; received < cap(parts); received++ {
+		select {
+		case part := <-parts:
+			for _, isin := range part.isins {
+				snapshot := snapshots[isin]
+				part.apply(&snapshot)
+				snapshots[isin] = snapshot
+				pending[isin]--
+			}
+		case <-ctx.Done():
+			for isin, count := range pending {
+				if snapshot := snapshots[isin]; count > 0 && snapshot.Error == nil {
+					snapshot.Error = ctx.Err()
+					snapshots[isin] = snapshot
+				}
+			}
+			return snapshots
+		}
+	}
+	return snapshots
+}
+
+// snapshotPart is the result of one request for a batch of ISINs
+type snapshotPart struct {
+	isins []string
+	apply func(snapshot *Snapshot)
+}
+
+// failed applies err to every ISIN of the batch
+func failed(isins []string, what string, err error) snapshotPart {
+	return snapshotPart{isins: isins, apply: func(snapshot *Snapshot) {
+		if snapshot.Error == nil {
+			snapshot.Error = fmt.Errorf("%s: %w", what, err)
+		}
+	}}
+}
+
+func (cl *MarketDataClient) snapshotQuotes(ctx context.Context, isins []string, mic string) snapshotPart {
+	quotes, err := fetchAll[Quote](ctx, cl.backend, "quotes/latest", &GetQuotesQuery{ISIN: isins, MIC: mic})
+	if err != nil {
+		return failed(isins, "quotes", err)
+	}
+	latest := make(map[string]Quote, len(quotes))
+	for _, quote := range quotes {
+		if current, ok := latest[quote.ISIN]; !ok || quote.Time.After(current.Time) {
+			latest[quote.ISIN] = quote
+		}
+	}
+	return snapshotPart{isins: isins, apply: func(snapshot *Snapshot) {
+		if quote, ok := latest[snapshot.ISIN]; ok {
+			snapshot.Quote = &quote
+		}
+	}}
+}
+
+func (cl *MarketDataClient) snapshotTrades(ctx context.Context, isins []string, mic string) snapshotPart {
+	trades, err := fetchAll[Trade](ctx, cl.backend, "trades/latest", &GetTradesQuery{ISIN: isins, MIC: mic})
+	if err != nil {
+		return failed(isins, "trades", err)
+	}
+	latest := make(map[string]Trade, len(trades))
+	for _, trade := range trades {
+		if current, ok := latest[trade.ISIN]; !ok || trade.Time.After(current.Time) {
+			latest[trade.ISIN] = trade
+		}
+	}
+	return snapshotPart{isins: isins, apply: func(snapshot *Snapshot) {
+		if trade, ok := latest[snapshot.ISIN]; ok {
+			snapshot.Trade = &trade
+		}
+	}}
+}
+
+/*
+snapshotBars fetches the daily bars of the last two weeks, long enough to find the previous trading day
+across holidays. Daily bars are dated at midnight UTC of their trading day
+*/
+func (cl *MarketDataClient) snapshotBars(ctx context.Context, isins []string, mic string, now time.Time) snapshotPart {
+	query := &GetOHLCQuery{ISIN: isins, MIC: mic, From: now.AddDate(0, 0, -14), To: now, Sorting: "oldest_first"}
+	bars, err := fetchAll[OHLC](ctx, cl.backend, "ohlc/d1", query)
+	if err != nil {
+		return failed(isins, "ohlc", err)
+	}
+	location, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		location = time.UTC
+	}
+	year, month, day := now.In(location).Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	todays := make(map[string]OHLC)
+	previous := make(map[string]OHLC)
+	for _, bar := range bars {
+		year, month, day := bar.Time.UTC().Date()
+		date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		switch {
+		case date.Equal(today):
+			todays[bar.ISIN] = bar
+		case date.Before(today):
+			if current, ok := previous[bar.ISIN]; !ok || bar.Time.After(current.Time) {
+				previous[bar.ISIN] = bar
+			}
+		}
+	}
+	return snapshotPart{isins: isins, apply: func(snapshot *Snapshot) {
+		if bar, ok := todays[snapshot.ISIN]; ok {
+			snapshot.Today = &bar
+		}
+		if bar, ok := previous[snapshot.ISIN]; ok {
+			snapshot.Previous = &bar
+		}
+	}}
+}
+
+// fetchAll collects the values of every page of endpoint
+func fetchAll[T DataTypes](ctx context.Context, backend *client.Backend, endpoint string, query interface{}) ([]T, error) {
+	var values []T
+	if err := fetch(ctx, backend, endpoint, query, func(value T) { values = append(values, value) }); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
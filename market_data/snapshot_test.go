@@ -0,0 +1,174 @@
+package market_data
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/client"
+	"github.com/quantfamily/lemonmarkets/identifiers"
+	"github.com/stretchr/testify/assert"
+)
+
+func snapshotISINs(t *testing.T, n int) []string {
+	isins := make([]string, n)
+	for i := range isins {
+		isin, err := identifiers.NewISIN("DE", fmt.Sprintf("%09d", i+1))
+		assert.Nil(t, err)
+		isins[i] = string(isin)
+	}
+	return isins
+}
+
+// snapshotServer answers the latest quotes, latest trades and daily bars for every requested ISIN but missing
+type snapshotServer struct {
+	mu       sync.Mutex
+	requests []string
+	missing  string
+	failing  string
+}
+
+func (s *snapshotServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	isins := r.URL.Query()["isin"]
+	s.mu.Lock()
+	s.requests = append(s.requests, r.URL.Path+" "+strings.Join(isins, ","))
+	s.mu.Unlock()
+	if s.failing != "" && r.URL.Path == "/trades/latest" && strings.Contains(strings.Join(isins, ","), s.failing) {
+		http.Error(w, `{"status": "error", "error_code": "internal", "error_message": "trades are down"}`, 400)
+		return
+	}
+	var results []interface{}
+	for i, isin := range isins {
+		if isin == s.missing {
+			continue
+		}
+		price := float64(100 + i)
+		switch r.URL.Path {
+		case "/quotes/latest":
+			results = append(results, Quote{ISIN: isin, Mic: "XMUN", Bid: price, Ask: price + 0.1, Time: time.Date(2022, 1, 5, 9, 0, 0, 0, time.UTC)})
+		case "/trades/latest":
+			results = append(results, Trade{ISIN: isin, Mic: "XMUN", Price: float32(price), Volume: 10, Time: time.Date(2022, 1, 5, 8, 59, 0, 0, time.UTC)})
+		case "/ohlc/d1":
+			for day := 3; day <= 5; day++ {
+				bar := OHLC{ISIN: isin, Mic: "XMUN", Open: price, High: price + 1, Low: price - 1, Close: price + float64(day), Time: time.Date(2022, 1, day, 0, 0, 0, 0, time.UTC)}
+				results = append(results, bar)
+			}
+		}
+	}
+	raw, _ := json.Marshal(results)
+	fmt.Fprintf(w, `{"results": %s}`, raw)
+}
+
+func snapshotClient(handler http.Handler) (*MarketDataClient, func()) {
+	server := httptest.NewServer(handler)
+	backend := client.Backend{BaseURL: server.URL}
+	now := time.Date(2022, 1, 5, 10, 0, 0, 0, time.UTC)
+	return &MarketDataClient{backend: &backend, now: func() time.Time { return now }}, server.Close
+}
+
+func TestSnapshot(t *testing.T) {
+	t.Run("Successful test", func(t *testing.T) {
+		server := &snapshotServer{}
+		client, stop := snapshotClient(server)
+		defer stop()
+		isins := snapshotISINs(t, 12)
+		snapshots := client.Snapshot(context.Background(), isins, "XMUN")
+		assert.Len(t, snapshots, 12)
+		assert.Len(t, server.requests, 6, "two batches of three requests")
+
+		first := snapshots[isins[0]]
+		assert.Nil(t, first.Error)
+		assert.Equal(t, 100.0, first.Quote.Bid)
+		assert.Equal(t, float32(100), first.Trade.Price)
+		assert.Equal(t, time.Date(2022, 1, 5, 0, 0, 0, 0, time.UTC), first.Today.Time)
+		assert.Equal(t, 105.0, first.Today.Close)
+		assert.Equal(t, 104.0, first.PreviousClose())
+
+		eleventh := snapshots[isins[10]]
+		assert.Nil(t, eleventh.Error)
+		assert.Equal(t, 100.0, eleventh.Quote.Bid, "first of the second batch")
+	})
+	t.Run("missing data and invalid ISINs", func(t *testing.T) {
+		isins := snapshotISINs(t, 12)
+		server := &snapshotServer{missing: isins[11]}
+		client, stop := snapshotClient(server)
+		defer stop()
+		snapshots := client.Snapshot(context.Background(), []string{isins[11], "US0378331006", isins[0]}, "XMUN")
+		assert.Len(t, snapshots, 3)
+		missing := snapshots[isins[11]]
+		assert.Equal(t, isins[11], missing.ISIN)
+		assert.Nil(t, missing.Error)
+		assert.Nil(t, missing.Quote)
+		assert.Nil(t, missing.Today)
+		assert.Equal(t, 0.0, missing.PreviousClose())
+		assert.True(t, errors.Is(snapshots["US0378331006"].Error, identifiers.ErrInvalidISIN))
+		assert.NotNil(t, snapshots[isins[0]].Quote)
+		assert.Len(t, server.requests, 3)
+	})
+	t.Run("fail to get response", func(t *testing.T) {
+		isins := snapshotISINs(t, 12)
+		server := &snapshotServer{failing: isins[11]}
+		client, stop := snapshotClient(server)
+		defer stop()
+		snapshots := client.Snapshot(context.Background(), isins, "")
+		failed := snapshots[isins[10]]
+		assert.NotNil(t, failed.Error)
+		assert.True(t, strings.HasPrefix(failed.Error.Error(), "trades: "))
+		assert.NotNil(t, failed.Quote, "the quotes of the batch are still there")
+		assert.Nil(t, failed.Trade)
+		assert.Nil(t, snapshots[isins[0]].Error, "the other batch is not affected")
+	})
+	t.Run("invalid MIC", func(t *testing.T) {
+		server := &snapshotServer{}
+		client, stop := snapshotClient(server)
+		defer stop()
+		snapshots := client.Snapshot(context.Background(), snapshotISINs(t, 2), "MUNICH")
+		for _, snapshot := range snapshots {
+			assert.True(t, errors.Is(snapshot.Error, identifiers.ErrInvalidMIC))
+		}
+		assert.Empty(t, server.requests)
+	})
+	t.Run("canceled", func(t *testing.T) {
+		release := make(chan struct{})
+		client, stop := snapshotClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+			fmt.Fprint(w, `{"results": []}`)
+		}))
+		defer stop()
+		defer close(release)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		snapshots := client.Snapshot(ctx, snapshotISINs(t, 1), "XMUN")
+		for _, snapshot := range snapshots {
+			assert.Equal(t, context.DeadlineExceeded, snapshot.Error)
+		}
+	})
+	t.Run("hung request is canceled", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer server.Close()
+		defer close(release)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		done := make(chan error)
+		go func() {
+			_, err := fetchAll[Quote](ctx, &client.Backend{BaseURL: server.URL}, "quotes/latest", nil)
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		case <-time.After(time.Second):
+			t.Fatal("request is not canceled")
+		}
+	})
+}
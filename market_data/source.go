@@ -0,0 +1,17 @@
+package market_data
+
+/*
+MarketDataSource is everything that MarketDataClient offers, so that it can be replaced by a cache,
+recorded data or a mock in tests
+*/
+type MarketDataSource interface {
+	GetInstruments(query *GetInstrumentsQuery) <-chan Item[Instrument, error]
+	GetVenues() <-chan Item[Venue, error]
+	GetOHLCPerMinute(query *GetOHLCQuery) <-chan Item[OHLC, error]
+	GetOHLCPerHour(query *GetOHLCQuery) <-chan Item[OHLC, error]
+	GetOHLCPerDay(query *GetOHLCQuery) <-chan Item[OHLC, error]
+	GetQuotes(query *GetQuotesQuery) <-chan Item[Quote, error]
+	GetTrades(query *GetTradesQuery) <-chan Item[Trade, error]
+}
+
+var _ MarketDataSource = (*MarketDataClient)(nil)
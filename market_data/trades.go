@@ -1,8 +1,9 @@
 package market_data
 
 import (
-	"encoding/json"
 	"time"
+
+	"github.com/quantfamily/lemonmarkets/identifiers"
 )
 
 /*
@@ -19,6 +20,19 @@ type GetTradesQuery struct {
 	Page    int       `url:"page,omitempty"`
 }
 
+/*
+Validate checks ISINs and MIC locally, so that typos fail without a request
+*/
+func (q *GetTradesQuery) Validate() error {
+	if q == nil {
+		return nil
+	}
+	if err := identifiers.CheckISINs(q.ISIN...); err != nil {
+		return err
+	}
+	return identifiers.CheckMIC(q.MIC)
+}
+
 /*
 Trade containing information about a specific trade
 */
@@ -41,31 +55,9 @@ func (cl *MarketDataClient) GetTrades(query *GetTradesQuery) <-chan Item[Trade,
 
 func (cl *MarketDataClient) returnTrades(query *GetTradesQuery, ch chan<- Item[Trade, error]) {
 	defer close(ch)
-	response, err := cl.backend.Do("GET", "trades", query, nil)
-	if err != nil {
-		trade := Item[Trade, error]{}
-		trade.Error = err
-		ch <- trade
+	if err := query.Validate(); err != nil {
+		ch <- Item[Trade, error]{Error: err}
 		return
 	}
-	for {
-		var trades []Trade
-		trade := Item[Trade, error]{}
-		trade.Error = json.Unmarshal(response.Results, &trades)
-		if trade.Error != nil {
-			ch <- trade
-			return
-		}
-		for _, trade := range trades {
-			ch <- Item[Trade, error]{trade, nil}
-		}
-		if response.Next == "" {
-			return
-		}
-		response, trade.Error = cl.backend.Do("GET", response.Next, nil, nil)
-		if trade.Error != nil {
-			ch <- trade
-			return
-		}
-	}
+	returnData(cl.backend, "trades", query, ch)
 }
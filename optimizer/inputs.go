@@ -0,0 +1,43 @@
+package optimizer
+
+import (
+	"sort"
+
+	"github.com/quantfamily/lemonmarkets/returns"
+	"github.com/quantfamily/lemonmarkets/trading"
+)
+
+/*
+FromReturns estimates a problem from historical returns, the mean return and sample covariance of every ISIN
+scaled by periods per year, such as returns.TradingDaysPerYear for daily returns or 1 to keep them per period
+*/
+func FromReturns(frame *returns.Frame, periods float64) (Problem, error) {
+	covariance, err := returns.Covariance(frame)
+	if err != nil {
+		return Problem{}, err
+	}
+	covariance = covariance.Annualize(periods)
+	problem := Problem{ISINs: append([]string(nil), frame.ISINs...), Returns: frame.Mean(), Covariance: covariance.Slices()}
+	for i := range problem.Returns {
+		problem.Returns[i] *= periods
+	}
+	return problem, nil
+}
+
+// CurrentWeights are the weights of the holdings of a portfolio, cash is not included
+func CurrentWeights(portfolio *trading.Portfolio) map[string]float64 {
+	weights := make(map[string]float64, len(portfolio.Holdings))
+	for _, holding := range portfolio.Holdings {
+		weights[holding.Position.ISIN] += holding.Weight
+	}
+	return weights
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
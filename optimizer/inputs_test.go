@@ -0,0 +1,36 @@
+package optimizer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/quantfamily/lemonmarkets/returns"
+	"github.com/quantfamily/lemonmarkets/trading"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromReturns(t *testing.T) {
+	frame := &returns.Frame{ISINs: []string{"A", "B"}, Matrix: returns.Matrix{Rows: 3, Cols: 2, Data: []float64{
+		0.01, 0.02,
+		-0.01, 0,
+		0.03, 0.01,
+	}}}
+	problem, err := FromReturns(frame, 252)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"A", "B"}, problem.ISINs)
+	assert.InDeltaSlice(t, []float64{0.01 * 252, 0.01 * 252}, problem.Returns, 1e-9)
+	assert.InDelta(t, 0.0004*252, problem.Covariance[0][0], 1e-9)
+	assert.InDelta(t, 0.0001*252, problem.Covariance[0][1], 1e-9)
+	assert.Equal(t, problem.Covariance[0][1], problem.Covariance[1][0])
+
+	_, err = FromReturns(&returns.Frame{Matrix: returns.NewMatrix(1, 2)}, 252)
+	assert.True(t, errors.Is(err, returns.ErrNoData))
+}
+
+func TestCurrentWeights(t *testing.T) {
+	portfolio := &trading.Portfolio{Holdings: []trading.Holding{
+		{Position: trading.Position{ISIN: "A"}, Weight: 0.5},
+		{Position: trading.Position{ISIN: "B"}, Weight: 0.3},
+	}}
+	assert.Equal(t, map[string]float64{"A": 0.5, "B": 0.3}, CurrentWeights(portfolio))
+}
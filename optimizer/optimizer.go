@@ -0,0 +1,255 @@
+/*
+Package optimizer computes long-only target weights with equal weight, minimum variance, maximum Sharpe ratio
+or risk parity, under caps per instrument and per group and a turnover limit against the current holdings.
+
+Estimates come from the returns package, current weights from a trading.Portfolio, and the result is turned into
+targets for trading.NewRebalancePlan:
+
+	daily := prices.Returns(returns.Log)
+	problem, err := optimizer.FromReturns(daily, returns.TradingDaysPerYear)
+	types, err := trading.InstrumentTypes(marketData, problem.ISINs)
+	result, err := optimizer.Optimize(problem, optimizer.MinVariance, optimizer.Constraints{
+		MaxWeight:   0.4,
+		Groupings:   []optimizer.Grouping{{Groups: types, Max: map[string]float64{"etf": 0.6}}},
+		Current:     optimizer.CurrentWeights(&portfolio),
+		MaxTurnover: 0.2,
+	})
+	plan, err := trading.NewRebalancePlan(&portfolio, trading.RebalanceConfig{Targets: result.Targets(0.02), ...})
+
+The optimizer is deterministic, the same input gives the same weights.
+*/
+package optimizer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/quantfamily/lemonmarkets/trading"
+)
+
+var (
+	// ErrInfeasible is returned when no weights fulfill the constraints
+	ErrInfeasible = errors.New("constraints can not be fulfilled")
+	// ErrInvalidProblem is returned for estimates that do not fit together
+	ErrInvalidProblem = errors.New("invalid problem")
+)
+
+// Method of optimization
+type Method string
+
+const (
+	// EqualWeight gives every ISIN the same weight, as far as the constraints allow
+	EqualWeight Method = "equal_weight"
+	// MinVariance minimizes the variance of the portfolio
+	MinVariance Method = "min_variance"
+	// MaxSharpe maximizes the expected return over RiskFree per volatility
+	MaxSharpe Method = "max_sharpe"
+	// RiskParity makes every ISIN contribute the same to the variance
+	RiskParity Method = "risk_parity"
+)
+
+/*
+Problem holds the estimates for a set of ISINs. Covariance has a row and a column per ISIN in the same order,
+Returns the expected return per ISIN and is only needed for MaxSharpe. RiskFree is in the same period as Returns
+*/
+type Problem struct {
+	ISINs      []string
+	Returns    []float64
+	Covariance [][]float64
+	RiskFree   float64
+}
+
+func (p Problem) validate(method Method) error {
+	n := len(p.ISINs)
+	if n == 0 {
+		return fmt.Errorf("%w: no ISINs", ErrInvalidProblem)
+	}
+	seen := make(map[string]bool, n)
+	for _, isin := range p.ISINs {
+		if seen[isin] {
+			return fmt.Errorf("%w: %s is there twice", ErrInvalidProblem, isin)
+		}
+		seen[isin] = true
+	}
+	if method != EqualWeight {
+		if len(p.Covariance) != n {
+			return fmt.Errorf("%w: covariance has %d rows for %d ISINs", ErrInvalidProblem, len(p.Covariance), n)
+		}
+		for i, row := range p.Covariance {
+			if len(row) != n {
+				return fmt.Errorf("%w: covariance row %d has %d columns for %d ISINs", ErrInvalidProblem, i, len(row), n)
+			}
+			if row[i] <= 0 {
+				return fmt.Errorf("%w: variance of %s must be positive", ErrInvalidProblem, p.ISINs[i])
+			}
+		}
+	}
+	if method == MaxSharpe && len(p.Returns) != n {
+		return fmt.Errorf("%w: %d returns for %d ISINs", ErrInvalidProblem, len(p.Returns), n)
+	}
+	return nil
+}
+
+/*
+Grouping assigns ISINs to groups, such as instrument types from trading.InstrumentTypes or sectors,
+and caps the total weight of a group. Groups without a cap and ISINs without a group are not limited
+*/
+type Grouping struct {
+	Groups map[string]string
+	Max    map[string]float64
+}
+
+/*
+Constraints on the weights, which are always long-only and sum to one. MaxWeight caps every ISIN, 0 means no cap.
+Current are the weights held now, such as from CurrentWeights. When MaxTurnover is set and the one-way turnover,
+half the sum of absolute weight changes, would be larger, every change is scaled down to fit. The weights of
+ISINs that are held but not part of the problem are then kept in part, and weights only sum to one together with them
+*/
+type Constraints struct {
+	MaxWeight   float64
+	Groupings   []Grouping
+	Current     map[string]float64
+	MaxTurnover float64
+}
+
+/*
+Result of an optimization. Weights has every ISIN of the problem and every held ISIN, ExpectedReturn,
+Volatility and Sharpe are in the period of the problem and only cover its ISINs. RiskContributions
+are the shares of the variance per ISIN and sum to one
+*/
+type Result struct {
+	Method            Method
+	Weights           map[string]float64
+	ExpectedReturn    float64
+	Volatility        float64
+	Sharpe            float64
+	RiskContributions map[string]float64
+	Turnover          float64
+}
+
+/*
+Targets for trading.RebalanceConfig, sorted by ISIN. Held ISINs without weight are included with weight 0,
+so that they are sold
+*/
+func (r *Result) Targets(band float64) []trading.Target {
+	targets := make([]trading.Target, 0, len(r.Weights))
+	for isin, weight := range r.Weights {
+		targets = append(targets, trading.Target{ISIN: isin, Weight: weight, Band: band})
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].ISIN < targets[j].ISIN })
+	return targets
+}
+
+// Optimize returns the weights of the method under the constraints
+func Optimize(problem Problem, method Method, constraints Constraints) (*Result, error) {
+	if err := problem.validate(method); err != nil {
+		return nil, err
+	}
+	set, err := newFeasibleSet(problem.ISINs, constraints)
+	if err != nil {
+		return nil, err
+	}
+	var weights []float64
+	switch method {
+	case EqualWeight:
+		weights = set.project(equal(len(problem.ISINs)))
+	case MinVariance:
+		weights = minimize(problem.Covariance, nil, 1, set, set.project(equal(len(problem.ISINs))))
+	case MaxSharpe:
+		weights = maxSharpe(problem, set)
+	case RiskParity:
+		weights = set.project(riskParity(problem.Covariance))
+	default:
+		return nil, fmt.Errorf("unknown method %q", method)
+	}
+	if !set.contains(weights) {
+		return nil, ErrInfeasible
+	}
+	return result(problem, method, weights, constraints), nil
+}
+
+func equal(n int) []float64 {
+	weights := make([]float64, n)
+	for i := range weights {
+		weights[i] = 1 / float64(n)
+	}
+	return weights
+}
+
+// result applies the turnover limit and computes the statistics
+func result(problem Problem, method Method, weights []float64, constraints Constraints) *Result {
+	r := &Result{Method: method, Weights: make(map[string]float64), RiskContributions: make(map[string]float64)}
+	for i, isin := range problem.ISINs {
+		r.Weights[isin] = weights[i]
+	}
+	for isin := range constraints.Current {
+		if _, ok := r.Weights[isin]; !ok {
+			r.Weights[isin] = 0
+		}
+	}
+	r.Turnover = turnover(r.Weights, constraints.Current)
+	if constraints.MaxTurnover > 0 && r.Turnover > constraints.MaxTurnover {
+		scale := constraints.MaxTurnover / r.Turnover
+		for isin, weight := range r.Weights {
+			current := constraints.Current[isin]
+			r.Weights[isin] = current + scale*(weight-current)
+		}
+		r.Turnover = turnover(r.Weights, constraints.Current)
+		for i, isin := range problem.ISINs {
+			weights[i] = r.Weights[isin]
+		}
+	}
+
+	if len(problem.Returns) == len(weights) {
+		for i, weight := range weights {
+			r.ExpectedReturn += weight * problem.Returns[i]
+		}
+	}
+	if len(problem.Covariance) == len(weights) {
+		product := multiply(problem.Covariance, weights)
+		variance := dot(weights, product)
+		r.Volatility = math.Sqrt(variance)
+		for i, isin := range problem.ISINs {
+			if variance > 0 {
+				r.RiskContributions[isin] = weights[i] * product[i] / variance
+			}
+		}
+	}
+	if r.Volatility > 0 {
+		r.Sharpe = (r.ExpectedReturn - problem.RiskFree) / r.Volatility
+	}
+	return r
+}
+
+// turnover is the one-way turnover, half the sum of absolute weight changes
+func turnover(weights, current map[string]float64) float64 {
+	// summed in ISIN order, so that rounding is the same on every run
+	sum := 0.0
+	for _, isin := range sortedKeys(weights) {
+		sum += math.Abs(weights[isin] - current[isin])
+	}
+	for _, isin := range sortedKeys(current) {
+		if _, ok := weights[isin]; !ok {
+			sum += math.Abs(current[isin])
+		}
+	}
+	return sum / 2
+}
+
+func multiply(matrix [][]float64, vector []float64) []float64 {
+	product := make([]float64, len(matrix))
+	for i, row := range matrix {
+		product[i] = dot(row, vector)
+	}
+	return product
+}
+
+func dot(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
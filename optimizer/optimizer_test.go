@@ -0,0 +1,146 @@
+package optimizer
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/quantfamily/lemonmarkets/trading"
+	"github.com/stretchr/testify/assert"
+)
+
+// problem with uncorrelated ISINs with volatilities of 20%, 10% and 15%
+func problem() Problem {
+	return Problem{
+		ISINs:      []string{"A", "B", "C"},
+		Returns:    []float64{0.10, 0.05, 0.08},
+		Covariance: [][]float64{{0.04, 0, 0}, {0, 0.01, 0}, {0, 0, 0.0225}},
+	}
+}
+
+func weights(result *Result, isins ...string) []float64 {
+	values := make([]float64, len(isins))
+	for i, isin := range isins {
+		values[i] = result.Weights[isin]
+	}
+	return values
+}
+
+func TestOptimize(t *testing.T) {
+	t.Run("equal weight", func(t *testing.T) {
+		result, err := Optimize(Problem{ISINs: []string{"A", "B", "C", "D"}}, EqualWeight, Constraints{})
+		assert.Nil(t, err)
+		assert.InDeltaSlice(t, []float64{0.25, 0.25, 0.25, 0.25}, weights(result, "A", "B", "C", "D"), 1e-9)
+		assert.Equal(t, 0.0, result.Volatility, "no estimates")
+	})
+	t.Run("min variance", func(t *testing.T) {
+		result, err := Optimize(problem(), MinVariance, Constraints{})
+		assert.Nil(t, err)
+		sum := 25 + 100 + 400/9.0
+		assert.InDeltaSlice(t, []float64{25 / sum, 100 / sum, 400 / 9.0 / sum}, weights(result, "A", "B", "C"), 1e-6)
+		assert.InDelta(t, 0.0768, result.Volatility, 1e-4)
+		assert.Equal(t, MinVariance, result.Method)
+	})
+	t.Run("max weight", func(t *testing.T) {
+		result, err := Optimize(problem(), MinVariance, Constraints{MaxWeight: 0.4})
+		assert.Nil(t, err)
+		rest := 0.6 / (25 + 400/9.0)
+		assert.InDeltaSlice(t, []float64{25 * rest, 0.4, 400 / 9.0 * rest}, weights(result, "A", "B", "C"), 1e-6)
+	})
+	t.Run("group caps", func(t *testing.T) {
+		types := map[string]string{"A": "etf", "B": "stock", "C": "etf"}
+		result, err := Optimize(problem(), MinVariance, Constraints{Groupings: []Grouping{{Groups: types, Max: map[string]float64{"etf": 0.3}}}})
+		assert.Nil(t, err)
+		share := 0.3 / (25 + 400/9.0)
+		assert.InDeltaSlice(t, []float64{25 * share, 0.7, 400 / 9.0 * share}, weights(result, "A", "B", "C"), 1e-6)
+
+		sectors := Grouping{Groups: map[string]string{"A": "tech", "B": "tech"}, Max: map[string]float64{"tech": 0.6}}
+		result, err = Optimize(problem(), MinVariance, Constraints{Groupings: []Grouping{{Groups: types, Max: map[string]float64{"etf": 0.5}}, sectors}})
+		assert.Nil(t, err)
+		w := weights(result, "A", "B", "C")
+		assert.InDelta(t, 1, w[0]+w[1]+w[2], 1e-6)
+		assert.LessOrEqual(t, w[0]+w[2], 0.5+1e-6)
+		assert.LessOrEqual(t, w[0]+w[1], 0.6+1e-6)
+	})
+	t.Run("max sharpe", func(t *testing.T) {
+		result, err := Optimize(problem(), MaxSharpe, Constraints{})
+		assert.Nil(t, err)
+		sum := 2.5 + 5 + 0.08/0.0225
+		assert.InDeltaSlice(t, []float64{2.5 / sum, 5 / sum, 0.08 / 0.0225 / sum}, weights(result, "A", "B", "C"), 1e-3)
+		assert.InDelta(t, math.Sqrt(0.25+0.25+0.08*0.08/0.0225), result.Sharpe, 1e-6, "the root of the summed squared Sharpe ratios")
+
+		long := problem()
+		long.Returns = []float64{0.10, -0.05, 0.08}
+		result, err = Optimize(long, MaxSharpe, Constraints{})
+		assert.Nil(t, err)
+		assert.InDelta(t, 0, result.Weights["B"], 1e-6, "long-only")
+	})
+	t.Run("risk parity", func(t *testing.T) {
+		result, err := Optimize(problem(), RiskParity, Constraints{})
+		assert.Nil(t, err)
+		sum := 5 + 10 + 1/0.15
+		assert.InDeltaSlice(t, []float64{5 / sum, 10 / sum, 1 / 0.15 / sum}, weights(result, "A", "B", "C"), 1e-6)
+		for _, contribution := range result.RiskContributions {
+			assert.InDelta(t, 1/3.0, contribution, 1e-6)
+		}
+
+		correlated := problem()
+		correlated.Covariance = [][]float64{{0.04, 0.01, 0}, {0.01, 0.01, 0.005}, {0, 0.005, 0.0225}}
+		result, err = Optimize(correlated, RiskParity, Constraints{})
+		assert.Nil(t, err)
+		for _, contribution := range result.RiskContributions {
+			assert.InDelta(t, 1/3.0, contribution, 1e-6)
+		}
+	})
+	t.Run("deterministic", func(t *testing.T) {
+		first, _ := Optimize(problem(), MaxSharpe, Constraints{MaxWeight: 0.45})
+		second, _ := Optimize(problem(), MaxSharpe, Constraints{MaxWeight: 0.45})
+		assert.Equal(t, first, second)
+	})
+}
+
+func TestTurnover(t *testing.T) {
+	current := map[string]float64{"A": 0.6, "D": 0.4}
+	result, err := Optimize(problem(), EqualWeight, Constraints{Current: current})
+	assert.Nil(t, err)
+	assert.InDelta(t, 2/3.0, result.Turnover, 1e-9)
+	targets := result.Targets(0.02)
+	assert.Len(t, targets, 4)
+	assert.Equal(t, trading.Target{ISIN: "D", Weight: 0, Band: 0.02}, targets[3], "held ISINs are sold")
+
+	result, err = Optimize(problem(), EqualWeight, Constraints{Current: current, MaxTurnover: 1 / 3.0})
+	assert.Nil(t, err)
+	assert.InDelta(t, 1/3.0, result.Turnover, 1e-9)
+	assert.InDeltaSlice(t, []float64{0.6 - 0.4/3, 1 / 6.0, 1 / 6.0, 0.2}, weights(result, "A", "B", "C", "D"), 1e-9)
+}
+
+func TestOptimizeErrors(t *testing.T) {
+	_, err := Optimize(problem(), MinVariance, Constraints{MaxWeight: 0.3})
+	assert.True(t, errors.Is(err, ErrInfeasible))
+	assert.EqualError(t, err, "constraints can not be fulfilled: 3 ISINs at most 0.3 each")
+
+	types := map[string]string{"A": "etf", "B": "etf", "C": "etf"}
+	_, err = Optimize(problem(), MinVariance, Constraints{Groupings: []Grouping{{Groups: types, Max: map[string]float64{"etf": 0.9}}}})
+	assert.True(t, errors.Is(err, ErrInfeasible))
+
+	_, err = Optimize(Problem{}, EqualWeight, Constraints{})
+	assert.True(t, errors.Is(err, ErrInvalidProblem))
+	_, err = Optimize(Problem{ISINs: []string{"A", "A"}}, EqualWeight, Constraints{})
+	assert.EqualError(t, err, "invalid problem: A is there twice")
+	_, err = Optimize(Problem{ISINs: []string{"A", "B"}}, MinVariance, Constraints{})
+	assert.EqualError(t, err, "invalid problem: covariance has 0 rows for 2 ISINs")
+	invalid := problem()
+	invalid.Covariance[1] = []float64{0, 0}
+	_, err = Optimize(invalid, RiskParity, Constraints{})
+	assert.EqualError(t, err, "invalid problem: covariance row 1 has 2 columns for 3 ISINs")
+	invalid = problem()
+	invalid.Covariance[2][2] = 0
+	_, err = Optimize(invalid, MinVariance, Constraints{})
+	assert.EqualError(t, err, "invalid problem: variance of C must be positive")
+	invalid = problem()
+	invalid.Returns = nil
+	_, err = Optimize(invalid, MaxSharpe, Constraints{})
+	assert.EqualError(t, err, "invalid problem: 0 returns for 3 ISINs")
+	_, err = Optimize(problem(), "black_litterman", Constraints{})
+	assert.EqualError(t, err, `unknown method "black_litterman"`)
+}
@@ -0,0 +1,312 @@
+package optimizer
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	tolerance  = 1e-10
+	iterations = 5000
+)
+
+// group is a set of ISIN indices whose weights sum to at most max
+type group struct {
+	members []int
+	max     float64
+}
+
+/*
+feasibleSet are long-only weights summing to one, at most max each and within the caps of every grouping
+*/
+type feasibleSet struct {
+	n         int
+	max       float64
+	groupings [][]group
+}
+
+func newFeasibleSet(isins []string, constraints Constraints) (*feasibleSet, error) {
+	n := len(isins)
+	set := &feasibleSet{n: n, max: constraints.MaxWeight}
+	if set.max <= 0 || set.max > 1 {
+		set.max = 1
+	}
+	if float64(n)*set.max < 1-tolerance {
+		return nil, fmt.Errorf("%w: %d ISINs at most %v each", ErrInfeasible, n, set.max)
+	}
+	for _, grouping := range constraints.Groupings {
+		members := make(map[string][]int)
+		capacity := 0.0
+		for i, isin := range isins {
+			name, ok := grouping.Groups[isin]
+			if _, capped := grouping.Max[name]; ok && capped {
+				members[name] = append(members[name], i)
+			} else {
+				capacity += set.max
+			}
+		}
+		var groups []group
+		for _, name := range sortedKeys(members) {
+			g := group{members: members[name], max: grouping.Max[name]}
+			if g.max < 0 {
+				return nil, fmt.Errorf("%w: cap of %s is negative", ErrInfeasible, name)
+			}
+			capacity += math.Min(g.max, float64(len(g.members))*set.max)
+			groups = append(groups, g)
+		}
+		if capacity < 1-tolerance {
+			return nil, fmt.Errorf("%w: groups can hold at most %v", ErrInfeasible, capacity)
+		}
+		set.groupings = append(set.groupings, groups)
+	}
+	return set, nil
+}
+
+// project returns the closest weights in the set, by Dykstra's alternating projections when there are groupings
+func (s *feasibleSet) project(y []float64) []float64 {
+	if len(s.groupings) == 0 {
+		return s.projectBox(y)
+	}
+	x := append([]float64(nil), y...)
+	corrections := make([][]float64, len(s.groupings)+1)
+	for k := range corrections {
+		corrections[k] = make([]float64, s.n)
+	}
+	shifted := make([]float64, s.n)
+	for iteration := 0; iteration < iterations; iteration++ {
+		change := 0.0
+		for k := range corrections {
+			for i := range x {
+				shifted[i] = x[i] + corrections[k][i]
+			}
+			var z []float64
+			if k == 0 {
+				z = s.projectBox(shifted)
+			} else {
+				z = projectGroups(shifted, s.groupings[k-1])
+			}
+			for i := range x {
+				corrections[k][i] = shifted[i] - z[i]
+				change = math.Max(change, math.Abs(z[i]-x[i]))
+			}
+			x = z
+		}
+		if change < tolerance {
+			break
+		}
+	}
+	return x
+}
+
+// projectBox projects on weights between 0 and max that sum to one, by bisection on the shift of every weight
+func (s *feasibleSet) projectBox(y []float64) []float64 {
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, value := range y {
+		low = math.Min(low, value-1)
+		high = math.Max(high, value)
+	}
+	x := make([]float64, len(y))
+	fill := func(shift float64) float64 {
+		sum := 0.0
+		for i, value := range y {
+			x[i] = math.Max(0, math.Min(s.max, value-shift))
+			sum += x[i]
+		}
+		return sum
+	}
+	for iteration := 0; iteration < 200 && high-low > 1e-15; iteration++ {
+		middle := (low + high) / 2
+		if fill(middle) > 1 {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+	fill((low + high) / 2)
+	return x
+}
+
+// projectGroups lowers the weights of every group above its cap by the same amount
+func projectGroups(y []float64, groups []group) []float64 {
+	x := append([]float64(nil), y...)
+	for _, g := range groups {
+		sum := 0.0
+		for _, i := range g.members {
+			sum += x[i]
+		}
+		if sum > g.max {
+			shift := (sum - g.max) / float64(len(g.members))
+			for _, i := range g.members {
+				x[i] -= shift
+			}
+		}
+	}
+	return x
+}
+
+// contains is true for weights within the set, up to a small tolerance
+func (s *feasibleSet) contains(weights []float64) bool {
+	const slack = 1e-6
+	sum := 0.0
+	for _, weight := range weights {
+		if weight < -slack || weight > s.max+slack {
+			return false
+		}
+		sum += weight
+	}
+	if math.Abs(sum-1) > slack {
+		return false
+	}
+	for _, groups := range s.groupings {
+		for _, g := range groups {
+			total := 0.0
+			for _, i := range g.members {
+				total += weights[i]
+			}
+			if total > g.max+slack {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+/*
+minimize finds the weights in the set minimizing aversion / 2 * w'Σw - μ'w by accelerated projected gradient descent,
+starting from start. Without returns it is the minimum variance portfolio
+*/
+func minimize(covariance [][]float64, returns []float64, aversion float64, set *feasibleSet, start []float64) []float64 {
+	// the largest absolute row sum bounds the largest eigenvalue, so 1 / lipschitz is a safe step
+	lipschitz := 0.0
+	for _, row := range covariance {
+		sum := 0.0
+		for _, value := range row {
+			sum += math.Abs(value)
+		}
+		lipschitz = math.Max(lipschitz, sum)
+	}
+	lipschitz *= aversion
+	x := append([]float64(nil), start...)
+	y := append([]float64(nil), start...)
+	t := 1.0
+	step := make([]float64, len(x))
+	for iteration := 0; iteration < iterations; iteration++ {
+		gradient := multiply(covariance, y)
+		for i := range step {
+			step[i] = y[i] - (aversion*gradient[i]-value(returns, i))/lipschitz
+		}
+		next := set.project(step)
+		tNext := (1 + math.Sqrt(1+4*t*t)) / 2
+		change := 0.0
+		for i := range y {
+			change = math.Max(change, math.Abs(next[i]-x[i]))
+			y[i] = next[i] + (t-1)/tNext*(next[i]-x[i])
+		}
+		x, t = next, tNext
+		if change < tolerance {
+			break
+		}
+	}
+	return x
+}
+
+func value(values []float64, i int) float64 {
+	if values == nil {
+		return 0
+	}
+	return values[i]
+}
+
+/*
+maxSharpe searches the efficient portfolios for the highest Sharpe ratio. Risk aversions are tried on a
+logarithmic grid around the scale of the estimates, then refined by golden section around the best one
+*/
+func maxSharpe(problem Problem, set *feasibleSet) []float64 {
+	scale := 0.0
+	variance := 0.0
+	for i, row := range problem.Covariance {
+		scale = math.Max(scale, math.Abs(problem.Returns[i]-problem.RiskFree))
+		variance += row[i] / float64(len(row))
+	}
+	if scale == 0 {
+		scale = 1
+	}
+	base := scale / variance
+	start := set.project(equal(len(problem.ISINs)))
+	solve := func(exponent float64) ([]float64, float64) {
+		weights := minimize(problem.Covariance, problem.Returns, base*math.Pow(10, exponent), set, start)
+		return weights, sharpe(problem, weights)
+	}
+
+	const steps = 8
+	best, bestWeights, bestSharpe := 0.0, []float64(nil), math.Inf(-1)
+	for k := -3 * steps; k <= 3*steps; k++ {
+		exponent := float64(k) / steps
+		weights, ratio := solve(exponent)
+		if ratio > bestSharpe+tolerance {
+			best, bestWeights, bestSharpe = exponent, weights, ratio
+		}
+	}
+	golden := (math.Sqrt(5) - 1) / 2
+	low, high := best-1.0/steps, best+1.0/steps
+	for iteration := 0; iteration < 30; iteration++ {
+		a := high - golden*(high-low)
+		b := low + golden*(high-low)
+		weightsA, ratioA := solve(a)
+		weightsB, ratioB := solve(b)
+		if ratioA > bestSharpe+tolerance {
+			bestWeights, bestSharpe = weightsA, ratioA
+		}
+		if ratioB > bestSharpe+tolerance {
+			bestWeights, bestSharpe = weightsB, ratioB
+		}
+		if ratioA >= ratioB {
+			high = b
+		} else {
+			low = a
+		}
+	}
+	return bestWeights
+}
+
+func sharpe(problem Problem, weights []float64) float64 {
+	volatility := math.Sqrt(dot(weights, multiply(problem.Covariance, weights)))
+	if volatility == 0 {
+		return math.Inf(-1)
+	}
+	return (dot(weights, problem.Returns) - problem.RiskFree) / volatility
+}
+
+/*
+riskParity returns the long-only weights with equal risk contributions by cyclical coordinate descent,
+before any constraint is applied
+*/
+func riskParity(covariance [][]float64) []float64 {
+	n := len(covariance)
+	weights := make([]float64, n)
+	for i := range weights {
+		weights[i] = 1 / math.Sqrt(covariance[i][i])
+	}
+	budget := 1 / float64(n)
+	for iteration := 0; iteration < iterations; iteration++ {
+		change := 0.0
+		for i := range weights {
+			others := dot(covariance[i], weights) - covariance[i][i]*weights[i]
+			variance := covariance[i][i]
+			next := (-others + math.Sqrt(others*others+4*variance*budget)) / (2 * variance)
+			change = math.Max(change, math.Abs(next-weights[i]))
+			weights[i] = next
+		}
+		if change < tolerance {
+			break
+		}
+	}
+	sum := 0.0
+	for _, weight := range weights {
+		sum += weight
+	}
+	for i := range weights {
+		weights[i] /= sum
+	}
+	return weights
+}
@@ -0,0 +1,323 @@
+/*
+Package quality checks historical bars, quotes and trades for gaps, duplicates, rows out of order and
+impossible values, and optionally repairs them.
+
+A Validator keeps state per instrument and venue and is used row by row, or as a stage between a
+market data channel and its consumer:
+
+	bars, validator := quality.ValidateOHLC(client.GetOHLCPerMinute(&query), quality.Config{
+		Interval: time.Minute,
+		Schedule: &xetra,
+		Repair:   quality.Dedupe | quality.Drop,
+	})
+	for bar := range bars {
+		...
+	}
+	fmt.Println(validator.Report())
+*/
+package quality
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/calendar"
+	"github.com/quantfamily/lemonmarkets/market_data"
+)
+
+// Kinds of issues
+const (
+	Gap          = "gap"
+	Duplicate    = "duplicate"
+	OutOfOrder   = "out_of_order"
+	InvalidOHLC  = "invalid_ohlc"
+	CrossedQuote = "crossed_quote"
+	ZeroVolume   = "zero_volume"
+)
+
+// Repair modes, combine them with |
+type Repair int
+
+const (
+	// Dedupe drops rows repeating the previous row of the same instrument
+	Dedupe Repair = 1 << iota
+	// Drop drops rows out of order, bars breaking the OHLC invariants and crossed quotes
+	Drop
+	// ForwardFill inserts bars for missing intervals with the previous close and zero volume
+	ForwardFill
+)
+
+/*
+Config of a Validator. Interval is the bar interval used to find gaps in bars, gaps are not looked for
+without it. With a Schedule only intervals within sessions, or trading days for daily bars, are expected,
+otherwise every interval is. Zero volumes are reported but never repaired, since quiet markets have them
+*/
+type Config struct {
+	Interval time.Duration
+	Schedule *calendar.Schedule
+	Repair   Repair
+}
+
+// Issue found in a series. For gaps From is the first missing interval and Missing the number of missing intervals
+type Issue struct {
+	Kind    string
+	ISIN    string
+	MIC     string
+	Time    time.Time
+	From    time.Time
+	Missing int
+	Detail  string
+}
+
+func (i Issue) String() string {
+	text := fmt.Sprintf("%s %s %s at %s", i.Kind, i.ISIN, i.MIC, i.Time.Format(time.RFC3339))
+	if i.Kind == Gap {
+		text += fmt.Sprintf(": %d missing from %s", i.Missing, i.From.Format(time.RFC3339))
+	}
+	if i.Detail != "" {
+		text += ": " + i.Detail
+	}
+	return text
+}
+
+/*
+Report summarizes a validated series. Rows is what was read, Emitted what was passed on after repairs,
+Dropped and Filled the rows removed and inserted by repairs
+*/
+type Report struct {
+	Rows    int
+	Emitted int
+	Dropped int
+	Filled  int
+	Counts  map[string]int
+	Issues  []Issue
+}
+
+// OK is true when no issue was found
+func (r Report) OK() bool {
+	return len(r.Issues) == 0
+}
+
+func (r Report) String() string {
+	kinds := make([]string, 0, len(r.Counts))
+	for kind := range r.Counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	counts := make([]string, len(kinds))
+	for i, kind := range kinds {
+		counts[i] = fmt.Sprintf("%s=%d", kind, r.Counts[kind])
+	}
+	summary := fmt.Sprintf("%d rows, %d emitted, %d dropped, %d filled", r.Rows, r.Emitted, r.Dropped, r.Filled)
+	if len(counts) == 0 {
+		return summary + ", no issues"
+	}
+	return summary + ", issues: " + strings.Join(counts, " ")
+}
+
+// key of a series
+type key struct {
+	isin string
+	mic  string
+}
+
+// state of a series, the time and row of the newest row passed on
+type state struct {
+	time  time.Time
+	bar   market_data.OHLC
+	trade market_data.Trade
+}
+
+/*
+Validator checks rows of any number of instruments and venues, rows are expected oldest first per instrument
+and venue. It is not safe for concurrent use
+*/
+type Validator struct {
+	config Config
+	series map[key]*state
+	report Report
+}
+
+// NewValidator returns a Validator
+func NewValidator(config Config) *Validator {
+	return &Validator{config: config, series: make(map[key]*state), report: Report{Counts: make(map[string]int)}}
+}
+
+// Report of everything validated so far
+func (v *Validator) Report() Report {
+	report := v.report
+	report.Counts = make(map[string]int, len(v.report.Counts))
+	for kind, count := range v.report.Counts {
+		report.Counts[kind] = count
+	}
+	report.Issues = append([]Issue(nil), v.report.Issues...)
+	return report
+}
+
+func (v *Validator) flag(issue Issue) {
+	v.report.Counts[issue.Kind]++
+	v.report.Issues = append(v.report.Issues, issue)
+}
+
+func (v *Validator) state(isin, mic string) (*state, bool) {
+	k := key{isin, mic}
+	s, ok := v.series[k]
+	if !ok {
+		s = &state{}
+		v.series[k] = s
+	}
+	return s, ok
+}
+
+// order flags duplicates and rows out of order, keep is false for rows to drop
+func (v *Validator) order(s *state, seen bool, isin, mic string, t time.Time, duplicate bool) (keep bool) {
+	switch {
+	case !seen || t.After(s.time):
+		return true
+	case duplicate:
+		v.flag(Issue{Kind: Duplicate, ISIN: isin, MIC: mic, Time: t})
+		return v.config.Repair&Dedupe == 0
+	case t.Before(s.time):
+		v.flag(Issue{Kind: OutOfOrder, ISIN: isin, MIC: mic, Time: t, Detail: "after " + s.time.Format(time.RFC3339)})
+		return v.config.Repair&Drop == 0
+	}
+	return true
+}
+
+/*
+OHLC validates a bar and returns the bars to pass on: none when it is dropped, the bar and bars
+filled before it with ForwardFill
+*/
+func (v *Validator) OHLC(bar market_data.OHLC) []market_data.OHLC {
+	v.report.Rows++
+	s, seen := v.state(bar.ISIN, bar.Mic)
+	if !v.order(s, seen, bar.ISIN, bar.Mic, bar.Time, bar.Time.Equal(s.time)) {
+		v.report.Dropped++
+		return nil
+	}
+	if detail := ohlcProblem(bar); detail != "" {
+		v.flag(Issue{Kind: InvalidOHLC, ISIN: bar.ISIN, MIC: bar.Mic, Time: bar.Time, Detail: detail})
+		if v.config.Repair&Drop != 0 {
+			v.report.Dropped++
+			return nil
+		}
+	}
+	if bar.Volume == 0 {
+		v.flag(Issue{Kind: ZeroVolume, ISIN: bar.ISIN, MIC: bar.Mic, Time: bar.Time})
+	}
+	var bars []market_data.OHLC
+	if seen && bar.Time.After(s.time) {
+		missing := v.missing(s.time, bar.Time)
+		if len(missing) > 0 {
+			v.flag(Issue{Kind: Gap, ISIN: bar.ISIN, MIC: bar.Mic, Time: bar.Time, From: missing[0], Missing: len(missing)})
+		}
+		if v.config.Repair&ForwardFill != 0 {
+			for _, t := range missing {
+				close := s.bar.Close
+				bars = append(bars, market_data.OHLC{ISIN: bar.ISIN, Mic: bar.Mic, Time: t, Open: close, High: close, Low: close, Close: close})
+			}
+			v.report.Filled += len(missing)
+		}
+	}
+	if !seen || bar.Time.After(s.time) {
+		s.time = bar.Time
+		s.bar = bar
+	}
+	bars = append(bars, bar)
+	v.report.Emitted += len(bars)
+	return bars
+}
+
+// ohlcProblem describes a broken OHLC invariant, empty if there is none
+func ohlcProblem(bar market_data.OHLC) string {
+	switch {
+	case bar.Open <= 0 || bar.High <= 0 || bar.Low <= 0 || bar.Close <= 0:
+		return "prices must be positive"
+	case bar.High < bar.Low:
+		return fmt.Sprintf("high %v below low %v", bar.High, bar.Low)
+	case bar.Open < bar.Low || bar.Open > bar.High:
+		return fmt.Sprintf("open %v outside %v to %v", bar.Open, bar.Low, bar.High)
+	case bar.Close < bar.Low || bar.Close > bar.High:
+		return fmt.Sprintf("close %v outside %v to %v", bar.Close, bar.Low, bar.High)
+	}
+	return ""
+}
+
+/*
+missing returns the bar times expected strictly between from and to. Intraday bars are expected while
+the schedule is open, daily and longer bars on trading days
+*/
+func (v *Validator) missing(from, to time.Time) []time.Time {
+	interval := v.config.Interval
+	if interval <= 0 {
+		return nil
+	}
+	var missing []time.Time
+	for t := from.Add(interval); t.Before(to); t = t.Add(interval) {
+		if v.expected(t) {
+			missing = append(missing, t)
+		}
+	}
+	return missing
+}
+
+func (v *Validator) expected(t time.Time) bool {
+	schedule := v.config.Schedule
+	switch {
+	case schedule == nil:
+		return true
+	case v.config.Interval >= 24*time.Hour:
+		return schedule.IsTradingDay(t)
+	default:
+		return schedule.IsOpen(t)
+	}
+}
+
+// Quote validates a quote and returns the quotes to pass on, none when it is dropped
+func (v *Validator) Quote(quote market_data.Quote) []market_data.Quote {
+	v.report.Rows++
+	s, seen := v.state(quote.ISIN, quote.Mic)
+	if !v.order(s, seen, quote.ISIN, quote.Mic, quote.Time, quote.Time.Equal(s.time)) {
+		v.report.Dropped++
+		return nil
+	}
+	if quote.Bid > quote.Ask && quote.Ask > 0 {
+		v.flag(Issue{Kind: CrossedQuote, ISIN: quote.ISIN, MIC: quote.Mic, Time: quote.Time, Detail: fmt.Sprintf("bid %v above ask %v", quote.Bid, quote.Ask)})
+		if v.config.Repair&Drop != 0 {
+			v.report.Dropped++
+			return nil
+		}
+	}
+	if quote.BidVolume == 0 && quote.AskVolume == 0 {
+		v.flag(Issue{Kind: ZeroVolume, ISIN: quote.ISIN, MIC: quote.Mic, Time: quote.Time})
+	}
+	if !seen || quote.Time.After(s.time) {
+		s.time = quote.Time
+	}
+	v.report.Emitted++
+	return []market_data.Quote{quote}
+}
+
+/*
+Trade validates a trade and returns the trades to pass on, none when it is dropped.
+Several trades may share a timestamp, only a trade equal to the previous one is a duplicate
+*/
+func (v *Validator) Trade(trade market_data.Trade) []market_data.Trade {
+	v.report.Rows++
+	s, seen := v.state(trade.ISIN, trade.Mic)
+	if !v.order(s, seen, trade.ISIN, trade.Mic, trade.Time, seen && trade == s.trade) {
+		v.report.Dropped++
+		return nil
+	}
+	if trade.Volume == 0 {
+		v.flag(Issue{Kind: ZeroVolume, ISIN: trade.ISIN, MIC: trade.Mic, Time: trade.Time})
+	}
+	if !seen || !trade.Time.Before(s.time) {
+		s.time = trade.Time
+		s.trade = trade
+	}
+	v.report.Emitted++
+	return []market_data.Trade{trade}
+}
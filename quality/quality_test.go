@@ -0,0 +1,123 @@
+package quality
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/calendar"
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/stretchr/testify/assert"
+)
+
+const apple = "US0378331005"
+
+func minute(m int) time.Time {
+	return time.Date(2022, 1, 3, 9, m, 0, 0, time.UTC)
+}
+
+func bar(t time.Time, close float64) market_data.OHLC {
+	return market_data.OHLC{ISIN: apple, Mic: "XMUN", Open: close, High: close + 1, Low: close - 1, Close: close, Volume: 10, Time: t}
+}
+
+func times(bars []market_data.OHLC) []time.Time {
+	result := make([]time.Time, len(bars))
+	for i, bar := range bars {
+		result[i] = bar.Time
+	}
+	return result
+}
+
+func xetra(t *testing.T) *calendar.Schedule {
+	schedule, err := calendar.Default().Schedule("XETR")
+	assert.Nil(t, err)
+	return &schedule
+}
+
+func TestOHLC(t *testing.T) {
+	broken := bar(minute(5), 100)
+	broken.High = 98
+	zero := bar(minute(6), 100)
+	zero.Volume = 0
+	bars := []market_data.OHLC{bar(minute(0), 100), bar(minute(1), 101), bar(minute(1), 101), bar(minute(0), 100), bar(minute(4), 102), broken, zero}
+
+	t.Run("report only", func(t *testing.T) {
+		result, report := CheckOHLC(bars, Config{Interval: time.Minute})
+		assert.Equal(t, bars, result)
+		assert.Equal(t, map[string]int{Duplicate: 1, OutOfOrder: 1, Gap: 1, InvalidOHLC: 1, ZeroVolume: 1}, report.Counts)
+		assert.Equal(t, 7, report.Rows)
+		assert.Equal(t, 7, report.Emitted)
+		assert.False(t, report.OK())
+		gap := report.Issues[2]
+		assert.Equal(t, Gap, gap.Kind)
+		assert.Equal(t, minute(4), gap.Time)
+		assert.Equal(t, minute(2), gap.From)
+		assert.Equal(t, 2, gap.Missing)
+		assert.Equal(t, "high 98 below low 99", report.Issues[3].Detail)
+		assert.Equal(t, "7 rows, 7 emitted, 0 dropped, 0 filled, issues: duplicate=1 gap=1 invalid_ohlc=1 out_of_order=1 zero_volume=1", report.String())
+	})
+	t.Run("repair", func(t *testing.T) {
+		result, report := CheckOHLC(bars, Config{Interval: time.Minute, Repair: Dedupe | Drop | ForwardFill})
+		assert.Equal(t, []time.Time{minute(0), minute(1), minute(2), minute(3), minute(4), minute(5), minute(6)}, times(result), "the dropped bar is filled as well")
+		assert.Equal(t, market_data.OHLC{ISIN: apple, Mic: "XMUN", Open: 101, High: 101, Low: 101, Close: 101, Time: minute(2)}, result[2])
+		assert.Equal(t, 3, report.Dropped)
+		assert.Equal(t, 3, report.Filled)
+		assert.Equal(t, 7, report.Emitted)
+		assert.Equal(t, 1, report.Counts[ZeroVolume], "zero volumes are kept")
+	})
+	t.Run("series are independent", func(t *testing.T) {
+		other := bar(minute(0), 50)
+		other.Mic = "LMBPX"
+		_, report := CheckOHLC([]market_data.OHLC{bar(minute(1), 100), other, bar(minute(2), 100)}, Config{Interval: time.Minute})
+		assert.True(t, report.OK())
+		assert.Equal(t, "3 rows, 3 emitted, 0 dropped, 0 filled, no issues", report.String())
+	})
+	t.Run("no gaps outside sessions", func(t *testing.T) {
+		friday := time.Date(2022, 1, 7, 16, 29, 0, 0, time.UTC)
+		monday := time.Date(2022, 1, 10, 8, 0, 0, 0, time.UTC)
+		_, report := CheckOHLC([]market_data.OHLC{bar(friday, 100), bar(monday, 100), bar(monday.Add(2*time.Minute), 100)}, Config{Interval: time.Minute, Schedule: xetra(t)})
+		assert.Equal(t, map[string]int{Gap: 1}, report.Counts)
+		assert.Equal(t, monday.Add(time.Minute), report.Issues[0].From)
+		assert.Equal(t, 1, report.Issues[0].Missing)
+	})
+	t.Run("daily bars on trading days", func(t *testing.T) {
+		day := func(d int) market_data.OHLC {
+			return bar(time.Date(2022, 1, d, 0, 0, 0, 0, time.UTC), 100)
+		}
+		_, report := CheckOHLC([]market_data.OHLC{day(3), day(4), day(7), day(10)}, Config{Interval: 24 * time.Hour, Schedule: xetra(t)})
+		assert.Equal(t, 1, report.Counts[Gap])
+		assert.Equal(t, 2, report.Issues[0].Missing)
+		assert.Equal(t, time.Date(2022, 1, 5, 0, 0, 0, 0, time.UTC), report.Issues[0].From)
+	})
+}
+
+func TestQuotes(t *testing.T) {
+	quote := func(m int, bid, ask float64) market_data.Quote {
+		return market_data.Quote{ISIN: apple, Mic: "XMUN", Bid: bid, Ask: ask, BidVolume: 5, AskVolume: 5, Time: minute(m)}
+	}
+	empty := quote(3, 100, 101)
+	empty.BidVolume, empty.AskVolume = 0, 0
+	quotes := []market_data.Quote{quote(0, 100, 101), quote(0, 100, 101), quote(1, 102, 101), quote(2, 100, 0), empty}
+
+	result, report := CheckQuotes(quotes, Config{Interval: time.Minute})
+	assert.Len(t, result, 5)
+	assert.Equal(t, map[string]int{Duplicate: 1, CrossedQuote: 1, ZeroVolume: 1}, report.Counts)
+	assert.Equal(t, "crossed_quote US0378331005 XMUN at 2022-01-03T09:01:00Z: bid 102 above ask 101", report.Issues[1].String())
+
+	result, report = CheckQuotes(quotes, Config{Repair: Dedupe | Drop})
+	assert.Equal(t, []market_data.Quote{quotes[0], quotes[3], quotes[4]}, result)
+	assert.Equal(t, 2, report.Dropped)
+}
+
+func TestTrades(t *testing.T) {
+	trade := func(m int, price float32) market_data.Trade {
+		return market_data.Trade{ISIN: apple, Mic: "XMUN", Price: price, Volume: 3, Time: minute(m)}
+	}
+	zero := trade(3, 100)
+	zero.Volume = 0
+	trades := []market_data.Trade{trade(1, 100), trade(1, 101), trade(1, 101), trade(0, 99), zero}
+
+	result, report := CheckTrades(trades, Config{Repair: Dedupe | Drop})
+	assert.Equal(t, []market_data.Trade{trades[0], trades[1], zero}, result, "trades may share a timestamp")
+	assert.Equal(t, map[string]int{Duplicate: 1, OutOfOrder: 1, ZeroVolume: 1}, report.Counts)
+	assert.Equal(t, "out_of_order US0378331005 XMUN at 2022-01-03T09:00:00Z: after 2022-01-03T09:01:00Z", report.Issues[1].String())
+}
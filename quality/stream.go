@@ -0,0 +1,64 @@
+package quality
+
+import "github.com/quantfamily/lemonmarkets/market_data"
+
+/*
+ValidateOHLC validates bars from the market data client on their way to the consumer.
+Errors are passed on as they are. Read the report of the returned Validator once the channel is closed
+*/
+func ValidateOHLC(in <-chan market_data.Item[market_data.OHLC, error], config Config) (<-chan market_data.Item[market_data.OHLC, error], *Validator) {
+	validator := NewValidator(config)
+	return stage(in, validator.OHLC), validator
+}
+
+// ValidateQuotes validates quotes from the market data client, see ValidateOHLC
+func ValidateQuotes(in <-chan market_data.Item[market_data.Quote, error], config Config) (<-chan market_data.Item[market_data.Quote, error], *Validator) {
+	validator := NewValidator(config)
+	return stage(in, validator.Quote), validator
+}
+
+// ValidateTrades validates trades from the market data client, see ValidateOHLC
+func ValidateTrades(in <-chan market_data.Item[market_data.Trade, error], config Config) (<-chan market_data.Item[market_data.Trade, error], *Validator) {
+	validator := NewValidator(config)
+	return stage(in, validator.Trade), validator
+}
+
+// CheckOHLC validates bars already in memory and returns the repaired bars with the report
+func CheckOHLC(bars []market_data.OHLC, config Config) ([]market_data.OHLC, Report) {
+	return check(bars, NewValidator(config), (*Validator).OHLC)
+}
+
+// CheckQuotes validates quotes already in memory, see CheckOHLC
+func CheckQuotes(quotes []market_data.Quote, config Config) ([]market_data.Quote, Report) {
+	return check(quotes, NewValidator(config), (*Validator).Quote)
+}
+
+// CheckTrades validates trades already in memory, see CheckOHLC
+func CheckTrades(trades []market_data.Trade, config Config) ([]market_data.Trade, Report) {
+	return check(trades, NewValidator(config), (*Validator).Trade)
+}
+
+func stage[T market_data.DataTypes](in <-chan market_data.Item[T, error], validate func(T) []T) <-chan market_data.Item[T, error] {
+	out := make(chan market_data.Item[T, error])
+	go func() {
+		defer close(out)
+		for item := range in {
+			if item.Error != nil {
+				out <- item
+				continue
+			}
+			for _, row := range validate(item.Data) {
+				out <- market_data.Item[T, error]{Data: row}
+			}
+		}
+	}()
+	return out
+}
+
+func check[T market_data.DataTypes](rows []T, validator *Validator, validate func(*Validator, T) []T) ([]T, Report) {
+	result := make([]T, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, validate(validator, row)...)
+	}
+	return result, validator.Report()
+}
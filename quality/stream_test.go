@@ -0,0 +1,57 @@
+package quality
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateOHLC(t *testing.T) {
+	in := make(chan market_data.Item[market_data.OHLC, error], 4)
+	in <- market_data.Item[market_data.OHLC, error]{Data: bar(minute(0), 100)}
+	in <- market_data.Item[market_data.OHLC, error]{Data: bar(minute(0), 100)}
+	in <- market_data.Item[market_data.OHLC, error]{Data: bar(minute(2), 100)}
+	in <- market_data.Item[market_data.OHLC, error]{Error: errors.New("unknown http error from backend: 500")}
+	close(in)
+
+	out, validator := ValidateOHLC(in, Config{Interval: time.Minute, Repair: Dedupe | ForwardFill})
+	var bars []market_data.OHLC
+	var errs []error
+	for item := range out {
+		if item.Error != nil {
+			errs = append(errs, item.Error)
+			continue
+		}
+		bars = append(bars, item.Data)
+	}
+	assert.Equal(t, []time.Time{minute(0), minute(1), minute(2)}, times(bars))
+	assert.Len(t, errs, 1)
+	report := validator.Report()
+	assert.Equal(t, map[string]int{Duplicate: 1, Gap: 1}, report.Counts)
+	assert.Equal(t, 3, report.Rows)
+}
+
+func TestValidateQuotesAndTrades(t *testing.T) {
+	quotes := make(chan market_data.Item[market_data.Quote, error], 1)
+	quotes <- market_data.Item[market_data.Quote, error]{Data: market_data.Quote{ISIN: apple, Bid: 2, Ask: 1, BidVolume: 1, Time: minute(0)}}
+	close(quotes)
+	outQuotes, quoteValidator := ValidateQuotes(quotes, Config{Repair: Drop})
+	for range outQuotes {
+		t.Error("crossed quote is dropped")
+	}
+	assert.Equal(t, 1, quoteValidator.Report().Counts[CrossedQuote])
+
+	trades := make(chan market_data.Item[market_data.Trade, error], 1)
+	trades <- market_data.Item[market_data.Trade, error]{Data: market_data.Trade{ISIN: apple, Price: 1, Volume: 1, Time: minute(0)}}
+	close(trades)
+	outTrades, tradeValidator := ValidateTrades(trades, Config{})
+	count := 0
+	for range outTrades {
+		count++
+	}
+	assert.Equal(t, 1, count)
+	assert.True(t, tradeValidator.Report().OK())
+}
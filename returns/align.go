@@ -0,0 +1,132 @@
+package returns
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/calendar"
+	"github.com/quantfamily/lemonmarkets/market_data"
+)
+
+var (
+	// ErrNoData is returned when there are no prices or too few rows for a statistic
+	ErrNoData = errors.New("not enough data")
+	// ErrUnknownISIN is returned when an ISIN is not part of a frame
+	ErrUnknownISIN = errors.New("unknown ISIN")
+)
+
+/*
+AlignConfig decides the dates of the aligned prices. With a Schedule there is a row for every trading day
+from the first to the last bar and bars on other days are ignored, otherwise there is a row for every date
+with at least one bar. A missing close is filled with the previous close of the same ISIN, rows before every
+ISIN has a close are dropped. With Intersect only dates where every ISIN has a bar are kept and nothing is filled
+*/
+type AlignConfig struct {
+	Schedule  *calendar.Schedule
+	Intersect bool
+}
+
+// day is the UTC midnight of the date of t in UTC, the time of daily bars
+func day(t time.Time) time.Time {
+	year, month, d := t.UTC().Date()
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+/*
+Align returns the closes of daily bars per ISIN on common dates, columns sorted by ISIN.
+When an ISIN has several bars on a date, such as from several venues, the latest one is used
+*/
+func Align(series map[string][]market_data.OHLC, config AlignConfig) (*Frame, error) {
+	isins := make([]string, 0, len(series))
+	for isin := range series {
+		isins = append(isins, isin)
+	}
+	sort.Strings(isins)
+	if len(isins) == 0 {
+		return nil, ErrNoData
+	}
+
+	closes := make([]map[time.Time]float64, len(isins))
+	seen := make(map[time.Time]bool)
+	var first, last time.Time
+	for j, isin := range isins {
+		closes[j] = make(map[time.Time]float64)
+		times := make(map[time.Time]time.Time)
+		for _, bar := range series[isin] {
+			date := day(bar.Time)
+			if previous, ok := times[date]; ok && bar.Time.Before(previous) {
+				continue
+			}
+			times[date] = bar.Time
+			closes[j][date] = bar.Close
+			seen[date] = true
+			if first.IsZero() || date.Before(first) {
+				first = date
+			}
+			if date.After(last) {
+				last = date
+			}
+		}
+		if len(closes[j]) == 0 {
+			return nil, fmt.Errorf("%w: no bars of %s", ErrNoData, isin)
+		}
+	}
+
+	var dates []time.Time
+	if config.Schedule != nil {
+		for _, date := range config.Schedule.TradingDays(first, last) {
+			dates = append(dates, time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC))
+		}
+	} else {
+		for date := range seen {
+			dates = append(dates, date)
+		}
+		sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	}
+
+	frame := &Frame{ISINs: isins}
+	var data []float64
+	previous := make([]float64, len(isins))
+	for _, date := range dates {
+		complete := true
+		for j := range isins {
+			if value, ok := closes[j][date]; ok {
+				previous[j] = value
+			} else if config.Intersect {
+				complete = false
+			}
+			if previous[j] == 0 {
+				complete = false
+			}
+		}
+		if !complete {
+			continue
+		}
+		frame.Dates = append(frame.Dates, date)
+		data = append(data, previous...)
+	}
+	if len(frame.Dates) == 0 {
+		return nil, ErrNoData
+	}
+	frame.Matrix = Matrix{Rows: len(frame.Dates), Cols: len(isins), Data: data}
+	return frame, nil
+}
+
+// Load reads daily bars from the market data client per ISIN and aligns them, it stops at the first error
+func Load(streams map[string]<-chan market_data.Item[market_data.OHLC, error], config AlignConfig) (*Frame, error) {
+	series := make(map[string][]market_data.OHLC, len(streams))
+	for isin, stream := range streams {
+		for item := range stream {
+			if item.Error != nil {
+				for _, stream := range streams {
+					market_data.Drain(stream)
+				}
+				return nil, fmt.Errorf("%s: %w", isin, item.Error)
+			}
+			series[isin] = append(series[isin], item.Data)
+		}
+	}
+	return Align(series, config)
+}
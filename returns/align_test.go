@@ -0,0 +1,104 @@
+package returns
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/calendar"
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	apple = "US0378331005"
+	sap   = "DE0007164600"
+)
+
+func date(day int) time.Time {
+	return time.Date(2022, 1, day, 0, 0, 0, 0, time.UTC)
+}
+
+func bars(isin string, closes map[int]float64) []market_data.OHLC {
+	var result []market_data.OHLC
+	for day, close := range closes {
+		result = append(result, market_data.OHLC{ISIN: isin, Mic: "XMUN", Close: close, Time: date(day)})
+	}
+	return result
+}
+
+func TestAlign(t *testing.T) {
+	series := map[string][]market_data.OHLC{
+		apple: bars(apple, map[int]float64{3: 10, 4: 11, 5: 12, 7: 13, 8: 14}),
+		sap:   bars(sap, map[int]float64{4: 100, 6: 101, 7: 102}),
+	}
+
+	t.Run("forward fill", func(t *testing.T) {
+		frame, err := Align(series, AlignConfig{})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{sap, apple}, frame.ISINs)
+		assert.Equal(t, []time.Time{date(4), date(5), date(6), date(7), date(8)}, frame.Dates, "the 3rd has no SAP close yet")
+		assert.Equal(t, [][]float64{{100, 11}, {100, 12}, {101, 12}, {102, 13}, {102, 14}}, frame.Slices())
+	})
+	t.Run("intersect", func(t *testing.T) {
+		frame, err := Align(series, AlignConfig{Intersect: true})
+		assert.Nil(t, err)
+		assert.Equal(t, []time.Time{date(4), date(7)}, frame.Dates)
+		assert.Equal(t, [][]float64{{100, 11}, {102, 13}}, frame.Slices())
+	})
+	t.Run("trading days", func(t *testing.T) {
+		schedule, err := calendar.Default().Schedule("XETR")
+		assert.Nil(t, err)
+		weekend := map[string][]market_data.OHLC{
+			apple: bars(apple, map[int]float64{7: 10, 8: 99, 11: 12}),
+			sap:   bars(sap, map[int]float64{7: 100, 10: 101, 11: 102}),
+		}
+		frame, err := Align(weekend, AlignConfig{Schedule: &schedule})
+		assert.Nil(t, err)
+		assert.Equal(t, []time.Time{date(7), date(10), date(11)}, frame.Dates)
+		assert.Equal(t, [][]float64{{100, 10}, {101, 10}, {102, 12}}, frame.Slices(), "the Saturday bar is ignored")
+	})
+	t.Run("latest bar of a date", func(t *testing.T) {
+		late := market_data.OHLC{ISIN: apple, Mic: "LMBPX", Close: 20, Time: date(3).Add(time.Hour)}
+		frame, err := Align(map[string][]market_data.OHLC{apple: {late, bars(apple, map[int]float64{3: 10})[0]}}, AlignConfig{})
+		assert.Nil(t, err)
+		assert.Equal(t, []float64{20}, frame.Data)
+	})
+	t.Run("no data", func(t *testing.T) {
+		_, err := Align(nil, AlignConfig{})
+		assert.Equal(t, ErrNoData, err)
+		_, err = Align(map[string][]market_data.OHLC{apple: nil}, AlignConfig{})
+		assert.EqualError(t, err, "not enough data: no bars of US0378331005")
+		_, err = Align(map[string][]market_data.OHLC{apple: bars(apple, map[int]float64{3: 1}), sap: bars(sap, map[int]float64{4: 1})}, AlignConfig{Intersect: true})
+		assert.Equal(t, ErrNoData, err)
+	})
+}
+
+func channel(rows []market_data.OHLC, err error) <-chan market_data.Item[market_data.OHLC, error] {
+	ch := make(chan market_data.Item[market_data.OHLC, error], len(rows)+1)
+	for _, row := range rows {
+		ch <- market_data.Item[market_data.OHLC, error]{Data: row}
+	}
+	if err != nil {
+		ch <- market_data.Item[market_data.OHLC, error]{Error: err}
+	}
+	close(ch)
+	return ch
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("Successful test", func(t *testing.T) {
+		frame, err := Load(map[string]<-chan market_data.Item[market_data.OHLC, error]{
+			apple: channel(bars(apple, map[int]float64{3: 10, 4: 11}), nil),
+			sap:   channel(bars(sap, map[int]float64{3: 100, 4: 101}), nil),
+		}, AlignConfig{})
+		assert.Nil(t, err)
+		assert.Equal(t, [][]float64{{100, 10}, {101, 11}}, frame.Slices())
+	})
+	t.Run("fail to get response", func(t *testing.T) {
+		_, err := Load(map[string]<-chan market_data.Item[market_data.OHLC, error]{
+			apple: channel(nil, errors.New("unknown http error from backend: 500")),
+		}, AlignConfig{})
+		assert.EqualError(t, err, "US0378331005: unknown http error from backend: 500")
+	})
+}
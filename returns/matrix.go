@@ -0,0 +1,124 @@
+/*
+Package returns aligns daily bars of several instruments on common dates and computes returns, volatility,
+covariance, correlation, beta and drawdowns as matrices for portfolio construction.
+
+	prices, err := returns.Load(map[string]<-chan market_data.Item[market_data.OHLC, error]{
+		apple: client.GetOHLCPerDay(&appleQuery),
+		sap:   client.GetOHLCPerDay(&sapQuery),
+	}, returns.AlignConfig{Schedule: &xetra})
+	daily := prices.Returns(returns.Log)
+	covariance, err := returns.Covariance(daily)
+	annual := covariance.Annualize(returns.TradingDaysPerYear)
+*/
+package returns
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+Matrix is a dense matrix of Rows times Cols values stored row by row in Data
+*/
+type Matrix struct {
+	Rows int
+	Cols int
+	Data []float64
+}
+
+// NewMatrix returns a matrix of zeros
+func NewMatrix(rows, cols int) Matrix {
+	return Matrix{Rows: rows, Cols: cols, Data: make([]float64, rows*cols)}
+}
+
+// At returns the value in row i and column j
+func (m Matrix) At(i, j int) float64 {
+	return m.Data[i*m.Cols+j]
+}
+
+// Set the value in row i and column j
+func (m Matrix) Set(i, j int, value float64) {
+	m.Data[i*m.Cols+j] = value
+}
+
+// Row returns a copy of row i
+func (m Matrix) Row(i int) []float64 {
+	return append([]float64(nil), m.Data[i*m.Cols:(i+1)*m.Cols]...)
+}
+
+// Col returns a copy of column j
+func (m Matrix) Col(j int) []float64 {
+	col := make([]float64, m.Rows)
+	for i := range col {
+		col[i] = m.At(i, j)
+	}
+	return col
+}
+
+// Slices returns a copy as one slice per row
+func (m Matrix) Slices() [][]float64 {
+	rows := make([][]float64, m.Rows)
+	for i := range rows {
+		rows[i] = m.Row(i)
+	}
+	return rows
+}
+
+/*
+Frame is a time series of several instruments, a row per date and a column per ISIN
+*/
+type Frame struct {
+	ISINs []string
+	Dates []time.Time
+	Matrix
+}
+
+// Column returns the index of an ISIN, ErrUnknownISIN if it is not in the frame
+func (f *Frame) Column(isin string) (int, error) {
+	for j, name := range f.ISINs {
+		if name == isin {
+			return j, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s", ErrUnknownISIN, isin)
+}
+
+// Series returns the values of an ISIN, oldest first
+func (f *Frame) Series(isin string) ([]float64, error) {
+	j, err := f.Column(isin)
+	if err != nil {
+		return nil, err
+	}
+	return f.Col(j), nil
+}
+
+/*
+Square is a matrix with a row and a column per ISIN, such as a covariance matrix
+*/
+type Square struct {
+	ISINs []string
+	Matrix
+}
+
+// Get returns the value for two ISINs, ErrUnknownISIN if one of them is not in the matrix
+func (s Square) Get(row, col string) (float64, error) {
+	frame := Frame{ISINs: s.ISINs}
+	i, err := frame.Column(row)
+	if err != nil {
+		return 0, err
+	}
+	j, err := frame.Column(col)
+	if err != nil {
+		return 0, err
+	}
+	return s.At(i, j), nil
+}
+
+// Annualize returns a copy scaled by periods per year, for covariances of daily returns use TradingDaysPerYear
+func (s Square) Annualize(periods float64) Square {
+	scaled := Square{ISINs: append([]string(nil), s.ISINs...), Matrix: NewMatrix(s.Rows, s.Cols)}
+	for i, value := range s.Data {
+		scaled.Data[i] = value * periods
+	}
+	return scaled
+}
@@ -0,0 +1,41 @@
+package returns
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatrix(t *testing.T) {
+	m := NewMatrix(2, 3)
+	m.Set(0, 1, 1)
+	m.Set(1, 2, 2)
+	assert.Equal(t, 2.0, m.At(1, 2))
+	assert.Equal(t, []float64{0, 1, 0}, m.Row(0))
+	assert.Equal(t, []float64{0, 2}, m.Col(2))
+	assert.Equal(t, [][]float64{{0, 1, 0}, {0, 0, 2}}, m.Slices())
+	row := m.Row(0)
+	row[0] = 5
+	assert.Equal(t, 0.0, m.At(0, 0), "rows are copies")
+}
+
+func TestFrame(t *testing.T) {
+	frame := &Frame{ISINs: []string{apple, sap}, Matrix: Matrix{Rows: 2, Cols: 2, Data: []float64{1, 2, 3, 4}}}
+	series, err := frame.Series(sap)
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{2, 4}, series)
+	_, err = frame.Series("US88160R1014")
+	assert.True(t, errors.Is(err, ErrUnknownISIN))
+	assert.EqualError(t, err, "unknown ISIN: US88160R1014")
+
+	square := Square{ISINs: []string{apple, sap}, Matrix: Matrix{Rows: 2, Cols: 2, Data: []float64{1, 2, 3, 4}}}
+	value, err := square.Get(sap, apple)
+	assert.Nil(t, err)
+	assert.Equal(t, 3.0, value)
+	_, err = square.Get(apple, "US88160R1014")
+	assert.True(t, errors.Is(err, ErrUnknownISIN))
+	annual := square.Annualize(252)
+	assert.Equal(t, []float64{252, 504, 756, 1008}, annual.Data)
+	assert.Equal(t, 1.0, square.At(0, 0), "annualize copies")
+}
@@ -0,0 +1,199 @@
+package returns
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// TradingDaysPerYear annualizes statistics of daily returns
+const TradingDaysPerYear = 252
+
+// Kind of returns
+type Kind int
+
+const (
+	// Simple returns are the change over the previous price, p1 / p0 - 1
+	Simple Kind = iota
+	// Log returns are ln(p1 / p0), they add up over time
+	Log
+)
+
+/*
+Returns of prices, one row less than the prices, dated at the end of each period
+*/
+func (f *Frame) Returns(kind Kind) *Frame {
+	rows := f.Rows - 1
+	if rows < 0 {
+		rows = 0
+	}
+	returns := &Frame{ISINs: append([]string(nil), f.ISINs...), Matrix: NewMatrix(rows, f.Cols)}
+	if rows > 0 {
+		returns.Dates = append(returns.Dates, f.Dates[1:]...)
+	}
+	for i := 0; i < rows; i++ {
+		for j := 0; j < f.Cols; j++ {
+			ratio := f.At(i+1, j) / f.At(i, j)
+			if kind == Log {
+				returns.Set(i, j, math.Log(ratio))
+			} else {
+				returns.Set(i, j, ratio-1)
+			}
+		}
+	}
+	return returns
+}
+
+// Mean of every column
+func (f *Frame) Mean() []float64 {
+	means := make([]float64, f.Cols)
+	if f.Rows == 0 {
+		return means
+	}
+	for i := 0; i < f.Rows; i++ {
+		for j := range means {
+			means[j] += f.At(i, j)
+		}
+	}
+	for j := range means {
+		means[j] /= float64(f.Rows)
+	}
+	return means
+}
+
+/*
+RollingVolatility is the sample standard deviation of returns over window rows, scaled by the square root of
+periods per year, such as TradingDaysPerYear for daily returns or 1 to keep it per period.
+The first row is dated at the end of the first full window
+*/
+func RollingVolatility(returns *Frame, window int, periods float64) (*Frame, error) {
+	if window < 2 || returns.Rows < window {
+		return nil, fmt.Errorf("%w: volatility over %d rows needs a window of at least 2, got %d", ErrNoData, returns.Rows, window)
+	}
+	rows := returns.Rows - window + 1
+	volatility := &Frame{ISINs: append([]string(nil), returns.ISINs...), Dates: append([]time.Time(nil), returns.Dates[window-1:]...), Matrix: NewMatrix(rows, returns.Cols)}
+	scale := math.Sqrt(periods)
+	for j := 0; j < returns.Cols; j++ {
+		column := returns.Col(j)
+		for i := 0; i < rows; i++ {
+			volatility.Set(i, j, math.Sqrt(covariance(column[i:i+window], column[i:i+window]))*scale)
+		}
+	}
+	return volatility, nil
+}
+
+// covariance is the sample covariance of two series of the same length
+func covariance(a, b []float64) float64 {
+	meanA, meanB := 0.0, 0.0
+	for i := range a {
+		meanA += a[i]
+		meanB += b[i]
+	}
+	meanA /= float64(len(a))
+	meanB /= float64(len(b))
+	sum := 0.0
+	for i := range a {
+		sum += (a[i] - meanA) * (b[i] - meanB)
+	}
+	return sum / float64(len(a)-1)
+}
+
+// Covariance is the sample covariance matrix of the columns of returns
+func Covariance(returns *Frame) (Square, error) {
+	if returns.Rows < 2 {
+		return Square{}, fmt.Errorf("%w: covariance needs at least 2 rows, got %d", ErrNoData, returns.Rows)
+	}
+	columns := make([][]float64, returns.Cols)
+	for j := range columns {
+		columns[j] = returns.Col(j)
+	}
+	result := Square{ISINs: append([]string(nil), returns.ISINs...), Matrix: NewMatrix(returns.Cols, returns.Cols)}
+	for i := range columns {
+		for j := i; j < len(columns); j++ {
+			value := covariance(columns[i], columns[j])
+			result.Set(i, j, value)
+			result.Set(j, i, value)
+		}
+	}
+	return result, nil
+}
+
+// Correlation is the correlation matrix of the columns of returns, 0 for columns that do not change
+func Correlation(returns *Frame) (Square, error) {
+	result, err := Covariance(returns)
+	if err != nil {
+		return result, err
+	}
+	deviations := make([]float64, result.Cols)
+	for i := range deviations {
+		deviations[i] = math.Sqrt(result.At(i, i))
+	}
+	for i := range deviations {
+		for j := range deviations {
+			value := 0.0
+			if deviations[i] > 0 && deviations[j] > 0 {
+				value = result.At(i, j) / (deviations[i] * deviations[j])
+			}
+			if i == j {
+				value = 1
+			}
+			result.Set(i, j, value)
+		}
+	}
+	return result, nil
+}
+
+// Beta of every ISIN against the benchmark, the covariance with the benchmark over its variance
+func Beta(returns *Frame, benchmark string) (map[string]float64, error) {
+	b, err := returns.Column(benchmark)
+	if err != nil {
+		return nil, err
+	}
+	covariances, err := Covariance(returns)
+	if err != nil {
+		return nil, err
+	}
+	variance := covariances.At(b, b)
+	if variance == 0 {
+		return nil, fmt.Errorf("%w: %s does not change", ErrNoData, benchmark)
+	}
+	betas := make(map[string]float64, len(returns.ISINs))
+	for j, isin := range returns.ISINs {
+		betas[isin] = covariances.At(j, b) / variance
+	}
+	return betas, nil
+}
+
+// Drawdowns of prices, the fall from the highest price so far as a negative fraction, 0 at new highs
+func Drawdowns(prices *Frame) *Frame {
+	drawdowns := &Frame{ISINs: append([]string(nil), prices.ISINs...), Dates: append([]time.Time(nil), prices.Dates...), Matrix: NewMatrix(prices.Rows, prices.Cols)}
+	for j := 0; j < prices.Cols; j++ {
+		peak := 0.0
+		for i := 0; i < prices.Rows; i++ {
+			price := prices.At(i, j)
+			if price > peak {
+				peak = price
+			}
+			if peak > 0 {
+				drawdowns.Set(i, j, price/peak-1)
+			}
+		}
+	}
+	return drawdowns
+}
+
+// MaxDrawdowns are the largest falls from a peak per ISIN as positive fractions, like backtest.MaxDrawdown
+func MaxDrawdowns(prices *Frame) map[string]float64 {
+	drawdowns := Drawdowns(prices)
+	result := make(map[string]float64, len(prices.ISINs))
+	for j, isin := range prices.ISINs {
+		worst := 0.0
+		for i := 0; i < drawdowns.Rows; i++ {
+			if value := drawdowns.At(i, j); value < worst {
+				worst = value
+			}
+		}
+		result[isin] = -worst
+	}
+	return result
+}
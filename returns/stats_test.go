@@ -0,0 +1,102 @@
+package returns
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// prices of two ISINs where the second moves twice as much as the first
+func prices() *Frame {
+	return &Frame{
+		ISINs: []string{"A", "B"},
+		Dates: []time.Time{date(3), date(4), date(5), date(6), date(7)},
+		Matrix: Matrix{Rows: 5, Cols: 2, Data: []float64{
+			100, 100,
+			110, 120,
+			99, 96,
+			108.9, 115.2,
+			108.9, 115.2,
+		}},
+	}
+}
+
+func TestReturns(t *testing.T) {
+	simple := prices().Returns(Simple)
+	assert.Equal(t, []time.Time{date(4), date(5), date(6), date(7)}, simple.Dates)
+	assert.InDeltaSlice(t, []float64{0.1, -0.1, 0.1, 0}, simple.Col(0), 1e-9)
+	assert.InDeltaSlice(t, []float64{0.2, -0.2, 0.2, 0}, simple.Col(1), 1e-9)
+	assert.InDeltaSlice(t, []float64{0.025, 0.05}, simple.Mean(), 1e-9)
+
+	log := prices().Returns(Log)
+	assert.InDelta(t, math.Log(1.1), log.At(0, 0), 1e-9)
+
+	empty := (&Frame{ISINs: []string{"A"}, Matrix: NewMatrix(1, 1)}).Returns(Simple)
+	assert.Equal(t, 0, empty.Rows)
+	assert.Equal(t, []float64{0}, empty.Mean())
+}
+
+func TestRollingVolatility(t *testing.T) {
+	returns := prices().Returns(Simple)
+	volatility, err := RollingVolatility(returns, 2, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Time{date(5), date(6), date(7)}, volatility.Dates)
+	assert.InDeltaSlice(t, []float64{0.2 / math.Sqrt2, 0.2 / math.Sqrt2, 0.1 / math.Sqrt2}, volatility.Col(0), 1e-9)
+
+	annual, err := RollingVolatility(returns, 4, TradingDaysPerYear)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, annual.Rows)
+	assert.InDelta(t, 2*annual.At(0, 0), annual.At(0, 1), 1e-9)
+
+	_, err = RollingVolatility(returns, 5, 1)
+	assert.True(t, errors.Is(err, ErrNoData))
+	_, err = RollingVolatility(returns, 1, 1)
+	assert.True(t, errors.Is(err, ErrNoData))
+}
+
+func TestCovariance(t *testing.T) {
+	returns := prices().Returns(Simple)
+	covariance, err := Covariance(returns)
+	assert.Nil(t, err)
+	variance := (0.075*0.075 + 0.125*0.125 + 0.075*0.075 + 0.025*0.025) / 3
+	assert.InDeltaSlice(t, []float64{variance, 2 * variance, 2 * variance, 4 * variance}, covariance.Data, 1e-12)
+
+	correlation, err := Correlation(returns)
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float64{1, 1, 1, 1}, correlation.Data, 1e-9)
+
+	flat := &Frame{ISINs: []string{"A", "B"}, Matrix: Matrix{Rows: 2, Cols: 2, Data: []float64{0.1, 0, -0.1, 0}}}
+	correlation, err = Correlation(flat)
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{1, 0, 0, 1}, correlation.Data)
+
+	_, err = Covariance(&Frame{Matrix: NewMatrix(1, 2)})
+	assert.True(t, errors.Is(err, ErrNoData))
+}
+
+func TestBeta(t *testing.T) {
+	returns := prices().Returns(Simple)
+	betas, err := Beta(returns, "A")
+	assert.Nil(t, err)
+	assert.InDelta(t, 1, betas["A"], 1e-9)
+	assert.InDelta(t, 2, betas["B"], 1e-9)
+
+	_, err = Beta(returns, "C")
+	assert.True(t, errors.Is(err, ErrUnknownISIN))
+	flat := &Frame{ISINs: []string{"A", "B"}, Matrix: Matrix{Rows: 2, Cols: 2, Data: []float64{0.1, 0, -0.1, 0}}}
+	_, err = Beta(flat, "B")
+	assert.EqualError(t, err, "not enough data: B does not change")
+}
+
+func TestDrawdowns(t *testing.T) {
+	drawdowns := Drawdowns(prices())
+	assert.Equal(t, prices().Dates, drawdowns.Dates)
+	assert.InDeltaSlice(t, []float64{0, 0, -0.1, -0.01, -0.01}, drawdowns.Col(0), 1e-9)
+	assert.InDeltaSlice(t, []float64{0, 0, -0.2, -0.04, -0.04}, drawdowns.Col(1), 1e-9)
+	maximum := MaxDrawdowns(prices())
+	assert.InDelta(t, 0.1, maximum["A"], 1e-9)
+	assert.InDelta(t, 0.2, maximum["B"], 1e-9)
+}
@@ -0,0 +1,232 @@
+package simulator
+
+import (
+	"math"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/quantfamily/lemonmarkets/trading"
+)
+
+/*
+market is what a single quote or bar tells about the prices an order can trade at.
+For quotes buys trade at the ask and sells at the bid, for bars both trade at the open
+and high/low decide if limits and stops were reached during the bar
+*/
+type market struct {
+	buy, sell             int
+	buyVolume, sellVolume int
+	buyLow, buyHigh       int
+	sellLow, sellHigh     int
+	bar                   bool
+}
+
+func quoteMarket(quote market_data.Quote) market {
+	ask, bid := trading.ToAmount(quote.Ask), trading.ToAmount(quote.Bid)
+	return market{
+		buy:        ask,
+		sell:       bid,
+		buyVolume:  quote.AskVolume,
+		sellVolume: quote.BidVolume,
+		buyLow:     ask,
+		buyHigh:    ask,
+		sellLow:    bid,
+		sellHigh:   bid,
+	}
+}
+
+func barMarket(ohlc market_data.OHLC) market {
+	open, high, low := trading.ToAmount(ohlc.Open), trading.ToAmount(ohlc.High), trading.ToAmount(ohlc.Low)
+	return market{
+		buy:        open,
+		sell:       open,
+		buyVolume:  ohlc.Volume,
+		sellVolume: ohlc.Volume,
+		buyLow:     low,
+		buyHigh:    high,
+		sellLow:    low,
+		sellHigh:   high,
+		bar:        true,
+	}
+}
+
+// match tries to fill every open order of the ISIN, oldest first
+func (s *Simulator) match(isin string, m market) []trading.Order {
+	var changed []trading.Order
+	now := s.time()
+	for _, id := range s.sequence {
+		order := s.orders[id]
+		if order.ISIN != isin || (order.Status != trading.OrderActivated && order.Status != trading.OrderPartiallyExecuted) {
+			continue
+		}
+		if !order.ExpiresAt.IsZero() && now.After(order.ExpiresAt) {
+			order.Status = trading.OrderExpired
+			changed = append(changed, *order)
+			continue
+		}
+		// an empty or one-sided quote has no price to trade at on that side
+		if base, _, _ := m.side(order.Side); base <= 0 {
+			continue
+		}
+		triggeredNow := false
+		if order.StopPrice > 0 && !s.triggered[id] {
+			if !stopReached(order, m) {
+				continue
+			}
+			s.triggered[id] = true
+			triggeredNow = true
+		}
+		price, ok := fillPrice(order, m, triggeredNow)
+		if !ok {
+			continue
+		}
+		price = s.slip(order, price)
+		quantity := order.Quantity - order.ExecutedQuantity
+		if volume := m.volume(order.Side); s.config.Participation > 0 && volume > 0 {
+			if limit := int(float64(volume) * s.config.Participation); limit < quantity {
+				quantity = limit
+			}
+		}
+		// too little volume to take a share of, the order waits for the next event
+		if quantity <= 0 {
+			continue
+		}
+		if order.Side == trading.Buy {
+			quantity = s.affordable(order, quantity, price)
+			if quantity == 0 && order.ExecutedQuantity == 0 {
+				order.Status = trading.OrderRejected
+				order.RejectedAt = now
+				changed = append(changed, *order)
+				continue
+			}
+		}
+		if quantity <= 0 {
+			continue
+		}
+		s.fill(order, quantity, price)
+		changed = append(changed, *order)
+	}
+	return changed
+}
+
+func (m market) volume(side string) int {
+	if side == trading.Buy {
+		return m.buyVolume
+	}
+	return m.sellVolume
+}
+
+func (m market) side(side string) (base, low, high int) {
+	if side == trading.Buy {
+		return m.buy, m.buyLow, m.buyHigh
+	}
+	return m.sell, m.sellLow, m.sellHigh
+}
+
+// stopReached is true when a buy stop is touched from below or a sell stop from above
+func stopReached(order *trading.Order, m market) bool {
+	_, low, high := m.side(order.Side)
+	if order.Side == trading.Buy {
+		return high >= order.StopPrice
+	}
+	return low <= order.StopPrice
+}
+
+/*
+fillPrice returns the price before slippage an order trades at, false if the order does not trade on this event.
+triggered is true when a stop was reached on this event
+*/
+func fillPrice(order *trading.Order, m market, triggered bool) (int, bool) {
+	buy := order.Side == trading.Buy
+	base, low, high := m.side(order.Side)
+	// A bar that opens beyond the stop fills at the open, otherwise at the stop
+	if triggered && m.bar && (buy && base < order.StopPrice || !buy && base > order.StopPrice) {
+		base = order.StopPrice
+	}
+	if order.LimitPrice <= 0 {
+		return base, true
+	}
+	if buy {
+		if low > order.LimitPrice {
+			return 0, false
+		}
+		if base > order.LimitPrice {
+			base = order.LimitPrice
+		}
+		return base, true
+	}
+	if high < order.LimitPrice {
+		return 0, false
+	}
+	if base < order.LimitPrice {
+		base = order.LimitPrice
+	}
+	return base, true
+}
+
+// slip moves the price against the order, without crossing the limit price
+func (s *Simulator) slip(order *trading.Order, price int) int {
+	if order.Side == trading.Buy {
+		price = int(math.Round(float64(price) * (1 + s.config.Slippage)))
+		if order.LimitPrice > 0 && price > order.LimitPrice {
+			price = order.LimitPrice
+		}
+		return price
+	}
+	price = int(math.Round(float64(price) * (1 - s.config.Slippage)))
+	if order.LimitPrice > 0 && price < order.LimitPrice {
+		price = order.LimitPrice
+	}
+	return price
+}
+
+// affordable reduces a buy quantity to what the cash covers including fees
+func (s *Simulator) affordable(order *trading.Order, quantity, price int) int {
+	firstFill := order.ExecutedQuantity == 0
+	if most := s.cash / price; quantity > most {
+		quantity = most
+	}
+	for ; quantity > 0; quantity-- {
+		value := quantity * price
+		if value+s.config.Fees.charge(value, firstFill) <= s.cash {
+			return quantity
+		}
+	}
+	return 0
+}
+
+// fill executes quantity of the order at price and updates cash and positions
+func (s *Simulator) fill(order *trading.Order, quantity, price int) {
+	value := quantity * price
+	fee := s.config.Fees.charge(value, order.ExecutedQuantity == 0)
+	now := s.time()
+
+	order.ExecutedQuantity += quantity
+	order.ExecutedPriceTotal += value
+	order.ExecutedPrice = order.ExecutedPriceTotal / order.ExecutedQuantity
+	order.ExecutedAt = now
+	order.Charge += float64(fee)
+	order.ChargeableAt = now
+	order.Status = trading.OrderPartiallyExecuted
+	if order.ExecutedQuantity == order.Quantity {
+		order.Status = trading.OrderExecuted
+	}
+
+	position, ok := s.positions[order.ISIN]
+	if !ok {
+		position = &trading.Position{ISIN: order.ISIN, ISINTitle: order.ISINTitle}
+		s.positions[order.ISIN] = position
+	}
+	if order.Side == trading.Buy {
+		s.cash -= value + fee
+		total := position.BuyPriceAverage*position.Quantity + value
+		position.Quantity += quantity
+		position.BuyPriceAverage = total / position.Quantity
+	} else {
+		s.cash += value - fee
+		position.Quantity -= quantity
+	}
+	position.EstimatedPrice = price
+	if position.Quantity == 0 {
+		delete(s.positions, order.ISIN)
+	}
+}
@@ -0,0 +1,138 @@
+package simulator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/quantfamily/lemonmarkets/trading"
+	"github.com/stretchr/testify/assert"
+)
+
+func bar(open, high, low, close float64, volume int, minutes int) market_data.OHLC {
+	return market_data.OHLC{ISIN: "US88160R1014", Open: open, High: high, Low: low, Close: close, Volume: volume, Time: start.Add(time.Duration(minutes) * time.Minute)}
+}
+
+func activated(t *testing.T, sim *Simulator, order trading.Order) string {
+	t.Helper()
+	order.ISIN = "US88160R1014"
+	created := sim.CreateOrder(&order)
+	assert.Nil(t, created.Error)
+	assert.Nil(t, sim.ActivateOrder(created.Data.ID))
+	return created.Data.ID
+}
+
+func TestLimitOrders(t *testing.T) {
+	t.Run("buy limit on quotes", func(t *testing.T) {
+		sim := New(Config{Cash: 10000000})
+		id := activated(t, sim, trading.Order{Side: trading.Buy, Quantity: 1, LimitPrice: 1000000})
+		assert.Len(t, sim.OnQuote(quote(100, 101, 0)), 0)
+		changed := sim.OnQuote(quote(98, 99, 1))
+		assert.Equal(t, 990000, changed[0].ExecutedPrice)
+		assert.Equal(t, trading.OrderExecuted, sim.GetOrder(id).Data.Status)
+	})
+	t.Run("buy limit on bars fills at limit", func(t *testing.T) {
+		sim := New(Config{Cash: 10000000})
+		activated(t, sim, trading.Order{Side: trading.Buy, Quantity: 1, LimitPrice: 950000})
+		changed := sim.OnBar(bar(100, 101, 94, 96, 0, 0))
+		assert.Equal(t, 950000, changed[0].ExecutedPrice)
+	})
+	t.Run("sell limit on bars fills at open when better", func(t *testing.T) {
+		sim := New(Config{Cash: 10000000})
+		activated(t, sim, trading.Order{Side: trading.Buy, Quantity: 1})
+		sim.OnBar(bar(100, 100, 100, 100, 0, 0))
+		activated(t, sim, trading.Order{Side: trading.Sell, Quantity: 1, LimitPrice: 1050000})
+		changed := sim.OnBar(bar(110, 112, 108, 111, 0, 1))
+		assert.Equal(t, 1100000, changed[0].ExecutedPrice)
+	})
+}
+
+func TestStopOrders(t *testing.T) {
+	t.Run("buy stop triggers and stays triggered", func(t *testing.T) {
+		sim := New(Config{Cash: 10000000})
+		id := activated(t, sim, trading.Order{Side: trading.Buy, Quantity: 1, StopPrice: 1050000, LimitPrice: 1060000})
+		assert.Len(t, sim.OnQuote(quote(100, 101, 0)), 0)
+		assert.Len(t, sim.OnQuote(quote(106, 107, 1)), 0)
+		changed := sim.OnQuote(quote(104, 105, 2))
+		assert.Equal(t, 1050000, changed[0].ExecutedPrice)
+		assert.Equal(t, trading.OrderExecuted, sim.GetOrder(id).Data.Status)
+	})
+	t.Run("sell stop on bar fills at stop or gapped open", func(t *testing.T) {
+		sim := New(Config{Cash: 10000000})
+		activated(t, sim, trading.Order{Side: trading.Buy, Quantity: 2})
+		sim.OnBar(bar(100, 100, 100, 100, 0, 0))
+		activated(t, sim, trading.Order{Side: trading.Sell, Quantity: 1, StopPrice: 950000})
+		changed := sim.OnBar(bar(98, 99, 94, 95, 0, 1))
+		assert.Equal(t, 950000, changed[0].ExecutedPrice)
+		activated(t, sim, trading.Order{Side: trading.Sell, Quantity: 1, StopPrice: 900000})
+		changed = sim.OnBar(bar(85, 86, 80, 82, 0, 2))
+		assert.Equal(t, 850000, changed[0].ExecutedPrice)
+	})
+}
+
+func TestSlippageFeesAndPartialFills(t *testing.T) {
+	t.Run("slippage and fees", func(t *testing.T) {
+		sim := New(Config{Cash: 10000000, Slippage: 0.01, Fees: Fees{Fixed: 10000, Rate: 0.001}})
+		activated(t, sim, trading.Order{Side: trading.Buy, Quantity: 2})
+		changed := sim.OnQuote(quote(99, 100, 0))
+		assert.Equal(t, 1010000, changed[0].ExecutedPrice)
+		assert.Equal(t, float64(10000+2020), changed[0].Charge)
+		assert.Equal(t, 10000000-2020000-12020, sim.Cash())
+	})
+	t.Run("partial fills by participation", func(t *testing.T) {
+		sim := New(Config{Cash: 100000000, Participation: 0.1})
+		id := activated(t, sim, trading.Order{Side: trading.Buy, Quantity: 15})
+		changed := sim.OnQuote(quote(99, 100, 0))
+		assert.Equal(t, trading.OrderPartiallyExecuted, changed[0].Status)
+		assert.Equal(t, 10, changed[0].ExecutedQuantity)
+		sim.OnQuote(quote(99, 100, 1))
+		assert.Equal(t, trading.OrderExecuted, sim.GetOrder(id).Data.Status)
+	})
+	t.Run("too little volume", func(t *testing.T) {
+		sim := New(Config{Cash: 100000000, Participation: 0.1})
+		id := activated(t, sim, trading.Order{Side: trading.Buy, Quantity: 15})
+		thin := quote(99, 100, 0)
+		thin.AskVolume = 5
+		assert.Empty(t, sim.OnQuote(thin))
+		assert.Equal(t, trading.OrderActivated, sim.GetOrder(id).Data.Status, "not rejected while cash is enough")
+		sim.OnQuote(quote(99, 100, 1))
+		assert.Equal(t, 10, sim.GetOrder(id).Data.ExecutedQuantity)
+	})
+	t.Run("cash limits fills", func(t *testing.T) {
+		sim := New(Config{Cash: 2500000})
+		id := activated(t, sim, trading.Order{Side: trading.Buy, Quantity: 5})
+		changed := sim.OnQuote(quote(99, 100, 0))
+		assert.Equal(t, 2, changed[0].ExecutedQuantity)
+		assert.Nil(t, sim.DeleteOrder(id))
+		assert.Equal(t, 2, sim.GetOrder(id).Data.ExecutedQuantity)
+	})
+	t.Run("rejected without cash", func(t *testing.T) {
+		sim := New(Config{Cash: 500000})
+		activated(t, sim, trading.Order{Side: trading.Buy, Quantity: 5})
+		changed := sim.OnQuote(quote(99, 100, 0))
+		assert.Equal(t, trading.OrderRejected, changed[0].Status)
+	})
+}
+
+func TestMissingPrices(t *testing.T) {
+	sim := New(Config{Cash: 10000000})
+	buy := activated(t, sim, trading.Order{Side: trading.Buy, Quantity: 1})
+	assert.Empty(t, sim.OnQuote(quote(0, 0, 0)))
+	assert.Empty(t, sim.OnQuote(quote(99, 0, 1)), "no ask to buy at")
+	assert.Equal(t, trading.OrderActivated, sim.GetOrder(buy).Data.Status)
+
+	changed := sim.OnQuote(quote(0, 100, 2))
+	assert.Equal(t, trading.OrderExecuted, changed[0].Status, "the ask is enough for a buy")
+	sell := activated(t, sim, trading.Order{Side: trading.Sell, Quantity: 1})
+	assert.Empty(t, sim.OnQuote(quote(0, 100, 3)), "no bid to sell at")
+	assert.Equal(t, trading.OrderActivated, sim.GetOrder(sell).Data.Status)
+}
+
+func TestExpiry(t *testing.T) {
+	sim := New(Config{Cash: 10000000})
+	id := activated(t, sim, trading.Order{Side: trading.Buy, Quantity: 1, LimitPrice: 900000, ExpiresAt: start.Add(time.Minute)})
+	assert.Len(t, sim.OnQuote(quote(100, 101, 0)), 0)
+	changed := sim.OnQuote(quote(100, 101, 2))
+	assert.Equal(t, trading.OrderExpired, changed[0].Status)
+	assert.Equal(t, trading.OrderExpired, sim.GetOrder(id).Data.Status)
+}
@@ -0,0 +1,379 @@
+/*
+Package simulator is an offline paper exchange with the same order, position and account methods as trading.TradingClient.
+
+Orders are matched against quotes and OHLC bars that are fed to the simulator, so results are reproducible
+and no network or API key is needed. Prices and amounts use the same unit as the trading package
+(hundredths of a cent, 10000 = 1 EUR).
+*/
+package simulator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/client"
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/quantfamily/lemonmarkets/trading"
+)
+
+/*
+Fees charged per order. Fixed is charged once on the first fill, Rate is a fraction of every filled value
+*/
+type Fees struct {
+	Fixed int
+	Rate  float64
+}
+
+func (f Fees) charge(value int, firstFill bool) int {
+	fee := int(math.Round(float64(value) * f.Rate))
+	if firstFill {
+		fee += f.Fixed
+	}
+	return fee
+}
+
+/*
+Config for a simulator.
+Slippage is a fraction of the price that fills are moved against the order (0.001 = 10 basis points).
+Participation is the fraction of quote or bar volume an order can take per event, 0 fills completely.
+Clock is optional, without it the time of the latest quote or bar is used
+*/
+type Config struct {
+	Cash          int
+	Slippage      float64
+	Fees          Fees
+	Participation float64
+	Clock         trading.Clock
+}
+
+/*
+Simulator matches orders against quotes and bars and keeps cash and positions up to date
+*/
+type Simulator struct {
+	mu        sync.Mutex
+	config    Config
+	cash      int
+	now       time.Time
+	orders    map[string]*trading.Order
+	sequence  []string
+	positions map[string]*trading.Position
+	marks     map[string]int
+	triggered map[string]bool
+	nextID    int
+}
+
+var _ trading.Broker = (*Simulator)(nil)
+
+// New returns a simulator with the cash from the config
+func New(config Config) *Simulator {
+	return &Simulator{
+		config:    config,
+		cash:      config.Cash,
+		orders:    make(map[string]*trading.Order),
+		positions: make(map[string]*trading.Position),
+		marks:     make(map[string]int),
+		triggered: make(map[string]bool),
+	}
+}
+
+// Now returns the simulated time
+func (s *Simulator) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.time()
+}
+
+func (s *Simulator) time() time.Time {
+	if s.config.Clock != nil {
+		return s.config.Clock.Now()
+	}
+	return s.now
+}
+
+func lemonError(code, message string) error {
+	return &client.LemonError{Mode: "paper", Status: "error", Code: code, Message: message}
+}
+
+/*
+CreateOrder validates and stores an order as inactive, it has to be activated before it can be filled
+*/
+func (s *Simulator) CreateOrder(order *trading.Order) *trading.Item[trading.Order, error] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item := &trading.Item[trading.Order, error]{}
+	if order.Quantity <= 0 {
+		item.Error = lemonError("invalid_quantity", "quantity must be positive")
+		return item
+	}
+	if order.Side != trading.Buy && order.Side != trading.Sell {
+		item.Error = lemonError("invalid_side", fmt.Sprintf("unknown side %q", order.Side))
+		return item
+	}
+	if order.Side == trading.Sell && s.available(order.ISIN) < order.Quantity {
+		item.Error = lemonError("insufficient_holdings", fmt.Sprintf("not enough %s to sell", order.ISIN))
+		return item
+	}
+	s.nextID++
+	created := *order
+	created.ID = fmt.Sprintf("ord_sim_%d", s.nextID)
+	created.CreatedAt = s.time()
+	created.Status = trading.OrderInactive
+	created.Type = orderType(order)
+	created.ExecutedQuantity = 0
+	created.ExecutedPrice = 0
+	created.ExecutedPriceTotal = 0
+	created.Charge = 0
+	created.EstimatedPrice = s.estimate(&created)
+	created.EstimatedPriceTotal = created.EstimatedPrice * created.Quantity
+	s.orders[created.ID] = &created
+	s.sequence = append(s.sequence, created.ID)
+	item.Data = created
+	return item
+}
+
+/*
+ActivateOrder makes an inactive order eligible for filling, buys are rejected if the estimated total exceeds cash to invest
+*/
+func (s *Simulator) ActivateOrder(orderID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order, ok := s.orders[orderID]
+	if !ok {
+		return lemonError("order_not_found", fmt.Sprintf("order %s not found", orderID))
+	}
+	if order.Status != trading.OrderInactive {
+		return lemonError("order_not_inactive", fmt.Sprintf("order %s is %s", orderID, order.Status))
+	}
+	if order.Side == trading.Buy && order.EstimatedPriceTotal > s.cashToInvest() {
+		return lemonError("insufficient_balance", "estimated total price is greater than cash to invest")
+	}
+	order.Status = trading.OrderActivated
+	return nil
+}
+
+/*
+GetOrders returns orders oldest first, filtered by the non-empty fields of the query
+*/
+func (s *Simulator) GetOrders(query *trading.GetOrdersQuery) <-chan trading.Item[trading.Order, error] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var orders []trading.Order
+	for _, id := range s.sequence {
+		order := s.orders[id]
+		if matches(order, query) {
+			orders = append(orders, *order)
+		}
+	}
+	ch := make(chan trading.Item[trading.Order, error], len(orders))
+	for _, order := range orders {
+		ch <- trading.Item[trading.Order, error]{Data: order}
+	}
+	close(ch)
+	return ch
+}
+
+// GetOrder returns a single order
+func (s *Simulator) GetOrder(orderID string) *trading.Item[trading.Order, error] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item := &trading.Item[trading.Order, error]{}
+	order, ok := s.orders[orderID]
+	if !ok {
+		item.Error = lemonError("order_not_found", fmt.Sprintf("order %s not found", orderID))
+		return item
+	}
+	item.Data = *order
+	return item
+}
+
+/*
+DeleteOrder cancels an order that is not done yet, a partially filled order keeps its executed part
+*/
+func (s *Simulator) DeleteOrder(orderID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order, ok := s.orders[orderID]
+	if !ok {
+		return lemonError("order_not_found", fmt.Sprintf("order %s not found", orderID))
+	}
+	if order.Done() {
+		return lemonError("order_not_cancelable", fmt.Sprintf("order %s is %s", orderID, order.Status))
+	}
+	order.Status = trading.OrderCanceled
+	return nil
+}
+
+// GetPositions returns open positions sorted by ISIN, valued at the latest known price
+func (s *Simulator) GetPositions() <-chan trading.Item[trading.Position, error] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	isins := make([]string, 0, len(s.positions))
+	for isin := range s.positions {
+		isins = append(isins, isin)
+	}
+	sort.Strings(isins)
+	ch := make(chan trading.Item[trading.Position, error], len(isins))
+	for _, isin := range isins {
+		position := *s.positions[isin]
+		if mark, ok := s.marks[isin]; ok {
+			position.EstimatedPrice = mark
+		}
+		position.EstimatedPriceTotal = position.EstimatedPrice * position.Quantity
+		ch <- trading.Item[trading.Position, error]{Data: position}
+	}
+	close(ch)
+	return ch
+}
+
+/*
+GetAccount returns the simulated account, Balance is the cash and CashToInvest excludes cash reserved by open buys
+*/
+func (s *Simulator) GetAccount() *trading.Item[trading.Account, error] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account := trading.Account{
+		AccountID:      "acc_simulator",
+		Mode:           "paper",
+		Balance:        float32(s.cash),
+		CashToInvest:   float32(s.cashToInvest()),
+		CashToWithdraw: float32(s.cash),
+	}
+	return &trading.Item[trading.Account, error]{Data: account}
+}
+
+// Cash returns the simulated cash without rounding to float32 as done by GetAccount
+func (s *Simulator) Cash() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cash
+}
+
+/*
+OnQuote matches open orders for the quote's ISIN and returns the orders that changed
+*/
+func (s *Simulator) OnQuote(quote market_data.Quote) []trading.Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advance(quote.Time)
+	if quote.Bid > 0 && quote.Ask > 0 {
+		s.marks[quote.ISIN] = trading.ToAmount((quote.Bid + quote.Ask) / 2)
+	}
+	return s.match(quote.ISIN, quoteMarket(quote))
+}
+
+/*
+OnBar matches open orders for the bar's ISIN and returns the orders that changed.
+Bars are assumed to be fed when they are complete, using the bar's time as simulated time
+*/
+func (s *Simulator) OnBar(ohlc market_data.OHLC) []trading.Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advance(ohlc.Time)
+	s.marks[ohlc.ISIN] = trading.ToAmount(ohlc.Close)
+	return s.match(ohlc.ISIN, barMarket(ohlc))
+}
+
+/*
+FeedQuotes calls OnQuote for every quote on the channel and returns all changed orders, it stops at the first error
+*/
+func (s *Simulator) FeedQuotes(quotes <-chan market_data.Item[market_data.Quote, error]) ([]trading.Order, error) {
+	var changed []trading.Order
+	for quote := range quotes {
+		if quote.Error != nil {
+			return changed, quote.Error
+		}
+		changed = append(changed, s.OnQuote(quote.Data)...)
+	}
+	return changed, nil
+}
+
+/*
+FeedOHLC calls OnBar for every bar on the channel and returns all changed orders, it stops at the first error
+*/
+func (s *Simulator) FeedOHLC(ohlcs <-chan market_data.Item[market_data.OHLC, error]) ([]trading.Order, error) {
+	var changed []trading.Order
+	for ohlc := range ohlcs {
+		if ohlc.Error != nil {
+			return changed, ohlc.Error
+		}
+		changed = append(changed, s.OnBar(ohlc.Data)...)
+	}
+	return changed, nil
+}
+
+func (s *Simulator) advance(t time.Time) {
+	if t.After(s.now) {
+		s.now = t
+	}
+}
+
+// available is the held quantity not already reserved by open sell orders
+func (s *Simulator) available(isin string) int {
+	available := 0
+	if position, ok := s.positions[isin]; ok {
+		available = position.Quantity
+	}
+	for _, order := range s.orders {
+		if order.ISIN == isin && order.Side == trading.Sell && !order.Done() {
+			available -= order.Quantity - order.ExecutedQuantity
+		}
+	}
+	return available
+}
+
+// cashToInvest is the cash not reserved by open buy orders
+func (s *Simulator) cashToInvest() int {
+	reserved := 0
+	for _, order := range s.orders {
+		if order.Side == trading.Buy && order.Status != trading.OrderInactive && !order.Done() {
+			reserved += order.EstimatedPrice * (order.Quantity - order.ExecutedQuantity)
+		}
+	}
+	return s.cash - reserved
+}
+
+func (s *Simulator) estimate(order *trading.Order) int {
+	if order.LimitPrice > 0 {
+		return order.LimitPrice
+	}
+	if order.StopPrice > 0 {
+		return order.StopPrice
+	}
+	return s.marks[order.ISIN]
+}
+
+func orderType(order *trading.Order) string {
+	switch {
+	case order.StopPrice > 0 && order.LimitPrice > 0:
+		return trading.StopLimit
+	case order.StopPrice > 0:
+		return trading.Stop
+	case order.LimitPrice > 0:
+		return trading.Limit
+	}
+	return trading.Market
+}
+
+func matches(order *trading.Order, query *trading.GetOrdersQuery) bool {
+	if query == nil {
+		return true
+	}
+	switch {
+	case query.ISIN != "" && query.ISIN != order.ISIN:
+		return false
+	case query.Side != "" && query.Side != order.Side:
+		return false
+	case query.Status != "" && query.Status != order.Status:
+		return false
+	case query.Type != "" && query.Type != order.Type:
+		return false
+	case !query.From.IsZero() && order.CreatedAt.Before(query.From):
+		return false
+	case !query.To.IsZero() && order.CreatedAt.After(query.To):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,165 @@
+package simulator
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/client"
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/quantfamily/lemonmarkets/trading"
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2022, 3, 1, 9, 0, 0, 0, time.UTC)
+
+func quote(bid, ask float64, minutes int) market_data.Quote {
+	return market_data.Quote{ISIN: "US88160R1014", Bid: bid, Ask: ask, BidVolume: 100, AskVolume: 100, Time: start.Add(time.Duration(minutes) * time.Minute)}
+}
+
+func collect[T trading.DataTypes](ch <-chan trading.Item[T, error]) []T {
+	var data []T
+	for item := range ch {
+		data = append(data, item.Data)
+	}
+	return data
+}
+
+func TestCreateOrder(t *testing.T) {
+	sim := New(Config{Cash: 10000000})
+
+	t.Run("invalid quantity", func(t *testing.T) {
+		order := sim.CreateOrder(&trading.Order{ISIN: "US88160R1014", Side: trading.Buy})
+		var lemonErr *client.LemonError
+		assert.True(t, errors.As(order.Error, &lemonErr))
+		assert.Equal(t, "invalid_quantity", lemonErr.Code)
+	})
+	t.Run("sell without position", func(t *testing.T) {
+		order := sim.CreateOrder(&trading.Order{ISIN: "US88160R1014", Side: trading.Sell, Quantity: 1})
+		assert.NotNil(t, order.Error)
+	})
+	t.Run("order types", func(t *testing.T) {
+		market := sim.CreateOrder(&trading.Order{ISIN: "US88160R1014", Side: trading.Buy, Quantity: 1})
+		limit := sim.CreateOrder(&trading.Order{ISIN: "US88160R1014", Side: trading.Buy, Quantity: 1, LimitPrice: 10000})
+		stop := sim.CreateOrder(&trading.Order{ISIN: "US88160R1014", Side: trading.Buy, Quantity: 1, StopPrice: 10000})
+		stopLimit := sim.CreateOrder(&trading.Order{ISIN: "US88160R1014", Side: trading.Buy, Quantity: 1, StopPrice: 10000, LimitPrice: 11000})
+		assert.Equal(t, trading.Market, market.Data.Type)
+		assert.Equal(t, trading.Limit, limit.Data.Type)
+		assert.Equal(t, trading.Stop, stop.Data.Type)
+		assert.Equal(t, trading.StopLimit, stopLimit.Data.Type)
+		assert.Equal(t, trading.OrderInactive, market.Data.Status)
+		assert.NotEqual(t, market.Data.ID, limit.Data.ID)
+	})
+}
+
+func TestOrderLifecycle(t *testing.T) {
+	sim := New(Config{Cash: 10000000})
+	sim.OnQuote(quote(100, 101, 0))
+
+	order := sim.CreateOrder(&trading.Order{ISIN: "US88160R1014", Side: trading.Buy, Quantity: 5})
+	assert.Nil(t, order.Error)
+	assert.Equal(t, 1005000, order.Data.EstimatedPrice)
+
+	t.Run("inactive orders are not filled", func(t *testing.T) {
+		assert.Len(t, sim.OnQuote(quote(100, 101, 1)), 0)
+	})
+	t.Run("activate and fill", func(t *testing.T) {
+		assert.Nil(t, sim.ActivateOrder(order.Data.ID))
+		assert.NotNil(t, sim.ActivateOrder(order.Data.ID))
+		changed := sim.OnQuote(quote(100, 101, 2))
+		assert.Len(t, changed, 1)
+		assert.Equal(t, trading.OrderExecuted, changed[0].Status)
+		assert.Equal(t, 1010000, changed[0].ExecutedPrice)
+		assert.Equal(t, start.Add(2*time.Minute), changed[0].ExecutedAt)
+		assert.Equal(t, 10000000-5050000, sim.Cash())
+	})
+	t.Run("positions and account", func(t *testing.T) {
+		positions := collect(sim.GetPositions())
+		assert.Len(t, positions, 1)
+		assert.Equal(t, 5, positions[0].Quantity)
+		assert.Equal(t, 1010000, positions[0].BuyPriceAverage)
+		assert.Equal(t, 1005000, positions[0].EstimatedPrice)
+
+		account := sim.GetAccount()
+		assert.Nil(t, account.Error)
+		assert.Equal(t, float32(4950000), account.Data.Balance)
+	})
+	t.Run("executed orders cannot be deleted", func(t *testing.T) {
+		assert.NotNil(t, sim.DeleteOrder(order.Data.ID))
+	})
+	t.Run("get orders", func(t *testing.T) {
+		assert.Equal(t, trading.OrderExecuted, sim.GetOrder(order.Data.ID).Data.Status)
+		assert.NotNil(t, sim.GetOrder("ord_unknown").Error)
+		assert.Len(t, collect(sim.GetOrders(nil)), 1)
+		assert.Len(t, collect(sim.GetOrders(&trading.GetOrdersQuery{Status: trading.OrderExecuted})), 1)
+		assert.Len(t, collect(sim.GetOrders(&trading.GetOrdersQuery{Side: trading.Sell})), 0)
+	})
+	t.Run("sell closes the position", func(t *testing.T) {
+		sell := sim.CreateOrder(&trading.Order{ISIN: "US88160R1014", Side: trading.Sell, Quantity: 5})
+		assert.Nil(t, sell.Error)
+		assert.NotNil(t, sim.CreateOrder(&trading.Order{ISIN: "US88160R1014", Side: trading.Sell, Quantity: 1}).Error)
+		assert.Nil(t, sim.ActivateOrder(sell.Data.ID))
+		sim.OnQuote(quote(102, 103, 3))
+		assert.Len(t, collect(sim.GetPositions()), 0)
+		assert.Equal(t, 10000000+50000, sim.Cash())
+	})
+}
+
+func TestDeleteOrder(t *testing.T) {
+	sim := New(Config{Cash: 10000000})
+	order := sim.CreateOrder(&trading.Order{ISIN: "US88160R1014", Side: trading.Buy, Quantity: 5, LimitPrice: 900000})
+	assert.Nil(t, sim.ActivateOrder(order.Data.ID))
+	sim.OnQuote(quote(100, 101, 0))
+	assert.Nil(t, sim.DeleteOrder(order.Data.ID))
+	assert.Equal(t, trading.OrderCanceled, sim.GetOrder(order.Data.ID).Data.Status)
+	assert.Len(t, sim.OnQuote(quote(80, 81, 1)), 0)
+	assert.NotNil(t, sim.DeleteOrder("ord_unknown"))
+}
+
+func TestActivateInsufficientBalance(t *testing.T) {
+	sim := New(Config{Cash: 1000000})
+	order := sim.CreateOrder(&trading.Order{ISIN: "US88160R1014", Side: trading.Buy, Quantity: 5, LimitPrice: 1000000})
+	var lemonErr *client.LemonError
+	assert.True(t, errors.As(sim.ActivateOrder(order.Data.ID), &lemonErr))
+	assert.Equal(t, "insufficient_balance", lemonErr.Code)
+}
+
+func TestFeed(t *testing.T) {
+	t.Run("quotes", func(t *testing.T) {
+		sim := New(Config{Cash: 10000000})
+		order := sim.CreateOrder(&trading.Order{ISIN: "US88160R1014", Side: trading.Buy, Quantity: 1})
+		assert.Nil(t, sim.ActivateOrder(order.Data.ID))
+		ch := make(chan market_data.Item[market_data.Quote, error], 2)
+		ch <- market_data.Item[market_data.Quote, error]{Data: quote(100, 101, 0)}
+		ch <- market_data.Item[market_data.Quote, error]{Error: errors.New("backend down")}
+		close(ch)
+		changed, err := sim.FeedQuotes(ch)
+		assert.NotNil(t, err)
+		assert.Len(t, changed, 1)
+	})
+	t.Run("bars", func(t *testing.T) {
+		sim := New(Config{Cash: 10000000})
+		order := sim.CreateOrder(&trading.Order{ISIN: "US88160R1014", Side: trading.Buy, Quantity: 1})
+		assert.Nil(t, sim.ActivateOrder(order.Data.ID))
+		ch := make(chan market_data.Item[market_data.OHLC, error], 1)
+		ch <- market_data.Item[market_data.OHLC, error]{Data: market_data.OHLC{ISIN: "US88160R1014", Open: 100, High: 110, Low: 90, Close: 105, Time: start}}
+		close(ch)
+		changed, err := sim.FeedOHLC(ch)
+		assert.Nil(t, err)
+		assert.Equal(t, 1000000, changed[0].ExecutedPrice)
+		assert.Equal(t, start, sim.Now())
+	})
+}
+
+func TestSimulatorAsBroker(t *testing.T) {
+	sim := New(Config{Cash: 10000000})
+	var broker trading.Broker = sim
+	order := broker.CreateOrder(&trading.Order{ISIN: "US88160R1014", Side: trading.Buy, Quantity: 2})
+	assert.Nil(t, broker.ActivateOrder(order.Data.ID))
+	sim.OnQuote(quote(100, 101, 0))
+
+	portfolio := trading.LoadPortfolio(broker, trading.EstimatedPriceSource{}, nil)
+	assert.Nil(t, portfolio.Error)
+	assert.Equal(t, 2010000, portfolio.Data.MarketValue)
+	assert.Equal(t, 10000000-2020000, portfolio.Data.Cash)
+}
@@ -0,0 +1,80 @@
+package tax
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+var csvHeader = []string{
+	"year",
+	"equity_gains",
+	"equity_losses",
+	"other_gains",
+	"other_losses",
+	"dividends",
+	"fees",
+	"unknown_cost_proceeds",
+	"equity_loss_pot_start",
+	"equity_loss_pot_end",
+	"other_loss_pot_start",
+	"other_loss_pot_end",
+	"taxable_income",
+	"allowance_available",
+	"allowance_used",
+	"tax_base",
+	"capital_gains_tax",
+	"solidarity_surcharge",
+	"church_tax",
+	"tax_due",
+	"tax_withheld",
+	"difference",
+}
+
+/*
+WriteCSV writes one row per year, amounts are written in EUR with four decimals
+*/
+func (r *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, y := range r.Years {
+		amounts := []int{
+			y.EquityGains, y.EquityLosses, y.OtherGains, y.OtherLosses, y.Dividends, y.Fees, y.UnknownCostProceeds,
+			y.EquityLossPotStart, y.EquityLossPotEnd, y.OtherLossPotStart, y.OtherLossPotEnd,
+			y.TaxableIncome, y.AllowanceAvailable, y.AllowanceUsed, y.TaxBase,
+			y.CapitalGainsTax, y.SolidaritySurcharge, y.ChurchTax, y.TaxDue, y.TaxWithheld, y.Difference,
+		}
+		row := []string{strconv.Itoa(y.Year)}
+		for _, amount := range amounts {
+			row = append(row, formatEUR(amount))
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+/*
+WriteJSON writes the report as indented JSON, amounts are kept in hundredths of a cent
+*/
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// formatEUR formats an amount in hundredths of a cent without going through float
+func formatEUR(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%04d", sign, amount/10000, amount%10000)
+}
@@ -0,0 +1,32 @@
+package tax
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteCSV(t *testing.T) {
+	report := Report{Years: []Year{{Year: 2021, EquityLosses: 2000000, TaxDue: 131875, Difference: -5}}}
+	buffer := new(bytes.Buffer)
+	assert.Nil(t, report.WriteCSV(buffer))
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "year,equity_gains,equity_losses"))
+	assert.True(t, strings.HasPrefix(lines[1], "2021,0.0000,200.0000"))
+	assert.True(t, strings.HasSuffix(lines[1], ",13.1875,0.0000,-0.0005"))
+}
+
+func TestWriteJSON(t *testing.T) {
+	report := Report{Years: []Year{{Year: 2021, TaxDue: 131875}}}
+	buffer := new(bytes.Buffer)
+	assert.Nil(t, report.WriteJSON(buffer))
+
+	var decoded Report
+	assert.Nil(t, json.Unmarshal(buffer.Bytes(), &decoded))
+	assert.Equal(t, report, decoded)
+}
@@ -0,0 +1,269 @@
+/*
+Package tax builds yearly German capital-gains (Abgeltungsteuer) reports from LemonMarkets account data.
+
+The report is an aid for reconciliation and not tax advice. It applies the flat rate of 25% plus
+solidarity surcharge (and optional church tax), keeps the stock loss pot (Aktienverlusttopf) apart
+from the general loss pot and uses the saver's allowance (Sparer-Pauschbetrag) from the account.
+Partial exemptions for investment funds (Teilfreistellung) are not applied.
+*/
+package tax
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/quantfamily/lemonmarkets/trading"
+)
+
+const (
+	// Rate is the flat capital-gains tax rate
+	Rate = 0.25
+	// SolidarityRate is the solidarity surcharge applied on top of the capital-gains tax
+	SolidarityRate = 0.055
+)
+
+// Bucket is what loss pot a realized gain or loss belongs to
+type Bucket string
+
+const (
+	// Equity are gains and losses from selling stocks, losses can only be offset against equity gains
+	Equity Bucket = "equity"
+	// Other are all other capital gains and losses, such as ETFs, bonds and dividends
+	Other Bucket = "other"
+)
+
+/*
+Config for generating a report
+Types map ISIN to instrument type (as market_data.Instrument.Type), only "stock" counts as Equity.
+Allowance is used for years that are not covered by the allowance set on the account.
+TransferCosts is the cost basis of transferred in shares by statement ID, see trading.Ledger.
+All amounts are in hundredths of a cent like the rest of the trading API
+*/
+type Config struct {
+	Types         map[string]string
+	Allowance     int
+	ChurchTaxRate float64
+	TransferCosts map[string]int
+}
+
+/*
+Year is the tax situation for a single calendar year.
+UnknownCostProceeds are proceeds from selling transferred in shares without a cost basis,
+they are not part of the gains and need to be declared with the cost basis from the previous broker
+*/
+type Year struct {
+	Year                int `json:"year"`
+	EquityGains         int `json:"equity_gains"`
+	EquityLosses        int `json:"equity_losses"`
+	OtherGains          int `json:"other_gains"`
+	OtherLosses         int `json:"other_losses"`
+	Dividends           int `json:"dividends"`
+	Fees                int `json:"fees"`
+	UnknownCostProceeds int `json:"unknown_cost_proceeds"`
+	EquityLossPotStart  int `json:"equity_loss_pot_start"`
+	EquityLossPotEnd    int `json:"equity_loss_pot_end"`
+	OtherLossPotStart   int `json:"other_loss_pot_start"`
+	OtherLossPotEnd     int `json:"other_loss_pot_end"`
+	TaxableIncome       int `json:"taxable_income"`
+	AllowanceAvailable  int `json:"allowance_available"`
+	AllowanceUsed       int `json:"allowance_used"`
+	TaxBase             int `json:"tax_base"`
+	CapitalGainsTax     int `json:"capital_gains_tax"`
+	SolidaritySurcharge int `json:"solidarity_surcharge"`
+	ChurchTax           int `json:"church_tax"`
+	TaxDue              int `json:"tax_due"`
+	TaxWithheld         int `json:"tax_withheld"`
+	Difference          int `json:"difference"`
+}
+
+// Report holds one Year per calendar year with activity, oldest first
+type Report struct {
+	Years []Year `json:"years"`
+}
+
+/*
+NewReport replays orders and statements with FIFO (as required for German tax) and combines the
+realized gains with dividends, fees and taxes from the bank statements.
+The history has to be complete, an error is returned when the ledger rejects any event
+*/
+func NewReport(orders []trading.Order, statements []trading.Statement, bankStatements []trading.BankStatement, account trading.Account, config Config) (*Report, error) {
+	ledger := trading.NewLedger(trading.FIFO)
+	ledger.TransferCosts = config.TransferCosts
+	if err := ledger.Replay(orders, statements); err != nil {
+		return nil, err
+	}
+	if rejected := ledger.Rejected(); len(rejected) > 0 {
+		return nil, fmt.Errorf("history is incomplete, %d events rejected, first: %s", len(rejected), rejected[0].Reason)
+	}
+	years := make(map[int]*Year)
+	year := func(y int) *Year {
+		if _, ok := years[y]; !ok {
+			years[y] = &Year{Year: y}
+		}
+		return years[y]
+	}
+	for _, realization := range ledger.Realized() {
+		y := year(realization.Time.Year())
+		if realization.UnknownCost {
+			y.UnknownCostProceeds += realization.Proceeds
+			continue
+		}
+		switch bucket(config.Types[realization.ISIN]) {
+		case Equity:
+			addGain(&y.EquityGains, &y.EquityLosses, realization.Gain)
+		default:
+			addGain(&y.OtherGains, &y.OtherLosses, realization.Gain)
+		}
+	}
+	for _, statement := range bankStatements {
+		date := statement.Date.Time
+		if date.IsZero() {
+			date = statement.CreatedAt
+		}
+		amount := abs(statement.Amount)
+		switch statement.Type {
+		case "dividend":
+			year(date.Year()).Dividends += amount
+		case "tax_paid":
+			year(date.Year()).TaxWithheld += amount
+		case "tax_refunded":
+			year(date.Year()).TaxWithheld -= amount
+		case "fee":
+			year(date.Year()).Fees += amount
+		}
+	}
+
+	report := &Report{}
+	for _, y := range years {
+		report.Years = append(report.Years, *y)
+	}
+	sort.Slice(report.Years, func(i, j int) bool { return report.Years[i].Year < report.Years[j].Year })
+
+	equityPot, otherPot := 0, 0
+	for i := range report.Years {
+		y := &report.Years[i]
+		y.EquityLossPotStart, y.OtherLossPotStart = equityPot, otherPot
+		y.AllowanceAvailable = allowance(y.Year, account, config)
+		calculate(y, config.ChurchTaxRate)
+		equityPot, otherPot = y.EquityLossPotEnd, y.OtherLossPotEnd
+	}
+	return report, nil
+}
+
+/*
+Generate fetches orders, statements, bank statements and account from LemonMarkets and builds a report
+*/
+func Generate(cl *trading.TradingClient, config Config) (*Report, error) {
+	account := cl.GetAccount()
+	if account.Error != nil {
+		return nil, account.Error
+	}
+	var orders []trading.Order
+	for order := range cl.GetOrders(nil) {
+		if order.Error != nil {
+			return nil, order.Error
+		}
+		orders = append(orders, order.Data)
+	}
+	var statements []trading.Statement
+	for statement := range cl.GetStatements() {
+		if statement.Error != nil {
+			return nil, statement.Error
+		}
+		statements = append(statements, statement.Data)
+	}
+	var bankStatements []trading.BankStatement
+	for bankStatement := range cl.GetBankStatements() {
+		if bankStatement.Error != nil {
+			return nil, bankStatement.Error
+		}
+		bankStatements = append(bankStatements, bankStatement.Data)
+	}
+	return NewReport(orders, statements, bankStatements, account.Data, config)
+}
+
+// Year returns the report for a specific year
+func (r *Report) Year(year int) (Year, error) {
+	for _, y := range r.Years {
+		if y.Year == year {
+			return y, nil
+		}
+	}
+	return Year{}, fmt.Errorf("no activity in %d", year)
+}
+
+/*
+calculate offsets losses and allowance for the year and computes the tax.
+Stock losses only offset stock gains, other losses offset all capital income
+*/
+func calculate(y *Year, churchTaxRate float64) {
+	equity := y.EquityGains - y.EquityLosses - y.EquityLossPotStart
+	if equity < 0 {
+		y.EquityLossPotEnd = -equity
+		equity = 0
+	}
+	other := y.OtherGains + y.Dividends - y.OtherLosses - y.OtherLossPotStart
+	if other < 0 {
+		offset := min(-other, equity)
+		equity -= offset
+		other += offset
+	}
+	if other < 0 {
+		y.OtherLossPotEnd = -other
+		other = 0
+	}
+	y.TaxableIncome = equity + other
+	y.AllowanceUsed = min(y.TaxableIncome, y.AllowanceAvailable)
+	y.TaxBase = y.TaxableIncome - y.AllowanceUsed
+
+	// With church tax the capital-gains tax is reduced, see § 32d Abs. 1 EStG
+	tax := float64(y.TaxBase) / (4 + churchTaxRate)
+	y.CapitalGainsTax = int(math.Floor(tax))
+	y.SolidaritySurcharge = int(math.Floor(tax * SolidarityRate))
+	y.ChurchTax = int(math.Floor(tax * churchTaxRate))
+	y.TaxDue = y.CapitalGainsTax + y.SolidaritySurcharge + y.ChurchTax
+	y.Difference = y.TaxWithheld - y.TaxDue
+}
+
+func allowance(year int, account trading.Account, config Config) int {
+	if account.TaxAllowance <= 0 || account.TaxAllowanceStart.IsZero() {
+		return config.Allowance
+	}
+	if year < account.TaxAllowanceStart.Year() {
+		return config.Allowance
+	}
+	if !account.TaxAllowanceEnd.IsZero() && year > account.TaxAllowanceEnd.Year() {
+		return config.Allowance
+	}
+	return account.TaxAllowance
+}
+
+func bucket(instrumentType string) Bucket {
+	if instrumentType == "stock" {
+		return Equity
+	}
+	return Other
+}
+
+func addGain(gains, losses *int, gain int) {
+	if gain < 0 {
+		*losses -= gain
+	} else {
+		*gains += gain
+	}
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
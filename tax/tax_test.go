@@ -0,0 +1,152 @@
+package tax
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/trading"
+	"github.com/stretchr/testify/assert"
+)
+
+func order(id, isin, side string, quantity, price int, executedAt time.Time) trading.Order {
+	return trading.Order{
+		ID:                 id,
+		ISIN:               isin,
+		Side:               side,
+		Status:             "executed",
+		ExecutedQuantity:   quantity,
+		ExecutedPrice:      price,
+		ExecutedPriceTotal: quantity * price,
+		ExecutedAt:         executedAt,
+	}
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 10, 0, 0, 0, time.UTC)
+}
+
+func fixtures() ([]trading.Order, []trading.BankStatement, Config) {
+	orders := []trading.Order{
+		order("1", "STOCK", "buy", 10, 1000000, date(2021, 1, 10)),
+		order("2", "STOCK", "sell", 10, 800000, date(2021, 3, 10)),
+		order("3", "ETF1", "buy", 10, 500000, date(2021, 2, 10)),
+		order("4", "ETF1", "sell", 10, 600000, date(2021, 6, 10)),
+		order("5", "STOCK", "buy", 10, 1000000, date(2022, 1, 10)),
+		order("6", "STOCK", "sell", 10, 1300000, date(2022, 5, 10)),
+		order("7", "ETF2", "buy", 10, 500000, date(2022, 2, 10)),
+		order("8", "ETF2", "sell", 10, 300000, date(2022, 6, 10)),
+	}
+	bankStatements := []trading.BankStatement{
+		{Type: "dividend", Amount: 500000, Date: trading.Date{Time: date(2021, 5, 1)}},
+		{Type: "tax_paid", Amount: 131875, Date: trading.Date{Time: date(2021, 12, 31)}},
+		{Type: "pay_in", Amount: 100000000, Date: trading.Date{Time: date(2021, 1, 1)}},
+	}
+	config := Config{Types: map[string]string{"STOCK": "stock", "ETF1": "etf", "ETF2": "etf"}, Allowance: 1000000}
+	return orders, bankStatements, config
+}
+
+func TestNewReport(t *testing.T) {
+	orders, bankStatements, config := fixtures()
+
+	t.Run("Selling more than held", func(t *testing.T) {
+		_, err := NewReport(orders[1:2], nil, nil, trading.Account{}, config)
+		assert.NotNil(t, err)
+	})
+	t.Run("Loss pots and allowance", func(t *testing.T) {
+		report, err := NewReport(orders, nil, bankStatements, trading.Account{}, config)
+		assert.Nil(t, err)
+		assert.Len(t, report.Years, 2)
+
+		y2021, err := report.Year(2021)
+		assert.Nil(t, err)
+		assert.Equal(t, 2000000, y2021.EquityLosses)
+		assert.Equal(t, 1000000, y2021.OtherGains)
+		assert.Equal(t, 500000, y2021.Dividends)
+		assert.Equal(t, 2000000, y2021.EquityLossPotEnd)
+		assert.Equal(t, 1500000, y2021.TaxableIncome)
+		assert.Equal(t, 1000000, y2021.AllowanceUsed)
+		assert.Equal(t, 500000, y2021.TaxBase)
+		assert.Equal(t, 125000, y2021.CapitalGainsTax)
+		assert.Equal(t, 6875, y2021.SolidaritySurcharge)
+		assert.Equal(t, 131875, y2021.TaxDue)
+		assert.Equal(t, 0, y2021.Difference)
+
+		y2022, err := report.Year(2022)
+		assert.Nil(t, err)
+		assert.Equal(t, 2000000, y2022.EquityLossPotStart)
+		assert.Equal(t, 0, y2022.EquityLossPotEnd)
+		assert.Equal(t, 1000000, y2022.OtherLossPotEnd)
+		assert.Equal(t, 0, y2022.TaxableIncome)
+		assert.Equal(t, 0, y2022.TaxDue)
+	})
+	t.Run("Allowance from account", func(t *testing.T) {
+		account := trading.Account{TaxAllowance: 500000, TaxAllowanceStart: date(2021, 1, 1)}
+		report, err := NewReport(orders, nil, bankStatements, account, config)
+		assert.Nil(t, err)
+		assert.Equal(t, 500000, report.Years[0].AllowanceUsed)
+		assert.Equal(t, 1000000, report.Years[0].TaxBase)
+	})
+	t.Run("Church tax", func(t *testing.T) {
+		config.ChurchTaxRate = 0.09
+		report, err := NewReport(orders, nil, bankStatements, trading.Account{}, config)
+		assert.Nil(t, err)
+		assert.Equal(t, 122249, report.Years[0].CapitalGainsTax)
+		assert.Equal(t, 6723, report.Years[0].SolidaritySurcharge)
+		assert.Equal(t, 11002, report.Years[0].ChurchTax)
+	})
+	t.Run("Transferred shares", func(t *testing.T) {
+		statements := []trading.Statement{{ID: "hs_import", Type: "import", ISIN: "STOCK", Quantity: 10, CreatedAt: date(2020, 12, 1)}}
+		sell := []trading.Order{order("1", "STOCK", "sell", 10, 1000000, date(2021, 3, 10))}
+
+		report, err := NewReport(sell, statements, nil, trading.Account{}, config)
+		assert.Nil(t, err)
+		assert.Equal(t, 10000000, report.Years[0].UnknownCostProceeds)
+		assert.Equal(t, 0, report.Years[0].EquityGains, "proceeds without cost basis are not taxed as gain")
+		assert.Equal(t, 0, report.Years[0].TaxDue)
+
+		config := config
+		config.TransferCosts = map[string]int{"hs_import": 8000000}
+		report, err = NewReport(sell, statements, nil, trading.Account{}, config)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, report.Years[0].UnknownCostProceeds)
+		assert.Equal(t, 2000000, report.Years[0].EquityGains)
+	})
+	t.Run("Unknown year", func(t *testing.T) {
+		report, _ := NewReport(nil, nil, nil, trading.Account{}, config)
+		_, err := report.Year(2020)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestGenerate(t *testing.T) {
+	t.Run("fail to get account", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+		cl := trading.NewClient("", trading.Environment(server.URL))
+		_, err := Generate(cl, Config{})
+		assert.NotNil(t, err)
+	})
+	t.Run("Successful test", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/account":
+				fmt.Fprint(w, `{"status": "ok", "results": {"tax_allowance": 8010000, "tax_allowance_start": "2021-01-01T00:00:00.000+00:00"}}`)
+			case "/account/bankstatements":
+				fmt.Fprint(w, `{"status": "ok", "results": [{"type": "dividend", "amount": 100000, "date": "2021-05-01"}]}`)
+			default:
+				fmt.Fprint(w, `{"status": "ok", "results": []}`)
+			}
+		}))
+		defer server.Close()
+		cl := trading.NewClient("", trading.Environment(server.URL))
+		report, err := Generate(cl, Config{})
+		assert.Nil(t, err)
+		assert.Equal(t, 100000, report.Years[0].AllowanceUsed)
+		assert.Equal(t, 0, report.Years[0].TaxDue)
+	})
+}
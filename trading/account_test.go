@@ -189,6 +189,7 @@ func TestGetBankStatements(t *testing.T) {
 		bankstatement := <-bankstatementCh
 		assert.Nil(t, bankstatement.Error)
 		assert.Equal(t, 100000, bankstatement.Data.Amount)
+		assert.Equal(t, "2021-12-16", bankstatement.Data.Date.Format(DateLayout))
 	})
 }
 
@@ -0,0 +1,154 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotAmendable is returned for orders that are already canceled, expired or rejected
+var ErrNotAmendable = errors.New("order can not be amended")
+
+/*
+OrderChanges to an order, fields left at zero keep the value of the original.
+Quantity is the new total quantity, including what the original has executed already
+*/
+type OrderChanges struct {
+	Quantity   int
+	LimitPrice int
+	StopPrice  int
+	ExpiresAt  time.Time
+}
+
+// AmendStatus tells how far an amend got
+type AmendStatus string
+
+const (
+	// AmendApplied is an original canceled without executions and replaced in full
+	AmendApplied AmendStatus = "applied"
+	// AmendPartial is an original that executed in part before it was canceled, the replacement has the rest
+	AmendPartial AmendStatus = "partial"
+	// AmendTooLate is an original that executed in full before it could be canceled, nothing was replaced
+	AmendTooLate AmendStatus = "too_late"
+	// AmendCanceled is an original that was canceled but could not be replaced or activated, the error tells why
+	AmendCanceled AmendStatus = "canceled"
+)
+
+/*
+Amendment is the result of AmendOrder. Original is the original order as it was last seen,
+Replacement the order that was created for it, if any
+*/
+type Amendment struct {
+	Status      AmendStatus
+	Original    Order
+	Replacement Order
+}
+
+/*
+AmendOrder changes an order by deleting it and creating a replacement, as there is no endpoint to modify orders.
+The replacement is only created once the original is confirmed canceled, and only for the quantity it did not execute,
+so that an original that executes in the meantime does not lead to a double position. The replacement is activated
+unless the original was still inactive. When ctx is done before the cancel is confirmed nothing is replaced and
+ctx.Err() is returned, ctx should therefore have a deadline
+*/
+func (cl *TradingClient) AmendOrder(ctx context.Context, orderID string, changes OrderChanges) *Item[Amendment, error] {
+	return Amend(ctx, cl, orderID, changes)
+}
+
+// Amend is AmendOrder for any broker, such as the simulator
+func Amend(ctx context.Context, broker OrderPlacer, orderID string, changes OrderChanges) *Item[Amendment, error] {
+	item := &Item[Amendment, error]{}
+	if err := ctx.Err(); err != nil {
+		item.Error = err
+		return item
+	}
+	original := broker.GetOrder(orderID)
+	if original.Error != nil {
+		item.Error = original.Error
+		return item
+	}
+	item.Data.Original = original.Data
+	switch original.Data.Status {
+	case OrderExecuted:
+		item.Data.Status = AmendTooLate
+		return item
+	case OrderCanceled, OrderExpired, OrderRejected:
+		item.Error = fmt.Errorf("%w: %s is %s", ErrNotAmendable, orderID, original.Data.Status)
+		return item
+	}
+	quantity := original.Data.Quantity
+	if changes.Quantity != 0 {
+		quantity = changes.Quantity
+	}
+	if quantity <= original.Data.ExecutedQuantity {
+		item.Error = fmt.Errorf("%w: quantity %d is not more than the executed %d", ErrNotAmendable, quantity, original.Data.ExecutedQuantity)
+		return item
+	}
+
+	if err := broker.DeleteOrder(orderID); err != nil {
+		// deleting fails for an order that is done already, such as one that executed in the meantime
+		current := broker.GetOrder(orderID)
+		if current.Error != nil || !current.Data.Done() {
+			item.Error = err
+			return item
+		}
+	}
+	canceled, err := awaitDone(ctx, broker, orderID)
+	if err != nil {
+		item.Error = err
+		return item
+	}
+	item.Data.Original = canceled
+	switch canceled.Status {
+	case OrderCanceled:
+	case OrderExecuted:
+		item.Data.Status = AmendTooLate
+		return item
+	default:
+		item.Error = fmt.Errorf("%w: %s is %s", ErrNotAmendable, orderID, canceled.Status)
+		return item
+	}
+
+	item.Data.Status = AmendCanceled
+	remaining := quantity - canceled.ExecutedQuantity
+	if remaining <= 0 {
+		item.Data.Status = AmendTooLate
+		return item
+	}
+	replacement := Order{
+		ISIN:       canceled.ISIN,
+		Side:       canceled.Side,
+		Quantity:   remaining,
+		LimitPrice: canceled.LimitPrice,
+		StopPrice:  canceled.StopPrice,
+		Venue:      canceled.Venue,
+		ExpiresAt:  canceled.ExpiresAt,
+	}
+	if changes.LimitPrice != 0 {
+		replacement.LimitPrice = changes.LimitPrice
+	}
+	if changes.StopPrice != 0 {
+		replacement.StopPrice = changes.StopPrice
+	}
+	if !changes.ExpiresAt.IsZero() {
+		replacement.ExpiresAt = changes.ExpiresAt
+	}
+	created := broker.CreateOrder(&replacement)
+	if created.Error != nil {
+		item.Error = created.Error
+		return item
+	}
+	item.Data.Replacement = created.Data
+	if original.Data.Status != OrderInactive {
+		if item.Error = broker.ActivateOrder(created.Data.ID); item.Error != nil {
+			return item
+		}
+		item.Data.Replacement.Status = OrderActivated
+	}
+	item.Data.Status = AmendApplied
+	if canceled.ExecutedQuantity > 0 {
+		item.Data.Status = AmendPartial
+	}
+	return item
+}
@@ -0,0 +1,173 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/client"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAmender holds a single order that can execute right before it is deleted
+type fakeAmender struct {
+	*fakePlacer
+	order        Order
+	fillOnDelete int
+	pending      int
+	deletes      int
+}
+
+func newFakeAmender(status string) *fakeAmender {
+	expires := time.Date(2022, 3, 4, 22, 0, 0, 0, time.UTC)
+	order := Order{ID: "ord_original", ISIN: "US88160R1014", Side: Buy, Quantity: 10, LimitPrice: 1000000, Venue: "XMUN", ExpiresAt: expires, Status: status}
+	return &fakeAmender{fakePlacer: &fakePlacer{}, order: order}
+}
+
+func (f *fakeAmender) GetOrder(orderID string) *Item[Order, error] {
+	if orderID != f.order.ID {
+		return &Item[Order, error]{Error: errors.New("order not found")}
+	}
+	order := f.order
+	if f.deletes > 0 && f.pending > 0 {
+		f.pending--
+		order.Status = OrderOpen
+	}
+	return &Item[Order, error]{Data: order}
+}
+
+func (f *fakeAmender) GetOrders(query *GetOrdersQuery) <-chan Item[Order, error] {
+	ch := make(chan Item[Order, error])
+	close(ch)
+	return ch
+}
+
+func (f *fakeAmender) DeleteOrder(orderID string) error {
+	f.deletes++
+	f.order.ExecutedQuantity += f.fillOnDelete
+	if f.order.ExecutedQuantity == f.order.Quantity {
+		f.order.Status = OrderExecuted
+		return errors.New("order can not be deleted")
+	}
+	f.order.Status = OrderCanceled
+	return nil
+}
+
+func TestAmend(t *testing.T) {
+	ctx := context.Background()
+	t.Run("Successful test", func(t *testing.T) {
+		broker := newFakeAmender(OrderActivated)
+		amendment := Amend(ctx, broker, "ord_original", OrderChanges{LimitPrice: 1010000})
+		assert.Nil(t, amendment.Error)
+		assert.Equal(t, AmendApplied, amendment.Data.Status)
+		assert.Equal(t, OrderCanceled, amendment.Data.Original.Status)
+		assert.Equal(t, Order{ID: "ord_0", ISIN: "US88160R1014", Side: Buy, Quantity: 10, LimitPrice: 1010000, Venue: "XMUN", ExpiresAt: broker.order.ExpiresAt}, broker.created[0])
+		assert.Equal(t, []string{"ord_0"}, broker.activated)
+		assert.Equal(t, OrderActivated, amendment.Data.Replacement.Status)
+	})
+	t.Run("partially executed", func(t *testing.T) {
+		broker := newFakeAmender(OrderActivated)
+		broker.fillOnDelete = 4
+		amendment := Amend(ctx, broker, "ord_original", OrderChanges{Quantity: 20})
+		assert.Nil(t, amendment.Error)
+		assert.Equal(t, AmendPartial, amendment.Data.Status)
+		assert.Equal(t, 4, amendment.Data.Original.ExecutedQuantity)
+		assert.Equal(t, 16, amendment.Data.Replacement.Quantity, "only what is left of the new quantity")
+		assert.Equal(t, 1000000, amendment.Data.Replacement.LimitPrice)
+	})
+	t.Run("executed while deleting", func(t *testing.T) {
+		broker := newFakeAmender(OrderActivated)
+		broker.fillOnDelete = 10
+		amendment := Amend(ctx, broker, "ord_original", OrderChanges{LimitPrice: 1010000})
+		assert.Nil(t, amendment.Error)
+		assert.Equal(t, AmendTooLate, amendment.Data.Status)
+		assert.Empty(t, broker.created)
+	})
+	t.Run("executed before", func(t *testing.T) {
+		broker := newFakeAmender(OrderExecuted)
+		amendment := Amend(ctx, broker, "ord_original", OrderChanges{LimitPrice: 1010000})
+		assert.Nil(t, amendment.Error)
+		assert.Equal(t, AmendTooLate, amendment.Data.Status)
+		assert.Equal(t, 0, broker.deletes)
+	})
+	t.Run("wait for the cancel", func(t *testing.T) {
+		broker := newFakeAmender(OrderActivated)
+		broker.pending = 2
+		amendment := Amend(ctx, broker, "ord_original", OrderChanges{LimitPrice: 1010000})
+		assert.Nil(t, amendment.Error)
+		assert.Equal(t, AmendApplied, amendment.Data.Status)
+		assert.Equal(t, 0, broker.pending)
+	})
+	t.Run("cancel not confirmed", func(t *testing.T) {
+		broker := newFakeAmender(OrderActivated)
+		broker.pending = 1000
+		timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		amendment := Amend(timeout, broker, "ord_original", OrderChanges{LimitPrice: 1010000})
+		assert.Equal(t, context.DeadlineExceeded, amendment.Error)
+		assert.Empty(t, broker.created, "nothing is replaced before the cancel is confirmed")
+	})
+	t.Run("inactive", func(t *testing.T) {
+		broker := newFakeAmender(OrderInactive)
+		amendment := Amend(ctx, broker, "ord_original", OrderChanges{Quantity: 5})
+		assert.Nil(t, amendment.Error)
+		assert.Equal(t, AmendApplied, amendment.Data.Status)
+		assert.Equal(t, 5, broker.created[0].Quantity)
+		assert.Empty(t, broker.activated, "an inactive original is replaced by an inactive order")
+	})
+	t.Run("fail to create replacement", func(t *testing.T) {
+		broker := newFakeAmender(OrderActivated)
+		broker.failISIN = "US88160R1014"
+		amendment := Amend(ctx, broker, "ord_original", OrderChanges{LimitPrice: 1010000})
+		assert.EqualError(t, amendment.Error, "rejected")
+		assert.Equal(t, AmendCanceled, amendment.Data.Status)
+	})
+	t.Run("not amendable", func(t *testing.T) {
+		amendment := Amend(ctx, newFakeAmender(OrderCanceled), "ord_original", OrderChanges{LimitPrice: 1010000})
+		assert.True(t, errors.Is(amendment.Error, ErrNotAmendable))
+		assert.EqualError(t, amendment.Error, "order can not be amended: ord_original is canceled")
+
+		broker := newFakeAmender(OrderPartiallyExecuted)
+		broker.order.ExecutedQuantity = 6
+		amendment = Amend(ctx, broker, "ord_original", OrderChanges{Quantity: 6})
+		assert.EqualError(t, amendment.Error, "order can not be amended: quantity 6 is not more than the executed 6")
+		assert.Equal(t, 0, broker.deletes)
+	})
+	t.Run("fail to get order", func(t *testing.T) {
+		amendment := Amend(ctx, newFakeAmender(OrderActivated), "ord_unknown", OrderChanges{})
+		assert.EqualError(t, amendment.Error, "order not found")
+	})
+}
+
+func TestAmendOrder(t *testing.T) {
+	deleted := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/orders/ord_1" && deleted:
+			w.Write([]byte(`{"status": "ok", "results": {"id": "ord_1", "isin": "US88160R1014", "side": "sell", "quantity": 3, "limit_price": 2000000, "status": "canceled"}}`))
+		case r.Method == "GET" && r.URL.Path == "/orders/ord_1":
+			w.Write([]byte(`{"status": "ok", "results": {"id": "ord_1", "isin": "US88160R1014", "side": "sell", "quantity": 3, "limit_price": 2000000, "status": "activated"}}`))
+		case r.Method == "DELETE" && r.URL.Path == "/orders/ord_1":
+			deleted = true
+			w.Write([]byte(`{"status": "ok"}`))
+		case r.Method == "POST" && r.URL.Path == "/orders":
+			w.Write([]byte(`{"status": "ok", "results": {"id": "ord_2", "isin": "US88160R1014", "side": "sell", "quantity": 3, "limit_price": 1900000, "status": "inactive"}}`))
+		case r.Method == "POST" && r.URL.Path == "/orders/ord_2/activate":
+			w.Write([]byte(`{"status": "ok"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	backend := client.Backend{BaseURL: server.URL}
+	cl := TradingClient{backend: &backend}
+
+	amendment := cl.AmendOrder(context.Background(), "ord_1", OrderChanges{LimitPrice: 1900000})
+	assert.Nil(t, amendment.Error)
+	assert.Equal(t, AmendApplied, amendment.Data.Status)
+	assert.Equal(t, "ord_2", amendment.Data.Replacement.ID)
+	assert.Equal(t, OrderActivated, amendment.Data.Replacement.Status)
+}
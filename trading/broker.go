@@ -0,0 +1,144 @@
+package trading
+
+import (
+	"errors"
+	"log"
+)
+
+// OrderEntry places, activates and deletes orders
+type OrderEntry interface {
+	CreateOrder(order *Order) *Item[Order, error]
+	ActivateOrder(orderID string) error
+	DeleteOrder(orderID string) error
+}
+
+// OrderQuery returns placed orders
+type OrderQuery interface {
+	GetOrder(orderID string) *Item[Order, error]
+	GetOrders(query *GetOrdersQuery) <-chan Item[Order, error]
+}
+
+// PositionReader returns current positions
+type PositionReader interface {
+	GetPositions() <-chan Item[Position, error]
+}
+
+// AccountReader returns account information
+type AccountReader interface {
+	GetAccount() *Item[Account, error]
+}
+
+/*
+Broker is everything a strategy needs to trade, implemented by TradingClient as well as the simulator
+so that strategies can be moved between them without changes
+*/
+type Broker interface {
+	OrderEntry
+	OrderQuery
+	PositionReader
+	AccountReader
+}
+
+var _ Broker = (*TradingClient)(nil)
+
+// ErrReadOnly is returned by ReadOnlyBroker for calls that would change orders
+var ErrReadOnly = errors.New("broker is read-only")
+
+/*
+ReadOnlyBroker passes queries to the wrapped broker and rejects every call that would change orders
+*/
+type ReadOnlyBroker struct {
+	Broker
+}
+
+// NewReadOnlyBroker wraps a broker so that it can not place or change orders
+func NewReadOnlyBroker(broker Broker) *ReadOnlyBroker {
+	return &ReadOnlyBroker{Broker: broker}
+}
+
+// CreateOrder returns ErrReadOnly
+func (b *ReadOnlyBroker) CreateOrder(order *Order) *Item[Order, error] {
+	return &Item[Order, error]{Error: ErrReadOnly}
+}
+
+// ActivateOrder returns ErrReadOnly
+func (b *ReadOnlyBroker) ActivateOrder(orderID string) error {
+	return ErrReadOnly
+}
+
+// DeleteOrder returns ErrReadOnly
+func (b *ReadOnlyBroker) DeleteOrder(orderID string) error {
+	return ErrReadOnly
+}
+
+/*
+LoggingBroker logs every call to the wrapped broker together with its error, if any
+*/
+type LoggingBroker struct {
+	Broker
+	logger *log.Logger
+}
+
+// NewLoggingBroker wraps a broker, logger defaults to log.Default() when nil
+func NewLoggingBroker(broker Broker, logger *log.Logger) *LoggingBroker {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LoggingBroker{Broker: broker, logger: logger}
+}
+
+// CreateOrder logs the order to be created and the result
+func (b *LoggingBroker) CreateOrder(order *Order) *Item[Order, error] {
+	b.logger.Printf("CreateOrder %s %d %s limit=%d stop=%d", order.Side, order.Quantity, order.ISIN, order.LimitPrice, order.StopPrice)
+	item := b.Broker.CreateOrder(order)
+	b.logResult("CreateOrder", item.Data.ID, item.Error)
+	return item
+}
+
+// ActivateOrder logs the activation and the result
+func (b *LoggingBroker) ActivateOrder(orderID string) error {
+	err := b.Broker.ActivateOrder(orderID)
+	b.logResult("ActivateOrder", orderID, err)
+	return err
+}
+
+// DeleteOrder logs the deletion and the result
+func (b *LoggingBroker) DeleteOrder(orderID string) error {
+	err := b.Broker.DeleteOrder(orderID)
+	b.logResult("DeleteOrder", orderID, err)
+	return err
+}
+
+// GetOrder logs the lookup and the result
+func (b *LoggingBroker) GetOrder(orderID string) *Item[Order, error] {
+	item := b.Broker.GetOrder(orderID)
+	b.logResult("GetOrder", orderID, item.Error)
+	return item
+}
+
+// GetOrders logs the call, errors are returned on the channel as usual
+func (b *LoggingBroker) GetOrders(query *GetOrdersQuery) <-chan Item[Order, error] {
+	b.logger.Printf("GetOrders")
+	return b.Broker.GetOrders(query)
+}
+
+// GetPositions logs the call, errors are returned on the channel as usual
+func (b *LoggingBroker) GetPositions() <-chan Item[Position, error] {
+	b.logger.Printf("GetPositions")
+	return b.Broker.GetPositions()
+}
+
+// GetAccount logs the call and the result
+func (b *LoggingBroker) GetAccount() *Item[Account, error] {
+	item := b.Broker.GetAccount()
+	b.logResult("GetAccount", item.Data.AccountID, item.Error)
+	return item
+}
+
+func (b *LoggingBroker) logResult(call, id string, err error) {
+	if err != nil {
+		b.logger.Printf("%s %s failed: %v", call, id, err)
+		return
+	}
+	b.logger.Printf("%s %s ok", call, id)
+}
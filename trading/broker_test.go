@@ -0,0 +1,66 @@
+package trading
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/quantfamily/lemonmarkets/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOnlyBroker(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"status": "ok", "results": {"account_id": "acc_1"}}`))
+	}))
+	defer server.Close()
+	backend := client.Backend{BaseURL: server.URL}
+	broker := NewReadOnlyBroker(&TradingClient{backend: &backend})
+
+	assert.Equal(t, ErrReadOnly, broker.CreateOrder(&Order{Quantity: 1}).Error)
+	assert.Equal(t, ErrReadOnly, broker.ActivateOrder("ord_1"))
+	assert.Equal(t, ErrReadOnly, broker.DeleteOrder("ord_1"))
+	assert.Equal(t, 0, requests)
+
+	account := broker.GetAccount()
+	assert.Nil(t, account.Error)
+	assert.Equal(t, "acc_1", account.Data.AccountID)
+	assert.Equal(t, 1, requests)
+}
+
+func TestLoggingBroker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"status": "ok", "results": {"id": "ord_1"}}`))
+	}))
+	defer server.Close()
+	backend := client.Backend{BaseURL: server.URL}
+	output := new(bytes.Buffer)
+	broker := NewLoggingBroker(&TradingClient{backend: &backend}, log.New(output, "", 0))
+
+	order := broker.CreateOrder(&Order{ISIN: "US88160R1014", Side: Buy, Quantity: 1})
+	assert.Nil(t, order.Error)
+	assert.Nil(t, broker.ActivateOrder("ord_1"))
+	assert.NotNil(t, broker.DeleteOrder("ord_1"))
+	assert.Nil(t, broker.GetOrder("ord_1").Error)
+	for range broker.GetOrders(nil) {
+	}
+	for range broker.GetPositions() {
+	}
+	broker.GetAccount()
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	assert.Equal(t, "CreateOrder buy 1 US88160R1014 limit=0 stop=0", lines[0])
+	assert.Equal(t, "CreateOrder ord_1 ok", lines[1])
+	assert.Equal(t, "ActivateOrder ord_1 ok", lines[2])
+	assert.True(t, strings.HasPrefix(lines[3], "DeleteOrder ord_1 failed:"))
+	assert.Len(t, lines, 8)
+}
@@ -0,0 +1,398 @@
+package trading
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// CostMethod decides which lots are consumed when a position is reduced
+type CostMethod int
+
+const (
+	// FIFO consumes the oldest lot first
+	FIFO CostMethod = iota
+	// AverageCost keeps a single pooled lot per ISIN at the average buy price
+	AverageCost
+)
+
+// EventType is the kind of change a LedgerEvent makes to holdings
+type EventType string
+
+const (
+	EventBuy         EventType = "buy"
+	EventSell        EventType = "sell"
+	EventTransferIn  EventType = "transfer_in"
+	EventTransferOut EventType = "transfer_out"
+)
+
+/*
+LedgerEvent is a single change to holdings that the Ledger replays.
+Amount is the total price without fees and Fees the charge for the event,
+both in hundredths of a cent like the rest of the trading API.
+UnknownCost marks a transfer in whose cost basis is not known, Amount and Fees are not used for it
+*/
+type LedgerEvent struct {
+	ID          string
+	Type        EventType
+	Time        time.Time
+	ISIN        string
+	Quantity    int
+	Amount      int
+	Fees        int
+	UnknownCost bool
+}
+
+/*
+Lot is an open quantity of an ISIN, Cost is the total cost including buy fees.
+Lots with UnknownCost come from transfers without a known cost basis and have a Cost of 0
+*/
+type Lot struct {
+	ISIN        string
+	Time        time.Time
+	Quantity    int
+	Cost        int
+	UnknownCost bool
+}
+
+/*
+Realization is the result of closing (part of) a position
+Gain is Proceeds - CostBasis - Fees, where Fees are the fees of the selling event.
+A sale of shares with UnknownCost has no CostBasis and no Gain and is left out of RealizedBy,
+a sale that takes from lots with known and unknown cost is split into one realization each
+*/
+type Realization struct {
+	ID          string
+	ISIN        string
+	Time        time.Time
+	Quantity    int
+	Proceeds    int
+	CostBasis   int
+	Fees        int
+	Gain        int
+	UnknownCost bool
+}
+
+// RejectedEvent is an event that could not be applied during Replay, such as a sell of more than is held
+type RejectedEvent struct {
+	Event  LedgerEvent
+	Reason string
+}
+
+/*
+Ledger keeps cost basis lots per ISIN and the realized gains from replayed events.
+TransferCosts is the cost basis of transfers in, such as imports, by statement ID in hundredths of a cent.
+Transfers without one are kept as lots with unknown cost
+*/
+type Ledger struct {
+	Method        CostMethod
+	TransferCosts map[string]int
+	lots          map[string][]Lot
+	realized      []Realization
+	skipped       []Statement
+	rejected      []RejectedEvent
+}
+
+// NewLedger returns an empty ledger using the given cost method
+func NewLedger(method CostMethod) *Ledger {
+	return &Ledger{Method: method, lots: make(map[string][]Lot)}
+}
+
+/*
+OrderEvent converts an executed order to a buy or sell event.
+Returns false for orders without any executed quantity
+*/
+func OrderEvent(order Order) (LedgerEvent, bool) {
+	if order.ExecutedQuantity <= 0 {
+		return LedgerEvent{}, false
+	}
+	event := LedgerEvent{
+		ID:       order.ID,
+		Type:     EventBuy,
+		Time:     order.ExecutedAt,
+		ISIN:     order.ISIN,
+		Quantity: order.ExecutedQuantity,
+		Amount:   order.ExecutedPriceTotal,
+		Fees:     int(order.Charge),
+	}
+	if order.Side == Sell {
+		event.Type = EventSell
+	}
+	if event.Time.IsZero() {
+		event.Time = order.CreatedAt
+	}
+	if event.Amount == 0 {
+		event.Amount = order.ExecutedPrice * order.ExecutedQuantity
+	}
+	return event, true
+}
+
+/*
+StatementEvent converts a position statement that is not caused by an order, such as an import, to a transfer event.
+Statements that belong to an order are covered by OrderEvent and return false, as do statement types the ledger does not know.
+Statements do not carry a price, so transfers in have an unknown cost
+*/
+func StatementEvent(statement Statement) (LedgerEvent, bool) {
+	if statement.OrderID != "" {
+		return LedgerEvent{}, false
+	}
+	event := LedgerEvent{
+		ID:       statement.ID,
+		Time:     statement.Date.Time,
+		ISIN:     statement.ISIN,
+		Quantity: statement.Quantity,
+	}
+	if !statement.CreatedAt.IsZero() {
+		event.Time = statement.CreatedAt
+	}
+	switch statement.Type {
+	case "import", "snx", "order_buy":
+		event.Type = EventTransferIn
+		event.UnknownCost = true
+	case "export", "order_sell":
+		event.Type = EventTransferOut
+	default:
+		return LedgerEvent{}, false
+	}
+	return event, true
+}
+
+/*
+Replay applies executed orders and statements in time order.
+Events at the same time are ordered by ID so that the result is deterministic.
+Events that can not be applied, such as a sell of more than is held, are left out and reported by Rejected,
+so that a history that does not start at the beginning of the account still gives the rest
+*/
+func (l *Ledger) Replay(orders []Order, statements []Statement) error {
+	var events []LedgerEvent
+	for _, order := range orders {
+		if event, ok := OrderEvent(order); ok {
+			events = append(events, event)
+		}
+	}
+	for _, statement := range statements {
+		if event, ok := StatementEvent(statement); ok {
+			if cost, known := l.TransferCosts[event.ID]; known && event.Type == EventTransferIn {
+				event.Amount = cost
+				event.UnknownCost = false
+			}
+			events = append(events, event)
+		} else if statement.OrderID == "" {
+			l.skipped = append(l.skipped, statement)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].Time.Equal(events[j].Time) {
+			return events[i].Time.Before(events[j].Time)
+		}
+		return events[i].ID < events[j].ID
+	})
+	for _, event := range events {
+		if err := l.Apply(event); err != nil {
+			l.rejected = append(l.rejected, RejectedEvent{Event: event, Reason: err.Error()})
+		}
+	}
+	return nil
+}
+
+// Apply a single event to the ledger, events must be applied in time order
+func (l *Ledger) Apply(event LedgerEvent) error {
+	if event.Quantity <= 0 {
+		return fmt.Errorf("event %s: quantity must be positive, got %d", event.ID, event.Quantity)
+	}
+	switch event.Type {
+	case EventBuy, EventTransferIn:
+		lot := Lot{ISIN: event.ISIN, Time: event.Time, Quantity: event.Quantity, Cost: event.Amount + event.Fees}
+		if event.UnknownCost {
+			lot.Cost = 0
+			lot.UnknownCost = true
+		}
+		l.addLot(lot)
+		return nil
+	case EventSell:
+		cost, unknown, err := l.removeQuantity(event.ISIN, event.Quantity)
+		if err != nil {
+			return fmt.Errorf("event %s: %w", event.ID, err)
+		}
+		realization := Realization{ID: event.ID, ISIN: event.ISIN, Time: event.Time}
+		if known := event.Quantity - unknown; known > 0 {
+			proceeds := event.Amount * known / event.Quantity
+			fees := event.Fees * known / event.Quantity
+			realization.Quantity = known
+			realization.Proceeds = proceeds
+			realization.CostBasis = cost
+			realization.Fees = fees
+			realization.Gain = proceeds - cost - fees
+			l.realized = append(l.realized, realization)
+			event.Amount -= proceeds
+			event.Fees -= fees
+		}
+		if unknown > 0 {
+			l.realized = append(l.realized, Realization{
+				ID:          event.ID,
+				ISIN:        event.ISIN,
+				Time:        event.Time,
+				Quantity:    unknown,
+				Proceeds:    event.Amount,
+				Fees:        event.Fees,
+				UnknownCost: true,
+			})
+		}
+		return nil
+	case EventTransferOut:
+		_, _, err := l.removeQuantity(event.ISIN, event.Quantity)
+		if err != nil {
+			return fmt.Errorf("event %s: %w", event.ID, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("event %s: unknown event type %q", event.ID, event.Type)
+}
+
+// addLot adds a lot, with AverageCost it is pooled with the lot of the same ISIN that has known or unknown cost alike
+func (l *Ledger) addLot(lot Lot) {
+	lots := l.lots[lot.ISIN]
+	if l.Method == AverageCost {
+		for i := range lots {
+			if lots[i].UnknownCost == lot.UnknownCost {
+				lots[i].Quantity += lot.Quantity
+				lots[i].Cost += lot.Cost
+				return
+			}
+		}
+	}
+	l.lots[lot.ISIN] = append(lots, lot)
+}
+
+/*
+removeQuantity consumes lots and returns the cost basis of the removed quantity with known cost
+and the removed quantity with unknown cost
+*/
+func (l *Ledger) removeQuantity(isin string, quantity int) (int, int, error) {
+	if held := l.Quantity(isin); held < quantity {
+		return 0, 0, fmt.Errorf("cannot remove %d of %s, only %d held", quantity, isin, held)
+	}
+	lots := l.lots[isin]
+	cost, unknown := 0, 0
+	for quantity > 0 {
+		lot := &lots[0]
+		take := quantity
+		if lot.Quantity < take {
+			take = lot.Quantity
+		}
+		if lot.UnknownCost {
+			unknown += take
+		} else {
+			lotCost := lot.Cost * take / lot.Quantity
+			cost += lotCost
+			lot.Cost -= lotCost
+		}
+		lot.Quantity -= take
+		quantity -= take
+		if lot.Quantity == 0 {
+			lots = lots[1:]
+		}
+	}
+	if len(lots) == 0 {
+		delete(l.lots, isin)
+	} else {
+		l.lots[isin] = lots
+	}
+	return cost, unknown, nil
+}
+
+// Quantity returns the currently held quantity of an ISIN
+func (l *Ledger) Quantity(isin string) int {
+	quantity := 0
+	for _, lot := range l.lots[isin] {
+		quantity += lot.Quantity
+	}
+	return quantity
+}
+
+// Lots returns a copy of the open lots of an ISIN, oldest first
+func (l *Ledger) Lots(isin string) []Lot {
+	return append([]Lot(nil), l.lots[isin]...)
+}
+
+// ISINs returns all ISINs with open lots, sorted
+func (l *Ledger) ISINs() []string {
+	isins := make([]string, 0, len(l.lots))
+	for isin := range l.lots {
+		isins = append(isins, isin)
+	}
+	sort.Strings(isins)
+	return isins
+}
+
+// Realized returns every realization in the order they happened
+func (l *Ledger) Realized() []Realization {
+	return append([]Realization(nil), l.realized...)
+}
+
+// Skipped returns statements that Replay could not turn into an event
+func (l *Ledger) Skipped() []Statement {
+	return append([]Statement(nil), l.skipped...)
+}
+
+// Rejected returns the events that Replay could not apply, in the order they were replayed
+func (l *Ledger) Rejected() []RejectedEvent {
+	return append([]RejectedEvent(nil), l.rejected...)
+}
+
+/*
+RealizedBy sums realized gains grouped by key, e.g ByISIN, ByYear or ByMonth.
+Realizations with unknown cost are left out
+*/
+func (l *Ledger) RealizedBy(key func(Realization) string) map[string]int {
+	gains := make(map[string]int)
+	for _, realization := range l.realized {
+		if realization.UnknownCost {
+			continue
+		}
+		gains[key(realization)] += realization.Gain
+	}
+	return gains
+}
+
+// ByISIN groups realizations per ISIN
+func ByISIN(realization Realization) string {
+	return realization.ISIN
+}
+
+// ByYear groups realizations per calendar year (UTC)
+func ByYear(realization Realization) string {
+	return realization.Time.UTC().Format("2006")
+}
+
+// ByMonth groups realizations per calendar month (UTC)
+func ByMonth(realization Realization) string {
+	return realization.Time.UTC().Format("2006-01")
+}
+
+/*
+GetLedger fetches all orders and statements from LemonMarkets and replays them into a ledger
+*/
+func (cl *TradingClient) GetLedger(method CostMethod) *Item[Ledger, error] {
+	item := &Item[Ledger, error]{}
+	var orders []Order
+	for order := range cl.GetOrders(nil) {
+		if order.Error != nil {
+			item.Error = order.Error
+			return item
+		}
+		orders = append(orders, order.Data)
+	}
+	var statements []Statement
+	for statement := range cl.GetStatements() {
+		if statement.Error != nil {
+			item.Error = statement.Error
+			return item
+		}
+		statements = append(statements, statement.Data)
+	}
+	ledger := NewLedger(method)
+	item.Error = ledger.Replay(orders, statements)
+	item.Data = *ledger
+	return item
+}
@@ -0,0 +1,164 @@
+package trading
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/client"
+	"github.com/quantfamily/lemonmarkets/client/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func ledgerFixtures(t *testing.T) ([]Order, []Statement) {
+	t.Helper()
+	var orderResponse, statementResponse client.Response
+	var orders []Order
+	var statements []Statement
+	assert.Nil(t, json.Unmarshal(helpers.ParseFile(t, "get_ledger_orders.json"), &orderResponse))
+	assert.Nil(t, json.Unmarshal(orderResponse.Results, &orders))
+	assert.Nil(t, json.Unmarshal(helpers.ParseFile(t, "get_ledger_statements.json"), &statementResponse))
+	assert.Nil(t, json.Unmarshal(statementResponse.Results, &statements))
+	return orders, statements
+}
+
+func TestLedger(t *testing.T) {
+	orders, statements := ledgerFixtures(t)
+
+	t.Run("FIFO", func(t *testing.T) {
+		ledger := NewLedger(FIFO)
+		assert.Nil(t, ledger.Replay(orders, statements))
+
+		realized := ledger.Realized()
+		assert.Len(t, realized, 1)
+		assert.Equal(t, "ord_sell1", realized[0].ID)
+		assert.Equal(t, 35015000, realized[0].CostBasis)
+		assert.Equal(t, 10000, realized[0].Fees)
+		assert.Equal(t, 6975000, realized[0].Gain)
+
+		lots := ledger.Lots("US19260Q1076")
+		assert.Len(t, lots, 1)
+		assert.Equal(t, 5, lots[0].Quantity)
+		assert.Equal(t, 15005000, lots[0].Cost)
+		assert.Equal(t, 4, ledger.Quantity("DE0008232125"))
+		assert.Equal(t, []string{"DE0008232125", "US19260Q1076"}, ledger.ISINs())
+	})
+	t.Run("AverageCost", func(t *testing.T) {
+		ledger := NewLedger(AverageCost)
+		assert.Nil(t, ledger.Replay(orders, statements))
+
+		realized := ledger.Realized()
+		assert.Len(t, realized, 1)
+		assert.Equal(t, 37515000, realized[0].CostBasis)
+		assert.Equal(t, 4475000, realized[0].Gain)
+		assert.Equal(t, 12505000, ledger.Lots("US19260Q1076")[0].Cost)
+	})
+	t.Run("Grouping", func(t *testing.T) {
+		ledger := NewLedger(FIFO)
+		assert.Nil(t, ledger.Replay(orders, statements))
+		assert.Equal(t, map[string]int{"US19260Q1076": 6975000}, ledger.RealizedBy(ByISIN))
+		assert.Equal(t, map[string]int{"2022": 6975000}, ledger.RealizedBy(ByYear))
+		assert.Equal(t, map[string]int{"2022-01": 6975000}, ledger.RealizedBy(ByMonth))
+	})
+	t.Run("Replay is independent of input order", func(t *testing.T) {
+		reversed := make([]Order, len(orders))
+		for i, order := range orders {
+			reversed[len(orders)-1-i] = order
+		}
+		ledger := NewLedger(FIFO)
+		assert.Nil(t, ledger.Replay(reversed, statements))
+		assert.Equal(t, 6975000, ledger.Realized()[0].Gain)
+	})
+	t.Run("Sell more than held", func(t *testing.T) {
+		ledger := NewLedger(FIFO)
+		err := ledger.Apply(LedgerEvent{ID: "1", Type: EventSell, ISIN: "US19260Q1076", Quantity: 1, Time: time.Now()})
+		assert.NotNil(t, err)
+	})
+	t.Run("Sell more than held is rejected by Replay", func(t *testing.T) {
+		ledger := NewLedger(FIFO)
+		oversold := Order{ID: "ord_oversold", ISIN: "DE0008232125", Side: "sell", Status: "executed", ExecutedQuantity: 10,
+			ExecutedPrice: 100000, ExecutedAt: time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC)}
+		assert.Nil(t, ledger.Replay(append(orders, oversold), statements))
+
+		rejected := ledger.Rejected()
+		assert.Len(t, rejected, 1)
+		assert.Equal(t, "ord_oversold", rejected[0].Event.ID)
+		assert.Contains(t, rejected[0].Reason, "only 4 held")
+		assert.Equal(t, 6975000, ledger.RealizedBy(ByISIN)["US19260Q1076"], "later events are still applied")
+		assert.Equal(t, 4, ledger.Quantity("DE0008232125"))
+	})
+	t.Run("Transfer in has unknown cost", func(t *testing.T) {
+		ledger := NewLedger(FIFO)
+		assert.Nil(t, ledger.Replay(orders, statements))
+		lots := ledger.Lots("DE0008232125")
+		assert.Len(t, lots, 1)
+		assert.True(t, lots[0].UnknownCost)
+		assert.Equal(t, 0, lots[0].Cost)
+	})
+	t.Run("Transfer in with explicit cost", func(t *testing.T) {
+		ledger := NewLedger(FIFO)
+		ledger.TransferCosts = map[string]int{"hs_import1": 400000}
+		assert.Nil(t, ledger.Replay(orders, statements))
+		lots := ledger.Lots("DE0008232125")
+		assert.False(t, lots[0].UnknownCost)
+		assert.Equal(t, 400000, lots[0].Cost)
+	})
+	t.Run("Sale of unknown cost is left out of gains", func(t *testing.T) {
+		for _, method := range []CostMethod{FIFO, AverageCost} {
+			ledger := NewLedger(method)
+			start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+			assert.Nil(t, ledger.Apply(LedgerEvent{ID: "in", Type: EventTransferIn, ISIN: "DE0008232125", Quantity: 4, Time: start, UnknownCost: true}))
+			assert.Nil(t, ledger.Apply(LedgerEvent{ID: "buy", Type: EventBuy, ISIN: "DE0008232125", Quantity: 2, Amount: 2000, Time: start.Add(time.Hour)}))
+			assert.Nil(t, ledger.Apply(LedgerEvent{ID: "sell", Type: EventSell, ISIN: "DE0008232125", Quantity: 5, Amount: 5000, Fees: 50, Time: start.Add(2 * time.Hour)}))
+
+			realized := ledger.Realized()
+			assert.Len(t, realized, 2)
+			assert.Equal(t, Realization{ID: "sell", ISIN: "DE0008232125", Time: start.Add(2 * time.Hour),
+				Quantity: 1, Proceeds: 1000, CostBasis: 1000, Fees: 10, Gain: -10}, realized[0])
+			assert.Equal(t, Realization{ID: "sell", ISIN: "DE0008232125", Time: start.Add(2 * time.Hour),
+				Quantity: 4, Proceeds: 4000, Fees: 40, UnknownCost: true}, realized[1])
+			assert.Equal(t, map[string]int{"DE0008232125": -10}, ledger.RealizedBy(ByISIN))
+			assert.Equal(t, []Lot{{ISIN: "DE0008232125", Time: start.Add(time.Hour), Quantity: 1, Cost: 1000}}, ledger.Lots("DE0008232125"))
+		}
+	})
+	t.Run("Unknown statement is skipped", func(t *testing.T) {
+		ledger := NewLedger(FIFO)
+		assert.Nil(t, ledger.Replay(nil, []Statement{{ID: "hs_1", Type: "coupon", ISIN: "US19260Q1076", Quantity: 1}}))
+		assert.Len(t, ledger.Skipped(), 1)
+	})
+}
+
+func TestGetLedger(t *testing.T) {
+	orderBytes := helpers.ParseFile(t, "get_ledger_orders.json")
+	statementBytes := helpers.ParseFile(t, "get_ledger_statements.json")
+
+	t.Run("fail to get orders", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+		backend := client.Backend{BaseURL: server.URL}
+		client := TradingClient{backend: &backend}
+		ledger := client.GetLedger(FIFO)
+		assert.NotNil(t, ledger.Error)
+	})
+	t.Run("Successful test", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/orders":
+				fmt.Fprint(w, string(orderBytes))
+			case "/positions/statements":
+				fmt.Fprint(w, string(statementBytes))
+			}
+		}))
+		defer server.Close()
+		backend := client.Backend{BaseURL: server.URL}
+		client := TradingClient{backend: &backend}
+		ledger := client.GetLedger(FIFO)
+		assert.Nil(t, ledger.Error)
+		assert.Equal(t, 6975000, ledger.Data.RealizedBy(ByISIN)["US19260Q1076"])
+	})
+}
@@ -4,6 +4,30 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
+
+	"github.com/quantfamily/lemonmarkets/identifiers"
+)
+
+// Order statuses as reported by LemonMarkets
+const (
+	OrderInactive          = "inactive"
+	OrderActivated         = "activated"
+	OrderOpen              = "open"
+	OrderPartiallyExecuted = "partially_executed"
+	OrderExecuted          = "executed"
+	OrderCanceled          = "canceled"
+	OrderExpired           = "expired"
+	OrderRejected          = "rejected"
+)
+
+// Order sides and types
+const (
+	Buy       = "buy"
+	Sell      = "sell"
+	Market    = "market"
+	Limit     = "limit"
+	Stop      = "stop"
+	StopLimit = "stop_limit"
 )
 
 /*
@@ -38,6 +62,15 @@ type Order struct {
 	Idempotency           string                 `json:"idempotency,omitempty"`
 }
 
+// Done is true for an executed, canceled, expired or rejected order, statuses it does not leave anymore
+func (o *Order) Done() bool {
+	switch o.Status {
+	case OrderExecuted, OrderCanceled, OrderExpired, OrderRejected:
+		return true
+	}
+	return false
+}
+
 /*
 RegulatoryInformation information for an order
 */
@@ -63,11 +96,27 @@ type RegulatoryInformation struct {
 	LegalDisclaimer                 string  `json:"legal_disclaimer"`
 }
 
+/*
+Validate checks ISIN and venue of the order locally, empty values are left to the backend
+*/
+func (o *Order) Validate() error {
+	if o.ISIN != "" {
+		if err := identifiers.CheckISINs(o.ISIN); err != nil {
+			return err
+		}
+	}
+	return identifiers.CheckMIC(o.Venue)
+}
+
 /*
 CreateOrder places a order on LemonMarkets and returns response from the backend
 */
 func (cl *TradingClient) CreateOrder(order *Order) *Item[Order, error] {
 	item := &Item[Order, error]{}
+	if err := order.Validate(); err != nil {
+		item.Error = err
+		return item
+	}
 
 	orderData, err := json.Marshal(order)
 	if err != nil {
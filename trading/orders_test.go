@@ -2,6 +2,7 @@ package trading
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/quantfamily/lemonmarkets/client"
 	"github.com/quantfamily/lemonmarkets/client/helpers"
+	"github.com/quantfamily/lemonmarkets/identifiers"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -46,6 +48,18 @@ func TestCreateOrder(t *testing.T) {
 		assert.NotNil(t, order.Error)
 		assert.ObjectsAreEqual(&json.SyntaxError{}, order.Error)
 	})
+	t.Run("invalid identifiers", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("invalid order was sent to the backend")
+		}))
+		defer server.Close()
+		backend := client.Backend{BaseURL: server.URL}
+		client := TradingClient{backend: &backend}
+		order := client.CreateOrder(&Order{ISIN: "US0378331006", Quantity: 10})
+		assert.True(t, errors.Is(order.Error, identifiers.ErrInvalidISIN))
+		order = client.CreateOrder(&Order{ISIN: "US0378331005", Venue: "munich", Quantity: 10})
+		assert.True(t, errors.Is(order.Error, identifiers.ErrInvalidMIC))
+	})
 	t.Run("Successful test", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			fmt.Fprint(w, string(rawFileBytes))
@@ -58,6 +72,15 @@ func TestCreateOrder(t *testing.T) {
 	})
 }
 
+func TestOrderDone(t *testing.T) {
+	for _, status := range []string{"executed", "canceled", "expired", "rejected"} {
+		assert.True(t, (&Order{Status: status}).Done(), status)
+	}
+	for _, status := range []string{"inactive", "activated", "open", "partially_executed"} {
+		assert.False(t, (&Order{Status: status}).Done(), status)
+	}
+}
+
 func TestActivateOrder(t *testing.T) {
 	t.Run("fail to get response", func(t *testing.T) {
 		expectedErr := client.LemonError{
@@ -0,0 +1,236 @@
+package trading
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+)
+
+// UnknownType is the instrument type used for holdings where no type could be resolved
+const UnknownType = "unknown"
+
+/*
+PriceSource returns the price a position should be valued at.
+Prices are in the same unit as the rest of the trading API (hundredths of a cent, 10000 = 1 EUR)
+*/
+type PriceSource interface {
+	Price(position Position) (int, error)
+}
+
+// PriceSourceFunc allows an ordinary function to be used as PriceSource
+type PriceSourceFunc func(position Position) (int, error)
+
+// Price calls f(position)
+func (f PriceSourceFunc) Price(position Position) (int, error) {
+	return f(position)
+}
+
+// EstimatedPriceSource values positions at the estimated price given by LemonMarkets
+type EstimatedPriceSource struct{}
+
+// Price returns the estimated price of the position
+func (EstimatedPriceSource) Price(position Position) (int, error) {
+	return position.EstimatedPrice, nil
+}
+
+// QuoteGetter is anything that can return quotes, such as market_data.MarketDataClient
+type QuoteGetter interface {
+	GetQuotes(query *market_data.GetQuotesQuery) <-chan market_data.Item[market_data.Quote, error]
+}
+
+/*
+QuotePriceSource values positions at the mid of the latest quote (bid + ask) / 2
+MIC is optional and limits quotes to a specific venue
+*/
+type QuotePriceSource struct {
+	Quotes QuoteGetter
+	MIC    string
+}
+
+// Price returns the mid price of the latest quote for the position
+func (s QuotePriceSource) Price(position Position) (int, error) {
+	query := market_data.GetQuotesQuery{ISIN: []string{position.ISIN}, MIC: s.MIC, Sorting: "newest_first", Limit: 1}
+	quotes := s.Quotes.GetQuotes(&query)
+	defer market_data.Drain(quotes)
+	quote, ok := <-quotes
+	if !ok {
+		return 0, fmt.Errorf("no quote found for %s", position.ISIN)
+	}
+	if quote.Error != nil {
+		return 0, quote.Error
+	}
+	return ToAmount((quote.Data.Bid + quote.Data.Ask) / 2), nil
+}
+
+// OHLCGetter is anything that can return daily OHLC, such as market_data.MarketDataClient
+type OHLCGetter interface {
+	GetOHLCPerDay(query *market_data.GetOHLCQuery) <-chan market_data.Item[market_data.OHLC, error]
+}
+
+/*
+ClosePriceSource values positions at the close of the latest daily bar
+MIC is optional and limits bars to a specific venue
+*/
+type ClosePriceSource struct {
+	OHLC OHLCGetter
+	MIC  string
+}
+
+// Price returns the latest daily close for the position
+func (s ClosePriceSource) Price(position Position) (int, error) {
+	query := market_data.GetOHLCQuery{ISIN: []string{position.ISIN}, MIC: s.MIC, Sorting: "newest_first", Limit: 1}
+	ohlcs := s.OHLC.GetOHLCPerDay(&query)
+	defer market_data.Drain(ohlcs)
+	ohlc, ok := <-ohlcs
+	if !ok {
+		return 0, fmt.Errorf("no ohlc found for %s", position.ISIN)
+	}
+	if ohlc.Error != nil {
+		return 0, ohlc.Error
+	}
+	return ToAmount(ohlc.Data.Close), nil
+}
+
+/*
+Holding is a position valued at a price from a PriceSource
+Amounts are in hundredths of a cent, Weight and UnrealizedPnLPct are fractions (0.1 = 10%)
+*/
+type Holding struct {
+	Position         Position
+	Type             string
+	Price            int
+	MarketValue      int
+	CostBasis        int
+	UnrealizedPnL    int
+	UnrealizedPnLPct float64
+	Weight           float64
+}
+
+/*
+Portfolio is a snapshot of positions and cash valued at a given point in time
+Exposure holds the weight of the total value per instrument type, cash is not included
+*/
+type Portfolio struct {
+	Time             time.Time
+	Holdings         []Holding
+	Cash             int
+	MarketValue      int
+	TotalValue       int
+	CostBasis        int
+	UnrealizedPnL    int
+	UnrealizedPnLPct float64
+	Exposure         map[string]float64
+}
+
+/*
+NewPortfolio values positions using the price source, types map ISIN to instrument type and may be nil
+*/
+func NewPortfolio(positions []Position, cash int, prices PriceSource, types map[string]string) (*Portfolio, error) {
+	portfolio := &Portfolio{Time: time.Now(), Cash: cash, Exposure: make(map[string]float64)}
+	for _, position := range positions {
+		price, err := prices.Price(position)
+		if err != nil {
+			return nil, fmt.Errorf("price for %s: %w", position.ISIN, err)
+		}
+		instrumentType, ok := types[position.ISIN]
+		if !ok || instrumentType == "" {
+			instrumentType = UnknownType
+		}
+		holding := Holding{
+			Position:    position,
+			Type:        instrumentType,
+			Price:       price,
+			MarketValue: price * position.Quantity,
+			CostBasis:   position.BuyPriceAverage * position.Quantity,
+		}
+		holding.UnrealizedPnL = holding.MarketValue - holding.CostBasis
+		holding.UnrealizedPnLPct = ratio(holding.UnrealizedPnL, holding.CostBasis)
+		portfolio.Holdings = append(portfolio.Holdings, holding)
+		portfolio.MarketValue += holding.MarketValue
+		portfolio.CostBasis += holding.CostBasis
+	}
+	portfolio.TotalValue = portfolio.MarketValue + portfolio.Cash
+	portfolio.UnrealizedPnL = portfolio.MarketValue - portfolio.CostBasis
+	portfolio.UnrealizedPnLPct = ratio(portfolio.UnrealizedPnL, portfolio.CostBasis)
+	for i := range portfolio.Holdings {
+		holding := &portfolio.Holdings[i]
+		holding.Weight = ratio(holding.MarketValue, portfolio.TotalValue)
+		portfolio.Exposure[holding.Type] += holding.Weight
+	}
+	sort.Slice(portfolio.Holdings, func(i, j int) bool {
+		return portfolio.Holdings[i].MarketValue > portfolio.Holdings[j].MarketValue
+	})
+	return portfolio, nil
+}
+
+/*
+GetPortfolio fetches account and positions from LemonMarkets and values them using the price source
+Cash is taken from Account.CashToInvest, types map ISIN to instrument type and may be nil
+*/
+func (cl *TradingClient) GetPortfolio(prices PriceSource, types map[string]string) *Item[Portfolio, error] {
+	return LoadPortfolio(cl, prices, types)
+}
+
+/*
+LoadPortfolio is GetPortfolio for any broker, such as the simulator
+*/
+func LoadPortfolio(broker Broker, prices PriceSource, types map[string]string) *Item[Portfolio, error] {
+	item := &Item[Portfolio, error]{}
+	account := broker.GetAccount()
+	if account.Error != nil {
+		item.Error = account.Error
+		return item
+	}
+	var positions []Position
+	for position := range broker.GetPositions() {
+		if position.Error != nil {
+			item.Error = position.Error
+			return item
+		}
+		positions = append(positions, position.Data)
+	}
+	portfolio, err := NewPortfolio(positions, int(math.Round(float64(account.Data.CashToInvest))), prices, types)
+	if err != nil {
+		item.Error = err
+		return item
+	}
+	item.Data = *portfolio
+	return item
+}
+
+// InstrumentGetter is anything that can return instruments, such as market_data.MarketDataClient
+type InstrumentGetter interface {
+	GetInstruments(query *market_data.GetInstrumentsQuery) <-chan market_data.Item[market_data.Instrument, error]
+}
+
+/*
+InstrumentTypes looks up the instrument type (stock, etf, etc) for each ISIN
+*/
+func InstrumentTypes(instruments InstrumentGetter, isins []string) (map[string]string, error) {
+	types := make(map[string]string)
+	if len(isins) == 0 {
+		return types, nil
+	}
+	for instrument := range instruments.GetInstruments(&market_data.GetInstrumentsQuery{ISIN: isins}) {
+		if instrument.Error != nil {
+			return nil, instrument.Error
+		}
+		types[instrument.Data.ISIN] = instrument.Data.Type
+	}
+	return types, nil
+}
+
+// ToAmount converts a price in EUR, as used by market_data, to hundredths of a cent as used by trading
+func ToAmount(price float64) int {
+	return int(math.Round(price * 10000))
+}
+
+func ratio(numerator, denominator int) float64 {
+	if denominator == 0 {
+		return 0
+	}
+	return float64(numerator) / float64(denominator)
+}
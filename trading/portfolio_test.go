@@ -0,0 +1,189 @@
+package trading
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/quantfamily/lemonmarkets/client"
+	"github.com/quantfamily/lemonmarkets/client/helpers"
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeMarketData struct {
+	quotes      []market_data.Quote
+	ohlcs       []market_data.OHLC
+	instruments []market_data.Instrument
+	venues      []market_data.Venue
+	err         error
+}
+
+func (f *fakeMarketData) GetQuotes(query *market_data.GetQuotesQuery) <-chan market_data.Item[market_data.Quote, error] {
+	return returnFake(f.quotes, f.err)
+}
+
+func (f *fakeMarketData) GetOHLCPerDay(query *market_data.GetOHLCQuery) <-chan market_data.Item[market_data.OHLC, error] {
+	return returnFake(f.ohlcs, f.err)
+}
+
+func (f *fakeMarketData) GetInstruments(query *market_data.GetInstrumentsQuery) <-chan market_data.Item[market_data.Instrument, error] {
+	return returnFake(f.instruments, f.err)
+}
+
+func (f *fakeMarketData) GetVenues() <-chan market_data.Item[market_data.Venue, error] {
+	return returnFake(f.venues, f.err)
+}
+
+func returnFake[T market_data.DataTypes](data []T, err error) <-chan market_data.Item[T, error] {
+	ch := make(chan market_data.Item[T, error], len(data)+1)
+	if err != nil {
+		ch <- market_data.Item[T, error]{Error: err}
+	}
+	for _, d := range data {
+		ch <- market_data.Item[T, error]{Data: d}
+	}
+	close(ch)
+	return ch
+}
+
+func TestNewPortfolio(t *testing.T) {
+	positions := []Position{
+		{ISIN: "US19260Q1076", Quantity: 2, BuyPriceAverage: 2000000, EstimatedPrice: 2500000},
+		{ISIN: "IE00B4L5Y983", Quantity: 10, BuyPriceAverage: 700000, EstimatedPrice: 600000},
+	}
+	types := map[string]string{"US19260Q1076": "stock"}
+
+	t.Run("price source fails", func(t *testing.T) {
+		failing := PriceSourceFunc(func(position Position) (int, error) {
+			return 0, errors.New("no price")
+		})
+		portfolio, err := NewPortfolio(positions, 0, failing, types)
+		assert.Nil(t, portfolio)
+		assert.NotNil(t, err)
+	})
+	t.Run("Successful test", func(t *testing.T) {
+		portfolio, err := NewPortfolio(positions, 3000000, EstimatedPriceSource{}, types)
+		assert.Nil(t, err)
+		assert.Equal(t, 11000000, portfolio.MarketValue)
+		assert.Equal(t, 14000000, portfolio.TotalValue)
+		assert.Equal(t, 11000000, portfolio.CostBasis)
+		assert.Equal(t, 0, portfolio.UnrealizedPnL)
+
+		coinbase := portfolio.Holdings[1]
+		assert.Equal(t, "US19260Q1076", coinbase.Position.ISIN)
+		assert.Equal(t, 1000000, coinbase.UnrealizedPnL)
+		assert.InDelta(t, 0.25, coinbase.UnrealizedPnLPct, 1e-9)
+		assert.InDelta(t, 5.0/14.0, coinbase.Weight, 1e-9)
+
+		etf := portfolio.Holdings[0]
+		assert.Equal(t, UnknownType, etf.Type)
+		assert.Equal(t, -1000000, etf.UnrealizedPnL)
+		assert.InDelta(t, 6.0/14.0, portfolio.Exposure[UnknownType], 1e-9)
+		assert.InDelta(t, 5.0/14.0, portfolio.Exposure["stock"], 1e-9)
+	})
+}
+
+func TestPriceSources(t *testing.T) {
+	position := Position{ISIN: "US88160R1014"}
+
+	t.Run("quote, fail to get quote", func(t *testing.T) {
+		source := QuotePriceSource{Quotes: &fakeMarketData{err: errors.New("backend down")}}
+		_, err := source.Price(position)
+		assert.NotNil(t, err)
+	})
+	t.Run("quote, no quote", func(t *testing.T) {
+		source := QuotePriceSource{Quotes: &fakeMarketData{}}
+		_, err := source.Price(position)
+		assert.NotNil(t, err)
+	})
+	t.Run("quote, mid price", func(t *testing.T) {
+		source := QuotePriceSource{Quotes: &fakeMarketData{quotes: []market_data.Quote{{Bid: 920.5, Ask: 921.1}}}}
+		price, err := source.Price(position)
+		assert.Nil(t, err)
+		assert.Equal(t, 9208000, price)
+	})
+	t.Run("close, fail to get ohlc", func(t *testing.T) {
+		source := ClosePriceSource{OHLC: &fakeMarketData{err: errors.New("backend down")}}
+		_, err := source.Price(position)
+		assert.NotNil(t, err)
+	})
+	t.Run("close, latest close", func(t *testing.T) {
+		source := ClosePriceSource{OHLC: &fakeMarketData{ohlcs: []market_data.OHLC{{Close: 609.5}}}}
+		price, err := source.Price(position)
+		assert.Nil(t, err)
+		assert.Equal(t, 6095000, price)
+	})
+}
+
+func TestInstrumentTypes(t *testing.T) {
+	t.Run("fail to get instruments", func(t *testing.T) {
+		_, err := InstrumentTypes(&fakeMarketData{err: errors.New("backend down")}, []string{"US19260Q1076"})
+		assert.NotNil(t, err)
+	})
+	t.Run("Successful test", func(t *testing.T) {
+		instruments := []market_data.Instrument{{ISIN: "US19260Q1076", Type: "stock"}}
+		types, err := InstrumentTypes(&fakeMarketData{instruments: instruments}, []string{"US19260Q1076"})
+		assert.Nil(t, err)
+		assert.Equal(t, "stock", types["US19260Q1076"])
+	})
+}
+
+func TestGetPortfolio(t *testing.T) {
+	accountBytes := helpers.ParseFile(t, "get_account.json")
+	positionBytes := helpers.ParseFile(t, "get_positions.json")
+
+	t.Run("fail to get account", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+		backend := client.Backend{BaseURL: server.URL}
+		client := TradingClient{backend: &backend}
+		portfolio := client.GetPortfolio(EstimatedPriceSource{}, nil)
+		assert.NotNil(t, portfolio.Error)
+	})
+	t.Run("Successful test", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/account":
+				fmt.Fprint(w, string(accountBytes))
+			case "/positions":
+				fmt.Fprint(w, string(positionBytes))
+			}
+		}))
+		defer server.Close()
+		backend := client.Backend{BaseURL: server.URL}
+		client := TradingClient{backend: &backend}
+		portfolio := client.GetPortfolio(EstimatedPriceSource{}, nil)
+		assert.Nil(t, portfolio.Error)
+		assert.Equal(t, 80000000, portfolio.Data.Cash)
+		assert.Equal(t, 5800000, portfolio.Data.MarketValue)
+		assert.Equal(t, -130000, portfolio.Data.UnrealizedPnL)
+	})
+	t.Run("cash is rounded", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/account":
+				fmt.Fprint(w, strings.Replace(string(accountBytes), `"cash_to_invest": 80000000`, `"cash_to_invest": 12345.99`, 1))
+			case "/positions":
+				fmt.Fprint(w, string(positionBytes))
+			}
+		}))
+		defer server.Close()
+		backend := client.Backend{BaseURL: server.URL}
+		client := TradingClient{backend: &backend}
+		portfolio := client.GetPortfolio(EstimatedPriceSource{}, nil)
+		assert.Nil(t, portfolio.Error)
+		assert.Equal(t, 12346, portfolio.Data.Cash)
+	})
+}
+
+func TestToAmount(t *testing.T) {
+	assert.Equal(t, 1505000, ToAmount(150.5))
+	assert.Equal(t, 3, ToAmount(0.00025), "rounded to the nearest hundredth of a cent")
+	assert.Equal(t, -123400, ToAmount(-12.34))
+}
@@ -108,6 +108,7 @@ func TestGetStatements(t *testing.T) {
 		statement := <-statementCh
 		assert.Nil(t, statement.Error)
 		assert.Equal(t, "US19260Q1076", statement.Data.ISIN)
+		assert.Equal(t, time.Date(2021, 12, 10, 0, 0, 0, 0, time.UTC), statement.Data.Date.Time)
 	})
 }
 
@@ -0,0 +1,279 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+/*
+Target weight for an ISIN, Band is the absolute drift that is tolerated before trading (0.02 = 2 percentage points)
+*/
+type Target struct {
+	ISIN   string
+	Weight float64
+	Band   float64
+}
+
+/*
+RebalanceConfig describes the wanted portfolio and the constraints when trading towards it.
+Holdings without a target are sold. MinOrderValue and CashReserve are in hundredths of a cent,
+weights are relative to the total value of the portfolio without the CashReserve
+*/
+type RebalanceConfig struct {
+	Targets       []Target
+	Prices        PriceSource
+	MinOrderValue int
+	CashReserve   int
+	Venue         string
+	ExpiresAt     time.Time
+}
+
+/*
+Leg is a single order in a rebalance plan, Value is the estimated value of the order at Price
+*/
+type Leg struct {
+	Order         Order
+	Price         int
+	Value         int
+	CurrentWeight float64
+	TargetWeight  float64
+}
+
+/*
+RebalancePlan is a dry-run of the orders needed to get back within tolerance, sells are placed before buys
+*/
+type RebalancePlan struct {
+	Portfolio Portfolio
+	Legs      []Leg
+	CashAfter int
+}
+
+/*
+NewRebalancePlan computes the orders needed to bring every instrument outside of its band back to its target weight.
+Quantities are whole shares, buys are reduced to fit the cash available after sells and the cash reserve
+*/
+func NewRebalancePlan(portfolio *Portfolio, config RebalanceConfig) (*RebalancePlan, error) {
+	sum := 0.0
+	for _, target := range config.Targets {
+		if target.Weight < 0 {
+			return nil, fmt.Errorf("negative target weight for %s", target.ISIN)
+		}
+		sum += target.Weight
+	}
+	if sum > 1+1e-9 {
+		return nil, fmt.Errorf("target weights sum to %f, more than 1", sum)
+	}
+	investable := portfolio.TotalValue - config.CashReserve
+	if investable < 0 {
+		investable = 0
+	}
+
+	holdings := make(map[string]Holding)
+	for _, holding := range portfolio.Holdings {
+		holdings[holding.Position.ISIN] = holding
+	}
+	targets := make(map[string]Target)
+	for _, target := range config.Targets {
+		targets[target.ISIN] = target
+	}
+	for isin := range holdings {
+		if _, ok := targets[isin]; !ok {
+			targets[isin] = Target{ISIN: isin}
+		}
+	}
+	isins := make([]string, 0, len(targets))
+	for isin := range targets {
+		isins = append(isins, isin)
+	}
+	sort.Strings(isins)
+
+	var sells, buys []Leg
+	for _, isin := range isins {
+		target := targets[isin]
+		holding, held := holdings[isin]
+		current := ratio(holding.MarketValue, investable)
+		if math.Abs(current-target.Weight) <= target.Band {
+			continue
+		}
+		price := holding.Price
+		if !held {
+			var err error
+			if price, err = config.Prices.Price(Position{ISIN: isin}); err != nil {
+				return nil, fmt.Errorf("price for %s: %w", isin, err)
+			}
+		}
+		if price <= 0 {
+			return nil, fmt.Errorf("no valid price for %s", isin)
+		}
+		delta := int(target.Weight*float64(investable)) - holding.MarketValue
+		leg := Leg{Price: price, CurrentWeight: current, TargetWeight: target.Weight}
+		leg.Order = Order{ISIN: isin, Venue: config.Venue, ExpiresAt: config.ExpiresAt}
+		if delta < 0 {
+			quantity := -delta / price
+			if target.Weight == 0 || quantity > holding.Position.Quantity {
+				quantity = holding.Position.Quantity
+			}
+			leg.Order.Side = Sell
+			leg.Order.Quantity = quantity
+			sells = append(sells, leg)
+		} else {
+			leg.Order.Side = Buy
+			leg.Order.Quantity = delta / price
+			buys = append(buys, leg)
+		}
+	}
+
+	plan := &RebalancePlan{Portfolio: *portfolio, CashAfter: portfolio.Cash}
+	for _, leg := range sells {
+		leg.Value = leg.Order.Quantity * leg.Price
+		if leg.Order.Quantity == 0 || leg.Value < config.MinOrderValue {
+			continue
+		}
+		plan.CashAfter += leg.Value
+		plan.Legs = append(plan.Legs, leg)
+	}
+	// Largest deficit first so that scarce cash goes where the drift is biggest
+	sort.SliceStable(buys, func(i, j int) bool {
+		return buys[i].TargetWeight-buys[i].CurrentWeight > buys[j].TargetWeight-buys[j].CurrentWeight
+	})
+	for _, leg := range buys {
+		available := plan.CashAfter - config.CashReserve
+		if available < 0 {
+			available = 0
+		}
+		if maxQuantity := available / leg.Price; leg.Order.Quantity > maxQuantity {
+			leg.Order.Quantity = maxQuantity
+		}
+		leg.Value = leg.Order.Quantity * leg.Price
+		if leg.Order.Quantity == 0 || leg.Value < config.MinOrderValue {
+			continue
+		}
+		plan.CashAfter -= leg.Value
+		plan.Legs = append(plan.Legs, leg)
+	}
+	return plan, nil
+}
+
+/*
+PlanRebalance values the current portfolio with the configured price source and returns a rebalance plan
+*/
+func (cl *TradingClient) PlanRebalance(config RebalanceConfig) *Item[RebalancePlan, error] {
+	return LoadRebalancePlan(cl, config)
+}
+
+// LoadRebalancePlan is PlanRebalance for any broker, such as the simulator
+func LoadRebalancePlan(broker Broker, config RebalanceConfig) *Item[RebalancePlan, error] {
+	item := &Item[RebalancePlan, error]{}
+	portfolio := LoadPortfolio(broker, config.Prices, nil)
+	if portfolio.Error != nil {
+		item.Error = portfolio.Error
+		return item
+	}
+	plan, err := NewRebalancePlan(&portfolio.Data, config)
+	if err != nil {
+		item.Error = err
+		return item
+	}
+	item.Data = *plan
+	return item
+}
+
+// OrderPlacer is what Execute needs, implemented by TradingClient as well as the simulator
+type OrderPlacer interface {
+	OrderEntry
+	OrderQuery
+}
+
+/*
+LegResult is the outcome of executing a leg, Order is the order as last returned by the backend
+*/
+type LegResult struct {
+	Leg     Leg
+	Order   Order
+	Skipped bool
+	Error   error
+}
+
+/*
+Execute creates and activates every leg in order. The buys are sized with the cash from the sells,
+so they are only placed once every sell is executed. If a sell fails, is not executed in full or ctx is done
+while waiting for the sells, the buys are skipped
+*/
+func (p *RebalancePlan) Execute(ctx context.Context, broker OrderPlacer) []LegResult {
+	results := make([]LegResult, 0, len(p.Legs))
+	sellFailed, sellsDone := false, false
+	for _, leg := range p.Legs {
+		result := LegResult{Leg: leg}
+		if leg.Order.Side == Buy && !sellsDone && !sellFailed {
+			sellsDone = true
+			sellFailed = awaitSells(ctx, broker, results)
+		}
+		if sellFailed && leg.Order.Side == Buy {
+			result.Skipped = true
+			results = append(results, result)
+			continue
+		}
+		order := leg.Order
+		created := broker.CreateOrder(&order)
+		result.Order = created.Data
+		result.Error = created.Error
+		if result.Error == nil {
+			result.Error = broker.ActivateOrder(created.Data.ID)
+		}
+		if result.Error != nil && leg.Order.Side == Sell {
+			sellFailed = true
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// awaitSells waits for every placed sell to be done and returns true when one of them is not executed
+func awaitSells(ctx context.Context, broker OrderQuery, results []LegResult) bool {
+	failed := false
+	for i := range results {
+		result := &results[i]
+		if result.Leg.Order.Side != Sell || result.Error != nil {
+			continue
+		}
+		order, err := awaitDone(ctx, broker, result.Order.ID)
+		if err != nil {
+			result.Error = err
+			return true
+		}
+		result.Order = order
+		if order.Status != OrderExecuted {
+			result.Error = fmt.Errorf("sell %s is %s", order.ID, order.Status)
+			failed = true
+		}
+	}
+	return failed
+}
+
+/*
+awaitDone gets the order until it is canceled, executed, expired or rejected, waiting twice as long after every
+attempt up to a second
+*/
+func awaitDone(ctx context.Context, broker OrderQuery, orderID string) (Order, error) {
+	wait := 50 * time.Millisecond
+	for {
+		item := broker.GetOrder(orderID)
+		if item.Error != nil {
+			return Order{}, item.Error
+		}
+		if item.Data.Done() {
+			return item.Data, nil
+		}
+		select {
+		case <-ctx.Done():
+			return item.Data, ctx.Err()
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > time.Second {
+			wait = time.Second
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/quantfamily/lemonmarkets/client"
+	"github.com/quantfamily/lemonmarkets/client/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePlacer struct {
+	created   []Order
+	activated []string
+	calls     []string
+	failISIN  string
+	pending   int
+	status    string
+}
+
+func (f *fakePlacer) CreateOrder(order *Order) *Item[Order, error] {
+	if order.ISIN == f.failISIN {
+		return &Item[Order, error]{Error: errors.New("rejected")}
+	}
+	created := *order
+	created.ID = fmt.Sprintf("ord_%d", len(f.created))
+	f.created = append(f.created, created)
+	f.calls = append(f.calls, "create "+created.ID)
+	return &Item[Order, error]{Data: created}
+}
+
+func (f *fakePlacer) ActivateOrder(orderID string) error {
+	f.activated = append(f.activated, orderID)
+	return nil
+}
+
+// GetOrder returns the order as open for the first pending calls and with status afterwards, executed by default
+func (f *fakePlacer) GetOrder(orderID string) *Item[Order, error] {
+	f.calls = append(f.calls, "get "+orderID)
+	order := Order{ID: orderID, Status: "open"}
+	if f.pending > 0 {
+		f.pending--
+	} else if order.Status = f.status; order.Status == "" {
+		order.Status = "executed"
+	}
+	return &Item[Order, error]{Data: order}
+}
+
+func (f *fakePlacer) GetOrders(query *GetOrdersQuery) <-chan Item[Order, error] {
+	ch := make(chan Item[Order, error])
+	close(ch)
+	return ch
+}
+
+func (f *fakePlacer) DeleteOrder(orderID string) error {
+	return nil
+}
+
+func rebalancePortfolio(t *testing.T) *Portfolio {
+	t.Helper()
+	positions := []Position{
+		{ISIN: "A", Quantity: 10, BuyPriceAverage: 1000000, EstimatedPrice: 1000000},
+		{ISIN: "B", Quantity: 5, BuyPriceAverage: 2000000, EstimatedPrice: 2000000},
+	}
+	portfolio, err := NewPortfolio(positions, 0, EstimatedPriceSource{}, nil)
+	assert.Nil(t, err)
+	return portfolio
+}
+
+func rebalancePrices() PriceSource {
+	return PriceSourceFunc(func(position Position) (int, error) {
+		if position.ISIN == "C" {
+			return 500000, nil
+		}
+		return 0, fmt.Errorf("unknown ISIN %s", position.ISIN)
+	})
+}
+
+func TestNewRebalancePlan(t *testing.T) {
+	targets := []Target{{"A", 0.3, 0.05}, {"B", 0.3, 0.05}, {"C", 0.4, 0.05}}
+
+	t.Run("weights above one", func(t *testing.T) {
+		_, err := NewRebalancePlan(rebalancePortfolio(t), RebalanceConfig{Targets: []Target{{"A", 0.7, 0}, {"B", 0.7, 0}}})
+		assert.NotNil(t, err)
+	})
+	t.Run("missing price", func(t *testing.T) {
+		_, err := NewRebalancePlan(rebalancePortfolio(t), RebalanceConfig{Targets: []Target{{"D", 0.5, 0}}, Prices: rebalancePrices()})
+		assert.NotNil(t, err)
+	})
+	t.Run("sells before buys", func(t *testing.T) {
+		plan, err := NewRebalancePlan(rebalancePortfolio(t), RebalanceConfig{Targets: targets, Prices: rebalancePrices()})
+		assert.Nil(t, err)
+		assert.Len(t, plan.Legs, 3)
+		assert.Equal(t, Order{ISIN: "A", Side: "sell", Quantity: 4}, plan.Legs[0].Order)
+		assert.Equal(t, Order{ISIN: "B", Side: "sell", Quantity: 2}, plan.Legs[1].Order)
+		assert.Equal(t, Order{ISIN: "C", Side: "buy", Quantity: 16}, plan.Legs[2].Order)
+		assert.Equal(t, 0, plan.CashAfter)
+	})
+	t.Run("cash reserve limits buys", func(t *testing.T) {
+		plan, err := NewRebalancePlan(rebalancePortfolio(t), RebalanceConfig{Targets: targets, Prices: rebalancePrices(), CashReserve: 1000000})
+		assert.Nil(t, err)
+		assert.Equal(t, 14, plan.Legs[2].Order.Quantity)
+		assert.Equal(t, 1000000, plan.CashAfter)
+	})
+	t.Run("cash reserve is part of the drift", func(t *testing.T) {
+		config := RebalanceConfig{Targets: []Target{{"A", 0.5, 0.05}, {"B", 0.5, 0.05}}, CashReserve: 4000000}
+		plan, err := NewRebalancePlan(rebalancePortfolio(t), config)
+		assert.Nil(t, err)
+		assert.Len(t, plan.Legs, 2)
+		assert.InDelta(t, 0.625, plan.Legs[0].CurrentWeight, 1e-9)
+		assert.Equal(t, Order{ISIN: "A", Side: "sell", Quantity: 2}, plan.Legs[0].Order)
+		assert.Equal(t, Order{ISIN: "B", Side: "sell", Quantity: 1}, plan.Legs[1].Order)
+		assert.Equal(t, 4000000, plan.CashAfter)
+	})
+	t.Run("within band and minimum order value", func(t *testing.T) {
+		config := RebalanceConfig{
+			Targets:       []Target{{"A", 0.45, 0.1}, {"B", 0.45, 0}, {"C", 0.1, 0}},
+			Prices:        rebalancePrices(),
+			MinOrderValue: 2000000,
+		}
+		plan, err := NewRebalancePlan(rebalancePortfolio(t), config)
+		assert.Nil(t, err)
+		assert.Len(t, plan.Legs, 0)
+	})
+	t.Run("untargeted holdings are sold", func(t *testing.T) {
+		plan, err := NewRebalancePlan(rebalancePortfolio(t), RebalanceConfig{Targets: []Target{{"A", 0.5, 0}}, Prices: rebalancePrices()})
+		assert.Nil(t, err)
+		assert.Len(t, plan.Legs, 1)
+		assert.Equal(t, Order{ISIN: "B", Side: "sell", Quantity: 5}, plan.Legs[0].Order)
+	})
+}
+
+func TestRebalancePlanExecute(t *testing.T) {
+	plan, err := NewRebalancePlan(rebalancePortfolio(t), RebalanceConfig{
+		Targets: []Target{{"A", 0.3, 0}, {"B", 0.3, 0}, {"C", 0.4, 0}},
+		Prices:  rebalancePrices(),
+	})
+	assert.Nil(t, err)
+
+	t.Run("all legs executed", func(t *testing.T) {
+		placer := &fakePlacer{}
+		results := plan.Execute(context.Background(), placer)
+		assert.Len(t, results, 3)
+		for _, result := range results {
+			assert.Nil(t, result.Error)
+			assert.False(t, result.Skipped)
+		}
+		assert.Equal(t, []string{"ord_0", "ord_1", "ord_2"}, placer.activated)
+		assert.Equal(t, "executed", results[0].Order.Status)
+	})
+	t.Run("buys wait for the sells", func(t *testing.T) {
+		placer := &fakePlacer{pending: 2}
+		results := plan.Execute(context.Background(), placer)
+		assert.Nil(t, results[2].Error)
+		assert.Equal(t, []string{"create ord_0", "create ord_1", "get ord_0", "get ord_0", "get ord_0", "get ord_1", "create ord_2"}, placer.calls)
+	})
+	t.Run("sell not executed skips buys", func(t *testing.T) {
+		placer := &fakePlacer{status: "expired"}
+		results := plan.Execute(context.Background(), placer)
+		assert.NotNil(t, results[0].Error)
+		assert.True(t, results[2].Skipped)
+		assert.Len(t, placer.created, 2)
+	})
+	t.Run("canceled while waiting skips buys", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		placer := &fakePlacer{status: "open"}
+		results := plan.Execute(ctx, placer)
+		assert.ErrorIs(t, results[0].Error, context.Canceled)
+		assert.True(t, results[2].Skipped)
+		assert.Len(t, placer.created, 2)
+	})
+	t.Run("failed sell skips buys", func(t *testing.T) {
+		placer := &fakePlacer{failISIN: "B"}
+		results := plan.Execute(context.Background(), placer)
+		assert.Nil(t, results[0].Error)
+		assert.NotNil(t, results[1].Error)
+		assert.True(t, results[2].Skipped)
+		assert.Len(t, placer.created, 1)
+	})
+}
+
+func TestPlanRebalance(t *testing.T) {
+	accountBytes := helpers.ParseFile(t, "get_account.json")
+	positionBytes := helpers.ParseFile(t, "get_positions.json")
+
+	t.Run("fail to get portfolio", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+		backend := client.Backend{BaseURL: server.URL}
+		client := TradingClient{backend: &backend}
+		plan := client.PlanRebalance(RebalanceConfig{Prices: EstimatedPriceSource{}})
+		assert.NotNil(t, plan.Error)
+	})
+	t.Run("Successful test", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/account":
+				fmt.Fprint(w, string(accountBytes))
+			case "/positions":
+				fmt.Fprint(w, string(positionBytes))
+			}
+		}))
+		defer server.Close()
+		backend := client.Backend{BaseURL: server.URL}
+		client := TradingClient{backend: &backend}
+		plan := client.PlanRebalance(RebalanceConfig{Prices: EstimatedPriceSource{}})
+		assert.Nil(t, plan.Error)
+		assert.Len(t, plan.Data.Legs, 1)
+		assert.Equal(t, "sell", plan.Data.Legs[0].Order.Side)
+		assert.Equal(t, 2, plan.Data.Legs[0].Order.Quantity)
+	})
+}
@@ -0,0 +1,288 @@
+package trading
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+)
+
+// ErrVenueClosed is returned when a savings plan is due but the venue is not open
+var ErrVenueClosed = errors.New("venue is closed")
+
+// Allocation is the share of a savings plan amount that goes into an ISIN
+type Allocation struct {
+	ISIN   string  `json:"isin"`
+	Weight float64 `json:"weight"`
+}
+
+/*
+SavingsPlan invests Amount (hundredths of a cent) across the allocations every time the schedule is due.
+The first run is the first due time after Start
+*/
+type SavingsPlan struct {
+	ID          string
+	Amount      int
+	Allocations []Allocation
+	Schedule    Schedule
+	Start       time.Time
+	Venue       string
+}
+
+// Validate checks that the plan has a start, a schedule, an amount and no negative weights
+func (p *SavingsPlan) Validate() error {
+	if p.Start.IsZero() {
+		return fmt.Errorf("savings plan %s has no start", p.ID)
+	}
+	if p.Schedule == nil {
+		return fmt.Errorf("savings plan %s has no schedule", p.ID)
+	}
+	if p.Amount <= 0 {
+		return fmt.Errorf("savings plan %s has no amount", p.ID)
+	}
+	for _, allocation := range p.Allocations {
+		if allocation.Weight < 0 {
+			return fmt.Errorf("negative weight for %s in savings plan %s", allocation.ISIN, p.ID)
+		}
+	}
+	return nil
+}
+
+/*
+SavingsRun is a journal entry for a due date of a savings plan.
+Leftover is the money per ISIN that was not enough for a whole share and is carried to the next run.
+Skipped runs were missed and are not executed, see SavingsPlanExecutor.MaxRuns
+*/
+type SavingsRun struct {
+	PlanID     string         `json:"plan_id"`
+	Due        time.Time      `json:"due"`
+	ExecutedAt time.Time      `json:"executed_at"`
+	Orders     []Order        `json:"orders"`
+	Leftover   map[string]int `json:"leftover"`
+	Skipped    bool           `json:"skipped,omitempty"`
+	Errors     []string       `json:"errors,omitempty"`
+}
+
+// Journal keeps track of which due dates of a savings plan have been executed
+type Journal interface {
+	Runs(planID string) ([]SavingsRun, error)
+	Record(run SavingsRun) error
+}
+
+// MemoryJournal keeps runs in memory
+type MemoryJournal struct {
+	mu   sync.Mutex
+	runs []SavingsRun
+}
+
+// Runs returns recorded runs for the plan, oldest first
+func (j *MemoryJournal) Runs(planID string) ([]SavingsRun, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var runs []SavingsRun
+	for _, run := range j.runs {
+		if run.PlanID == planID {
+			runs = append(runs, run)
+		}
+	}
+	return runs, nil
+}
+
+// Record stores a run
+func (j *MemoryJournal) Record(run SavingsRun) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.runs = append(j.runs, run)
+	return nil
+}
+
+// FileJournal keeps runs as JSON lines in a file, the file is created on first Record
+type FileJournal struct {
+	Path string
+}
+
+// Runs reads recorded runs for the plan, oldest first
+func (j FileJournal) Runs(planID string) ([]SavingsRun, error) {
+	file, err := os.Open(j.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var runs []SavingsRun
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var run SavingsRun
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			return nil, err
+		}
+		if run.PlanID == planID {
+			runs = append(runs, run)
+		}
+	}
+	return runs, scanner.Err()
+}
+
+// Record appends a run to the file
+func (j FileJournal) Record(run SavingsRun) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(j.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// VenueGetter is anything that can return venues, such as market_data.MarketDataClient
+type VenueGetter interface {
+	GetVenues() <-chan market_data.Item[market_data.Venue, error]
+}
+
+/*
+VenueOpen returns a function that reports if the venue with the given MIC is open right now
+*/
+func VenueOpen(venues VenueGetter, mic string) func() (bool, error) {
+	return func() (bool, error) {
+		ch := venues.GetVenues()
+		defer market_data.Drain(ch)
+		for venue := range ch {
+			if venue.Error != nil {
+				return false, venue.Error
+			}
+			if venue.Data.Mic == mic {
+				return venue.Data.IsOpen, nil
+			}
+		}
+		return false, fmt.Errorf("unknown venue %s", mic)
+	}
+}
+
+/*
+SavingsPlanExecutor places the orders of a savings plan.
+Every due date is executed at most once, runs missed since the last recorded run are caught up on the next call to Run.
+Missed runs are bought at the current price, so only the latest MaxRuns (1 when not set) due dates are executed
+and older ones are recorded as skipped.
+IsOpen is optional, when set nothing is executed while it reports false
+*/
+type SavingsPlanExecutor struct {
+	Plan    SavingsPlan
+	Orders  OrderEntry
+	Prices  PriceSource
+	Journal Journal
+	Clock   Clock
+	IsOpen  func() (bool, error)
+	MaxRuns int
+}
+
+/*
+Run executes every due date that is not yet in the journal and returns the new runs.
+Orders are sized from the price source, money that does not fill a whole share is carried forward
+*/
+func (e *SavingsPlanExecutor) Run() ([]SavingsRun, error) {
+	if err := e.Plan.Validate(); err != nil {
+		return nil, err
+	}
+	clock := e.Clock
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	history, err := e.Journal.Runs(e.Plan.ID)
+	if err != nil {
+		return nil, err
+	}
+	from := e.Plan.Start
+	leftover := make(map[string]int)
+	if len(history) > 0 {
+		last := history[len(history)-1]
+		from = last.Due
+		for isin, amount := range last.Leftover {
+			leftover[isin] = amount
+		}
+	}
+	due := DueBetween(e.Plan.Schedule, from, clock.Now())
+	if len(due) == 0 {
+		return nil, nil
+	}
+	if e.IsOpen != nil {
+		open, err := e.IsOpen()
+		if err != nil {
+			return nil, err
+		}
+		if !open {
+			return nil, ErrVenueClosed
+		}
+	}
+	maxRuns := e.MaxRuns
+	if maxRuns <= 0 {
+		maxRuns = 1
+	}
+	var runs []SavingsRun
+	for i, dueAt := range due {
+		var run SavingsRun
+		if i < len(due)-maxRuns {
+			run = SavingsRun{PlanID: e.Plan.ID, Due: dueAt, ExecutedAt: clock.Now(), Leftover: leftover, Skipped: true}
+		} else {
+			run = e.execute(dueAt, leftover, clock)
+		}
+		if err := e.Journal.Record(run); err != nil {
+			return runs, err
+		}
+		leftover = run.Leftover
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+func (e *SavingsPlanExecutor) execute(due time.Time, leftover map[string]int, clock Clock) SavingsRun {
+	run := SavingsRun{PlanID: e.Plan.ID, Due: due, ExecutedAt: clock.Now(), Leftover: make(map[string]int)}
+	for _, allocation := range e.Plan.Allocations {
+		budget := int(float64(e.Plan.Amount)*allocation.Weight) + leftover[allocation.ISIN]
+		run.Leftover[allocation.ISIN] = budget
+		price, err := e.Prices.Price(Position{ISIN: allocation.ISIN})
+		if err == nil && price <= 0 {
+			err = fmt.Errorf("no valid price")
+		}
+		if err != nil {
+			run.Errors = append(run.Errors, fmt.Sprintf("%s: %v", allocation.ISIN, err))
+			continue
+		}
+		quantity := budget / price
+		if quantity == 0 {
+			continue
+		}
+		order := Order{
+			ISIN:        allocation.ISIN,
+			Side:        Buy,
+			Quantity:    quantity,
+			Venue:       e.Plan.Venue,
+			Idempotency: fmt.Sprintf("%s-%s-%s", e.Plan.ID, due.UTC().Format("20060102T1504"), allocation.ISIN),
+		}
+		created := e.Orders.CreateOrder(&order)
+		err = created.Error
+		if err == nil {
+			err = e.Orders.ActivateOrder(created.Data.ID)
+		}
+		if err != nil {
+			run.Errors = append(run.Errors, fmt.Sprintf("%s: %v", allocation.ISIN, err))
+			continue
+		}
+		run.Orders = append(run.Orders, created.Data)
+		run.Leftover[allocation.ISIN] = budget - quantity*price
+	}
+	return run
+}
@@ -0,0 +1,172 @@
+package trading
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/market_data"
+	"github.com/stretchr/testify/assert"
+)
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c *fixedClock) Now() time.Time {
+	return c.now
+}
+
+func savingsPlanExecutor(journal Journal, clock Clock) (*SavingsPlanExecutor, *fakePlacer) {
+	placer := &fakePlacer{}
+	prices := PriceSourceFunc(func(position Position) (int, error) {
+		switch position.ISIN {
+		case "A":
+			return 250000, nil
+		case "B":
+			return 300000, nil
+		}
+		return 0, errors.New("no price")
+	})
+	executor := &SavingsPlanExecutor{
+		Plan: SavingsPlan{
+			ID:          "etf-plan",
+			Amount:      1000000,
+			Allocations: []Allocation{{"A", 0.6}, {"B", 0.4}},
+			Schedule:    MonthlySchedule{BusinessDay: 1, Hour: 9},
+			Start:       time.Date(2021, 12, 31, 0, 0, 0, 0, time.UTC),
+		},
+		Orders:  placer,
+		Prices:  prices,
+		Journal: journal,
+		Clock:   clock,
+	}
+	return executor, placer
+}
+
+func TestSavingsPlanExecutor(t *testing.T) {
+	t.Run("missed runs are caught up exactly once", func(t *testing.T) {
+		clock := &fixedClock{now: time.Date(2022, 2, 15, 0, 0, 0, 0, time.UTC)}
+		executor, placer := savingsPlanExecutor(&MemoryJournal{}, clock)
+		executor.MaxRuns = 12
+
+		runs, err := executor.Run()
+		assert.Nil(t, err)
+		assert.Len(t, runs, 2)
+		assert.Equal(t, map[string]int{"A": 100000, "B": 100000}, runs[0].Leftover)
+		assert.Equal(t, map[string]int{"A": 200000, "B": 200000}, runs[1].Leftover)
+		assert.Len(t, placer.created, 4)
+		assert.Equal(t, 2, placer.created[0].Quantity)
+		assert.Equal(t, "etf-plan-20220103T0900-A", placer.created[0].Idempotency)
+
+		runs, err = executor.Run()
+		assert.Nil(t, err)
+		assert.Len(t, runs, 0)
+		assert.Len(t, placer.created, 4)
+
+		clock.now = time.Date(2022, 3, 2, 0, 0, 0, 0, time.UTC)
+		runs, err = executor.Run()
+		assert.Nil(t, err)
+		assert.Len(t, runs, 1)
+		assert.Equal(t, 3, runs[0].Orders[0].Quantity)
+	})
+	t.Run("only the latest missed run is executed by default", func(t *testing.T) {
+		clock := &fixedClock{now: time.Date(2022, 4, 15, 0, 0, 0, 0, time.UTC)}
+		journal := &MemoryJournal{}
+		executor, placer := savingsPlanExecutor(journal, clock)
+
+		runs, err := executor.Run()
+		assert.Nil(t, err)
+		assert.Len(t, runs, 4)
+		for _, run := range runs[:3] {
+			assert.True(t, run.Skipped)
+			assert.Len(t, run.Orders, 0)
+		}
+		assert.False(t, runs[3].Skipped)
+		assert.Equal(t, time.Date(2022, 4, 1, 9, 0, 0, 0, time.UTC), runs[3].Due)
+		assert.Len(t, placer.created, 2)
+		assert.Equal(t, "etf-plan-20220401T0900-A", placer.created[0].Idempotency)
+
+		recorded, err := journal.Runs("etf-plan")
+		assert.Nil(t, err)
+		assert.Len(t, recorded, 4)
+	})
+	t.Run("missed runs are limited by MaxRuns", func(t *testing.T) {
+		clock := &fixedClock{now: time.Date(2022, 4, 15, 0, 0, 0, 0, time.UTC)}
+		executor, placer := savingsPlanExecutor(&MemoryJournal{}, clock)
+		executor.MaxRuns = 2
+
+		runs, err := executor.Run()
+		assert.Nil(t, err)
+		assert.Len(t, runs, 4)
+		assert.True(t, runs[1].Skipped)
+		assert.False(t, runs[2].Skipped)
+		assert.Len(t, placer.created, 4)
+	})
+	t.Run("plan without start is rejected", func(t *testing.T) {
+		clock := &fixedClock{now: time.Date(2022, 1, 3, 10, 0, 0, 0, time.UTC)}
+		executor, placer := savingsPlanExecutor(&MemoryJournal{}, clock)
+		executor.Plan.Start = time.Time{}
+
+		runs, err := executor.Run()
+		assert.NotNil(t, err)
+		assert.Len(t, runs, 0)
+		assert.Len(t, placer.created, 0)
+	})
+	t.Run("venue closed", func(t *testing.T) {
+		clock := &fixedClock{now: time.Date(2022, 1, 3, 10, 0, 0, 0, time.UTC)}
+		executor, placer := savingsPlanExecutor(&MemoryJournal{}, clock)
+		executor.IsOpen = VenueOpen(&fakeMarketData{venues: []market_data.Venue{{Mic: "XMUN", IsOpen: false}}}, "XMUN")
+
+		runs, err := executor.Run()
+		assert.Equal(t, ErrVenueClosed, err)
+		assert.Len(t, runs, 0)
+		assert.Len(t, placer.created, 0)
+	})
+	t.Run("failed allocation keeps budget", func(t *testing.T) {
+		clock := &fixedClock{now: time.Date(2022, 1, 3, 10, 0, 0, 0, time.UTC)}
+		executor, _ := savingsPlanExecutor(&MemoryJournal{}, clock)
+		executor.Plan.Allocations = append(executor.Plan.Allocations, Allocation{"C", 0.1})
+
+		runs, err := executor.Run()
+		assert.Nil(t, err)
+		assert.Len(t, runs[0].Errors, 1)
+		assert.Equal(t, 100000, runs[0].Leftover["C"])
+	})
+	t.Run("file journal", func(t *testing.T) {
+		journal := FileJournal{Path: filepath.Join(t.TempDir(), "journal.jsonl")}
+		clock := &fixedClock{now: time.Date(2022, 1, 3, 10, 0, 0, 0, time.UTC)}
+		executor, _ := savingsPlanExecutor(journal, clock)
+
+		runs, err := executor.Run()
+		assert.Nil(t, err)
+		assert.Len(t, runs, 1)
+
+		executor, placer := savingsPlanExecutor(journal, clock)
+		runs, err = executor.Run()
+		assert.Nil(t, err)
+		assert.Len(t, runs, 0)
+		assert.Len(t, placer.created, 0)
+
+		recorded, err := journal.Runs("etf-plan")
+		assert.Nil(t, err)
+		assert.Equal(t, 100000, recorded[0].Leftover["A"])
+	})
+}
+
+func TestVenueOpen(t *testing.T) {
+	t.Run("fail to get venues", func(t *testing.T) {
+		_, err := VenueOpen(&fakeMarketData{err: errors.New("backend down")}, "XMUN")()
+		assert.NotNil(t, err)
+	})
+	t.Run("unknown venue", func(t *testing.T) {
+		_, err := VenueOpen(&fakeMarketData{}, "XMUN")()
+		assert.NotNil(t, err)
+	})
+	t.Run("open venue", func(t *testing.T) {
+		open, err := VenueOpen(&fakeMarketData{venues: []market_data.Venue{{Mic: "XMUN", IsOpen: true}}}, "XMUN")()
+		assert.Nil(t, err)
+		assert.True(t, open)
+	})
+}
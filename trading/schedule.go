@@ -0,0 +1,152 @@
+package trading
+
+import "time"
+
+// Clock returns the current time, replace with a fixed clock in tests
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the wall clock
+type SystemClock struct{}
+
+// Now returns time.Now()
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// TradingCalendar tells if a day is a trading day
+type TradingCalendar interface {
+	IsTradingDay(day time.Time) bool
+}
+
+// WeekdayCalendar treats Monday to Friday as trading days
+type WeekdayCalendar struct{}
+
+// IsTradingDay returns true for Monday to Friday
+func (WeekdayCalendar) IsTradingDay(day time.Time) bool {
+	return day.Weekday() != time.Saturday && day.Weekday() != time.Sunday
+}
+
+/*
+HolidayCalendar is a WeekdayCalendar with additional closed days, given as dates in DateLayout (YYYY-MM-DD)
+*/
+type HolidayCalendar struct {
+	Holidays map[string]bool
+}
+
+// IsTradingDay returns true for weekdays that are not holidays
+func (c HolidayCalendar) IsTradingDay(day time.Time) bool {
+	return WeekdayCalendar{}.IsTradingDay(day) && !c.Holidays[day.Format(DateLayout)]
+}
+
+/*
+Schedule returns the first time a job is due strictly after a given time,
+the zero time when the calendar has no trading day within the next year
+*/
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+/*
+MonthlySchedule is due on the n:th trading day of every month (BusinessDay 1 is the first trading day),
+or on the last trading day of months with fewer trading days. Calendar defaults to WeekdayCalendar and Location to UTC
+*/
+type MonthlySchedule struct {
+	BusinessDay int
+	Hour        int
+	Minute      int
+	Location    *time.Location
+	Calendar    TradingCalendar
+}
+
+// Next returns the first due time after the given time
+func (s MonthlySchedule) Next(after time.Time) time.Time {
+	location, calendar := scheduleDefaults(s.Location, s.Calendar)
+	after = after.In(location)
+	for month := 0; month <= 12; month++ {
+		first := time.Date(after.Year(), after.Month()+time.Month(month), 1, s.Hour, s.Minute, 0, 0, location)
+		due, ok := nthTradingDay(first, s.BusinessDay, calendar)
+		if ok && due.After(after) {
+			return due
+		}
+	}
+	return time.Time{}
+}
+
+/*
+WeeklySchedule is due every week on Weekday, rolled forward to the next trading day when the market is closed.
+Calendar defaults to WeekdayCalendar and Location to UTC
+*/
+type WeeklySchedule struct {
+	Weekday  time.Weekday
+	Hour     int
+	Minute   int
+	Location *time.Location
+	Calendar TradingCalendar
+}
+
+// Next returns the first due time after the given time
+func (s WeeklySchedule) Next(after time.Time) time.Time {
+	location, calendar := scheduleDefaults(s.Location, s.Calendar)
+	after = after.In(location)
+	offset := (int(s.Weekday) - int(after.Weekday()) + 7) % 7
+	// Start one week back so a rolled forward day from last week is not missed
+	day := time.Date(after.Year(), after.Month(), after.Day()+offset-7, s.Hour, s.Minute, 0, 0, location)
+	limit := after.AddDate(1, 0, 0)
+	for !day.After(limit) {
+		due := day
+		for !calendar.IsTradingDay(due) && !due.After(limit) {
+			due = due.AddDate(0, 0, 1)
+		}
+		if due.After(limit) {
+			return time.Time{}
+		}
+		if due.After(after) {
+			return due
+		}
+		day = day.AddDate(0, 0, 7)
+	}
+	return time.Time{}
+}
+
+// DueBetween returns every due time of the schedule in (from, to]
+func DueBetween(schedule Schedule, from, to time.Time) []time.Time {
+	var due []time.Time
+	for next := schedule.Next(from); !next.IsZero() && !next.After(to); next = schedule.Next(next) {
+		due = append(due, next)
+	}
+	return due
+}
+
+func scheduleDefaults(location *time.Location, calendar TradingCalendar) (*time.Location, TradingCalendar) {
+	if location == nil {
+		location = time.UTC
+	}
+	if calendar == nil {
+		calendar = WeekdayCalendar{}
+	}
+	return location, calendar
+}
+
+/*
+nthTradingDay returns the n:th trading day in the month of first, the last one if the month has fewer trading days
+and false if it has none
+*/
+func nthTradingDay(first time.Time, n int, calendar TradingCalendar) (time.Time, bool) {
+	if n < 1 {
+		n = 1
+	}
+	count := 0
+	var last time.Time
+	for day := first; day.Month() == first.Month(); day = day.AddDate(0, 0, 1) {
+		if calendar.IsTradingDay(day) {
+			count++
+			last = day
+			if count == n {
+				return day, true
+			}
+		}
+	}
+	return last, count > 0
+}
@@ -0,0 +1,91 @@
+package trading
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendars(t *testing.T) {
+	saturday := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	monday := time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC)
+	assert.False(t, WeekdayCalendar{}.IsTradingDay(saturday))
+	assert.True(t, WeekdayCalendar{}.IsTradingDay(monday))
+
+	holidays := HolidayCalendar{Holidays: map[string]bool{"2022-01-03": true}}
+	assert.False(t, holidays.IsTradingDay(monday))
+	assert.True(t, holidays.IsTradingDay(monday.AddDate(0, 0, 1)))
+}
+
+func TestMonthlySchedule(t *testing.T) {
+	schedule := MonthlySchedule{BusinessDay: 1, Hour: 9}
+
+	t.Run("first business day", func(t *testing.T) {
+		next := schedule.Next(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+		assert.Equal(t, time.Date(2022, 1, 3, 9, 0, 0, 0, time.UTC), next)
+	})
+	t.Run("after due time moves to next month", func(t *testing.T) {
+		next := schedule.Next(time.Date(2022, 1, 3, 9, 0, 0, 0, time.UTC))
+		assert.Equal(t, time.Date(2022, 2, 1, 9, 0, 0, 0, time.UTC), next)
+	})
+	t.Run("holiday is skipped", func(t *testing.T) {
+		schedule := MonthlySchedule{BusinessDay: 1, Hour: 9, Calendar: HolidayCalendar{Holidays: map[string]bool{"2022-02-01": true}}}
+		next := schedule.Next(time.Date(2022, 1, 15, 0, 0, 0, 0, time.UTC))
+		assert.Equal(t, time.Date(2022, 2, 2, 9, 0, 0, 0, time.UTC), next)
+	})
+	t.Run("last trading day of short months", func(t *testing.T) {
+		schedule := MonthlySchedule{BusinessDay: 25, Hour: 9}
+		next := schedule.Next(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+		assert.Equal(t, time.Date(2022, 1, 31, 9, 0, 0, 0, time.UTC), next)
+		next = schedule.Next(next)
+		assert.Equal(t, time.Date(2022, 2, 28, 9, 0, 0, 0, time.UTC), next)
+	})
+	t.Run("second business day in location", func(t *testing.T) {
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		if err != nil {
+			t.Skip("missing timezone data")
+		}
+		schedule := MonthlySchedule{BusinessDay: 2, Hour: 9, Location: berlin}
+		next := schedule.Next(time.Date(2022, 4, 30, 0, 0, 0, 0, time.UTC))
+		assert.Equal(t, time.Date(2022, 5, 3, 7, 0, 0, 0, time.UTC), next.UTC())
+	})
+}
+
+func TestWeeklySchedule(t *testing.T) {
+	holidays := HolidayCalendar{Holidays: map[string]bool{"2022-01-10": true}}
+
+	t.Run("next weekday", func(t *testing.T) {
+		schedule := WeeklySchedule{Weekday: time.Monday, Hour: 9}
+		next := schedule.Next(time.Date(2022, 1, 5, 0, 0, 0, 0, time.UTC))
+		assert.Equal(t, time.Date(2022, 1, 10, 9, 0, 0, 0, time.UTC), next)
+	})
+	t.Run("rolled forward on holiday", func(t *testing.T) {
+		schedule := WeeklySchedule{Weekday: time.Monday, Hour: 9, Calendar: holidays}
+		next := schedule.Next(time.Date(2022, 1, 10, 10, 0, 0, 0, time.UTC))
+		assert.Equal(t, time.Date(2022, 1, 11, 9, 0, 0, 0, time.UTC), next)
+	})
+}
+
+// closedCalendar has no trading days at all
+type closedCalendar struct{}
+
+func (closedCalendar) IsTradingDay(day time.Time) bool {
+	return false
+}
+
+func TestNoTradingDays(t *testing.T) {
+	from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.True(t, MonthlySchedule{BusinessDay: 1, Calendar: closedCalendar{}}.Next(from).IsZero())
+	assert.True(t, WeeklySchedule{Weekday: time.Monday, Calendar: closedCalendar{}}.Next(from).IsZero())
+	assert.Empty(t, DueBetween(WeeklySchedule{Calendar: closedCalendar{}}, from, from.AddDate(2, 0, 0)))
+}
+
+func TestDueBetween(t *testing.T) {
+	due := DueBetween(MonthlySchedule{BusinessDay: 1, Hour: 9}, time.Date(2021, 12, 31, 0, 0, 0, 0, time.UTC), time.Date(2022, 3, 15, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, []time.Time{
+		time.Date(2022, 1, 3, 9, 0, 0, 0, time.UTC),
+		time.Date(2022, 2, 1, 9, 0, 0, 0, time.UTC),
+		time.Date(2022, 3, 1, 9, 0, 0, 0, time.UTC),
+	}, due)
+}
@@ -1,10 +1,15 @@
 package trading
 
-import "github.com/quantfamily/lemonmarkets/client"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/quantfamily/lemonmarkets/client"
+)
 
 // DataTypes
 type DataTypes interface {
-	Order | Position | Account | Withdrawal | BankStatement | Document | Statement
+	Order | Position | Account | Withdrawal | BankStatement | Document | Statement | Portfolio | Ledger | RebalancePlan | Amendment
 }
 
 // Item
@@ -13,6 +18,40 @@ type Item[data DataTypes, err error] struct {
 	Error err
 }
 
+// DateLayout is the layout LemonMarkets use for dates without time (YYYY-MM-DD)
+const DateLayout = "2006-01-02"
+
+// Date is a calendar day as sent by LemonMarkets, e.g "2021-12-10"
+type Date struct {
+	time.Time
+}
+
+// UnmarshalJSON parses a date in DateLayout, null or empty string leaves the zero value
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var value *string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if value == nil || *value == "" {
+		d.Time = time.Time{}
+		return nil
+	}
+	parsed, err := time.Parse(DateLayout, *value)
+	if err != nil {
+		return err
+	}
+	d.Time = parsed
+	return nil
+}
+
+// MarshalJSON formats the date in DateLayout, the zero value is sent as null
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.Format(DateLayout))
+}
+
 // Environment
 type Environment string
 